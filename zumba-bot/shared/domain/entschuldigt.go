@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Entschuldigung ist ein entschuldigter Zeitraum eines Mitglieds (Tabelle
+// stammtisch_entschuldigt, im Admin-UI gepflegt): lange Krankheit,
+// Elternzeit, Auslandssemester. Treffen darin sind neutral – sie verlängern
+// keine Fehltage-Serie und unterbrechen keine; die Rangliste kann sie
+// optional ganz herausnehmen.
+type Entschuldigung struct {
+	ID        int64
+	UserID    string
+	Von       time.Time // erster entschuldigter Tag
+	Bis       time.Time // letzter entschuldigter Tag (inklusive)
+	Grund     string
+	CreatedAt time.Time
+}
+
+// Covers meldet, ob der Tag d im Zeitraum liegt (Tagesbasis).
+func (e Entschuldigung) Covers(d time.Time) bool {
+	day := dateOnly(d)
+	return !day.Before(dateOnly(e.Von)) && !day.After(dateOnly(e.Bis))
+}
+
+// ValidateEntschuldigung prüft einen Zeitraum vor dem Speichern.
+func ValidateEntschuldigung(e Entschuldigung) error {
+	switch {
+	case e.UserID == "":
+		return fmt.Errorf("Mitglied fehlt")
+	case e.Von.IsZero() || e.Bis.IsZero():
+		return fmt.Errorf("Zeitraum fehlt")
+	case dateOnly(e.Bis).Before(dateOnly(e.Von)):
+		return fmt.Errorf("Ende liegt vor dem Beginn")
+	case e.Grund == "":
+		return fmt.Errorf("Grund fehlt")
+	}
+	return nil
+}
+
+// Entschuldigungen sind Zeiträume beliebiger Mitglieder.
+type Entschuldigungen []Entschuldigung
+
+// Of liefert die Zeiträume von userID.
+func (es Entschuldigungen) Of(userID string) Entschuldigungen {
+	var out Entschuldigungen
+	for _, e := range es {
+		if e.UserID == userID {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Covering liefert den Zeitraum von userID, in dem der Tag d liegt.
+func (es Entschuldigungen) Covering(userID string, d time.Time) (Entschuldigung, bool) {
+	for _, e := range es {
+		if e.UserID == userID && e.Covers(d) {
+			return e, true
+		}
+	}
+	return Entschuldigung{}, false
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "testing"
+
+func TestEntschuldigungCovers(t *testing.T) {
+	e := Entschuldigung{UserID: "u1", Von: day("2026-03-05"), Bis: day("2026-03-19"), Grund: "Krank"}
+	for d, want := range map[string]bool{
+		"2026-03-04": false, "2026-03-05": true, "2026-03-12": true, "2026-03-19": true, "2026-03-20": false,
+	} {
+		if got := e.Covers(day(d)); got != want {
+			t.Errorf("Covers(%s) = %v, want %v", d, got, want)
+		}
+	}
+	es := Entschuldigungen{e, {UserID: "u2", Von: day("2026-01-01"), Bis: day("2026-12-31"), Grund: "Ausland"}}
+	if _, ok := es.Covering("u1", day("2026-04-02")); ok {
+		t.Error("u1 am 02.04. nicht entschuldigt")
+	}
+	if got, ok := es.Covering("u2", day("2026-04-02")); !ok || got.Grund != "Ausland" {
+		t.Errorf("Covering(u2) = %+v, %v", got, ok)
+	}
+	if n := len(es.Of("u1")); n != 1 {
+		t.Errorf("Of(u1) = %d Zeiträume, want 1", n)
+	}
+}
+
+func TestValidateEntschuldigung(t *testing.T) {
+	ok := Entschuldigung{UserID: "u1", Von: day("2026-03-05"), Bis: day("2026-03-05"), Grund: "OP"}
+	if err := ValidateEntschuldigung(ok); err != nil {
+		t.Errorf("eintägiger Zeitraum abgelehnt: %v", err)
+	}
+	bad := ok
+	bad.Bis = day("2026-03-04")
+	if ValidateEntschuldigung(bad) == nil {
+		t.Error("Ende vor Beginn akzeptiert")
+	}
+	bad = ok
+	bad.Grund = ""
+	if ValidateEntschuldigung(bad) == nil {
+		t.Error("ohne Grund akzeptiert")
+	}
+}
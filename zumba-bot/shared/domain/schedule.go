@@ -0,0 +1,274 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule beschreibt, an welchen Tagen der Stammtisch stattfindet:
+// ein fester Wochentag, optional nur jede n-te Woche (gezählt ab Anchor) und
+// optional pro Monat ein abweichender Wochentag ("donnerstags, im August
+// dienstags"). Sperrtage (excluded_days) sind NICHT Teil des Schedules – sie
+// werden wie bisher von den Auswertungen zusätzlich herausgefiltert.
+//
+// Der Nullwert ist kein gültiger Schedule; Konsumenten fallen mit OrDefault
+// auf DefaultSchedule (jeden Donnerstag) zurück.
+type Schedule struct {
+	Weekday time.Weekday
+	// EveryWeeks: 1 = jede Woche, 2 = jede zweite Woche usw.
+	EveryWeeks int
+	// Anchor ist ein beliebiger Tag in einer Woche MIT Treffen (nur für
+	// EveryWeeks > 1 relevant). Wochen beginnen Montag.
+	Anchor time.Time
+	// MonthWeekday verlegt das Treffen in einzelnen Monaten auf einen anderen
+	// Wochentag derselben Woche.
+	MonthWeekday map[time.Month]time.Weekday
+}
+
+// DefaultSchedule ist der historische Rhythmus: jeden Donnerstag.
+var DefaultSchedule = Schedule{Weekday: time.Thursday, EveryWeeks: 1}
+
+// IsZero meldet den (ungültigen) Nullwert.
+func (s Schedule) IsZero() bool { return s.EveryWeeks == 0 }
+
+// OrDefault liefert s bzw. DefaultSchedule, falls s der Nullwert ist.
+func (s Schedule) OrDefault() Schedule {
+	if s.IsZero() {
+		return DefaultSchedule
+	}
+	return s
+}
+
+// WeekdayIn liefert den Treffen-Wochentag im Monat m.
+func (s Schedule) WeekdayIn(m time.Month) time.Weekday {
+	if wd, ok := s.MonthWeekday[m]; ok {
+		return wd
+	}
+	return s.Weekday
+}
+
+// IsMeeting meldet, ob an t (Tagesbasis, Kalenderdatum von t) ein Treffen
+// vorgesehen ist.
+func (s Schedule) IsMeeting(t time.Time) bool {
+	s = s.OrDefault()
+	d := dateOnly(t)
+	if d.Weekday() != s.WeekdayIn(d.Month()) {
+		return false
+	}
+	if s.EveryWeeks <= 1 {
+		return true
+	}
+	weeks := int(weekStart(d).Sub(weekStart(dateOnly(s.Anchor))).Hours() / (24 * 7))
+	return ((weeks%s.EveryWeeks)+s.EveryWeeks)%s.EveryWeeks == 0
+}
+
+// Meetings liefert alle Treffen-Tage in [start, end] aufsteigend (UTC
+// Mitternacht, Sperrtage nicht berücksichtigt).
+func (s Schedule) Meetings(start, end time.Time) []time.Time {
+	var out []time.Time
+	last := dateOnly(end)
+	for d := dateOnly(start); !d.After(last); d = d.AddDate(0, 0, 1) {
+		if s.IsMeeting(d) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Next liefert das nächste Treffen STRIKT nach t (Tagesbasis).
+func (s Schedule) Next(t time.Time) time.Time {
+	d := dateOnly(t).AddDate(0, 0, 1)
+	// Spätestens nach EveryWeeks Wochen + Monatswechsel kommt ein Treffen;
+	// die Schranke schützt nur vor Endlosschleifen bei kaputten Schedules.
+	for i := 0; i < 7*(s.OrDefault().EveryWeeks+5); i++ {
+		if s.IsMeeting(d) {
+			return d
+		}
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// OnOrAfter liefert t selbst, wenn t ein Treffen ist, sonst Next(t).
+func (s Schedule) OnOrAfter(t time.Time) time.Time {
+	if s.IsMeeting(t) {
+		return dateOnly(t)
+	}
+	return s.Next(t)
+}
+
+// String liefert die Spezifikation im ParseSchedule-Format, z. B.
+// "do", "mi/2@2026-01-07" oder "do;aug=di".
+func (s Schedule) String() string {
+	s = s.OrDefault()
+	var b strings.Builder
+	b.WriteString(weekdayCodes[s.Weekday])
+	if s.EveryWeeks > 1 {
+		fmt.Fprintf(&b, "/%d@%s", s.EveryWeeks, s.Anchor.Format("2006-01-02"))
+	}
+	months := make([]int, 0, len(s.MonthWeekday))
+	for m := range s.MonthWeekday {
+		months = append(months, int(m))
+	}
+	sort.Ints(months)
+	for _, m := range months {
+		fmt.Fprintf(&b, ";%s=%s", monthCodes[time.Month(m)], weekdayCodes[s.MonthWeekday[time.Month(m)]])
+	}
+	return b.String()
+}
+
+// Describe liefert eine deutsche Kurzbeschreibung für UI und Logs,
+// z. B. "alle 2 Wochen mittwochs" oder "donnerstags (Aug: dienstags)".
+func (s Schedule) Describe() string {
+	s = s.OrDefault()
+	var out string
+	if s.EveryWeeks > 1 {
+		out = fmt.Sprintf("alle %d Wochen %s", s.EveryWeeks, weekdayAdverbDE[s.Weekday])
+	} else {
+		out = weekdayAdverbDE[s.Weekday]
+	}
+	if len(s.MonthWeekday) == 0 {
+		return out
+	}
+	months := make([]int, 0, len(s.MonthWeekday))
+	for m := range s.MonthWeekday {
+		months = append(months, int(m))
+	}
+	sort.Ints(months)
+	parts := make([]string, 0, len(months))
+	for _, m := range months {
+		parts = append(parts, fmt.Sprintf("%s: %s", monthNamesDE[m-1], weekdayAdverbDE[s.MonthWeekday[time.Month(m)]]))
+	}
+	return out + " (" + strings.Join(parts, ", ") + ")"
+}
+
+// ParseSchedule liest eine Schedule-Spezifikation (Env MEETING_SCHEDULE):
+//
+//	<wochentag>[/<n>@<anker-datum>][;<monat>=<wochentag>]...
+//
+// Wochentage deutsch oder englisch abgekürzt (do/thu, di/tue …), Monate
+// dreibuchstabig (jan … dez bzw. dec). Beispiele: "do", "mi/2@2026-01-07",
+// "do;aug=di". Leer = DefaultSchedule.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "" {
+		return DefaultSchedule, nil
+	}
+	parts := strings.Split(spec, ";")
+	s := Schedule{EveryWeeks: 1}
+
+	base := strings.TrimSpace(parts[0])
+	if wd, rest, ok := strings.Cut(base, "/"); ok {
+		n, anchor, ok := strings.Cut(rest, "@")
+		if !ok {
+			return Schedule{}, fmt.Errorf("schedule %q: Intervall braucht einen Anker (z. B. mi/2@2026-01-07)", spec)
+		}
+		every, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil || every < 1 {
+			return Schedule{}, fmt.Errorf("schedule %q: ungültiges Intervall %q", spec, n)
+		}
+		a, err := time.Parse("2006-01-02", strings.TrimSpace(anchor))
+		if err != nil {
+			return Schedule{}, fmt.Errorf("schedule %q: ungültiger Anker %q", spec, anchor)
+		}
+		s.EveryWeeks, s.Anchor = every, a
+		base = wd
+	}
+	wd, ok := parseWeekday(base)
+	if !ok {
+		return Schedule{}, fmt.Errorf("schedule %q: unbekannter Wochentag %q", spec, base)
+	}
+	s.Weekday = wd
+
+	for _, p := range parts[1:] {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		m, w, ok := strings.Cut(p, "=")
+		if !ok {
+			return Schedule{}, fmt.Errorf("schedule %q: Monatsausnahme %q ohne '='", spec, p)
+		}
+		month, ok := parseMonth(strings.TrimSpace(m))
+		if !ok {
+			return Schedule{}, fmt.Errorf("schedule %q: unbekannter Monat %q", spec, m)
+		}
+		mwd, ok := parseWeekday(strings.TrimSpace(w))
+		if !ok {
+			return Schedule{}, fmt.Errorf("schedule %q: unbekannter Wochentag %q", spec, w)
+		}
+		if s.MonthWeekday == nil {
+			s.MonthWeekday = map[time.Month]time.Weekday{}
+		}
+		s.MonthWeekday[month] = mwd
+	}
+	return s, nil
+}
+
+var weekdayCodes = map[time.Weekday]string{
+	time.Monday: "mo", time.Tuesday: "di", time.Wednesday: "mi", time.Thursday: "do",
+	time.Friday: "fr", time.Saturday: "sa", time.Sunday: "so",
+}
+
+var weekdayAliases = map[string]time.Weekday{
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+}
+
+var weekdayNamesDE = map[time.Weekday]string{
+	time.Monday: "Montag", time.Tuesday: "Dienstag", time.Wednesday: "Mittwoch", time.Thursday: "Donnerstag",
+	time.Friday: "Freitag", time.Saturday: "Samstag", time.Sunday: "Sonntag",
+}
+
+var weekdayAdverbDE = map[time.Weekday]string{
+	time.Monday: "montags", time.Tuesday: "dienstags", time.Wednesday: "mittwochs", time.Thursday: "donnerstags",
+	time.Friday: "freitags", time.Saturday: "samstags", time.Sunday: "sonntags",
+}
+
+var monthCodes = map[time.Month]string{
+	time.January: "jan", time.February: "feb", time.March: "mar", time.April: "apr",
+	time.May: "mai", time.June: "jun", time.July: "jul", time.August: "aug",
+	time.September: "sep", time.October: "okt", time.November: "nov", time.December: "dez",
+}
+
+var monthAliases = map[string]time.Month{
+	"mär": time.March, "may": time.May, "oct": time.October, "dec": time.December,
+}
+
+var monthNamesDE = []string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"}
+
+// WeekdayNameDE liefert den deutschen Namen eines Wochentags ("Donnerstag").
+func WeekdayNameDE(wd time.Weekday) string { return weekdayNamesDE[wd] }
+
+func parseWeekday(v string) (time.Weekday, bool) {
+	for wd, code := range weekdayCodes {
+		if code == v {
+			return wd, true
+		}
+	}
+	wd, ok := weekdayAliases[v]
+	return wd, ok
+}
+
+func parseMonth(v string) (time.Month, bool) {
+	for m, code := range monthCodes {
+		if code == v {
+			return m, true
+		}
+	}
+	m, ok := monthAliases[v]
+	return m, ok
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// weekStart liefert den Montag der Woche von d.
+func weekStart(d time.Time) time.Time {
+	offset := (int(d.Weekday()) + 6) % 7
+	return d.AddDate(0, 0, -offset)
+}
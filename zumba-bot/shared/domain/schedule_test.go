@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestDefaultScheduleJedenDonnerstag(t *testing.T) {
+	got := Schedule{}.Meetings(day("2026-01-01"), day("2026-01-31"))
+	want := []string{"2026-01-01", "2026-01-08", "2026-01-15", "2026-01-22", "2026-01-29"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].Format("2006-01-02") != want[i] {
+			t.Errorf("[%d] got %s, want %s", i, got[i].Format("2006-01-02"), want[i])
+		}
+	}
+}
+
+func TestScheduleJedeZweiteWoche(t *testing.T) {
+	s, err := ParseSchedule("mi/2@2026-01-07")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]bool{
+		"2026-01-07": true, "2026-01-14": false, "2026-01-21": true,
+		"2025-12-24": true, "2025-12-31": false, "2026-01-22": false,
+	}
+	for d, want := range cases {
+		if got := s.IsMeeting(day(d)); got != want {
+			t.Errorf("IsMeeting(%s) = %v, want %v", d, got, want)
+		}
+	}
+	if got := s.Next(day("2026-01-07")); !got.Equal(day("2026-01-21")) {
+		t.Errorf("Next = %v, want 2026-01-21", got)
+	}
+	if got := s.Describe(); got != "alle 2 Wochen mittwochs" {
+		t.Errorf("Describe = %q", got)
+	}
+}
+
+func TestScheduleMonatsausnahme(t *testing.T) {
+	s, err := ParseSchedule("thu;aug=tue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsMeeting(day("2026-07-30")) || s.IsMeeting(day("2026-08-06")) {
+		t.Error("Juli donnerstags, August nicht")
+	}
+	if !s.IsMeeting(day("2026-08-04")) || s.IsMeeting(day("2026-07-28")) {
+		t.Error("August dienstags, Juli nicht")
+	}
+	if got := s.Next(day("2026-07-30")); !got.Equal(day("2026-08-04")) {
+		t.Errorf("Monatswechsel: Next = %v, want 2026-08-04", got)
+	}
+	if got := s.String(); got != "do;aug=di" {
+		t.Errorf("String = %q", got)
+	}
+	if got := s.Describe(); got != "donnerstags (Aug: dienstags)" {
+		t.Errorf("Describe = %q", got)
+	}
+}
+
+func TestParseScheduleFehler(t *testing.T) {
+	for _, spec := range []string{"xx", "mi/2", "mi/0@2026-01-07", "do;aug", "do;foo=di"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q): Fehler erwartet", spec)
+		}
+	}
+	s, err := ParseSchedule("")
+	if err != nil || s.String() != "do" {
+		t.Errorf("leer → Default erwartet, got %q, %v", s.String(), err)
+	}
+}
@@ -0,0 +1,55 @@
+package penalty
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EinspruchStatus ist der Stand eines Einspruchs gegen eine Strafe (Spalte
+// strafen.einspruch; leer = kein Einspruch).
+type EinspruchStatus string
+
+const (
+	// EinspruchOffen: eingelegt, der Admin hat noch nicht entschieden.
+	EinspruchOffen EinspruchStatus = "einspruch"
+	// EinspruchBestaetigt: abgelehnt – die Strafe bleibt offen.
+	EinspruchBestaetigt EinspruchStatus = "bestaetigt"
+	// EinspruchAufgehoben: stattgegeben – die Strafe wird gelöscht.
+	EinspruchAufgehoben EinspruchStatus = "aufgehoben"
+)
+
+// Einspruch ist der Widerspruch eines Mitglieds gegen eine No-Show-Strafe
+// ("ich hab doch abgesagt, der Bot hat's nicht erkannt"). Als Beleg hängt
+// die letzte Nachricht des Mitglieds vor dem Treffen dran – als bot_trace-
+// und/oder ml_messages-Zeile.
+type Einspruch struct {
+	Status        EinspruchStatus
+	Grund         string
+	Am            *time.Time
+	Notiz         string // Begründung der Entscheidung
+	EntschiedenAm *time.Time
+	TraceID       int64 // bot_trace.id; 0 = kein Beleg
+	MLMessageID   int64 // ml_messages.id; 0 = kein Beleg
+}
+
+// Offen meldet einen Einspruch, über den noch nicht entschieden ist.
+func (e Einspruch) Offen() bool { return e.Status == EinspruchOffen }
+
+// KannEinspruch meldet, ob gegen die Strafe Einspruch eingelegt werden kann:
+// nur gegen persistierte, offene No-Show-Strafen und nur einmal. Fehltage
+// ergeben sich aus den Abwesenheiten – dort korrigiert man die Anwesenheit.
+func KannEinspruch(e Entry) bool {
+	return e.ID != 0 && e.Art == ArtNoShow && e.Status == StatusOffen && e.Einspruch.Status == ""
+}
+
+// ValidateEntscheidung prüft die Entscheidung über einen Einspruch.
+func ValidateEntscheidung(status EinspruchStatus, notiz string) error {
+	if status != EinspruchBestaetigt && status != EinspruchAufgehoben {
+		return fmt.Errorf("Entscheidung muss %q oder %q sein", EinspruchBestaetigt, EinspruchAufgehoben)
+	}
+	if strings.TrimSpace(notiz) == "" {
+		return fmt.Errorf("Begründung der Entscheidung fehlt")
+	}
+	return nil
+}
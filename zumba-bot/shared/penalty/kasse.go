@@ -0,0 +1,145 @@
+package penalty
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type BuchungArt string
+
+const (
+	BuchungZahlung BuchungArt = "zahlung" // Geld in die Kasse
+	BuchungAusgabe BuchungArt = "ausgabe" // Geld aus der Kasse (Runde, Weihnachtsfeier …)
+)
+
+// Buchung ist eine Zeile im Kassenbuch (Tabelle strafen_kasse). Zahlungen
+// können sich auf eine Strafe beziehen (auch teilweise); Ausgaben nie.
+type Buchung struct {
+	ID        int64
+	Art       BuchungArt
+	UserID    string // Zahlung: wer eingezahlt hat; leer = ohne Mitglied
+	StrafeID  int64  // Zahlung auf diese Strafe; 0 = ohne Bezug
+	Betrag    int    // Euro, immer positiv
+	Datum     time.Time
+	Notiz     string
+	CreatedAt time.Time
+}
+
+// Wert ist der Betrag mit Vorzeichen aus Sicht der Kasse.
+func (b Buchung) Wert() int {
+	if b.Art == BuchungAusgabe {
+		return -b.Betrag
+	}
+	return b.Betrag
+}
+
+// ValidateBuchung prüft eine Buchung vor dem Speichern.
+func ValidateBuchung(b Buchung) error {
+	switch {
+	case b.Art != BuchungZahlung && b.Art != BuchungAusgabe:
+		return fmt.Errorf("unbekannte Buchungsart %q", b.Art)
+	case b.Betrag <= 0:
+		return fmt.Errorf("Betrag muss positiv sein")
+	case b.Datum.IsZero():
+		return fmt.Errorf("Datum fehlt")
+	case b.Art == BuchungAusgabe && b.StrafeID != 0:
+		return fmt.Errorf("Ausgaben beziehen sich auf keine Strafe")
+	case b.Art == BuchungAusgabe && b.Notiz == "":
+		return fmt.Errorf("Ausgaben brauchen eine Notiz (wofür?)")
+	}
+	return nil
+}
+
+// ErrBegleichungVorSerienende: die Begleichung ist vor dem letzten
+// gezählten Fehltag der Serie datiert.
+var ErrBegleichungVorSerienende = errors.New("Begleichung vor dem letzten Fehltag der Serie")
+
+// ValidateBegleichung prüft, ob die Zahlung b die Strafe e begleichen darf.
+// Der Buchungstag wird zu beglichen_am und schneidet eine Fehltage-Serie
+// (Segments) – rückdatiert vor ihren letzten gezählten Fehltag würde er sie
+// nachträglich kürzen: Die Strafe hätte weniger Tage als bezahlt, und die
+// Fehltage danach begännen eine neue Serie.
+func ValidateBegleichung(e Entry, b Buchung) error {
+	if e.Art == ArtFehltage && !e.Ende.IsZero() && dateOnly(b.Datum).Before(dateOnly(e.Ende)) {
+		return fmt.Errorf("%w (%s)", ErrBegleichungVorSerienende, e.Ende.Format("02.01.2006"))
+	}
+	return nil
+}
+
+// Kasse fasst das Kassenbuch zusammen.
+type Kasse struct {
+	Einnahmen int
+	Ausgaben  int
+	Buchungen int // Anzahl; 0 = Kassenbuch noch nicht benutzt
+}
+
+// Stand ist der aktuelle Kassenstand in Euro.
+func (k Kasse) Stand() int { return k.Einnahmen - k.Ausgaben }
+
+// Kassenstand summiert die Buchungen.
+func Kassenstand(bs []Buchung) Kasse {
+	k := Kasse{Buchungen: len(bs)}
+	for _, b := range bs {
+		if b.Art == BuchungAusgabe {
+			k.Ausgaben += b.Betrag
+		} else {
+			k.Einnahmen += b.Betrag
+		}
+	}
+	return k
+}
+
+// Konto ist der Stand eines Mitglieds: was noch offen ist und was es
+// insgesamt eingezahlt hat.
+type Konto struct {
+	UserID  string
+	Name    string
+	Offen   int // Euro, Rest der offenen Strafen
+	Bezahlt int // Euro, alle Zahlungen im Kassenbuch
+}
+
+// Konten liefert die Konten aller Mitglieder mit offenen Strafen oder
+// Zahlungen; entries stammen aus Assess(in, …). Wer am meisten schuldet,
+// steht oben.
+func Konten(in Input, entries []Entry) []Konto {
+	byUser := make(map[string]*Konto, len(in.Users))
+	for _, u := range in.Users {
+		byUser[u.UserID] = &Konto{UserID: u.UserID, Name: u.Name}
+	}
+	for _, e := range entries {
+		if k, ok := byUser[e.UserID]; ok && e.Status == StatusOffen {
+			k.Offen += e.Rest()
+		}
+	}
+	for _, b := range in.Buchungen {
+		if k, ok := byUser[b.UserID]; ok && b.Art == BuchungZahlung {
+			k.Bezahlt += b.Betrag
+		}
+	}
+	var out []Konto
+	for _, u := range in.Users {
+		if k := byUser[u.UserID]; k.Offen > 0 || k.Bezahlt > 0 {
+			out = append(out, *k)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Offen != out[j].Offen {
+			return out[i].Offen > out[j].Offen
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// bezahltJeStrafe summiert die Zahlungen je Strafe.
+func bezahltJeStrafe(bs []Buchung) map[int64]int {
+	out := make(map[int64]int)
+	for _, b := range bs {
+		if b.Art == BuchungZahlung && b.StrafeID != 0 {
+			out[b.StrafeID] += b.Betrag
+		}
+	}
+	return out
+}
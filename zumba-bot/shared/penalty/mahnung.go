@@ -0,0 +1,157 @@
+package penalty
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mahnwesen sind die Stufen, nach denen der Bot an offene Strafen erinnert.
+// Maßgeblich ist je Mitglied die älteste offene Strafe (der "Anker"): ist sie
+// Tage[0] Tage alt, ist Stufe 1 fällig. Danach geht es höchstens eine Stufe
+// je Lauf weiter, und zwar Tage[i]-Tage[i-1] Tage nach der vorigen Mahnung –
+// auch eine schon sehr alte Strafe durchläuft so alle Stufen. Jede Stufe geht
+// einmal je Anker raus; ist der Anker beglichen, rückt die nächstälteste
+// Strafe nach und beginnt wieder bei Stufe 1.
+type Mahnwesen struct {
+	Tage     []int // Stufe i+1 ab Tage[i] Tagen (aufsteigend)
+	GruppeAb int   // ab dieser Stufe zusätzlich @-Erwähnung in der Gruppe; 0 = nie
+	Gebuehr  int   // Euro; Mahngebühr beim Erreichen der letzten Stufe, 0 = keine
+}
+
+// ParseMahnTage liest die Stufen aus "14,28,42" (Tage, streng aufsteigend).
+// Leer = kein Mahnwesen.
+func ParseMahnTage(s string) ([]int, error) {
+	var out []int
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("Stufe %q: positive Tageszahl erwartet", p)
+		}
+		if len(out) > 0 && n <= out[len(out)-1] {
+			return nil, fmt.Errorf("Stufen müssen aufsteigen (%d nach %d)", n, out[len(out)-1])
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// Stufe ist die höchste Stufe, deren Schwelle alter Tage erreicht (0 = noch
+// keine). Fällig ist davon höchstens die nächste (siehe Lage).
+func (m Mahnwesen) Stufe(alter int) int {
+	n := 0
+	for _, t := range m.Tage {
+		if alter >= t {
+			n++
+		}
+	}
+	return n
+}
+
+// Mahnung ist eine verschickte Erinnerung (Tabelle strafen_mahnung).
+type Mahnung struct {
+	ID        int64
+	UserID    string
+	Stufe     int
+	StrafeID  int64 // Anker: älteste offene Strafe beim Versand
+	Offen     int   // Euro, offener Gesamtbetrag beim Versand
+	Gruppe    bool  // zusätzlich in der Gruppe zu erwähnen (Gruppen-Stufe, nicht abbestellt)
+	GebuehrID int64 // dabei angelegte Mahngebühr; 0 = keine
+	CreatedAt time.Time
+}
+
+// MahnLage ist der Mahnstand eines Mitglieds mit offenen Strafen.
+type MahnLage struct {
+	UserID  string
+	Name    string
+	Anker   Entry // älteste offene Strafe
+	Offen   int   // Euro, Rest aller offenen Strafen
+	Alter   int   // Tage seit Anker.Datum
+	Stufe   int   // fällige Stufe: Gemahnt+1, sobald deren Abstand erreicht ist, sonst Gemahnt
+	Gemahnt int   // höchste verschickte Stufe für diesen Anker
+	Zuletzt *time.Time
+}
+
+// Faellig meldet, ob eine neue Stufe verschickt werden muss.
+func (l MahnLage) Faellig() bool { return l.Stufe > l.Gemahnt }
+
+// Lage bewertet den Mahnstand zum Stichtag asOf aus den bewerteten Strafen
+// (Assess) und den verschickten Mahnungen. Gemahnt wird nur, was offen und
+// persistiert ist; Strafen mit offenem Einspruch ruhen. Sortiert nach Name.
+func (m Mahnwesen) Lage(entries []Entry, log []Mahnung, asOf time.Time) []MahnLage {
+	byUser := make(map[string]*MahnLage)
+	var order []string
+	for _, e := range entries {
+		if e.ID == 0 || e.Status != StatusOffen || e.Rest() == 0 || e.Einspruch.Offen() {
+			continue
+		}
+		l, ok := byUser[e.UserID]
+		if !ok {
+			l = &MahnLage{UserID: e.UserID, Name: e.Name, Anker: e}
+			byUser[e.UserID] = l
+			order = append(order, e.UserID)
+		}
+		l.Offen += e.Rest()
+		if e.Datum.Before(l.Anker.Datum) || (e.Datum.Equal(l.Anker.Datum) && e.ID < l.Anker.ID) {
+			l.Anker = e
+		}
+	}
+
+	out := make([]MahnLage, 0, len(order))
+	for _, uid := range order {
+		l := byUser[uid]
+		l.Alter = tageZwischen(l.Anker.Datum, asOf)
+		for _, x := range log {
+			if x.StrafeID != l.Anker.ID || x.Stufe < l.Gemahnt {
+				continue
+			}
+			l.Gemahnt = x.Stufe
+			at := x.CreatedAt
+			l.Zuletzt = &at
+		}
+		l.Stufe = m.faellig(l, asOf)
+		out = append(out, *l)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// faellig ist die Stufe, die für l zum Stichtag asOf dran ist: die erste ab
+// Tage[0] Tagen, jede weitere frühestens Tage[i]-Tage[i-1] Tage nach der
+// vorigen Mahnung – nie mehrere auf einmal.
+func (m Mahnwesen) faellig(l *MahnLage, asOf time.Time) int {
+	next := min(m.Stufe(l.Alter), l.Gemahnt+1)
+	if next <= l.Gemahnt {
+		return l.Gemahnt
+	}
+	if l.Gemahnt > 0 && l.Zuletzt != nil && tageZwischen(*l.Zuletzt, asOf) < m.Tage[l.Gemahnt]-m.Tage[l.Gemahnt-1] {
+		return l.Gemahnt
+	}
+	return next
+}
+
+// GebuehrFuer ist die Mahngebühr, die mit der Mahnung l anfällt: nur auf der
+// letzten Stufe, nur wenn alle Stufen davor für diesen Anker verschickt
+// wurden, und nie für eine Mahngebühr selbst.
+func (m Mahnwesen) GebuehrFuer(l MahnLage) int {
+	if !m.Letzte(l.Stufe) || l.Gemahnt != l.Stufe-1 || l.Anker.Art == ArtMahngebuehr {
+		return 0
+	}
+	return m.Gebuehr
+}
+
+// tageZwischen zählt die Kalendertage von a bis b.
+func tageZwischen(a, b time.Time) int {
+	return int(dateOnly(b).Sub(dateOnly(a)).Hours() / 24)
+}
+
+// Letzte meldet, ob stufe die letzte (höchste) Stufe ist.
+func (m Mahnwesen) Letzte(stufe int) bool { return stufe > 0 && stufe == len(m.Tage) }
+
+// Gruppe meldet, ob auf stufe zusätzlich in der Gruppe erwähnt wird.
+func (m Mahnwesen) Gruppe(stufe int) bool { return m.GruppeAb > 0 && stufe >= m.GruppeAb }
@@ -8,6 +8,24 @@
 //   - "noshow": manuell im Admin-UI gepflegt (nicht abgemeldet und nicht
 //     gekommen), fester Betrag (Default 50 €).
 //
+// Schwelle und Beträge stehen in versionierten Regelwerken (RuleSet, Tabelle
+// strafen_regelwerk, im Admin-UI gepflegt); die Zahlen oben sind die
+// ursprünglichen. Eine Serie wird immer nach dem Regelwerk bewertet, das an
+// ihrem ersten Fehltag galt – eine Regeländerung rechnet alte Strafen nicht
+// um.
+//
+// Was tatsächlich in die Kasse geflossen ist, steht im Kassenbuch (Buchung,
+// Tabelle strafen_kasse): Zahlungen – auch Teilzahlungen – auf Strafen und
+// Ausgaben aus der Kasse. Entry.Bezahlt/Rest zeigen den Stand je Strafe.
+//
+// Offene Strafen mahnt der Bot in Stufen an (Mahnwesen, Tabelle
+// strafen_mahnung); auf der letzten Stufe kann eine Mahngebühr als eigene
+// Strafart dazukommen.
+//
+// Gegen eine No-Show-Strafe kann das Mitglied Einspruch einlegen (Einspruch,
+// Spalten strafen.einspruch*); der Admin bestätigt die Strafe oder hebt sie
+// auf (= löscht sie).
+//
 // Serien-Semantik: eine Serie ("Segment") ist eine Folge aufeinanderfolgender
 // abgemeldeter Donnerstage. Sie wird beendet durch Anwesenheit ODER durch
 // einen Reset-Zeitpunkt (Begleichen/Löschen einer Fehltage-Strafe des Users):
@@ -15,6 +33,13 @@
 // Anforderung: 6x gefehlt → 30 € → abends beglichen → nächster Fehltag ist
 // Serie 1, nicht 35 €).
 //
+// Entschuldigte Zeiträume (UserData.Entschuldigt: Krankheit, Elternzeit …)
+// sind neutral: ein Treffen darin zählt weder als Fehltag noch als
+// Anwesenheit, die Serie läuft danach einfach weiter.
+//
+// "Donnerstag" steht hier historisch für einen Treffen-Tag: welche Tage
+// zählen, bestimmt Input.Schedule (domain.Schedule, Default jeden Donnerstag).
+//
 // Dieses Package lebt im shared-Modul und ist die EINZIGE Kopie der
 // Strafen-Domain-Logik – whatsapp-bot, zumba-admin-ui und wrapped importieren
 // es (früher wortgleich 3× dupliziert).
@@ -23,13 +48,16 @@ package penalty
 import (
 	"sort"
 	"time"
+
+	"github.com/michael/zumba-shared/domain"
 )
 
 type Art string
 
 const (
-	ArtFehltage Art = "fehltage"
-	ArtNoShow   Art = "noshow"
+	ArtFehltage    Art = "fehltage"
+	ArtNoShow      Art = "noshow"
+	ArtMahngebuehr Art = "mahngebuehr" // vom Mahnwesen angelegt, fester Betrag
 )
 
 type Status string
@@ -40,34 +68,27 @@ const (
 	StatusGeloescht Status = "geloescht"
 )
 
-// Beträge in ganzen Euro.
-const (
-	MinFehltage    = 5  // ab so vielen Fehltagen in Folge greift die Strafe
-	BasisBetrag    = 25 // Betrag bei genau MinFehltage
-	ProTagBetrag   = 5  // jeder weitere Fehltag
-	NoShowDefault  = 50 // Default für manuelle No-Show-Strafen
-	clampStartDate = "2025-12-01"
-)
-
 // Row ist eine persistierte Zeile der Tabelle strafen.
 type Row struct {
 	ID          int64
 	UserID      string
 	Art         Art
 	Datum       time.Time // fehltage: 1. Fehltag der Serie; noshow: Tag des No-Shows
-	Betrag      int       // Euro; nur für noshow gesetzt (fehltage: dynamisch)
+	Betrag      int       // Euro; für noshow/mahngebuehr gesetzt (fehltage: dynamisch)
 	Status      Status
 	CreatedAt   time.Time
 	BeglichenAm *time.Time
 	GeloeschtAm *time.Time
+	Einspruch   Einspruch // nur noshow; Status leer = keiner
 }
 
 // UserData sind die Eingangsdaten eines Users für die Bewertung.
 type UserData struct {
 	UserID         string
 	Name           string
-	EffectiveStart time.Time // GREATEST(startDate, 2025-12-01)
+	EffectiveStart time.Time // GREATEST(startDate, Beginn des ersten Regelwerks)
 	Absences       []time.Time
+	Entschuldigt   []domain.Entschuldigung // Treffen darin sind neutral
 }
 
 // Input bündelt alles, was Assess braucht.
@@ -75,6 +96,12 @@ type Input struct {
 	Users    []UserData
 	Excluded []time.Time
 	Rows     []Row // alle strafen-Zeilen (inkl. beglichen/geloescht)
+	// Schedule bestimmt die Treffen-Tage; Nullwert = jeden Donnerstag.
+	Schedule domain.Schedule
+	// Rules sind die Regelwerke; leer = DefaultRuleSet.
+	Rules RuleSets
+	// Buchungen sind die Kassenbuch-Zeilen (für Entry.Bezahlt und Konten).
+	Buchungen []Buchung
 }
 
 // Entry ist eine bewertete Strafe. ID == 0 bedeutet: automatische Strafe, die
@@ -85,59 +112,63 @@ type Entry struct {
 	Name        string
 	Art         Art
 	Datum       time.Time
-	Tage        int // nur fehltage: Länge der Serie
-	Betrag      int // Euro, berechnet bzw. Row-Betrag
+	Tage        int       // nur fehltage: Länge der Serie
+	Ende        time.Time // nur fehltage: letzter gezählter Fehltag der Serie
+	Betrag      int       // Euro, berechnet bzw. Row-Betrag
+	Bezahlt     int       // Euro, Zahlungen im Kassenbuch auf diese Strafe
 	Status      Status
 	BeglichenAm *time.Time
+	// SichtbarBis ist bei beglichenen Strafen das nächste Treffen nach der
+	// Begleichung (letzter Tag im Report); nil = Donnerstags-Default.
+	SichtbarBis *time.Time
+	Einspruch   Einspruch
+}
+
+// Rest ist der noch offene Betrag nach Teilzahlungen (nie negativ).
+func (e Entry) Rest() int {
+	if e.Bezahlt >= e.Betrag {
+		return 0
+	}
+	return e.Betrag - e.Bezahlt
 }
 
 // Segment ist eine Serie aufeinanderfolgender Fehltage.
 type Segment struct {
 	Start time.Time
+	Ende  time.Time // letzter gezählter Fehltag
 	Tage  int
 }
 
-// ClampStart liefert den effektiven Startpunkt eines Users (Domänen-Konvention:
-// nie vor dem 01.12.2025).
+// ClampStart liefert den effektiven Startpunkt eines Users nach dem
+// Default-Regelwerk (Domänen-Konvention der Statistik: nie vor dem
+// 01.12.2025). Für Strafen gilt RuleSets.ClampStart.
 func ClampStart(startDate *time.Time) time.Time {
-	clamp, _ := time.Parse("2006-01-02", clampStartDate)
-	if startDate == nil || startDate.Before(clamp) {
-		return clamp
-	}
-	return *startDate
+	return RuleSets(nil).ClampStart(startDate)
 }
 
-// Betrag berechnet den Strafbetrag einer Fehltage-Serie (0 = keine Strafe).
-func Betrag(tage int) int {
-	if tage < MinFehltage {
-		return 0
-	}
-	return BasisBetrag + (tage-MinFehltage)*ProTagBetrag
-}
-
-// Thursdays liefert alle gültigen Donnerstage in [start, asOf] aufsteigend,
-// ohne Sperrtage.
-func Thursdays(start, asOf time.Time, excluded map[string]bool) []time.Time {
+// Meetings liefert alle gültigen Treffen-Tage des Schedules in [start, asOf]
+// aufsteigend, ohne Sperrtage.
+func Meetings(s domain.Schedule, start, asOf time.Time, excluded map[string]bool) []time.Time {
 	var out []time.Time
-	d := dateOnly(start)
-	end := dateOnly(asOf)
-	for ; !d.After(end); d = d.AddDate(0, 0, 1) {
-		if d.Weekday() == time.Thursday && !excluded[iso(d)] {
+	for _, d := range s.Meetings(start, asOf) {
+		if !excluded[iso(d)] {
 			out = append(out, d)
 		}
 	}
 	return out
 }
 
+// Thursdays ist Meetings mit dem Default-Schedule (jeden Donnerstag).
+func Thursdays(start, asOf time.Time, excluded map[string]bool) []time.Time {
+	return Meetings(domain.DefaultSchedule, start, asOf, excluded)
+}
+
 // NextThursday liefert den nächsten Donnerstag STRIKT nach t (Tagesbasis).
 // Fällt t auf einen Donnerstag, ist das Ergebnis t+7 Tage. Bis einschließlich
-// dieses Tages werden beglichene Strafen noch im Report ausgewiesen.
+// dieses Tages werden beglichene Strafen noch im Report ausgewiesen
+// (Default-Schedule; mit eigenem Schedule setzt Assess Entry.SichtbarBis).
 func NextThursday(t time.Time) time.Time {
-	d := dateOnly(t).AddDate(0, 0, 1)
-	for d.Weekday() != time.Thursday {
-		d = d.AddDate(0, 0, 1)
-	}
-	return d
+	return domain.DefaultSchedule.Next(t)
 }
 
 // Segments zerlegt die Donnerstage eines Users in Fehltag-Serien. resets sind
@@ -174,6 +205,7 @@ func Segments(thursdays []time.Time, absent map[string]bool, resets []time.Time)
 			cur = &Segment{Start: t}
 		}
 		cur.Tage++
+		cur.Ende = t
 		lastAbsent = t
 	}
 	closeCur()
@@ -194,8 +226,12 @@ func resetBetween(resetDays []time.Time, a, b time.Time) bool {
 
 // Assess bewertet alle User: persistierte Strafen bekommen ihren berechneten
 // Betrag, erkannte aber noch nicht persistierte Fehltage-Strafen kommen als
-// Kandidaten (ID == 0, Status offen) dazu.
+// Kandidaten (ID == 0, Status offen) dazu. Jede Serie gilt nach dem
+// Regelwerk ihres ersten Fehltags; Bezahlt kommt aus dem Kassenbuch.
 func Assess(in Input, asOf time.Time) []Entry {
+	sched := in.Schedule.OrDefault()
+	rules := in.Rules.Sorted()
+	bezahlt := bezahltJeStrafe(in.Buchungen)
 	excluded := make(map[string]bool, len(in.Excluded))
 	for _, d := range in.Excluded {
 		excluded[iso(d)] = true
@@ -208,26 +244,7 @@ func Assess(in Input, asOf time.Time) []Entry {
 	var out []Entry
 	for _, u := range in.Users {
 		rows := rowsByUser[u.UserID]
-
-		absent := make(map[string]bool, len(u.Absences))
-		for _, a := range u.Absences {
-			absent[iso(a)] = true
-		}
-		var resets []time.Time
-		for _, r := range rows {
-			if r.Art != ArtFehltage {
-				continue
-			}
-			if r.BeglichenAm != nil {
-				resets = append(resets, *r.BeglichenAm)
-			}
-			if r.GeloeschtAm != nil {
-				resets = append(resets, *r.GeloeschtAm)
-			}
-		}
-
-		thursdays := Thursdays(u.EffectiveStart, asOf, excluded)
-		segs := Segments(thursdays, absent, resets)
+		_, segs := userSegments(u, rows, sched, excluded, asOf)
 		segByStart := make(map[string]Segment, len(segs))
 		for _, s := range segs {
 			segByStart[iso(s.Start)] = s
@@ -238,9 +255,14 @@ func Assess(in Input, asOf time.Time) []Entry {
 			e := Entry{
 				ID: r.ID, UserID: u.UserID, Name: u.Name, Art: r.Art,
 				Datum: r.Datum, Status: r.Status, BeglichenAm: r.BeglichenAm,
+				Bezahlt: bezahlt[r.ID], Einspruch: r.Einspruch,
+			}
+			if r.BeglichenAm != nil {
+				bis := sched.Next(*r.BeglichenAm)
+				e.SichtbarBis = &bis
 			}
 			switch r.Art {
-			case ArtNoShow:
+			case ArtNoShow, ArtMahngebuehr:
 				e.Betrag = r.Betrag
 			case ArtFehltage:
 				claimed[iso(r.Datum)] = true
@@ -250,8 +272,8 @@ func Assess(in Input, asOf time.Time) []Entry {
 					// Admin-UI nachträglich korrigiert. Nicht ausweisen.
 					continue
 				}
-				e.Tage = seg.Tage
-				e.Betrag = Betrag(seg.Tage)
+				e.Tage, e.Ende = seg.Tage, seg.Ende
+				e.Betrag = rules.At(seg.Start).Betrag(seg.Tage)
 				if e.Betrag == 0 {
 					continue
 				}
@@ -260,12 +282,13 @@ func Assess(in Input, asOf time.Time) []Entry {
 		}
 
 		for _, seg := range segs {
-			if seg.Tage < MinFehltage || claimed[iso(seg.Start)] {
+			r := rules.At(seg.Start)
+			if seg.Tage < r.MinFehltage || claimed[iso(seg.Start)] {
 				continue
 			}
 			out = append(out, Entry{
 				UserID: u.UserID, Name: u.Name, Art: ArtFehltage,
-				Datum: seg.Start, Tage: seg.Tage, Betrag: Betrag(seg.Tage),
+				Datum: seg.Start, Tage: seg.Tage, Ende: seg.Ende, Betrag: r.Betrag(seg.Tage),
 				Status: StatusOffen,
 			})
 		}
@@ -280,9 +303,107 @@ func Assess(in Input, asOf time.Time) []Entry {
 	return out
 }
 
+// userSegments liefert die Treffen-Tage von u bis asOf und seine
+// Fehltag-Serien; rows sind die strafen-Zeilen des Users (Resets).
+func userSegments(u UserData, rows []Row, sched domain.Schedule, excluded map[string]bool, asOf time.Time) ([]time.Time, []Segment) {
+	absent := make(map[string]bool, len(u.Absences))
+	for _, a := range u.Absences {
+		absent[iso(a)] = true
+	}
+	var resets []time.Time
+	for _, r := range rows {
+		if r.Art != ArtFehltage {
+			continue
+		}
+		if r.BeglichenAm != nil {
+			resets = append(resets, *r.BeglichenAm)
+		}
+		if r.GeloeschtAm != nil {
+			resets = append(resets, *r.GeloeschtAm)
+		}
+	}
+	meetings := neutral(Meetings(sched, u.EffectiveStart, asOf, excluded), u.Entschuldigt)
+	return meetings, Segments(meetings, absent, resets)
+}
+
+// neutral lässt die entschuldigten Treffen weg: Segments sieht den letzten
+// Tag davor und den ersten danach als aufeinanderfolgend.
+func neutral(meetings []time.Time, entschuldigt []domain.Entschuldigung) []time.Time {
+	if len(entschuldigt) == 0 {
+		return meetings
+	}
+	out := meetings[:0:0]
+	for _, m := range meetings {
+		if !covered(entschuldigt, m) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func covered(entschuldigt []domain.Entschuldigung, d time.Time) bool {
+	for _, e := range entschuldigt {
+		if e.Covers(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentRun liefert die Länge der laufenden Fehltag-Serie von userID zum
+// Stichtag asOf (0 = beim letzten Treffen da oder Serie beglichen). Ab
+// MinFehltage greift die Fehltage-Strafe – "meine statistik" zeigt damit,
+// wie viele Absagen in Folge noch straffrei sind.
+func CurrentRun(in Input, userID string, asOf time.Time) int {
+	return currentRun(in, userID, asOf).Tage
+}
+
+// CurrentRules liefert das Regelwerk, nach dem die laufende Serie von userID
+// bewertet wird – ohne laufende Serie das zum Stichtag gültige (dann beginnt
+// die nächste Serie frühestens jetzt).
+func CurrentRules(in Input, userID string, asOf time.Time) RuleSet {
+	rules := in.Rules.Sorted()
+	if seg := currentRun(in, userID, asOf); seg.Tage > 0 {
+		return rules.At(seg.Start)
+	}
+	return rules.At(asOf)
+}
+
+func currentRun(in Input, userID string, asOf time.Time) Segment {
+	excluded := make(map[string]bool, len(in.Excluded))
+	for _, d := range in.Excluded {
+		excluded[iso(d)] = true
+	}
+	var rows []Row
+	for _, r := range in.Rows {
+		if r.UserID == userID {
+			rows = append(rows, r)
+		}
+	}
+	for _, u := range in.Users {
+		if u.UserID != userID {
+			continue
+		}
+		meetings, segs := userSegments(u, rows, in.Schedule.OrDefault(), excluded, asOf)
+		if len(segs) == 0 || len(meetings) == 0 {
+			return Segment{}
+		}
+		// Laufend ist nur die Serie, die bis zum letzten Treffen reicht;
+		// ein Reset mitten in der Serie hat sie bereits geschnitten.
+		last := segs[len(segs)-1]
+		for i, m := range meetings {
+			if m.Equal(last.Start) && i+last.Tage == len(meetings) {
+				return last
+			}
+		}
+		return Segment{}
+	}
+	return Segment{}
+}
+
 // VisibleAt entscheidet, ob eine Strafe zum Stichtag im Report erscheint:
 // offene immer, beglichene von der Begleichung bis einschließlich zum
-// Folgedonnerstag, gelöschte nie.
+// folgenden Treffen (SichtbarBis, sonst Folgedonnerstag), gelöschte nie.
 func VisibleAt(e Entry, asOf time.Time) bool {
 	switch e.Status {
 	case StatusOffen:
@@ -291,8 +412,12 @@ func VisibleAt(e Entry, asOf time.Time) bool {
 		if e.BeglichenAm == nil {
 			return false
 		}
+		bis := NextThursday(*e.BeglichenAm)
+		if e.SichtbarBis != nil {
+			bis = dateOnly(*e.SichtbarBis)
+		}
 		day := dateOnly(asOf)
-		return !day.Before(dateOnly(*e.BeglichenAm)) && !day.After(NextThursday(*e.BeglichenAm))
+		return !day.Before(dateOnly(*e.BeglichenAm)) && !day.After(bis)
 	default:
 		return false
 	}
@@ -1,8 +1,11 @@
 package penalty
 
 import (
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/michael/zumba-shared/domain"
 )
 
 // Donnerstage ab 2026-01-01 (ein Donnerstag).
@@ -29,7 +32,7 @@ func TestBetrag(t *testing.T) {
 		{0, 0}, {4, 0}, {5, 25}, {6, 30}, {10, 50},
 	}
 	for _, c := range cases {
-		if got := Betrag(c.tage); got != c.want {
+		if got := DefaultRuleSet.Betrag(c.tage); got != c.want {
 			t.Errorf("Betrag(%d) = %d, want %d", c.tage, got, c.want)
 		}
 	}
@@ -177,3 +180,423 @@ func TestNextThursday(t *testing.T) {
 		t.Errorf("Freitag → nächster Donnerstag: got %v", got)
 	}
 }
+
+// Dienstags-Gruppe: die Serie läuft über Dienstage, Donnerstage zählen nicht.
+func TestAssessEigenerSchedule(t *testing.T) {
+	tue := func(n int) time.Time { return thursday(n).AddDate(0, 0, -2) }
+	sched, err := domain.ParseSchedule("di")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := UserData{
+		UserID: "u1", Name: "Hans", EffectiveStart: tue(1),
+		Absences: []time.Time{tue(1), tue(2), tue(3), tue(4), tue(5), thursday(5)},
+	}
+	got := Assess(Input{Users: []UserData{u}, Schedule: sched}, tue(5))
+	if len(got) != 1 || got[0].Tage != 5 || !got[0].Datum.Equal(tue(1)) {
+		t.Fatalf("erwartet 5er-Serie ab erstem Dienstag, got %+v", got)
+	}
+}
+
+func TestVisibleAtEigenerSchedule(t *testing.T) {
+	sched, _ := domain.ParseSchedule("mi/2@2026-01-07")
+	u := user()
+	u.EffectiveStart = thursday(0)
+	row := Row{
+		ID: 1, UserID: "u1", Art: ArtNoShow, Datum: thursday(0), Betrag: 50,
+		Status: StatusBeglichen, BeglichenAm: ts(thursday(1), 20), // Do 08.01.
+	}
+	got := Assess(Input{Users: []UserData{u}, Rows: []Row{row}, Schedule: sched}, thursday(1))
+	if len(got) != 1 || got[0].SichtbarBis == nil {
+		t.Fatalf("erwartet beglichene Strafe mit SichtbarBis, got %+v", got)
+	}
+	// Nächstes Treffen nach dem 08.01. ist Mittwoch 21.01.
+	if !VisibleAt(got[0], thursday(2).AddDate(0, 0, 6)) {
+		t.Error("bis zum nächsten Treffen sichtbar")
+	}
+	if VisibleAt(got[0], thursday(2).AddDate(0, 0, 7)) {
+		t.Error("danach nicht mehr")
+	}
+}
+
+func TestCurrentRun(t *testing.T) {
+	// Zwei Fehltage bis zum Stichtag → laufende Serie 2.
+	in := Input{Users: []UserData{user(thursday(0), thursday(2), thursday(3))}}
+	if got := CurrentRun(in, "u1", thursday(3)); got != 2 {
+		t.Errorf("CurrentRun = %d, want 2", got)
+	}
+	// Beim letzten Treffen da → keine laufende Serie.
+	if got := CurrentRun(in, "u1", thursday(4)); got != 0 {
+		t.Errorf("CurrentRun nach Anwesenheit = %d, want 0", got)
+	}
+	// Unbekannter User.
+	if got := CurrentRun(in, "u2", thursday(3)); got != 0 {
+		t.Errorf("CurrentRun unbekannt = %d, want 0", got)
+	}
+}
+
+func TestCurrentRunNachBegleichen(t *testing.T) {
+	// 6x gefehlt, am Abend des 6. beglichen, danach 1x gefehlt → Serie 1.
+	u := user(thursday(0), thursday(1), thursday(2), thursday(3), thursday(4), thursday(5), thursday(6))
+	rows := []Row{{ID: 1, UserID: "u1", Art: ArtFehltage, Datum: thursday(0), Status: StatusBeglichen, BeglichenAm: ts(thursday(5), 22)}}
+	if got := CurrentRun(Input{Users: []UserData{u}, Rows: rows}, "u1", thursday(6)); got != 1 {
+		t.Errorf("CurrentRun = %d, want 1", got)
+	}
+}
+
+// neueRegeln: ab thursday(3) 4 Fehltage, 30 € Basis, +10 € je weiterem Tag.
+func neueRegeln() RuleSets {
+	neu := RuleSet{GiltAb: thursday(3), MinFehltage: 4, BasisBetrag: 30, ProTagBetrag: 10, NoShowDefault: 60}
+	// Absichtlich unsortiert – Assess sortiert selbst.
+	return RuleSets{neu, DefaultRuleSet}
+}
+
+func TestRuleSetsAt(t *testing.T) {
+	rs := neueRegeln().Sorted()
+	cases := []struct {
+		d    time.Time
+		want int
+	}{
+		{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 5}, // vor dem ersten: das erste
+		{thursday(2), 5},
+		{thursday(3), 4},
+		{thursday(9), 4},
+	}
+	for _, c := range cases {
+		if got := rs.At(c.d).MinFehltage; got != c.want {
+			t.Errorf("At(%s).MinFehltage = %d, want %d", iso(c.d), got, c.want)
+		}
+	}
+	if got := RuleSets(nil).At(thursday(0)); got.MinFehltage != DefaultRuleSet.MinFehltage {
+		t.Errorf("ohne Regelwerke: %+v", got)
+	}
+}
+
+func TestAssessSerieBehaeltRegelnIhresStarts(t *testing.T) {
+	// Alte Serie: startet vor den neuen Regeln, 6 Fehltage → 25 + 5 = 30 €.
+	alt := user(thursday(0), thursday(1), thursday(2), thursday(3), thursday(4), thursday(5))
+	// Neue Serie: startet unter den neuen Regeln, 4 Fehltage → 30 €, 5 → 40 €.
+	neu := UserData{UserID: "u2", Name: "Inge", EffectiveStart: thursday(0),
+		Absences: []time.Time{thursday(3), thursday(4), thursday(5), thursday(6), thursday(7)}}
+	got := Assess(Input{Users: []UserData{alt, neu}, Rules: neueRegeln()}, thursday(7))
+	if len(got) != 2 {
+		t.Fatalf("erwartet 2 Strafen, got %+v", got)
+	}
+	if got[0].Name != "Hans" || got[0].Betrag != 30 {
+		t.Errorf("alte Serie: %+v", got[0])
+	}
+	if got[1].Name != "Inge" || got[1].Tage != 5 || got[1].Betrag != 40 {
+		t.Errorf("neue Serie: %+v", got[1])
+	}
+}
+
+func TestRuleSetsClampStart(t *testing.T) {
+	rs := RuleSets{{GiltAb: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), MinFehltage: 5}}
+	if got := rs.ClampStart(nil); iso(got) != "2026-02-01" {
+		t.Errorf("ClampStart(nil) = %s", iso(got))
+	}
+	later := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	if got := rs.ClampStart(&later); !got.Equal(later) {
+		t.Errorf("ClampStart(später) = %s", iso(got))
+	}
+	if got := ClampStart(nil); iso(got) != "2025-12-01" {
+		t.Errorf("Default-ClampStart = %s", iso(got))
+	}
+}
+
+// Regelwerke sind append-only, sobald sie gelten: nur künftige ohne
+// gespeicherte Strafen im Zeitraum lassen sich ändern oder löschen.
+func TestValidateRuleSetChange(t *testing.T) {
+	today := thursday(10)
+	rs := RuleSets{
+		{ID: 1, GiltAb: thursday(0), MinFehltage: 5},
+		{ID: 2, GiltAb: thursday(12), MinFehltage: 4},
+		{ID: 3, GiltAb: thursday(20), MinFehltage: 3},
+	}
+	rows := []Row{{ID: 1, Datum: thursday(3)}, {ID: 2, Datum: thursday(25)}}
+	for _, c := range []struct {
+		name string
+		id   int64
+		ok   bool
+	}{
+		{"in Kraft", 1, false},
+		{"künftig, keine Strafe im Zeitraum", 2, true},
+		{"künftig, Strafe im Zeitraum", 3, false},
+		{"unbekannt", 9, true},
+	} {
+		err := ValidateRuleSetChange(rs, c.id, rows, today)
+		if (err == nil) != c.ok || (err != nil && !errors.Is(err, ErrRuleSetInKraft)) {
+			t.Errorf("%s: err = %v", c.name, err)
+		}
+	}
+
+	neu := RuleSet{GiltAb: today.AddDate(0, 0, -1), MinFehltage: 5}
+	if err := ValidateRuleSetSave(rs, neu, rows, today); !errors.Is(err, ErrRuleSetInKraft) {
+		t.Errorf("gilt_ab in der Vergangenheit: err = %v", err)
+	}
+	neu.GiltAb = today
+	if err := ValidateRuleSetSave(rs, neu, rows, today); err != nil {
+		t.Errorf("ab heute: err = %v", err)
+	}
+	neu.ID = 1
+	if err := ValidateRuleSetSave(rs, neu, rows, today); !errors.Is(err, ErrRuleSetInKraft) {
+		t.Errorf("Regelwerk in Kraft geändert: err = %v", err)
+	}
+}
+
+func TestCurrentRules(t *testing.T) {
+	u := user(thursday(1), thursday(2), thursday(3))
+	in := Input{Users: []UserData{u}, Rules: neueRegeln()}
+	if got := CurrentRules(in, "u1", thursday(3)); got.MinFehltage != 5 {
+		t.Errorf("laufende Serie ab thursday(1): %+v", got)
+	}
+	// Bei thursday(4) da → keine Serie, es gelten die aktuellen Regeln.
+	if got := CurrentRules(in, "u1", thursday(4)); got.MinFehltage != 4 {
+		t.Errorf("ohne Serie: %+v", got)
+	}
+}
+
+func TestAssessTeilzahlung(t *testing.T) {
+	u := user(thursday(0), thursday(1), thursday(2), thursday(3), thursday(4), thursday(5))
+	in := Input{
+		Users: []UserData{u},
+		Rows:  []Row{{ID: 7, UserID: "u1", Art: ArtFehltage, Datum: thursday(0), Status: StatusOffen}},
+		Buchungen: []Buchung{
+			{Art: BuchungZahlung, UserID: "u1", StrafeID: 7, Betrag: 10},
+			{Art: BuchungZahlung, UserID: "u1", StrafeID: 7, Betrag: 5},
+			{Art: BuchungZahlung, UserID: "u1", Betrag: 20}, // ohne Strafe: zählt nicht
+		},
+	}
+	got := Assess(in, thursday(5))
+	if len(got) != 1 || got[0].Betrag != 30 || got[0].Bezahlt != 15 || got[0].Rest() != 15 {
+		t.Fatalf("erwartet 30€ mit 15€ bezahlt, got %+v", got)
+	}
+}
+
+func TestKassenstandUndKonten(t *testing.T) {
+	in := Input{
+		Users: []UserData{{UserID: "u1", Name: "Hans"}, {UserID: "u2", Name: "Sepp"}, {UserID: "u3", Name: "Toni"}},
+		Buchungen: []Buchung{
+			{Art: BuchungZahlung, UserID: "u1", StrafeID: 7, Betrag: 10},
+			{Art: BuchungZahlung, UserID: "u2", Betrag: 25},
+			{Art: BuchungAusgabe, Betrag: 30, Notiz: "Runde"},
+		},
+	}
+	k := Kassenstand(in.Buchungen)
+	if k.Einnahmen != 35 || k.Ausgaben != 30 || k.Stand() != 5 || k.Buchungen != 3 {
+		t.Errorf("Kassenstand = %+v (Stand %d)", k, k.Stand())
+	}
+
+	entries := []Entry{
+		{ID: 7, UserID: "u1", Betrag: 30, Bezahlt: 10, Status: StatusOffen},
+		{ID: 8, UserID: "u2", Betrag: 25, Status: StatusBeglichen},
+	}
+	got := Konten(in, entries)
+	want := []Konto{
+		{UserID: "u1", Name: "Hans", Offen: 20, Bezahlt: 10},
+		{UserID: "u2", Name: "Sepp", Bezahlt: 25},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Konten = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Konten[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestValidateBuchung(t *testing.T) {
+	ok := Buchung{Art: BuchungAusgabe, Betrag: 30, Datum: thursday(0), Notiz: "Runde"}
+	if err := ValidateBuchung(ok); err != nil {
+		t.Errorf("gültige Ausgabe abgelehnt: %v", err)
+	}
+	for name, b := range map[string]Buchung{
+		"ohne Betrag":        {Art: BuchungZahlung, Datum: thursday(0)},
+		"Ausgabe ohne Notiz": {Art: BuchungAusgabe, Betrag: 30, Datum: thursday(0)},
+		"Ausgabe auf Strafe": {Art: BuchungAusgabe, Betrag: 30, Datum: thursday(0), Notiz: "x", StrafeID: 1},
+		"unbekannte Art":     {Art: "spende", Betrag: 30, Datum: thursday(0)},
+	} {
+		if err := ValidateBuchung(b); err == nil {
+			t.Errorf("%s: erwartet Fehler", name)
+		}
+	}
+}
+
+// beglichen_am ist der Buchungstag und schneidet die Serie: rückdatiert vor
+// den letzten gezählten Fehltag würde die Begleichung die Strafe nachträglich
+// kürzen. Am letzten Fehltag selbst (Abend) ist sie erlaubt.
+func TestValidateBegleichungFehltageSerie(t *testing.T) {
+	u := user(thursday(0), thursday(1), thursday(2), thursday(3), thursday(4), thursday(5))
+	row := Row{ID: 1, UserID: "u1", Art: ArtFehltage, Datum: thursday(0), Status: StatusOffen}
+	got := Assess(Input{Users: []UserData{u}, Rows: []Row{row}}, thursday(6))
+	if len(got) != 1 || !got[0].Ende.Equal(thursday(5)) {
+		t.Fatalf("erwartet Serienende %s, got %+v", thursday(5).Format("2006-01-02"), got)
+	}
+	e := got[0]
+
+	rueck := Buchung{Art: BuchungZahlung, UserID: "u1", StrafeID: 1, Betrag: 30, Datum: thursday(3)}
+	if err := ValidateBegleichung(e, rueck); !errors.Is(err, ErrBegleichungVorSerienende) {
+		t.Errorf("rückdatierte Begleichung: err = %v, want ErrBegleichungVorSerienende", err)
+	}
+
+	amEnde := rueck
+	amEnde.Datum = thursday(5)
+	if err := ValidateBegleichung(e, amEnde); err != nil {
+		t.Fatalf("Begleichung am letzten Fehltag abgelehnt: %v", err)
+	}
+	row.Status, row.BeglichenAm = StatusBeglichen, &amEnde.Datum
+	got = Assess(Input{Users: []UserData{u}, Rows: []Row{row}}, thursday(6))
+	if len(got) != 1 || got[0].Tage != 6 || got[0].Betrag != 30 {
+		t.Errorf("Begleichung am Serienende darf die Strafe nicht kürzen, got %+v", got)
+	}
+
+	// No-Shows haben keine Serie.
+	ns := Entry{ID: 2, Art: ArtNoShow, Datum: thursday(5), Betrag: 50}
+	if err := ValidateBegleichung(ns, rueck); err != nil {
+		t.Errorf("No-Show: %v", err)
+	}
+}
+
+// Entschuldigte Treffen sind neutral: 3 Fehltage, 4 Wochen krank, 2 Fehltage
+// ergeben eine Serie von 5 – die Krankheit unterbricht nicht und zählt nicht.
+func TestAssessEntschuldigtIstNeutral(t *testing.T) {
+	u := user(thursday(0), thursday(1), thursday(2), thursday(7), thursday(8))
+	u.Entschuldigt = []domain.Entschuldigung{{UserID: "u1", Von: thursday(3), Bis: thursday(6).AddDate(0, 0, 2), Grund: "Reha"}}
+	got := Assess(Input{Users: []UserData{u}}, thursday(8))
+	if len(got) != 1 || got[0].Tage != 5 || !got[0].Datum.Equal(thursday(0)) {
+		t.Fatalf("erwartet eine Serie 5 ab %s, got %+v", thursday(0).Format("2006-01-02"), got)
+	}
+
+	// Ohne Entschuldigung wäre man dazwischen anwesend gewesen: zwei kurze
+	// Serien, keine Strafe.
+	u.Entschuldigt = nil
+	if got := Assess(Input{Users: []UserData{u}}, thursday(8)); len(got) != 0 {
+		t.Errorf("ohne Entschuldigung erwartet keine Strafe, got %+v", got)
+	}
+
+	// Auch abgemeldete Treffen im Zeitraum zählen nicht.
+	u = user(thursday(0), thursday(1), thursday(2), thursday(3), thursday(4))
+	u.Entschuldigt = []domain.Entschuldigung{{UserID: "u1", Von: thursday(2), Bis: thursday(4), Grund: "Elternzeit"}}
+	if got := Assess(Input{Users: []UserData{u}}, thursday(4)); len(got) != 0 {
+		t.Errorf("Absagen im entschuldigten Zeitraum zählen mit: %+v", got)
+	}
+	if n := CurrentRun(Input{Users: []UserData{u}}, "u1", thursday(4)); n != 2 {
+		t.Errorf("CurrentRun = %d, want 2", n)
+	}
+}
+
+func TestAssessEinspruch(t *testing.T) {
+	row := Row{
+		ID: 3, UserID: "u1", Art: ArtNoShow, Datum: thursday(0), Betrag: 50, Status: StatusOffen,
+		Einspruch: Einspruch{Status: EinspruchOffen, Grund: "hab abgesagt", TraceID: 42},
+	}
+	got := Assess(Input{Users: []UserData{user()}, Rows: []Row{row}}, thursday(1))
+	if len(got) != 1 || !got[0].Einspruch.Offen() || got[0].Einspruch.TraceID != 42 {
+		t.Fatalf("Einspruch nicht übernommen: %+v", got)
+	}
+	if KannEinspruch(got[0]) {
+		t.Error("zweiter Einspruch darf nicht möglich sein")
+	}
+	row.Einspruch = Einspruch{}
+	if e := Assess(Input{Users: []UserData{user()}, Rows: []Row{row}}, thursday(1)); !KannEinspruch(e[0]) {
+		t.Error("Einspruch gegen offene No-Show-Strafe muss möglich sein")
+	}
+	if KannEinspruch(Entry{ID: 4, Art: ArtFehltage, Status: StatusOffen}) {
+		t.Error("kein Einspruch gegen Fehltage")
+	}
+}
+
+func TestValidateEntscheidung(t *testing.T) {
+	if err := ValidateEntscheidung(EinspruchAufgehoben, "Absage im Trace gefunden"); err != nil {
+		t.Errorf("gültig: %v", err)
+	}
+	if ValidateEntscheidung(EinspruchOffen, "x") == nil {
+		t.Error("offen ist keine Entscheidung")
+	}
+	if ValidateEntscheidung(EinspruchBestaetigt, "  ") == nil {
+		t.Error("Begründung ist Pflicht")
+	}
+}
+
+func TestParseMahnTage(t *testing.T) {
+	if got, err := ParseMahnTage(" 14, 28,42 "); err != nil || len(got) != 3 || got[2] != 42 {
+		t.Errorf("got %v, %v", got, err)
+	}
+	if got, err := ParseMahnTage(""); err != nil || got != nil {
+		t.Errorf("leer: %v, %v", got, err)
+	}
+	for _, bad := range []string{"14,7", "0", "x"} {
+		if _, err := ParseMahnTage(bad); err == nil {
+			t.Errorf("%q: Fehler erwartet", bad)
+		}
+	}
+}
+
+// Anker ist die älteste offene Strafe; Teilzahlungen mindern den offenen
+// Betrag, Einsprüche ruhen, verschickte Stufen zählen nur für den Anker.
+func TestMahnLage(t *testing.T) {
+	m := Mahnwesen{Tage: []int{14, 28, 42}, GruppeAb: 3}
+	entries := []Entry{
+		{ID: 1, UserID: "u1", Name: "Hans", Art: ArtNoShow, Datum: thursday(0), Betrag: 50, Bezahlt: 20, Status: StatusOffen},
+		{ID: 2, UserID: "u1", Name: "Hans", Art: ArtNoShow, Datum: thursday(3), Betrag: 50, Status: StatusOffen},
+		{ID: 3, UserID: "u2", Name: "Anna", Art: ArtNoShow, Datum: thursday(0), Betrag: 50, Status: StatusOffen,
+			Einspruch: Einspruch{Status: EinspruchOffen}},
+		{ID: 4, UserID: "u3", Name: "Bert", Art: ArtNoShow, Datum: thursday(0), Betrag: 50, Status: StatusBeglichen},
+	}
+	log := []Mahnung{{UserID: "u1", Stufe: 1, StrafeID: 1}, {UserID: "u1", Stufe: 1, StrafeID: 9}}
+	got := m.Lage(entries, log, thursday(4)) // Anker 28 Tage alt
+	if len(got) != 1 {
+		t.Fatalf("nur Hans mahnen, got %+v", got)
+	}
+	l := got[0]
+	if l.Anker.ID != 1 || l.Offen != 80 || l.Alter != 28 || l.Stufe != 2 || l.Gemahnt != 1 || !l.Faellig() {
+		t.Errorf("Lage = %+v", l)
+	}
+	if m.Gruppe(2) || !m.Gruppe(3) || !m.Letzte(3) || m.Letzte(2) {
+		t.Error("Gruppe/Letzte falsch")
+	}
+}
+
+// Eine schon 60 Tage alte Strafe beginnt trotzdem bei Stufe 1 (ohne
+// Gebühr); jede weitere Stufe kommt erst im Abstand der Schwellen nach der
+// vorigen Mahnung, die Gebühr erst nach allen Stufen davor.
+func TestMahnLageEineStufeJeLauf(t *testing.T) {
+	m := Mahnwesen{Tage: []int{14, 28, 42}, Gebuehr: 5}
+	entries := []Entry{{ID: 1, UserID: "u1", Name: "Hans", Art: ArtNoShow, Datum: thursday(0), Betrag: 50, Status: StatusOffen}}
+	heute := thursday(0).AddDate(0, 0, 60)
+	lage := func(log []Mahnung, asOf time.Time) MahnLage {
+		t.Helper()
+		got := m.Lage(entries, log, asOf)
+		if len(got) != 1 {
+			t.Fatalf("Lage = %+v", got)
+		}
+		return got[0]
+	}
+
+	l := lage(nil, heute)
+	if l.Alter != 60 || l.Stufe != 1 || !l.Faellig() || m.GebuehrFuer(l) != 0 {
+		t.Fatalf("erster Lauf: Stufe %d, Gebühr %d (%+v)", l.Stufe, m.GebuehrFuer(l), l)
+	}
+
+	log := []Mahnung{{UserID: "u1", Stufe: 1, StrafeID: 1, CreatedAt: heute.Add(18 * time.Hour)}}
+	if l := lage(log, heute.AddDate(0, 0, 13)); l.Faellig() {
+		t.Errorf("Stufe 2 vor Ablauf von 14 Tagen: %+v", l)
+	}
+	l = lage(log, heute.AddDate(0, 0, 14))
+	if l.Stufe != 2 || !l.Faellig() || m.GebuehrFuer(l) != 0 {
+		t.Errorf("nach 14 Tagen: Stufe %d, Gebühr %d", l.Stufe, m.GebuehrFuer(l))
+	}
+
+	log = append(log, Mahnung{UserID: "u1", Stufe: 2, StrafeID: 1, CreatedAt: heute.AddDate(0, 0, 14)})
+	l = lage(log, heute.AddDate(0, 0, 28))
+	if l.Stufe != 3 || !l.Faellig() || m.GebuehrFuer(l) != 5 {
+		t.Errorf("letzte Stufe: Stufe %d, Gebühr %d", l.Stufe, m.GebuehrFuer(l))
+	}
+
+	// Rückt ein anderer Anker nach, beginnt er wieder bei Stufe 1.
+	log = []Mahnung{{UserID: "u1", Stufe: 3, StrafeID: 9, CreatedAt: heute}}
+	if l := lage(log, heute.AddDate(0, 0, 1)); l.Stufe != 1 || m.GebuehrFuer(l) != 0 {
+		t.Errorf("neuer Anker: Stufe %d", l.Stufe)
+	}
+}
@@ -0,0 +1,156 @@
+package penalty
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RuleSet ist ein Strafen-Regelwerk (Tabelle strafen_regelwerk), gültig ab
+// GiltAb bis zum nächsten. Beschließt die Gruppe neue Regeln, kommt ein neues
+// Regelwerk dazu – alte Serien behalten die Regeln ihres ersten Fehltags.
+type RuleSet struct {
+	ID            int64
+	GiltAb        time.Time
+	MinFehltage   int // ab so vielen Fehltagen in Folge greift die Strafe
+	BasisBetrag   int // Euro bei genau MinFehltage
+	ProTagBetrag  int // Euro je weiterem Fehltag
+	NoShowDefault int // Euro, Vorschlag für manuelle No-Show-Strafen
+	Notiz         string
+	CreatedAt     time.Time
+}
+
+// DefaultRuleSet sind die ursprünglichen Regeln (Startpunkt der Strafen
+// 01.12.2025). Damit wird eine leere strafen_regelwerk-Tabelle befüllt; ohne
+// Regelwerke rechnet Assess damit.
+var DefaultRuleSet = RuleSet{
+	GiltAb:        time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+	MinFehltage:   5,
+	BasisBetrag:   25,
+	ProTagBetrag:  5,
+	NoShowDefault: 50,
+	Notiz:         "Ursprüngliche Regeln",
+}
+
+// OrDefault liefert r bzw. DefaultRuleSet, falls r der Nullwert ist.
+func (r RuleSet) OrDefault() RuleSet {
+	if r.MinFehltage == 0 {
+		return DefaultRuleSet
+	}
+	return r
+}
+
+// Betrag berechnet den Strafbetrag einer Fehltage-Serie (0 = keine Strafe).
+func (r RuleSet) Betrag(tage int) int {
+	if tage < r.MinFehltage {
+		return 0
+	}
+	return r.BasisBetrag + (tage-r.MinFehltage)*r.ProTagBetrag
+}
+
+// ValidateRuleSet prüft ein Regelwerk vor dem Speichern.
+func ValidateRuleSet(r RuleSet) error {
+	switch {
+	case r.GiltAb.IsZero():
+		return fmt.Errorf("gültig ab fehlt")
+	case r.MinFehltage < 1:
+		return fmt.Errorf("mindestens 1 Fehltag")
+	case r.BasisBetrag < 0 || r.ProTagBetrag < 0 || r.NoShowDefault < 0:
+		return fmt.Errorf("Beträge dürfen nicht negativ sein")
+	}
+	return nil
+}
+
+// ErrRuleSetInKraft: das Regelwerk gilt schon (oder deckt gespeicherte
+// Strafen ab) und ist damit unveränderlich. Assess rechnet Beträge live – eine
+// Änderung würde alte Strafen stillschweigend umrechnen.
+var ErrRuleSetInKraft = errors.New("Regelwerk ist in Kraft")
+
+// ValidateRuleSetSave prüft, ob r in rs gespeichert werden darf: gilt_ab nie
+// vor today, ein bestehendes Regelwerk (r.ID != 0) nur, solange es nicht in
+// Kraft ist (ValidateRuleSetChange). rows sind alle strafen-Zeilen.
+func ValidateRuleSetSave(rs RuleSets, r RuleSet, rows []Row, today time.Time) error {
+	if dateOnly(r.GiltAb).Before(dateOnly(today)) {
+		return fmt.Errorf("%w: gültig ab %s liegt in der Vergangenheit", ErrRuleSetInKraft, r.GiltAb.Format("02.01.2006"))
+	}
+	if r.ID == 0 {
+		return nil
+	}
+	return ValidateRuleSetChange(rs, r.ID, rows, today)
+}
+
+// ValidateRuleSetChange prüft, ob das Regelwerk id geändert oder gelöscht
+// werden darf: nur solange es in der Zukunft beginnt und keine gespeicherte
+// Strafe in seinen Zeitraum fällt. Unbekannte IDs sind kein Fehler (das
+// meldet der Store).
+func ValidateRuleSetChange(rs RuleSets, id int64, rows []Row, today time.Time) error {
+	rs = rs.Sorted()
+	for i, r := range rs {
+		if r.ID != id {
+			continue
+		}
+		if !dateOnly(r.GiltAb).After(dateOnly(today)) {
+			return fmt.Errorf("%w: gilt seit %s", ErrRuleSetInKraft, r.GiltAb.Format("02.01.2006"))
+		}
+		// Zeitraum [GiltAb, nächstes GiltAb); vor dem ersten gilt das erste.
+		var bis time.Time
+		if i+1 < len(rs) {
+			bis = dateOnly(rs[i+1].GiltAb)
+		}
+		for _, row := range rows {
+			d := dateOnly(row.Datum)
+			if (i > 0 && d.Before(dateOnly(r.GiltAb))) || (!bis.IsZero() && !d.Before(bis)) {
+				continue
+			}
+			return fmt.Errorf("%w: Strafe vom %s fällt in seinen Zeitraum", ErrRuleSetInKraft, row.Datum.Format("02.01.2006"))
+		}
+		return nil
+	}
+	return nil
+}
+
+// RuleSets sind alle Regelwerke, aufsteigend nach GiltAb (siehe Sorted).
+type RuleSets []RuleSet
+
+// Sorted liefert eine nach GiltAb sortierte Kopie.
+func (rs RuleSets) Sorted() RuleSets {
+	out := append(RuleSets(nil), rs...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].GiltAb.Before(out[j].GiltAb) })
+	return out
+}
+
+// At liefert das am Tag d gültige Regelwerk. Vor dem ersten gilt das erste,
+// ohne Regelwerke DefaultRuleSet.
+func (rs RuleSets) At(d time.Time) RuleSet {
+	if len(rs) == 0 {
+		return DefaultRuleSet
+	}
+	day := dateOnly(d)
+	out := rs[0]
+	for _, r := range rs[1:] {
+		if dateOnly(r.GiltAb).After(day) {
+			break
+		}
+		out = r
+	}
+	return out
+}
+
+// Start ist der Beginn der Strafen: GiltAb des ersten Regelwerks.
+func (rs RuleSets) Start() time.Time {
+	if len(rs) == 0 {
+		return DefaultRuleSet.GiltAb
+	}
+	return dateOnly(rs[0].GiltAb)
+}
+
+// ClampStart liefert den effektiven Startpunkt eines Users für die Strafen:
+// nie vor dem ersten Regelwerk.
+func (rs RuleSets) ClampStart(startDate *time.Time) time.Time {
+	start := rs.Start()
+	if startDate == nil || startDate.Before(start) {
+		return start
+	}
+	return *startDate
+}
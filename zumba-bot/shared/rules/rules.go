@@ -0,0 +1,225 @@
+// Package rules ist die deterministische Vorklassifikation: eine im Admin-UI
+// gepflegte Regeltabelle (classifier_rule), die der Bot vor dem Classifier
+// auswertet. Eindeutige Fälle – ein einzelnes 👎, feste Sprüche der Gruppe –
+// brauchen kein LLM. Das Admin-UI nutzt dieselbe Auswertung, um Regeln vor
+// dem Einschalten gegen die geprüften ml_messages zu testen.
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Arten einer Regel.
+const (
+	KindExact = "exact" // ganze Nachricht, ohne Groß-/Kleinschreibung und Satzzeichen am Rand
+	KindRegex = "regex" // Go-Regexp, ohne Groß-/Kleinschreibung, irgendwo in der Nachricht
+	KindEmoji = "emoji" // Nachricht besteht nur aus diesem Emoji (ggf. mehrfach)
+)
+
+// Kinds sind alle Arten in Anzeigereihenfolge.
+var Kinds = []string{KindEmoji, KindExact, KindRegex}
+
+// Labels sind die zulässigen Ergebnisse (dieselben wie beim Classifier).
+var Labels = []string{"true", "false", "invalid"}
+
+// Rule ist eine Zeile aus classifier_rule. Kleinere Priority wird zuerst
+// geprüft, bei Gleichstand die ältere Regel.
+type Rule struct {
+	ID        int64
+	Kind      string
+	Pattern   string
+	Label     string
+	Priority  int
+	Enabled   bool
+	Note      string
+	Hits      int64
+	LastHitAt *time.Time
+	CreatedAt time.Time
+}
+
+// Validate prüft Art, Label und Muster (Regexp kompilierbar, Emoji/Text
+// nicht leer).
+func Validate(r Rule) error {
+	if !contains(Labels, r.Label) {
+		return fmt.Errorf("unbekanntes Label %q", r.Label)
+	}
+	switch r.Kind {
+	case KindExact:
+		if normalize(r.Pattern) == "" {
+			return fmt.Errorf("leerer Text")
+		}
+	case KindEmoji:
+		if stripEmoji(r.Pattern) == "" {
+			return fmt.Errorf("leeres Emoji")
+		}
+	case KindRegex:
+		if strings.TrimSpace(r.Pattern) == "" {
+			return fmt.Errorf("leerer Ausdruck")
+		}
+		if _, err := regexp.Compile("(?i)" + r.Pattern); err != nil {
+			return fmt.Errorf("ungültiger Ausdruck: %w", err)
+		}
+	default:
+		return fmt.Errorf("unbekannte Art %q", r.Kind)
+	}
+	return nil
+}
+
+// Set ist eine kompilierte, nach Priorität sortierte Regelmenge.
+type Set struct {
+	rules []compiled
+}
+
+type compiled struct {
+	Rule
+	re   *regexp.Regexp
+	norm string // exact: normalisierter Text, emoji: Emoji ohne Modifikatoren
+}
+
+// Compile übernimmt alle gültigen Regeln (auch ausgeschaltete – filtern ist
+// Sache des Aufrufers). Ungültige Regeln werden übersprungen und als Fehler
+// gemeldet, damit eine kaputte Regel nicht alle anderen lahmlegt.
+func Compile(rs []Rule) (*Set, []error) {
+	var s Set
+	var errs []error
+	for _, r := range rs {
+		if err := Validate(r); err != nil {
+			errs = append(errs, fmt.Errorf("Regel #%d: %w", r.ID, err))
+			continue
+		}
+		c := compiled{Rule: r}
+		switch r.Kind {
+		case KindExact:
+			c.norm = normalize(r.Pattern)
+		case KindEmoji:
+			c.norm = stripEmoji(r.Pattern)
+		case KindRegex:
+			c.re = regexp.MustCompile("(?i)" + r.Pattern)
+		}
+		s.rules = append(s.rules, c)
+	}
+	sort.SliceStable(s.rules, func(i, j int) bool {
+		if s.rules[i].Priority != s.rules[j].Priority {
+			return s.rules[i].Priority < s.rules[j].Priority
+		}
+		return s.rules[i].ID < s.rules[j].ID
+	})
+	return &s, errs
+}
+
+// Len ist die Zahl der kompilierten Regeln.
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.rules)
+}
+
+// Match liefert die erste passende Regel (nil = keine).
+func (s *Set) Match(text string) *Rule {
+	if s == nil {
+		return nil
+	}
+	norm, emoji := normalize(text), stripEmoji(text)
+	for i := range s.rules {
+		c := &s.rules[i]
+		var ok bool
+		switch c.Kind {
+		case KindExact:
+			ok = norm == c.norm
+		case KindEmoji:
+			ok = emoji != "" && strings.ReplaceAll(emoji, c.norm, "") == ""
+		case KindRegex:
+			ok = c.re.MatchString(text)
+		}
+		if ok {
+			r := c.Rule
+			return &r
+		}
+	}
+	return nil
+}
+
+// normalize: klein, Leerraum zusammengefasst, Satzzeichen/Emoji am Rand weg
+// ("Bin raus!!" == "bin raus").
+func normalize(s string) string {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	return strings.TrimFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+}
+
+// stripEmoji entfernt Leerraum, Variation Selectors und Hautfarben, damit
+// "👍🏽", "👍️" und "👍 👍" als 👍 zählen.
+func stripEmoji(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r), r == '\uFE0F', r == '\uFE0E', r >= 0x1F3FB && r <= 0x1F3FF:
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Sample ist eine handgeprüfte Nachricht (ml_messages) mit korrektem Label.
+type Sample struct {
+	Message string
+	Label   string
+}
+
+// Report ist das Ergebnis einer Regel gegen den geprüften Korpus.
+type Report struct {
+	Total   int      // geprüfte Nachrichten
+	Matched int      // davon von der Regel erfasst
+	Correct int      // davon mit dem richtigen Label
+	Wrong   []Sample // erfasst, aber falsches Label (Label = korrektes)
+	Hits    []Sample // erfasst und richtig (höchstens maxExamples)
+}
+
+const maxExamples = 10
+
+// Precision ist der Anteil richtiger unter den erfassten Nachrichten (0 ohne
+// Treffer).
+func (r Report) Precision() float64 {
+	if r.Matched == 0 {
+		return 0
+	}
+	return float64(r.Correct) / float64(r.Matched)
+}
+
+// Evaluate prüft eine einzelne Regel gegen corpus – unabhängig davon, ob sie
+// eingeschaltet ist oder eine andere Regel vorher greifen würde.
+func Evaluate(r Rule, corpus []Sample) (Report, error) {
+	if err := Validate(r); err != nil {
+		return Report{}, err
+	}
+	set, _ := Compile([]Rule{r})
+	rep := Report{Total: len(corpus)}
+	for _, s := range corpus {
+		if set.Match(s.Message) == nil {
+			continue
+		}
+		rep.Matched++
+		if s.Label == r.Label {
+			rep.Correct++
+			if len(rep.Hits) < maxExamples {
+				rep.Hits = append(rep.Hits, s)
+			}
+		} else {
+			rep.Wrong = append(rep.Wrong, s)
+		}
+	}
+	return rep, nil
+}
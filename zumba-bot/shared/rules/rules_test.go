@@ -0,0 +1,79 @@
+package rules
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	set, errs := Compile([]Rule{
+		{ID: 1, Kind: KindEmoji, Pattern: "👎", Label: "false", Priority: 10},
+		{ID: 2, Kind: KindEmoji, Pattern: "👍", Label: "true", Priority: 10},
+		{ID: 3, Kind: KindExact, Pattern: "Bin raus", Label: "false", Priority: 20},
+		{ID: 4, Kind: KindRegex, Pattern: `^stat?is?t?i?k+$`, Label: "invalid", Priority: 30},
+		{ID: 5, Kind: KindRegex, Pattern: `raus`, Label: "invalid", Priority: 50},
+	})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	cases := map[string]int64{
+		"👎":                  1,
+		"👎🏽 👎":               1,
+		"👍️":                 2,
+		"👍👎":                 0, // gemischt: keine Emoji-Regel
+		"bin raus!!":         3,
+		"  BIN  RAUS":        3,
+		"statisik":           4,
+		"ich bin raus heute": 5, // exact greift nicht, die Regex schon
+		"komme":              0,
+	}
+	for text, want := range cases {
+		var got int64
+		if r := set.Match(text); r != nil {
+			got = r.ID
+		}
+		if got != want {
+			t.Errorf("Match(%q) = #%d, want #%d", text, got, want)
+		}
+	}
+}
+
+func TestPrioritaetVorAlter(t *testing.T) {
+	set, _ := Compile([]Rule{
+		{ID: 1, Kind: KindRegex, Pattern: "raus", Label: "invalid", Priority: 100},
+		{ID: 2, Kind: KindExact, Pattern: "bin raus", Label: "false", Priority: 5},
+	})
+	if r := set.Match("bin raus"); r == nil || r.ID != 2 {
+		t.Errorf("Match = %+v, want #2", r)
+	}
+}
+
+func TestCompileUeberspringtUngueltige(t *testing.T) {
+	set, errs := Compile([]Rule{
+		{ID: 1, Kind: KindRegex, Pattern: "(", Label: "false"},
+		{ID: 2, Kind: KindExact, Pattern: "bin raus", Label: "vielleicht"},
+		{ID: 3, Kind: KindEmoji, Pattern: "❌", Label: "false"},
+	})
+	if len(errs) != 2 || set.Len() != 1 {
+		t.Errorf("errs = %v, Len = %d", errs, set.Len())
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	corpus := []Sample{
+		{Message: "👎", Label: "false"},
+		{Message: "👎👎", Label: "false"},
+		{Message: "👎", Label: "invalid"}, // Reaktion auf einen Witz
+		{Message: "bin dabei", Label: "true"},
+	}
+	rep, err := Evaluate(Rule{Kind: KindEmoji, Pattern: "👎", Label: "false"}, corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Total != 4 || rep.Matched != 3 || rep.Correct != 2 || len(rep.Wrong) != 1 || len(rep.Hits) != 2 {
+		t.Errorf("Report = %+v", rep)
+	}
+	if p := rep.Precision(); p < 0.66 || p > 0.67 {
+		t.Errorf("Precision = %.3f", p)
+	}
+	if _, err := Evaluate(Rule{Kind: KindRegex, Pattern: "(", Label: "false"}, corpus); err == nil {
+		t.Error("ungültige Regel ohne Fehler")
+	}
+}
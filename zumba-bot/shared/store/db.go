@@ -1,5 +1,5 @@
 // Package store bündelt die service-übergreifenden DB-Zugriffe auf die
-// Stammtisch-Domäne (Leaderboard, Strafen). Alle Funktionen nehmen ein
+// Stammtisch-Domäne (Leaderboard, Strafen, Zusagen). Alle Funktionen nehmen ein
 // Queryer/Execer entgegen, damit sie mit *sql.DB, *sql.Tx oder den dünnen
 // DB-Wrappern der Services funktionieren.
 package store
@@ -18,3 +18,20 @@ type Queryer interface {
 type Execer interface {
 	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
+
+// QueryExecer wird von *sql.DB und *sql.Tx erfüllt (Prüfen vor dem Schreiben).
+type QueryExecer interface {
+	Queryer
+	Execer
+}
+
+// RowQueryer wird von *sql.DB und *sql.Tx erfüllt (Einzelzeilen, RETURNING).
+type RowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// TxBeginner wird von *sql.DB erfüllt (Prüfen und Schreiben in einer
+// Transaktion).
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/michael/zumba-shared/penalty"
+)
+
+// Einspruch gegen No-Show-Strafen (Spalten strafen.einspruch*, DDL in
+// EnsureStrafenSchema). Das Mitglied legt ihn per Bot-Befehl ein, der Admin
+// entscheidet im Admin-UI.
+
+// EinspruchBeleg ist die letzte Nachricht eines Mitglieds vor dem Treffen –
+// der Beleg für "ich hab doch abgesagt". IDs 0 = nicht gefunden.
+type EinspruchBeleg struct {
+	TraceID     int64 // bot_trace.id
+	MLMessageID int64 // ml_messages.id
+	Nachricht   string
+	Am          time.Time
+}
+
+// FindEinspruchBeleg sucht die letzte Nachricht des Users in [von, bis) in
+// bot_trace (ohne Befehle) und ml_messages. Fehlt eine der Tabellen (kein
+// Shadow-Modus, frische DB), bleibt der Teil leer.
+func FindEinspruchBeleg(ctx context.Context, q Queryer, userID string, von, bis time.Time) (EinspruchBeleg, error) {
+	var b EinspruchBeleg
+	const traceQ = `
+		SELECT id, COALESCE(message, ''), created_at FROM bot_trace
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+		  AND COALESCE(message, '') <> ''
+		  AND COALESCE(path, '') NOT IN ('command', 'statistik')
+		ORDER BY created_at DESC LIMIT 1`
+	if err := scanBeleg(ctx, q, "bot_trace", traceQ, userID, von, bis, &b.TraceID, &b.Nachricht, &b.Am); err != nil {
+		return b, fmt.Errorf("FindEinspruchBeleg: %w", err)
+	}
+	var (
+		msg string
+		am  time.Time
+	)
+	const mlQ = `
+		SELECT id, message, created_at FROM ml_messages
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY created_at DESC LIMIT 1`
+	if err := scanBeleg(ctx, q, "ml_messages", mlQ, userID, von, bis, &b.MLMessageID, &msg, &am); err != nil {
+		return b, fmt.Errorf("FindEinspruchBeleg: %w", err)
+	}
+	if b.TraceID == 0 {
+		b.Nachricht, b.Am = msg, am
+	}
+	return b, nil
+}
+
+// scanBeleg liest eine Beleg-Zeile aus table; fehlt die Tabelle oder die
+// Zeile, bleiben die Ziele unverändert.
+func scanBeleg(ctx context.Context, q Queryer, table, query, userID string, von, bis time.Time, dest ...any) error {
+	var exists bool
+	if err := scanOne(ctx, q, []any{&exists}, `SELECT to_regclass($1) IS NOT NULL`, table); err != nil {
+		return fmt.Errorf("%s: %w", table, err)
+	}
+	if !exists {
+		return nil
+	}
+	if err := scanOne(ctx, q, dest, query, userID, von, bis); err != nil {
+		return fmt.Errorf("%s: %w", table, err)
+	}
+	return nil
+}
+
+// scanOne liest höchstens eine Zeile (keine Zeile = kein Fehler).
+func scanOne(ctx context.Context, q Queryer, dest []any, query string, args ...any) error {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ErhebeEinspruch legt Einspruch gegen die offene No-Show-Strafe id des
+// Users ein – je Strafe nur einmal. Die Beleg-IDs dürfen 0 sein.
+func ErhebeEinspruch(ctx context.Context, e Execer, id int64, userID, grund string, b EinspruchBeleg) error {
+	const q = `
+		UPDATE strafen
+		SET einspruch = 'einspruch', einspruch_grund = $3, einspruch_am = now(),
+		    einspruch_trace_id = NULLIF($4::bigint, 0), einspruch_ml_id = NULLIF($5::bigint, 0)
+		WHERE id = $1 AND "userId" = $2 AND art = 'noshow' AND status = 'offen'
+		  AND einspruch IS NULL`
+	res, err := e.ExecContext(ctx, q, id, userID, grund, b.TraceID, b.MLMessageID)
+	if err != nil {
+		return fmt.Errorf("ErhebeEinspruch: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("ErhebeEinspruch: keine offene No-Show-Strafe %d ohne Einspruch", id)
+	}
+	return nil
+}
+
+// EntscheideEinspruch entscheidet über einen offenen Einspruch. Aufgehoben
+// heißt: die Strafe wird im selben Statement gelöscht (soft, wie
+// LoescheStrafe), samt offener Mahngebühren, die für sie angefallen sind –
+// sonst würde das Mahnwesen die Gebühr weiter anmahnen. Bestätigt lässt sie
+// offen.
+func EntscheideEinspruch(ctx context.Context, e Execer, id int64, status penalty.EinspruchStatus, notiz string) error {
+	if err := penalty.ValidateEntscheidung(status, notiz); err != nil {
+		return fmt.Errorf("EntscheideEinspruch: %w", err)
+	}
+	const q = `
+		WITH s AS (
+		  UPDATE strafen
+		  SET einspruch = $2, einspruch_notiz = $3, einspruch_entschieden_am = now(),
+		      status = CASE WHEN $2 = 'aufgehoben' THEN 'geloescht' ELSE status END,
+		      geloescht_am = CASE WHEN $2 = 'aufgehoben' THEN now() ELSE geloescht_am END
+		  WHERE id = $1 AND einspruch = 'einspruch'
+		  RETURNING id
+		), g AS (
+		  UPDATE strafen SET status = 'geloescht', geloescht_am = now()
+		  WHERE $2 = 'aufgehoben' AND art = 'mahngebuehr' AND status = 'offen'
+		    AND id IN (SELECT gebuehr_id FROM strafen_mahnung WHERE strafe_id IN (SELECT id FROM s))
+		)
+		SELECT id FROM s`
+	res, err := e.ExecContext(ctx, q, id, string(status), notiz)
+	if err != nil {
+		return fmt.Errorf("EntscheideEinspruch: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("EntscheideEinspruch: kein offener Einspruch gegen Strafe %d", id)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/michael/zumba-shared/domain"
+)
+
+// Entschuldigte Zeiträume (Tabelle stammtisch_entschuldigt, DDL in
+// EnsureMemberSchema). Gepflegt im Admin-UI; Strafen und – optional – die
+// Rangliste lassen die Treffen darin außen vor.
+
+// ListEntschuldigungen liefert alle Zeiträume, je Mitglied chronologisch.
+func ListEntschuldigungen(ctx context.Context, q Queryer) (domain.Entschuldigungen, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, "userId", von, bis, grund, created_at
+		FROM stammtisch_entschuldigt
+		ORDER BY "userId", von, id`)
+	if err != nil {
+		return nil, fmt.Errorf("ListEntschuldigungen: %w", err)
+	}
+	defer rows.Close()
+	var out domain.Entschuldigungen
+	for rows.Next() {
+		var e domain.Entschuldigung
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Von, &e.Bis, &e.Grund, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ListEntschuldigungen scan: %w", err)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// InsertEntschuldigung legt einen Zeitraum an.
+func InsertEntschuldigung(ctx context.Context, e Execer, x domain.Entschuldigung) error {
+	if err := domain.ValidateEntschuldigung(x); err != nil {
+		return fmt.Errorf("InsertEntschuldigung: %w", err)
+	}
+	if _, err := e.ExecContext(ctx, `
+		INSERT INTO stammtisch_entschuldigt ("userId", von, bis, grund)
+		VALUES ($1, $2, $3, $4)`, x.UserID, x.Von, x.Bis, x.Grund); err != nil {
+		return fmt.Errorf("InsertEntschuldigung: %w", err)
+	}
+	return nil
+}
+
+// DeleteEntschuldigung entfernt einen Zeitraum; Strafen und Rangliste
+// rechnen danach wieder mit allen Treffen.
+func DeleteEntschuldigung(ctx context.Context, e Execer, id int64) error {
+	res, err := e.ExecContext(ctx, `DELETE FROM stammtisch_entschuldigt WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("DeleteEntschuldigung: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("DeleteEntschuldigung: Zeitraum %d nicht gefunden", id)
+	}
+	return nil
+}
+
+// excusedArgs liefert die Zeiträume als drei parallele Array-Parameter
+// (userId, von, bis) für die Rangliste – so braucht die Query die Tabelle
+// nicht (wrapped legt kein Schema an).
+func excusedArgs(es domain.Entschuldigungen) (users, von, bis any) {
+	u := make([]string, len(es))
+	v := make([]string, len(es))
+	b := make([]string, len(es))
+	for i, e := range es {
+		u[i] = e.UserID
+		v[i] = e.Von.Format("2006-01-02")
+		b[i] = e.Bis.Format("2006-01-02")
+	}
+	return pq.Array(u), pq.Array(v), pq.Array(b)
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/michael/zumba-shared/penalty"
+)
+
+// Kassenbuch der Strafenkasse (Tabelle strafen_kasse, DDL in
+// EnsureStrafenSchema): Zahlungen – auf Strafen, auch teilweise – und
+// Ausgaben. Der Kassenstand ist immer die Summe der Buchungen.
+
+// ListBuchungen liefert alle Buchungen, neueste zuerst.
+func ListBuchungen(ctx context.Context, q Queryer) ([]penalty.Buchung, error) {
+	return listBuchungen(ctx, q, time.Time{})
+}
+
+// listBuchungen liefert die Buchungen bis einschließlich asOf (Nullwert =
+// alle), neueste zuerst.
+func listBuchungen(ctx context.Context, q Queryer, asOf time.Time) ([]penalty.Buchung, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, art, COALESCE("userId", ''), COALESCE(strafe_id, 0), betrag, datum, notiz, created_at
+		FROM strafen_kasse
+		WHERE $1::date IS NULL OR datum <= $1
+		ORDER BY datum DESC, id DESC`, sql.NullTime{Time: asOf, Valid: !asOf.IsZero()})
+	if err != nil {
+		return nil, fmt.Errorf("ListBuchungen: %w", err)
+	}
+	defer rows.Close()
+	var out []penalty.Buchung
+	for rows.Next() {
+		var (
+			b   penalty.Buchung
+			art string
+		)
+		if err := rows.Scan(&b.ID, &art, &b.UserID, &b.StrafeID, &b.Betrag, &b.Datum, &b.Notiz, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ListBuchungen scan: %w", err)
+		}
+		b.Art = penalty.BuchungArt(art)
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// InsertBuchung bucht b. begleicht setzt die Strafe b.StrafeID im selben
+// Statement auf beglichen (Zahlung deckt den Rest) – Buchung und Status
+// ändern sich nur gemeinsam. beglichen_am ist der Buchungstag, nicht der
+// Zeitpunkt der Eingabe: Nachgetragene Zahlungen verschieben sonst die
+// Sichtbarkeit im Strafenblock.
+func InsertBuchung(ctx context.Context, e Execer, b penalty.Buchung, begleicht bool) error {
+	if err := penalty.ValidateBuchung(b); err != nil {
+		return fmt.Errorf("InsertBuchung: %w", err)
+	}
+	if begleicht && b.StrafeID == 0 {
+		return fmt.Errorf("InsertBuchung: begleichen ohne Strafe")
+	}
+	const q = `
+		WITH b AS (
+		  INSERT INTO strafen_kasse (art, "userId", strafe_id, betrag, datum, notiz)
+		  VALUES ($1, NULLIF($2, ''), NULLIF($3, 0), $4, $5, $6)
+		  RETURNING strafe_id
+		)
+		UPDATE strafen SET status = 'beglichen', beglichen_am = $5
+		WHERE $7 AND status = 'offen' AND id = (SELECT strafe_id FROM b)`
+	if _, err := e.ExecContext(ctx, q, string(b.Art), b.UserID, b.StrafeID, b.Betrag, b.Datum, b.Notiz, begleicht); err != nil {
+		return fmt.Errorf("InsertBuchung: %w", err)
+	}
+	return nil
+}
+
+// DeleteBuchung storniert eine Fehlbuchung. War die Strafe der Buchung damit
+// beglichen und decken die übrigen Zahlungen ihren Betrag nicht mehr, ist sie
+// im selben Statement wieder offen (beglichen_am = NULL) – sonst bliebe eine
+// unbezahlte Strafe beglichen und ihr Reset aktiv. betrag ist der bewertete
+// Betrag der Strafe (penalty.Entry.Betrag); er zählt nur bei Fehltagen, deren
+// Betrag nicht in der Zeile steht.
+func DeleteBuchung(ctx context.Context, q Queryer, id int64, betrag int) error {
+	const query = `
+		WITH b AS (
+		  DELETE FROM strafen_kasse WHERE id = $1 RETURNING strafe_id
+		), s AS (
+		  UPDATE strafen SET status = 'offen', beglichen_am = NULL
+		  WHERE status = 'beglichen' AND id = (SELECT strafe_id FROM b)
+		    AND (SELECT COALESCE(SUM(k.betrag), 0) FROM strafen_kasse k
+		         WHERE k.strafe_id = strafen.id AND k.art = 'zahlung' AND k.id <> $1)
+		        < COALESCE(strafen.betrag, $2)
+		)
+		SELECT count(*) FROM b`
+	var n int
+	if err := scanOne(ctx, q, []any{&n}, query, id, betrag); err != nil {
+		return fmt.Errorf("DeleteBuchung: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("DeleteBuchung: Buchung %d nicht gefunden", id)
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
+
 	"github.com/michael/zumba-shared/domain"
 )
 
@@ -25,8 +27,21 @@ type LeaderboardRow struct {
 	// Streak ist vorzeichenbehaftet: >0 = aktuelle Anwesenheits-Serie,
 	// <0 = aktuelle Abwesenheits-Serie, 0 = noch keine Donnerstage.
 	Streak int
+	// Rank ist der Platz in der Rangliste: gleiche Anwesenheit und gleiche
+	// Prozent teilen sich den Platz (wie die Medaillen im Statistik-Text).
+	// Users ist die Zahl der User in der Rangliste.
+	Rank  int
+	Users int
 }
 
+// rankedQ ergänzt die Rangliste um Platz und Teilnehmerzahl – auch
+// UserLeaderboardRow kennt so den Platz des einen Users.
+const rankedQ = `
+SELECT b.*,
+  DENSE_RANK() OVER (ORDER BY b.attendance_count DESC, b.attend_percentage DESC)::int AS rank,
+  COUNT(*) OVER ()::int AS users
+FROM (%s) b`
+
 func scanLeaderboardRows(ctx context.Context, q Queryer, query string, args ...any) ([]LeaderboardRow, error) {
 	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -42,6 +57,7 @@ func scanLeaderboardRows(ctx context.Context, q Queryer, query string, args ...a
 			&r.EffectiveStart, &r.ThursdayCount,
 			&r.AttendanceCount, &r.AwayCount,
 			&r.AttendPercent, &r.Streak,
+			&r.Rank, &r.Users,
 		); err != nil {
 			return nil, fmt.Errorf("Leaderboard scan: %w", err)
 		}
@@ -51,15 +67,22 @@ func scanLeaderboardRows(ctx context.Context, q Queryer, query string, args ...a
 }
 
 // Leaderboard liefert die Rangliste für den Zeitraum p (Ende wird in SQL an
-// current_date gekappt), sortiert nach Anwesenheit, Prozent, Name.
-func Leaderboard(ctx context.Context, q Queryer, p domain.Period) ([]LeaderboardRow, error) {
-	return scanLeaderboardRows(ctx, q, leaderboardQ, p.Start, p.End)
+// current_date gekappt), sortiert nach Anwesenheit, Prozent, Name. Gezählt
+// werden die Treffen-Tage des Schedules s, je User ohne die Treffen in
+// seinen excused-Zeiträumen (nil = alle Treffen zählen; siehe
+// LEADERBOARD_EXCLUDE_EXCUSED).
+func Leaderboard(ctx context.Context, q Queryer, p domain.Period, s domain.Schedule, excused domain.Entschuldigungen) ([]LeaderboardRow, error) {
+	query := fmt.Sprintf(rankedQ, leaderboardQ) + ` ORDER BY b.attendance_count DESC, b.attend_percentage DESC, b."userName"`
+	users, von, bis := excusedArgs(excused)
+	return scanLeaderboardRows(ctx, q, query, p.Start, p.End, MeetingDates(s, p.Start, p.End), users, von, bis)
 }
 
-// UserLeaderboardRow filtert die Rangliste in SQL auf einen einzelnen User.
-func UserLeaderboardRow(ctx context.Context, q Queryer, p domain.Period, userID string) (LeaderboardRow, error) {
-	query := `SELECT * FROM (` + leaderboardQ + `) b WHERE b."userId" = $3`
-	rows, err := scanLeaderboardRows(ctx, q, query, p.Start, p.End, userID)
+// UserLeaderboardRow filtert die Rangliste in SQL auf einen einzelnen User
+// (Platz bezogen auf die ganze Rangliste). Kein Treffer = leere Zeile.
+func UserLeaderboardRow(ctx context.Context, q Queryer, p domain.Period, s domain.Schedule, excused domain.Entschuldigungen, userID string) (LeaderboardRow, error) {
+	query := `SELECT * FROM (` + fmt.Sprintf(rankedQ, leaderboardQ) + `) r WHERE r."userId" = $7`
+	users, von, bis := excusedArgs(excused)
+	rows, err := scanLeaderboardRows(ctx, q, query, p.Start, p.End, MeetingDates(s, p.Start, p.End), users, von, bis, userID)
 	if err != nil {
 		return LeaderboardRow{}, err
 	}
@@ -68,3 +91,14 @@ func UserLeaderboardRow(ctx context.Context, q Queryer, p domain.Period, userID
 	}
 	return rows[0], nil
 }
+
+// MeetingDates liefert die Treffen-Tage von s in [start, end] als
+// date[]-Parameter für Queries (statt fest verdrahtetem ISODOW = 4).
+func MeetingDates(s domain.Schedule, start, end time.Time) any {
+	days := s.Meetings(start, end)
+	out := make([]string, len(days))
+	for i, d := range days {
+		out[i] = d.Format("2006-01-02")
+	}
+	return pq.Array(out)
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/michael/zumba-shared/penalty"
+)
+
+// Mahnwesen (Tabellen strafen_mahnung und strafen_mahn_optout, DDL in
+// EnsureStrafenSchema). Der Bot verschickt die Mahnungen und protokolliert
+// jede einzelne; das Admin-UI zeigt daraus die Mahnstufe je Mitglied.
+
+// ListMahnungen liefert alle verschickten Mahnungen, älteste zuerst.
+func ListMahnungen(ctx context.Context, q Queryer) ([]penalty.Mahnung, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, "userId", stufe, strafe_id, offen, gruppe, COALESCE(gebuehr_id, 0), created_at
+		FROM strafen_mahnung
+		ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("ListMahnungen: %w", err)
+	}
+	defer rows.Close()
+	var out []penalty.Mahnung
+	for rows.Next() {
+		var m penalty.Mahnung
+		if err := rows.Scan(&m.ID, &m.UserID, &m.Stufe, &m.StrafeID, &m.Offen, &m.Gruppe,
+			&m.GebuehrID, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ListMahnungen scan: %w", err)
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// InsertMahnung protokolliert eine Mahnung, bevor sie verschickt wird, und
+// liefert ihre ID. Mit gebuehr > 0 legt dasselbe Statement die Mahngebühr
+// als Strafe (Tag datum) an und verknüpft sie – Mahnung und Gebühr gibt es
+// nur gemeinsam. Je Anker und Stufe höchstens eine Mahnung (Unique-Index):
+// ein zweiter Versuch scheitert hier, nicht erst beim Mitglied.
+func InsertMahnung(ctx context.Context, q Queryer, m penalty.Mahnung, gebuehr int, datum time.Time) (int64, error) {
+	const query = `
+		WITH g AS (
+		  INSERT INTO strafen ("userId", art, datum, betrag)
+		  SELECT $1, 'mahngebuehr', $7, $6 WHERE $6::int > 0
+		  RETURNING id
+		)
+		INSERT INTO strafen_mahnung ("userId", stufe, strafe_id, offen, gruppe, gebuehr_id)
+		VALUES ($1, $2, $3, $4, $5, (SELECT id FROM g))
+		RETURNING id`
+	var id int64
+	if err := scanOne(ctx, q, []any{&id}, query, m.UserID, m.Stufe, m.StrafeID, m.Offen, m.Gruppe, gebuehr, datum); err != nil {
+		return 0, fmt.Errorf("InsertMahnung: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteMahnung nimmt eine nicht zugestellte Mahnung samt ihrer Mahngebühr
+// zurück (ein Statement), damit der nächste Lauf sie erneut versucht.
+func DeleteMahnung(ctx context.Context, e Execer, id int64) error {
+	const q = `
+		WITH m AS (
+		  DELETE FROM strafen_mahnung WHERE id = $1 RETURNING gebuehr_id
+		)
+		DELETE FROM strafen WHERE art = 'mahngebuehr' AND id = (SELECT gebuehr_id FROM m)`
+	if _, err := e.ExecContext(ctx, q, id); err != nil {
+		return fmt.Errorf("DeleteMahnung: %w", err)
+	}
+	return nil
+}
+
+// SetMahnOptOut nimmt userID aus der Erwähnung in der Gruppe (optOut) bzw.
+// wieder auf. Direktnachrichten bekommt er weiterhin.
+func SetMahnOptOut(ctx context.Context, e Execer, userID string, optOut bool) error {
+	q := `INSERT INTO strafen_mahn_optout ("userId") VALUES ($1) ON CONFLICT DO NOTHING`
+	if !optOut {
+		q = `DELETE FROM strafen_mahn_optout WHERE "userId" = $1`
+	}
+	if _, err := e.ExecContext(ctx, q, userID); err != nil {
+		return fmt.Errorf("SetMahnOptOut: %w", err)
+	}
+	return nil
+}
+
+// MahnOptOuts liefert die userIds, die in der Gruppe nicht erwähnt werden
+// wollen.
+func MahnOptOuts(ctx context.Context, q Queryer) (map[string]bool, error) {
+	rows, err := q.QueryContext(ctx, `SELECT "userId" FROM strafen_mahn_optout`)
+	if err != nil {
+		return nil, fmt.Errorf("MahnOptOuts: %w", err)
+	}
+	defer rows.Close()
+	out := map[string]bool{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("MahnOptOuts scan: %w", err)
+		}
+		out[id] = true
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAliasTaken: der Spitzname gehört schon einem (anderen) Mitglied.
+var ErrAliasTaken = errors.New("Spitzname bereits vergeben")
+
+// Member ist ein Mitglied mit allen Namen, unter denen es in der Gruppe
+// genannt wird (userName plus Spitznamen aus user_alias).
+type Member struct {
+	UserID   string
+	UserName string
+	Aliases  []string
+}
+
+// EnsureMemberSchema legt die Spitznamen-Tabelle und die entschuldigten
+// Zeiträume an und ergänzt die Absagen um entered_by: wer eine Absage für
+// jemand anderen eingetragen hat (NULL = selbst bzw. Admin-UI). Bot und
+// Admin-UI rufen beide beim Start.
+func EnsureMemberSchema(ctx context.Context, e Execer) error {
+	const q = `
+		CREATE TABLE IF NOT EXISTS user_alias (
+		  alias      TEXT PRIMARY KEY,
+		  "userId"   TEXT NOT NULL,
+		  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		CREATE INDEX IF NOT EXISTS user_alias_user_idx ON user_alias ("userId");
+		CREATE TABLE IF NOT EXISTS stammtisch_entschuldigt (
+		  id         BIGSERIAL PRIMARY KEY,
+		  "userId"   TEXT NOT NULL,
+		  von        DATE NOT NULL,
+		  bis        DATE NOT NULL,
+		  grund      TEXT NOT NULL,
+		  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		  CHECK (bis >= von)
+		);
+		CREATE INDEX IF NOT EXISTS stammtisch_entschuldigt_user_idx ON stammtisch_entschuldigt ("userId");
+		ALTER TABLE public.stammtisch_abwesenheit
+		  ADD COLUMN IF NOT EXISTS entered_by TEXT;`
+	_, err := e.ExecContext(ctx, q)
+	return err
+}
+
+// Members liefert alle Mitglieder samt Spitznamen, nach Namen sortiert.
+// Aliasse werden klein gespeichert (Eindeutigkeit unabhängig von der
+// Schreibweise).
+func Members(ctx context.Context, q Queryer) ([]Member, error) {
+	const query = `
+		SELECT u."userId", u."userName", COALESCE(a.alias, '')
+		FROM public.users u
+		LEFT JOIN user_alias a ON a."userId" = u."userId"
+		ORDER BY u."userName", u."userId", a.alias`
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("Members: %w", err)
+	}
+	defer rows.Close()
+	var out []Member
+	for rows.Next() {
+		var id, name, alias string
+		if err := rows.Scan(&id, &name, &alias); err != nil {
+			return nil, fmt.Errorf("Members: %w", err)
+		}
+		if len(out) == 0 || out[len(out)-1].UserID != id {
+			out = append(out, Member{UserID: id, UserName: name})
+		}
+		if alias != "" {
+			m := &out[len(out)-1]
+			m.Aliases = append(m.Aliases, alias)
+		}
+	}
+	return out, rows.Err()
+}
+
+// NormalizeAlias ist die gespeicherte Form eines Spitznamens (klein, ohne
+// Rand-Leerzeichen).
+func NormalizeAlias(alias string) string {
+	return strings.ToLower(strings.Join(strings.Fields(alias), " "))
+}
+
+// AddAlias hinterlegt einen Spitznamen für userID. Gehört er schon jemandem,
+// liefert es ErrAliasTaken.
+func AddAlias(ctx context.Context, e Execer, userID, alias string) error {
+	res, err := e.ExecContext(ctx, `
+		INSERT INTO user_alias (alias, "userId") VALUES ($1, $2)
+		ON CONFLICT (alias) DO NOTHING`, NormalizeAlias(alias), userID)
+	if err != nil {
+		return fmt.Errorf("AddAlias: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAliasTaken
+	}
+	return nil
+}
+
+// DeleteAlias entfernt einen Spitznamen von userID.
+func DeleteAlias(ctx context.Context, e Execer, userID, alias string) error {
+	if _, err := e.ExecContext(ctx, `DELETE FROM user_alias WHERE alias = $1 AND "userId" = $2`,
+		NormalizeAlias(alias), userID); err != nil {
+		return fmt.Errorf("DeleteAlias: %w", err)
+	}
+	return nil
+}
+
+// Aliases liefert die Spitznamen von userID, alphabetisch.
+func Aliases(ctx context.Context, q Queryer, userID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT alias FROM user_alias WHERE "userId" = $1 ORDER BY alias`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("Aliases: %w", err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var a string
+		if err := rows.Scan(&a); err != nil {
+			return nil, fmt.Errorf("Aliases: %w", err)
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
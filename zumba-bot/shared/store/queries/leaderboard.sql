@@ -1,10 +1,19 @@
--- Rangliste je User: Donnerstage ab effektivem Start (GREATEST(startDate,
--- Periodenstart)), Anwesenheit = Donnerstage - Absagen (attendance-by-default),
--- Streak vorzeichenbehaftet über gaps-and-islands.
--- $1 = Periodenstart, $2 = Stichtag/Periodenende (wird an current_date gekappt).
+-- Rangliste je User: Treffen-Tage ab effektivem Start (GREATEST(startDate,
+-- Periodenstart)), Anwesenheit = Treffen - Absagen (attendance-by-default),
+-- Streak vorzeichenbehaftet über gaps-and-islands. Die Spalten heißen aus
+-- historischen Gründen weiter "thursday"; welche Tage zählen, kommt als $3
+-- aus dem domain.Schedule (früher fest ISODOW = 4).
+-- $1 = Periodenstart, $2 = Stichtag/Periodenende (wird an current_date gekappt),
+-- $3 = Treffen-Tage des Schedules im Zeitraum (date[]),
+-- $4/$5/$6 = entschuldigte Zeiträume als parallele Arrays (userId, von, bis);
+-- Treffen darin zählen für den User gar nicht (leer = alle zählen).
 -- Einzige Kopie dieser Query; früher dupliziert als whatsapp-bot stats.sql,
 -- zumba-admin-ui leaderboardQ und n8n whatsapp-statistic.sql.
-WITH startdates AS (
+WITH excused AS (
+    SELECT e.uid AS "userId", e.von, e.bis
+    FROM unnest($4::text[], $5::date[], $6::date[]) AS e(uid, von, bis)
+),
+startdates AS (
     SELECT
         u."userId",
         GREATEST(
@@ -26,8 +35,12 @@ user_thursdays AS (
     ) d(day)
     LEFT JOIN excluded_days ed
         ON ed.date = d.day
-    WHERE EXTRACT(ISODOW FROM d.day) = 4
+    WHERE d.day = ANY($3::date[])
       AND ed.date IS NULL
+      AND NOT EXISTS (
+          SELECT 1 FROM excused x
+          WHERE x."userId" = s."userId" AND d.day BETWEEN x.von AND x.bis
+      )
     GROUP BY s."userId", s.effective_start_date
 ),
 per_thursday AS (
@@ -45,8 +58,12 @@ per_thursday AS (
         ) day
         LEFT JOIN excluded_days ed
             ON ed.date = day
-        WHERE EXTRACT(ISODOW FROM day) = 4
+        WHERE day = ANY($3::date[])
           AND ed.date IS NULL
+          AND NOT EXISTS (
+              SELECT 1 FROM excused x
+              WHERE x."userId" = s."userId" AND day BETWEEN x.von AND x.bis
+          )
     ) d
     LEFT JOIN public.stammtisch_abwesenheit a
         ON a."userId" = s."userId"
@@ -105,7 +122,12 @@ LEFT JOIN public.stammtisch_abwesenheit a
     ON a."userId" = u."userId"
     AND a.date >= ut.effective_start_date
     AND a.date <= LEAST($2::date, current_date)
+    AND a.date = ANY($3::date[])
     AND a.date NOT IN (SELECT date FROM excluded_days)
+    AND NOT EXISTS (
+        SELECT 1 FROM excused x
+        WHERE x."userId" = u."userId" AND a.date BETWEEN x.von AND x.bis
+    )
 LEFT JOIN user_streak us ON us."userId" = u."userId"
 GROUP BY
     u."userId", u."userName", u."startDate",
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Zusagen liegen getrennt von stammtisch_abwesenheit: dort gilt weiter
+// "Anwesenheit ist der Default", eine Zusage löscht nur die Absage. Für die
+// Teilnehmerliste eines Treffens ("wer kommt") muss aber erkennbar sein, wer
+// ausdrücklich zugesagt hat und wer sich gar nicht gemeldet hat.
+
+// EnsureZusageSchema legt stammtisch_zusage idempotent an (Bot und Admin-UI
+// rufen es beim Start).
+func EnsureZusageSchema(ctx context.Context, e Execer) error {
+	const q = `
+		CREATE TABLE IF NOT EXISTS public.stammtisch_zusage (
+		  "userId"   TEXT NOT NULL,
+		  date       DATE NOT NULL,
+		  message    TEXT,
+		  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		  PRIMARY KEY ("userId", date)
+		)`
+	if _, err := e.ExecContext(ctx, q); err != nil {
+		return fmt.Errorf("EnsureZusageSchema: %w", err)
+	}
+	return nil
+}
+
+// MarkConfirmed hält eine Zusage fest (UPSERT, neuester Text gewinnt).
+func MarkConfirmed(ctx context.Context, e Execer, userID string, date time.Time, message string) error {
+	const q = `
+		INSERT INTO public.stammtisch_zusage ("userId", date, message)
+		VALUES ($1, $2, $3)
+		ON CONFLICT ("userId", date)
+		DO UPDATE SET message = EXCLUDED.message, created_at = now()`
+	if _, err := e.ExecContext(ctx, q, userID, date, message); err != nil {
+		return fmt.Errorf("MarkConfirmed: %w", err)
+	}
+	return nil
+}
+
+// Unconfirm entfernt eine Zusage (Bearbeitung/Löschung der Zusage-Nachricht).
+func Unconfirm(ctx context.Context, e Execer, userID string, date time.Time) error {
+	const q = `DELETE FROM public.stammtisch_zusage WHERE "userId" = $1 AND date = $2`
+	if _, err := e.ExecContext(ctx, q, userID, date); err != nil {
+		return fmt.Errorf("Unconfirm: %w", err)
+	}
+	return nil
+}
+
+// RosterStatus ist der Stand eines Mitglieds für ein Treffen.
+type RosterStatus string
+
+const (
+	RosterAbgesagt RosterStatus = "abgesagt"
+	RosterZugesagt RosterStatus = "zugesagt"
+	RosterOffen    RosterStatus = "offen" // nichts gesagt – kommt nach Default
+)
+
+// RosterEntry ist eine Zeile der Teilnehmerliste.
+type RosterEntry struct {
+	UserID   string
+	UserName string
+	Status   RosterStatus
+	Message  string // Absage- bzw. Zusage-Text
+}
+
+// Roster liefert die Teilnehmerliste für das Treffen am Tag date: alle
+// Mitglieder, deren Start nicht nach date liegt, nach Namen sortiert. Eine
+// Absage schlägt eine ältere Zusage (eine Zusage löscht ihrerseits die
+// Absage, beide Zeilen zugleich heißen also: zuletzt abgesagt).
+func Roster(ctx context.Context, q Queryer, date time.Time) ([]RosterEntry, error) {
+	const query = `
+		SELECT u."userId", u."userName",
+		       CASE WHEN a."userId" IS NOT NULL THEN 'abgesagt'
+		            WHEN z."userId" IS NOT NULL THEN 'zugesagt'
+		            ELSE 'offen' END,
+		       COALESCE(a.message, z.message, '')
+		FROM public.users u
+		LEFT JOIN public.stammtisch_abwesenheit a ON a."userId" = u."userId" AND a.date = $1
+		LEFT JOIN public.stammtisch_zusage z ON z."userId" = u."userId" AND z.date = $1
+		WHERE u."startDate" IS NULL OR u."startDate" <= $1
+		ORDER BY u."userName"`
+	rows, err := q.QueryContext(ctx, query, date)
+	if err != nil {
+		return nil, fmt.Errorf("Roster: %w", err)
+	}
+	defer rows.Close()
+	var out []RosterEntry
+	for rows.Next() {
+		var (
+			r      RosterEntry
+			status string
+		)
+		if err := rows.Scan(&r.UserID, &r.UserName, &status, &r.Message); err != nil {
+			return nil, fmt.Errorf("Roster: %w", err)
+		}
+		r.Status = RosterStatus(status)
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Roster: %w", err)
+	}
+	return out, nil
+}
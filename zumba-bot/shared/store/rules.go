@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/michael/zumba-shared/rules"
+)
+
+// EnsureRuleSchema legt die Regeltabelle der Vorklassifikation idempotent an.
+// Bot (liest, zählt Treffer) und Admin-UI (pflegt) rufen beide beim Start.
+func EnsureRuleSchema(ctx context.Context, e Execer) error {
+	const q = `
+		CREATE TABLE IF NOT EXISTS classifier_rule (
+		  id          BIGSERIAL PRIMARY KEY,
+		  kind        TEXT NOT NULL CHECK (kind IN ('exact', 'regex', 'emoji')),
+		  pattern     TEXT NOT NULL,
+		  label       TEXT NOT NULL CHECK (label IN ('true', 'false', 'invalid')),
+		  priority    INT NOT NULL DEFAULT 100,
+		  enabled     BOOLEAN NOT NULL DEFAULT false,
+		  note        TEXT NOT NULL DEFAULT '',
+		  hits        BIGINT NOT NULL DEFAULT 0,
+		  last_hit_at TIMESTAMPTZ,
+		  created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`
+	_, err := e.ExecContext(ctx, q)
+	return err
+}
+
+// ListRules liefert alle Regeln in Prüfreihenfolge (Priorität, dann Alter).
+func ListRules(ctx context.Context, q Queryer) ([]rules.Rule, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, kind, pattern, label, priority, enabled, note, hits, last_hit_at, created_at
+		FROM classifier_rule
+		ORDER BY priority, id`)
+	if err != nil {
+		return nil, fmt.Errorf("ListRules: %w", err)
+	}
+	defer rows.Close()
+	var out []rules.Rule
+	for rows.Next() {
+		var r rules.Rule
+		if err := rows.Scan(&r.ID, &r.Kind, &r.Pattern, &r.Label, &r.Priority, &r.Enabled,
+			&r.Note, &r.Hits, &r.LastHitAt, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ListRules scan: %w", err)
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// InsertRule legt eine Regel an (ausgeschaltet, bis sie getestet ist) und
+// liefert ihre ID.
+func InsertRule(ctx context.Context, q RowQueryer, r rules.Rule) (int64, error) {
+	if err := rules.Validate(r); err != nil {
+		return 0, fmt.Errorf("InsertRule: %w", err)
+	}
+	var id int64
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO classifier_rule (kind, pattern, label, priority, note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`, r.Kind, r.Pattern, r.Label, r.Priority, r.Note).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("InsertRule: %w", err)
+	}
+	return id, nil
+}
+
+// SetRuleEnabled schaltet eine Regel ein bzw. aus.
+func SetRuleEnabled(ctx context.Context, e Execer, id int64, enabled bool) error {
+	if _, err := e.ExecContext(ctx, `UPDATE classifier_rule SET enabled = $2 WHERE id = $1`, id, enabled); err != nil {
+		return fmt.Errorf("SetRuleEnabled: %w", err)
+	}
+	return nil
+}
+
+// DeleteRule löscht eine Regel samt Trefferzähler.
+func DeleteRule(ctx context.Context, e Execer, id int64) error {
+	if _, err := e.ExecContext(ctx, `DELETE FROM classifier_rule WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("DeleteRule: %w", err)
+	}
+	return nil
+}
+
+// RecordRuleHit zählt einen Treffer der Regel id.
+func RecordRuleHit(ctx context.Context, e Execer, id int64) error {
+	if _, err := e.ExecContext(ctx, `
+		UPDATE classifier_rule SET hits = hits + 1, last_hit_at = now() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("RecordRuleHit: %w", err)
+	}
+	return nil
+}
+
+// CorpusQueryer: VerifiedCorpus prüft erst, ob ml_messages existiert.
+type CorpusQueryer interface {
+	Queryer
+	RowQueryer
+}
+
+// VerifiedCorpus liefert alle im Admin-UI geprüften Nachrichten, neueste
+// zuerst (ohne ml_messages – Shadow-Modus nie aktiv – leer).
+func VerifiedCorpus(ctx context.Context, q CorpusQueryer) ([]rules.Sample, error) {
+	var exists bool
+	if err := q.QueryRowContext(ctx, `SELECT to_regclass('ml_messages') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("VerifiedCorpus: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	rows, err := q.QueryContext(ctx, `
+		SELECT message, COALESCE(corrected_label, gemini_label)
+		FROM ml_messages
+		WHERE verified AND COALESCE(corrected_label, gemini_label) IN ('true', 'false', 'invalid')
+		ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("VerifiedCorpus: %w", err)
+	}
+	defer rows.Close()
+	var out []rules.Sample
+	for rows.Next() {
+		var m rules.Sample
+		if err := rows.Scan(&m.Message, &m.Label); err != nil {
+			return nil, fmt.Errorf("VerifiedCorpus scan: %w", err)
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
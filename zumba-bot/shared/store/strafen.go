@@ -8,18 +8,22 @@ import (
 
 	"github.com/lib/pq"
 
+	"github.com/michael/zumba-shared/domain"
 	"github.com/michael/zumba-shared/penalty"
 )
 
-// EnsureStrafenSchema legt die Strafen-Tabelle idempotent an und migriert
+// EnsureStrafenSchema legt die Strafen-Tabellen idempotent an und migriert
 // kleinere Schema-Erweiterungen. whatsapp-bot und zumba-admin-ui rufen beide
-// dieselbe Funktion beim Start (Deploy-Reihenfolge ist offen).
+// dieselbe Funktion beim Start (Deploy-Reihenfolge ist offen). Eine leere
+// strafen_regelwerk-Tabelle bekommt die ursprünglichen Regeln. strafen_kasse
+// ist das Kassenbuch (siehe kasse.go), strafen_mahnung und
+// strafen_mahn_optout gehören zum Mahnwesen (siehe mahnung.go).
 func EnsureStrafenSchema(ctx context.Context, e Execer) error {
 	const q = `
 		CREATE TABLE IF NOT EXISTS strafen (
 		  id           BIGSERIAL PRIMARY KEY,
 		  "userId"     TEXT NOT NULL,
-		  art          TEXT NOT NULL CHECK (art IN ('fehltage','noshow')),
+		  art          TEXT NOT NULL CHECK (art IN ('fehltage','noshow','mahngebuehr')),
 		  datum        DATE NOT NULL,
 		  betrag       INT,
 		  status       TEXT NOT NULL DEFAULT 'offen'
@@ -30,6 +34,69 @@ func EnsureStrafenSchema(ctx context.Context, e Execer) error {
 		);
 		CREATE UNIQUE INDEX IF NOT EXISTS strafen_fehltage_unique
 		  ON strafen ("userId", datum) WHERE art = 'fehltage';
+		CREATE TABLE IF NOT EXISTS strafen_regelwerk (
+		  id             BIGSERIAL PRIMARY KEY,
+		  gilt_ab        DATE NOT NULL UNIQUE,
+		  min_fehltage   INT NOT NULL CHECK (min_fehltage >= 1),
+		  basis_betrag   INT NOT NULL CHECK (basis_betrag >= 0),
+		  pro_tag_betrag INT NOT NULL CHECK (pro_tag_betrag >= 0),
+		  noshow_default INT NOT NULL CHECK (noshow_default >= 0),
+		  notiz          TEXT NOT NULL DEFAULT '',
+		  created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		CREATE TABLE IF NOT EXISTS strafen_kasse (
+		  id         BIGSERIAL PRIMARY KEY,
+		  art        TEXT NOT NULL CHECK (art IN ('zahlung','ausgabe')),
+		  "userId"   TEXT,
+		  strafe_id  BIGINT REFERENCES strafen(id),
+		  betrag     INT NOT NULL CHECK (betrag > 0),
+		  datum      DATE NOT NULL,
+		  notiz      TEXT NOT NULL DEFAULT '',
+		  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		  CHECK (art = 'zahlung' OR strafe_id IS NULL)
+		);
+		CREATE INDEX IF NOT EXISTS strafen_kasse_strafe ON strafen_kasse (strafe_id);
+		-- Mahngebühr als dritte Strafart (Mahnwesen, siehe mahnung.go). Nur
+		-- einmal umbauen: DROP/ADD sperrt die Tabelle und prüft jede Zeile,
+		-- und beide Dienste laufen das bei jedem Start.
+		DO $$
+		BEGIN
+		  IF NOT EXISTS (
+		    SELECT 1 FROM pg_constraint
+		    WHERE conrelid = 'strafen'::regclass AND conname = 'strafen_art_check'
+		      AND pg_get_constraintdef(oid) LIKE '%mahngebuehr%'
+		  ) THEN
+		    ALTER TABLE strafen DROP CONSTRAINT IF EXISTS strafen_art_check;
+		    ALTER TABLE strafen ADD CONSTRAINT strafen_art_check
+		      CHECK (art IN ('fehltage','noshow','mahngebuehr'));
+		  END IF;
+		END $$;
+		CREATE TABLE IF NOT EXISTS strafen_mahnung (
+		  id         BIGSERIAL PRIMARY KEY,
+		  "userId"   TEXT NOT NULL,
+		  stufe      INT NOT NULL CHECK (stufe >= 1),
+		  strafe_id  BIGINT NOT NULL REFERENCES strafen(id),
+		  offen      INT NOT NULL,
+		  gruppe     BOOLEAN NOT NULL DEFAULT false,
+		  gebuehr_id BIGINT REFERENCES strafen(id),
+		  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		  UNIQUE (strafe_id, stufe)
+		);
+		CREATE TABLE IF NOT EXISTS strafen_mahn_optout (
+		  "userId"   TEXT PRIMARY KEY,
+		  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		-- Einspruch gegen No-Show-Strafen (siehe einspruch.go); die Belege
+		-- zeigen ohne Fremdschlüssel auf bot_trace/ml_messages (Tabellen des
+		-- Bots, werden ausgedünnt).
+		ALTER TABLE strafen ADD COLUMN IF NOT EXISTS einspruch TEXT
+		  CHECK (einspruch IN ('einspruch','bestaetigt','aufgehoben'));
+		ALTER TABLE strafen ADD COLUMN IF NOT EXISTS einspruch_grund TEXT NOT NULL DEFAULT '';
+		ALTER TABLE strafen ADD COLUMN IF NOT EXISTS einspruch_am TIMESTAMPTZ;
+		ALTER TABLE strafen ADD COLUMN IF NOT EXISTS einspruch_notiz TEXT NOT NULL DEFAULT '';
+		ALTER TABLE strafen ADD COLUMN IF NOT EXISTS einspruch_entschieden_am TIMESTAMPTZ;
+		ALTER TABLE strafen ADD COLUMN IF NOT EXISTS einspruch_trace_id BIGINT;
+		ALTER TABLE strafen ADD COLUMN IF NOT EXISTS einspruch_ml_id BIGINT;
 		-- Absage-Zeitpunkt für Wrapped 2027 ("kurzfristigste Absage"):
 		-- Altbestand bleibt bewusst NULL (Zeitpunkt unbekannt), Neueinträge
 		-- bekommen den Default – gilt für Bot und Admin-UI gleichermaßen.
@@ -37,42 +104,176 @@ func EnsureStrafenSchema(ctx context.Context, e Execer) error {
 		  ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ;
 		ALTER TABLE public.stammtisch_abwesenheit
 		  ALTER COLUMN created_at SET DEFAULT now();`
-	_, err := e.ExecContext(ctx, q)
+	if _, err := e.ExecContext(ctx, q); err != nil {
+		return err
+	}
+	d := penalty.DefaultRuleSet
+	const seed = `
+		INSERT INTO strafen_regelwerk (gilt_ab, min_fehltage, basis_betrag, pro_tag_betrag, noshow_default, notiz)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE NOT EXISTS (SELECT 1 FROM strafen_regelwerk)`
+	_, err := e.ExecContext(ctx, seed, d.GiltAb, d.MinFehltage, d.BasisBetrag, d.ProTagBetrag, d.NoShowDefault, d.Notiz)
 	return err
 }
 
+// ListRuleSets liefert alle Regelwerke, ältestes zuerst.
+func ListRuleSets(ctx context.Context, q Queryer) (penalty.RuleSets, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, gilt_ab, min_fehltage, basis_betrag, pro_tag_betrag, noshow_default, notiz, created_at
+		FROM strafen_regelwerk
+		ORDER BY gilt_ab`)
+	if err != nil {
+		return nil, fmt.Errorf("ListRuleSets: %w", err)
+	}
+	defer rows.Close()
+	var out penalty.RuleSets
+	for rows.Next() {
+		var r penalty.RuleSet
+		if err := rows.Scan(&r.ID, &r.GiltAb, &r.MinFehltage, &r.BasisBetrag, &r.ProTagBetrag,
+			&r.NoShowDefault, &r.Notiz, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ListRuleSets scan: %w", err)
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// SaveRuleSet legt ein Regelwerk an (ID == 0) bzw. ändert es. Pro Tag gibt
+// es höchstens eines (Unique-Index auf gilt_ab). Regelwerke sind append-only,
+// sobald sie gelten: gilt_ab nie vor today, ändern nur künftige ohne
+// gespeicherte Strafen im Zeitraum (penalty.ValidateRuleSetSave, Fehler
+// penalty.ErrRuleSetInKraft). Prüfung und Schreiben laufen in einer
+// Transaktion auf den gesperrten Regelwerken (ruleSetLage).
+func SaveRuleSet(ctx context.Context, db TxBeginner, r penalty.RuleSet, today time.Time) error {
+	if err := penalty.ValidateRuleSet(r); err != nil {
+		return fmt.Errorf("SaveRuleSet: %w", err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("SaveRuleSet: %w", err)
+	}
+	defer tx.Rollback()
+	rs, rows, err := ruleSetLage(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("SaveRuleSet: %w", err)
+	}
+	if err := penalty.ValidateRuleSetSave(rs, r, rows, today); err != nil {
+		return fmt.Errorf("SaveRuleSet: %w", err)
+	}
+	if r.ID == 0 {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO strafen_regelwerk (gilt_ab, min_fehltage, basis_betrag, pro_tag_betrag, noshow_default, notiz)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			r.GiltAb, r.MinFehltage, r.BasisBetrag, r.ProTagBetrag, r.NoShowDefault, r.Notiz)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE strafen_regelwerk
+			SET gilt_ab = $2, min_fehltage = $3, basis_betrag = $4, pro_tag_betrag = $5, noshow_default = $6, notiz = $7
+			WHERE id = $1`,
+			r.ID, r.GiltAb, r.MinFehltage, r.BasisBetrag, r.ProTagBetrag, r.NoShowDefault, r.Notiz)
+	}
+	if err != nil {
+		return fmt.Errorf("SaveRuleSet: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("SaveRuleSet: %w", err)
+	}
+	return nil
+}
+
+// DeleteRuleSet löscht ein Regelwerk – nie das letzte (ohne Regelwerk gäbe
+// es keine gültigen Regeln mehr) und nie eines, das schon gilt
+// (penalty.ValidateRuleSetChange). Wie SaveRuleSet in einer Transaktion.
+func DeleteRuleSet(ctx context.Context, db TxBeginner, id int64, today time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("DeleteRuleSet: %w", err)
+	}
+	defer tx.Rollback()
+	rs, rows, err := ruleSetLage(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("DeleteRuleSet: %w", err)
+	}
+	if err := penalty.ValidateRuleSetChange(rs, id, rows, today); err != nil {
+		return fmt.Errorf("DeleteRuleSet: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM strafen_regelwerk
+		WHERE id = $1 AND (SELECT count(*) FROM strafen_regelwerk) > 1`, id)
+	if err != nil {
+		return fmt.Errorf("DeleteRuleSet: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("DeleteRuleSet: Regelwerk %d nicht gefunden oder das letzte", id)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("DeleteRuleSet: %w", err)
+	}
+	return nil
+}
+
+// ruleSetLage sperrt die Regelwerke (SELECT … FOR UPDATE) und lädt, was die
+// Prüfung einer Regelwerk-Änderung braucht. Die Sperre hält parallele
+// Änderungen bis zum Ende der Transaktion fern – sonst könnten zwei
+// Admin-Requests beide gegen denselben Stand prüfen.
+func ruleSetLage(ctx context.Context, tx *sql.Tx) (penalty.RuleSets, []penalty.Row, error) {
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM strafen_regelwerk ORDER BY id FOR UPDATE`); err != nil {
+		return nil, nil, err
+	}
+	rs, err := ListRuleSets(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := ListStrafen(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rs, rows, nil
+}
+
 func scanStrafenRows(rows *sql.Rows) ([]penalty.Row, error) {
 	var out []penalty.Row
 	for rows.Next() {
 		var (
-			r          penalty.Row
-			art, state string
-			beg, del   sql.NullTime
+			r                 penalty.Row
+			art, state, einsp string
+			beg, del, am, ent sql.NullTime
+			traceID, mlID     sql.NullInt64
 		)
 		if err := rows.Scan(&r.ID, &r.UserID, &art, &r.Datum, &r.Betrag,
-			&state, &r.CreatedAt, &beg, &del); err != nil {
+			&state, &r.CreatedAt, &beg, &del,
+			&einsp, &r.Einspruch.Grund, &am, &r.Einspruch.Notiz, &ent, &traceID, &mlID); err != nil {
 			return nil, fmt.Errorf("strafen scan: %w", err)
 		}
 		r.Art, r.Status = penalty.Art(art), penalty.Status(state)
-		if beg.Valid {
-			t := beg.Time
-			r.BeglichenAm = &t
-		}
-		if del.Valid {
-			t := del.Time
-			r.GeloeschtAm = &t
-		}
+		r.BeglichenAm, r.GeloeschtAm = nullTime(beg), nullTime(del)
+		r.Einspruch.Status = penalty.EinspruchStatus(einsp)
+		r.Einspruch.Am, r.Einspruch.EntschiedenAm = nullTime(am), nullTime(ent)
+		r.Einspruch.TraceID, r.Einspruch.MLMessageID = traceID.Int64, mlID.Int64
 		out = append(out, r)
 	}
 	return out, rows.Err()
 }
 
+// strafenCols sind die Spalten in der Reihenfolge von scanStrafenRows.
+const strafenCols = `
+	id, "userId", art, datum, COALESCE(betrag, 0), status,
+	created_at, beglichen_am, geloescht_am,
+	COALESCE(einspruch, ''), einspruch_grund, einspruch_am, einspruch_notiz,
+	einspruch_entschieden_am, einspruch_trace_id, einspruch_ml_id`
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
+
 // ListStrafen liefert ALLE Zeilen inkl. beglichen/geloescht (Reset-Marker),
 // neueste zuerst.
 func ListStrafen(ctx context.Context, q Queryer) ([]penalty.Row, error) {
-	const query = `
-		SELECT id, "userId", art, datum, COALESCE(betrag, 0), status,
-		       created_at, beglichen_am, geloescht_am
+	const query = `SELECT` + strafenCols + `
 		FROM strafen
 		ORDER BY created_at DESC, id DESC`
 	rows, err := q.QueryContext(ctx, query)
@@ -84,14 +285,20 @@ func ListStrafen(ctx context.Context, q Queryer) ([]penalty.Row, error) {
 }
 
 // PenaltyInputs sammelt die Eingangsdaten für penalty.Assess zum Stichtag
-// asOf: User (mit auf 2025-12-01 geklemmtem Start), deren
-// Abwesenheits-Donnerstage, Sperrtage und strafen-Zeilen. Alle Queries sind
-// auf [2025-12-01, asOf] begrenzt – Zeilen außerhalb können das Ergebnis
-// nicht beeinflussen (Assess betrachtet nur Donnerstage in diesem Fenster,
-// und Resets zukünftiger strafen-Zeilen liegen immer nach deren datum).
-func PenaltyInputs(ctx context.Context, q Queryer, asOf time.Time) (penalty.Input, error) {
-	var in penalty.Input
-	minStart := penalty.ClampStart(nil)
+// asOf: Regelwerke, User (mit auf den Beginn des ersten Regelwerks
+// geklemmtem Start), deren Abwesenheiten an Treffen-Tagen des Schedules s,
+// Sperrtage, strafen-Zeilen, Kassenbuch-Buchungen und entschuldigte
+// Zeiträume. Alle Queries bis auf die (wenigen) Zeiträume sind auf
+// [Beginn, asOf] begrenzt – Zeilen außerhalb können das Ergebnis nicht
+// beeinflussen (Assess betrachtet nur Donnerstage in diesem Fenster, und
+// Resets zukünftiger strafen-Zeilen liegen immer nach deren datum).
+func PenaltyInputs(ctx context.Context, q Queryer, s domain.Schedule, asOf time.Time) (penalty.Input, error) {
+	in := penalty.Input{Schedule: s}
+	var err error
+	if in.Rules, err = ListRuleSets(ctx, q); err != nil {
+		return in, fmt.Errorf("PenaltyInputs: %w", err)
+	}
+	minStart := in.Rules.Start()
 
 	const usersQ = `SELECT "userId", "userName", "startDate" FROM public.users`
 	rows, err := q.QueryContext(ctx, usersQ)
@@ -112,7 +319,7 @@ func PenaltyInputs(ctx context.Context, q Queryer, asOf time.Time) (penalty.Inpu
 		if start.Valid {
 			sd = &start.Time
 		}
-		u.EffectiveStart = penalty.ClampStart(sd)
+		u.EffectiveStart = in.Rules.ClampStart(sd)
 		idx[u.UserID] = len(in.Users)
 		in.Users = append(in.Users, u)
 	}
@@ -122,10 +329,10 @@ func PenaltyInputs(ctx context.Context, q Queryer, asOf time.Time) (penalty.Inpu
 
 	const absQ = `
 		SELECT "userId", date FROM public.stammtisch_abwesenheit
-		WHERE EXTRACT(ISODOW FROM date) = 4
+		WHERE date = ANY($3::date[])
 		  AND date >= $1 AND date <= $2
 		  AND date NOT IN (SELECT date FROM excluded_days)`
-	arows, err := q.QueryContext(ctx, absQ, minStart, asOf)
+	arows, err := q.QueryContext(ctx, absQ, minStart, asOf, MeetingDates(s, minStart, asOf))
 	if err != nil {
 		return in, fmt.Errorf("PenaltyInputs absences: %w", err)
 	}
@@ -163,9 +370,7 @@ func PenaltyInputs(ctx context.Context, q Queryer, asOf time.Time) (penalty.Inpu
 		return in, err
 	}
 
-	const strafenQ = `
-		SELECT id, "userId", art, datum, COALESCE(betrag, 0), status,
-		       created_at, beglichen_am, geloescht_am
+	const strafenQ = `SELECT` + strafenCols + `
 		FROM strafen
 		WHERE datum <= $1
 		ORDER BY "userId", datum`
@@ -174,8 +379,24 @@ func PenaltyInputs(ctx context.Context, q Queryer, asOf time.Time) (penalty.Inpu
 		return in, fmt.Errorf("PenaltyInputs strafen: %w", err)
 	}
 	defer srows.Close()
-	in.Rows, err = scanStrafenRows(srows)
-	return in, err
+	if in.Rows, err = scanStrafenRows(srows); err != nil {
+		return in, err
+	}
+
+	if in.Buchungen, err = listBuchungen(ctx, q, asOf); err != nil {
+		return in, fmt.Errorf("PenaltyInputs: %w", err)
+	}
+
+	excused, err := ListEntschuldigungen(ctx, q)
+	if err != nil {
+		return in, fmt.Errorf("PenaltyInputs: %w", err)
+	}
+	for _, e := range excused {
+		if i, ok := idx[e.UserID]; ok {
+			in.Users[i].Entschuldigt = append(in.Users[i].Entschuldigt, e)
+		}
+	}
+	return in, nil
 }
 
 // AutoStrafe ist der Marker einer erkannten Fehltage-Strafe.
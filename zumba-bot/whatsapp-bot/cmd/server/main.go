@@ -2,25 +2,51 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
+	"github.com/michael/zumba-whatsapp-bot/internal/chatlog"
+	"github.com/michael/zumba-whatsapp-bot/internal/clarify"
+	"github.com/michael/zumba-whatsapp-bot/internal/classcache"
 	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
 	"github.com/michael/zumba-whatsapp-bot/internal/config"
 	"github.com/michael/zumba-whatsapp-bot/internal/db"
 	"github.com/michael/zumba-whatsapp-bot/internal/evolution"
+	"github.com/michael/zumba-whatsapp-bot/internal/inbox"
+	"github.com/michael/zumba-whatsapp-bot/internal/outbox"
 	"github.com/michael/zumba-whatsapp-bot/internal/renderer"
+	"github.com/michael/zumba-whatsapp-bot/internal/ruleset"
+	"github.com/michael/zumba-whatsapp-bot/internal/scheduler"
 	"github.com/michael/zumba-whatsapp-bot/internal/shadow"
 	"github.com/michael/zumba-whatsapp-bot/internal/sink"
 	"github.com/michael/zumba-whatsapp-bot/internal/store"
 	"github.com/michael/zumba-whatsapp-bot/internal/tracestore"
 	"github.com/michael/zumba-whatsapp-bot/internal/web"
+	"github.com/michael/zumba-whatsapp-bot/internal/worker"
 )
 
+// drainTimeout: so lange darf der Shutdown laufende Verarbeitungen abwarten
+// (Renderer bis 90s). Muss unter terminationGracePeriodSeconds im Helm-Chart
+// bleiben.
+const drainTimeout = 100 * time.Second
+
+// llmTimeout: so lange darf das LLM (Primär- + Fallback-Modell) brauchen,
+// bevor bei aktivem ML-Fallback das eigene Modell entscheidet.
+const llmTimeout = 40 * time.Second
+
 func main() {
+	// SIGTERM (k3s-Pod-Neustart beim GitOps-Upgrade) / Ctrl+C beendet sauber.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
 	if err := godotenv.Load(); err == nil {
 		log.Printf("📄 Loaded .env")
 	}
@@ -39,13 +65,90 @@ func main() {
 	defer pg.Close()
 	log.Printf("✅ Connected to PostgreSQL '%s' on %s:%s", cfg.DB.Name, cfg.DB.Host, cfg.DB.Port)
 
-	st := store.NewPostgres(pg)
+	st := store.NewPostgres(pg, cfg.Schedule)
+	st.ExcludeExcused = cfg.LeaderboardExcludeExcused
+	log.Printf("📅 Stammtisch: %s (MEETING_SCHEDULE=%s)", cfg.Schedule.Describe(), cfg.Schedule)
+	if st.ExcludeExcused {
+		log.Printf("🩹 Rangliste ohne entschuldigte Treffen (LEADERBOARD_EXCLUDE_EXCUSED)")
+	}
 	// Strafen-Tabelle (Marker für Fehltage-Strafen; No-Shows pflegt das
 	// Admin-UI). Beide Services legen sie idempotent an.
 	if err := st.EnsureStrafenSchema(context.Background()); err != nil {
 		log.Printf("⚠️  strafen Schema: %v", err)
 	}
-	cl := classifier.NewGemini(cfg.Gemini.APIKey, cfg.Gemini.Model, cfg.Gemini.FallbackModel)
+	// Zusagen (getrennt von den Absagen, für "wer kommt"). Beide Services.
+	if err := st.EnsureZusageSchema(context.Background()); err != nil {
+		log.Printf("⚠️  stammtisch_zusage Schema: %v", err)
+	}
+	// Abbestellte Erinnerungen ("erinnerung aus").
+	if err := st.EnsureReminderSchema(context.Background()); err != nil {
+		log.Printf("⚠️  bot_reminder_optout Schema: %v", err)
+	}
+	// Wirkung je Nachricht (für bearbeitete/gelöschte WhatsApp-Nachrichten).
+	if err := st.EnsureMessageEffectSchema(context.Background()); err != nil {
+		log.Printf("⚠️  bot_message_effect Schema: %v", err)
+	}
+	// Spitznamen (für "Tom und ich kommen nicht"), wer eine Absage für
+	// andere eingetragen hat, und entschuldigte Zeiträume.
+	if err := st.EnsureMemberSchema(context.Background()); err != nil {
+		log.Printf("⚠️  user_alias/stammtisch_entschuldigt Schema: %v", err)
+	}
+	// LLM-Classifier: Primär- und Fallback-Modell bei beliebigem Anbieter
+	// (Gemini oder OpenAI-kompatibel, z. B. Ollama auf dem Pi).
+	primary, err := classifier.NewProvider(cfg.LLM.Primary.Provider, cfg.LLM.Primary.BaseURL, cfg.LLM.Primary.APIKey, cfg.LLM.Primary.Model)
+	if err != nil {
+		log.Fatalf("LLM: %v", err)
+	}
+	var fallback classifier.Provider
+	if cfg.LLM.Fallback.Provider != classifier.ProviderNone {
+		if fallback, err = classifier.NewProvider(cfg.LLM.Fallback.Provider, cfg.LLM.Fallback.BaseURL, cfg.LLM.Fallback.APIKey, cfg.LLM.Fallback.Model); err != nil {
+			log.Fatalf("LLM-Fallback: %v", err)
+		}
+	}
+	log.Printf("🧠 LLM: %s (Fallback: %s)", cfg.LLM.Primary, cfg.LLM.Fallback)
+	base := classifier.NewLLM(primary, fallback)
+	var llm classifier.Backend = base
+	// Cache vor dem LLM: gleiche (normalisierte) Nachricht → gleiche Antwort
+	// ohne Modell-Aufruf; geprüfte Label aus ml_messages gehen vor.
+	if cfg.CacheTTL > 0 {
+		cc := classcache.New(pg.DB, llm, cfg.CacheTTL)
+		if err := cc.EnsureSchema(context.Background()); err != nil {
+			log.Printf("⚠️  classifier_cache Schema: %v (Cache deaktiviert)", err)
+		} else {
+			llm = cc
+			go cc.Run(ctx, 5*time.Minute)
+			log.Printf("🗃  Classifier-Cache aktiv (classifier_cache, TTL %s, Prompt %s)", cfg.CacheTTL, classifier.PromptVersion())
+		}
+	}
+	var cl web.Classifier = llm
+	// Eigenes Modell (classifier-service): Shadow-Modus, Fallback, wenn
+	// Gemini ausfällt, und Canary-Routing.
+	var sh *shadow.Shadow
+	if cfg.ClassifierURL != "" {
+		local := classifier.NewLocal(cfg.ClassifierURL, cfg.MLFallback.Threshold)
+		if cfg.MLFallback.Enabled {
+			cl = &classifier.WithFallback{Primary: llm, Fallback: local, Timeout: llmTimeout}
+			log.Printf("🛟 ML-Fallback aktiv (LLM-Ausfall → eigenes Modell, Schwelle %.2f)", cfg.MLFallback.Threshold)
+		}
+		// ML-Shadow-Modus: jede Entscheidung landet mit beiden Labels und
+		// ihrer Route dauerhaft in ml_messages.
+		sh = shadow.New(pg.DB, local, llm)
+		if err := sh.EnsureSchema(context.Background()); err != nil {
+			log.Printf("⚠️  ml_messages Schema: %v (Shadow-Modus und Canary deaktiviert)", err)
+			sh = nil
+		} else {
+			if err := sh.SavePolicy(context.Background(), cfg.MLRoute); err != nil {
+				log.Printf("⚠️  ml_route_policy: %v", err)
+			}
+			// Canary: ein Anteil der Nachrichten entscheidet das Modell
+			// (ab Konfidenz-Schwelle), Kill-Switch im Admin-UI.
+			if cfg.MLRoute.Share > 0 {
+				cl = &classifier.Router{Primary: cl, Model: local, Policy: cfg.MLRoute, Killed: sh.Killed}
+				log.Printf("🐤 Canary aktiv: %d %% der Nachrichten entscheidet das Modell (ab Konfidenz %.2f)",
+					cfg.MLRoute.Share, cfg.MLRoute.MinConfidence)
+			}
+		}
+	}
 
 	var snd web.Sender
 	switch cfg.Output.Mode {
@@ -61,11 +164,54 @@ func main() {
 	}
 
 	srv := web.New(st, cl, snd, cfg.GroupJID, cfg.Location)
+	srv.Schedule = cfg.Schedule
 	srv.PreviewJID = cfg.PreviewJID
+	srv.Commands.SetAdmins(cfg.Admins)
+	log.Printf("💬 Befehle aktiv (%d Admins)", len(cfg.Admins))
 	if cfg.PreviewJID != "" {
 		log.Printf("📱 Vorschau-Modus aktiv → %s", cfg.PreviewJID)
 	}
 
+	// Gesprächskontext: "ich auch", "+1" … klassifiziert das LLM mit den
+	// Nachrichten davor bzw. der zitierten Nachricht.
+	if cfg.Context.Messages > 0 {
+		hist := chatlog.New(pg.DB, cfg.Context.Messages, cfg.Context.MaxAge)
+		if err := hist.EnsureSchema(context.Background()); err != nil {
+			log.Printf("⚠️  bot_chat_history Schema: %v (ohne Gesprächskontext)", err)
+		} else {
+			srv.History, srv.Contextual = hist, base
+			log.Printf("💭 Gesprächskontext aktiv (bis %d Nachrichten, max. %s alt)", cfg.Context.Messages, cfg.Context.MaxAge)
+		}
+	}
+
+	// Rückfrage: "schau ma mal" → "Kommst du heute? 👍 / 👎", entschieden per
+	// Antwort oder Reaktion. Braucht einen Sender, der zitieren kann
+	// (Evolution).
+	if cfg.Clarify.Enabled {
+		cs := clarify.New(pg.DB)
+		if _, ok := snd.(web.QuoteSender); !ok {
+			log.Printf("⚠️  Rückfragen brauchen OUTPUT_MODE=evolution (deaktiviert)")
+		} else if err := cs.EnsureSchema(context.Background()); err != nil {
+			log.Printf("⚠️  bot_clarification Schema: %v (Rückfragen deaktiviert)", err)
+		} else {
+			srv.Clarify, srv.ClarifyTTL = cs, cfg.Clarify.TTL
+			log.Printf("🤔 Rückfragen aktiv (offen bis %s, höchstens bis zum Termin)", cfg.Clarify.TTL)
+		}
+	}
+
+	// Quittung: Reaktion an der Nachricht nach eingetragener Ab-/Zusage.
+	srv.Reactions = web.Reactions{Absent: cfg.Reaction.Absent, Present: cfg.Reaction.Present, Error: cfg.Reaction.Error}
+
+	// Vorklassifikation: Regeln aus dem Admin-UI (👎, feste Sprüche)
+	// entscheiden vor dem Classifier; neu geladen jede Minute.
+	rs := ruleset.New(pg.DB)
+	if err := rs.EnsureSchema(context.Background()); err != nil {
+		log.Printf("⚠️  classifier_rule Schema: %v (Vorklassifikation deaktiviert)", err)
+	} else {
+		srv.Rules = rs
+		go rs.Run(ctx, time.Minute)
+	}
+
 	// Bild-Karte: Statistik als PNG über den renderer-service.
 	if cfg.RendererURL != "" {
 		srv.Renderer = renderer.NewClient(cfg.RendererURL)
@@ -76,7 +222,7 @@ func main() {
 		log.Printf("🖼  \"statistik\"-Antwort als Bild (STATS_FORMAT=image)")
 	}
 
-	// Trace-Aufzeichnung (Gruppe + Donnerstag) in der zumba-DB.
+	// Trace-Aufzeichnung (Zumba-Gruppe) in der zumba-DB.
 	tracer := tracestore.New(pg.DB)
 	if err := tracer.EnsureSchema(context.Background()); err != nil {
 		log.Printf("⚠️  bot_trace Schema: %v (Aufzeichnung deaktiviert)", err)
@@ -85,21 +231,143 @@ func main() {
 		log.Printf("🧭 Trace-Aufzeichnung aktiv (bot_trace, 21 Tage Retention)")
 	}
 
-	// ML-Shadow-Modus: eigenes Modell klassifiziert parallel zu Gemini,
-	// beide Ergebnisse landen dauerhaft in ml_messages.
-	if cfg.ClassifierURL != "" {
-		sh := shadow.New(pg.DB, cfg.ClassifierURL)
-		if err := sh.EnsureSchema(context.Background()); err != nil {
-			log.Printf("⚠️  ml_messages Schema: %v (Shadow-Modus deaktiviert)", err)
+	// Inbox: Dedupe von Webhook-Wiederholungen + Retry fehlgeschlagener Events.
+	// Der Retry-Loop hat einen eigenen Context: Beim Shutdown wird er vor dem
+	// Leeren der Warteschlange beendet, sonst liefe ein Retry nach dem
+	// Schließen des Pools synchron mit abgebrochenem Context.
+	retryCtx, stopRetry := context.WithCancel(context.Background())
+	defer stopRetry()
+	retryDone := make(chan struct{})
+	ib := inbox.New(pg.DB)
+	if err := ib.EnsureSchema(context.Background()); err != nil {
+		log.Printf("⚠️  bot_inbox Schema: %v (Dedupe deaktiviert)", err)
+		close(retryDone)
+	} else {
+		srv.Inbox = ib
+		go func() {
+			defer close(retryDone)
+			srv.RunInboxRetry(retryCtx, time.Minute)
+		}()
+		log.Printf("📥 Inbox aktiv (bot_inbox, Retry jede Minute, max. %d Versuche)", inbox.MaxAttempts)
+	}
+
+	// Outbox: Statistik + Wochenreport überleben einen Evolution-Ausfall.
+	ob := outbox.New(pg.DB, snd)
+	if err := ob.EnsureSchema(context.Background()); err != nil {
+		log.Printf("⚠️  bot_outbox Schema: %v (Versand ohne Wiederholung)", err)
+	} else {
+		srv.Outbox = ob
+		go ob.Run(ctx, 15*time.Second)
+		log.Printf("📤 Outbox aktiv (bot_outbox, Wiederholung mit Backoff, max. Alter %s)", outbox.MaxAge)
+	}
+
+	// Scheduler: zeitgesteuerte Jobs (Wochenreport) im Bot statt per
+	// k8s-CronJob. Termine + Läufe in bot_job/bot_job_run, verpasste Termine
+	// werden nach dem Start nachgeholt, bei mehreren Replicas läuft jeder
+	// Termin nur einmal.
+	schedDone := make(chan struct{})
+	sch := scheduler.New(pg.DB, cfg.Location)
+	if err := sch.EnsureSchema(context.Background()); err != nil {
+		log.Printf("⚠️  bot_job Schema: %v (Scheduler deaktiviert, kein Wochenreport)", err)
+		close(schedDone)
+	} else {
+		wr := cfg.WeeklyReport
+		job := scheduler.Job{
+			Name:    "wochenreport",
+			Cron:    wr.Cron,
+			Payload: json.RawMessage(fmt.Sprintf(`{"format":%q}`, wr.Format)),
+			Enabled: wr.Enabled,
+		}
+		if err := sch.Register(context.Background(), job, srv.WeeklyJob); err != nil {
+			log.Printf("⚠️  Job wochenreport: %v", err)
+		} else if wr.Enabled {
+			log.Printf("⏰ Wochenreport-Job aktiv (%s, %s, %s)", wr.Cron, cfg.Location, wr.Format)
 		} else {
-			srv.Shadow = sh
-			log.Printf("🤖 ML-Shadow-Modus aktiv → %s", cfg.ClassifierURL)
+			log.Printf("⏰ Wochenreport-Job deaktiviert (WEEKLY_REPORT_ENABLED)")
+		}
+		rm := cfg.Reminder
+		job = scheduler.Job{
+			Name:    "erinnerung",
+			Cron:    rm.Cron,
+			Payload: json.RawMessage(fmt.Sprintf(`{"mode":%q}`, rm.Mode)),
+			Enabled: rm.Enabled,
+			// Nach dem Treffen nützt keine Erinnerung mehr.
+			CatchUp: 2 * time.Hour,
 		}
+		if err := sch.Register(context.Background(), job, srv.ReminderJob); err != nil {
+			log.Printf("⚠️  Job erinnerung: %v", err)
+		} else if rm.Enabled {
+			log.Printf("⏰ Erinnerungs-Job aktiv (%s, %s, an Stammtisch-Tagen)", rm.Cron, rm.Mode)
+		} else {
+			log.Printf("⏰ Erinnerungs-Job deaktiviert (REMINDER_ENABLED)")
+		}
+		dn := cfg.Dunning
+		payload, _ := json.Marshal(map[string]any{"tage": dn.Days, "gruppeAb": dn.GroupLevel, "gebuehr": dn.Fee})
+		job = scheduler.Job{
+			Name:    "mahnung",
+			Cron:    dn.Cron,
+			Payload: payload,
+			Enabled: dn.Enabled,
+		}
+		if err := sch.Register(context.Background(), job, srv.MahnJob); err != nil {
+			log.Printf("⚠️  Job mahnung: %v", err)
+		} else if dn.Enabled {
+			log.Printf("💸 Mahn-Job aktiv (%s, Stufen nach %v Tagen, Gruppe ab Stufe %d, Gebühr %d€)", dn.Cron, dn.Days, dn.GroupLevel, dn.Fee)
+		} else {
+			log.Printf("💸 Mahn-Job deaktiviert (DUNNING_ENABLED)")
+		}
+		go func() {
+			sch.Run(ctx, 30*time.Second)
+			close(schedDone)
+		}()
+	}
+
+	if sh != nil {
+		srv.Shadow = sh
+		log.Printf("🤖 ML-Shadow-Modus aktiv → %s", cfg.ClassifierURL)
 	}
 
+	// Webhooks asynchron: Evolution bekommt sofort sein 200, die Verarbeitung
+	// läuft im Pool (je Chat in Eingangsreihenfolge).
+	pool := worker.New(cfg.Workers, 100)
+	srv.Queue = pool
+	log.Printf("🧵 Webhook-Verarbeitung asynchron (%d Worker)", cfg.Workers)
+
 	addr := fmt.Sprintf(":%s", cfg.Port)
-	log.Printf("🍻 Zumba WhatsApp-Bot läuft auf http://localhost%s (Webhook: POST /webhook/whatsapp)", addr)
-	if err := http.ListenAndServe(addr, srv.Routes()); err != nil {
-		log.Fatal(err)
+	hs := &http.Server{Addr: addr, Handler: srv.Routes()}
+	go func() {
+		log.Printf("🍻 Zumba WhatsApp-Bot läuft auf http://localhost%s (Webhook: POST /webhook/whatsapp)", addr)
+		if err := hs.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Printf("🛑 Shutdown: nehme keine Webhooks mehr an, arbeite Warteschlange ab …")
+	sctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	// Erst keine neuen Requests und Retries mehr, dann die Queue leeren. Was
+	// nicht fertig wird, steht als "processing" in bot_inbox und wird nach
+	// dem Neustart vom Retry-Loop übernommen.
+	if err := hs.Shutdown(sctx); err != nil {
+		log.Printf("⚠️  HTTP-Shutdown: %v", err)
+	}
+	stopRetry()
+	select {
+	case <-retryDone:
+	case <-sctx.Done():
+		log.Printf("⚠️  Inbox-Retry nicht rechtzeitig beendet")
+	}
+	if err := pool.Shutdown(sctx); err != nil {
+		log.Printf("⚠️  Warteschlange nicht vollständig abgearbeitet: %v", err)
+	} else {
+		log.Printf("✅ Warteschlange abgearbeitet, beende")
+	}
+	// Ein gerade laufender Job (Wochenreport) darf noch fertig werden.
+	select {
+	case <-schedDone:
+	case <-sctx.Done():
+		log.Printf("⚠️  Scheduler-Job nicht rechtzeitig fertig – Lease läuft ab, nächste Replica holt nach")
 	}
 }
@@ -5,6 +5,7 @@ go 1.26.5
 require (
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.12.3
+	golang.org/x/text v0.40.0
 )
 
 require github.com/michael/zumba-shared v0.0.0
@@ -0,0 +1,120 @@
+// Package chatlog ist der kurze Gesprächsverlauf der Gruppe: jede
+// klassifizierte Nachricht landet in bot_chat_history, damit Anschluss-
+// Nachrichten wie "ich auch", "dito" oder "+1" mit den Nachrichten davor
+// klassifiziert werden können. Nur ein paar Tage Retention – es geht um
+// Kontext, nicht um ein Archiv.
+package chatlog
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// retention: so lange bleiben Nachrichten im Verlauf.
+const retention = 3 * 24 * time.Hour
+
+// Message ist eine Nachricht im Verlauf.
+type Message struct {
+	Chat      string // remoteJid der Gruppe
+	MessageID string
+	UserID    string
+	UserName  string
+	Text      string
+	SentAt    time.Time
+}
+
+// Store schreibt bot_chat_history in die zumba-DB.
+type Store struct {
+	db *sql.DB
+
+	// Limit: höchstens so viele Nachrichten davor (Env CONTEXT_MESSAGES).
+	Limit int
+	// MaxAge: ältere Nachrichten sind kein Kontext mehr (Env CONTEXT_MAX_AGE).
+	MaxAge time.Duration
+}
+
+func New(db *sql.DB, limit int, maxAge time.Duration) *Store {
+	return &Store{db: db, Limit: limit, MaxAge: maxAge}
+}
+
+const schemaSQL = `
+CREATE TABLE IF NOT EXISTS bot_chat_history (
+  chat       TEXT NOT NULL,
+  message_id TEXT NOT NULL,
+  user_id    TEXT NOT NULL,
+  user_name  TEXT NOT NULL DEFAULT '',
+  text       TEXT NOT NULL,
+  sent_at    TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (chat, message_id)
+);
+CREATE INDEX IF NOT EXISTS bot_chat_history_sent_idx ON bot_chat_history (chat, sent_at);`
+
+// EnsureSchema legt die Tabelle idempotent an (beim Start aufgerufen).
+func (s *Store) EnsureSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, schemaSQL)
+	return err
+}
+
+// Add nimmt eine Nachricht in den Verlauf auf (Wiederholungen derselben
+// Message-ID zählen einmal) und räumt alte Einträge ab.
+func (s *Store) Add(ctx context.Context, m Message) error {
+	const q = `
+		INSERT INTO bot_chat_history (chat, message_id, user_id, user_name, text, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (chat, message_id) DO NOTHING`
+	if _, err := s.db.ExecContext(ctx, q, m.Chat, m.MessageID, m.UserID, m.UserName, m.Text, m.SentAt); err != nil {
+		return fmt.Errorf("Add: %w", err)
+	}
+	_, _ = s.db.ExecContext(ctx, `DELETE FROM bot_chat_history WHERE sent_at < now() - $1 * interval '1 second'`,
+		retention.Seconds()) // best-effort
+	return nil
+}
+
+// Before liefert bis zu Limit Nachrichten aus chat, die vor at (höchstens
+// MaxAge davor) geschrieben wurden, älteste zuerst. Die Nachricht
+// excludeID selbst zählt nie als ihr eigener Kontext.
+func (s *Store) Before(ctx context.Context, chat string, at time.Time, excludeID string) ([]Message, error) {
+	const q = `
+		SELECT chat, message_id, user_id, user_name, text, sent_at
+		FROM bot_chat_history
+		WHERE chat = $1 AND message_id <> $2 AND sent_at <= $3 AND sent_at >= $4
+		ORDER BY sent_at DESC, message_id DESC
+		LIMIT $5`
+	rows, err := s.db.QueryContext(ctx, q, chat, excludeID, at, at.Add(-s.MaxAge), s.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("Before: %w", err)
+	}
+	defer rows.Close()
+	var out []Message
+	for rows.Next() {
+		var m Message
+		if err := rows.Scan(&m.Chat, &m.MessageID, &m.UserID, &m.UserName, &m.Text, &m.SentAt); err != nil {
+			return nil, fmt.Errorf("Before: %w", err)
+		}
+		out = append(out, m)
+	}
+	// Abfrage neueste zuerst (für LIMIT), Ausgabe chronologisch.
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, rows.Err()
+}
+
+// Get liefert eine Nachricht per Message-ID (nil = nicht im Verlauf), z. B.
+// den Absender-Namen einer zitierten Nachricht.
+func (s *Store) Get(ctx context.Context, chat, messageID string) (*Message, error) {
+	var m Message
+	err := s.db.QueryRowContext(ctx, `
+		SELECT chat, message_id, user_id, user_name, text, sent_at
+		FROM bot_chat_history WHERE chat = $1 AND message_id = $2`, chat, messageID).
+		Scan(&m.Chat, &m.MessageID, &m.UserID, &m.UserName, &m.Text, &m.SentAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	return &m, nil
+}
@@ -0,0 +1,209 @@
+// Package clarify ist die Rückfrage bei unklaren Nachrichten: Sagt der
+// Classifier "invalid" zu etwas, das nach Ab-/Zusage klingt ("schau ma mal"),
+// fragt der Bot per Antwort mit Zitat nach ("Kommst du heute? 👍 / 👎") und
+// merkt sich die offene Frage in bot_clarification. Eine Antwort auf seine
+// Nachricht oder eine Reaktion darauf entscheidet dann – bis die Frage
+// abläuft.
+package clarify
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lib/pq"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+)
+
+// Question ist eine offene Rückfrage.
+type Question struct {
+	ID        string // Message-ID der Rückfrage des Bots
+	Chat      string
+	UserID    string // wer gefragt wurde (nur dessen Antwort zählt)
+	UserName  string
+	MessageID string // die unklare Nachricht
+	Message   string
+	Dates     []time.Time // Termine, um die es geht
+	ExpiresAt time.Time
+}
+
+// Store schreibt bot_clarification in die zumba-DB.
+type Store struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Store { return &Store{db: db} }
+
+const schemaSQL = `
+CREATE TABLE IF NOT EXISTS bot_clarification (
+  question_id TEXT PRIMARY KEY,
+  chat        TEXT NOT NULL,
+  user_id     TEXT NOT NULL,
+  user_name   TEXT NOT NULL DEFAULT '',
+  message_id  TEXT NOT NULL,
+  message     TEXT NOT NULL,
+  dates       DATE[] NOT NULL,
+  expires_at  TIMESTAMPTZ NOT NULL,
+  answer      TEXT,
+  answered_at TIMESTAMPTZ,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS bot_clarification_user_idx ON bot_clarification (chat, user_id);`
+
+// EnsureSchema legt die Tabelle idempotent an (beim Start aufgerufen).
+func (s *Store) EnsureSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, schemaSQL)
+	return err
+}
+
+// Ask merkt sich eine gestellte Rückfrage. Eine ältere, noch offene Frage an
+// dasselbe Mitglied gilt damit als erledigt – es zählt die letzte. Abgelaufene
+// Fragen älter als eine Woche werden abgeräumt.
+func (s *Store) Ask(ctx context.Context, q Question) error {
+	iso := make([]string, len(q.Dates))
+	for i, d := range q.Dates {
+		iso[i] = d.Format("2006-01-02")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Ask: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE bot_clarification SET expires_at = now()
+		WHERE chat = $1 AND user_id = $2 AND expires_at > now()`, q.Chat, q.UserID); err != nil {
+		return fmt.Errorf("Ask: %w", err)
+	}
+	const ins = `
+		INSERT INTO bot_clarification (question_id, chat, user_id, user_name, message_id, message, dates, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::date[], $8)
+		ON CONFLICT (question_id) DO NOTHING`
+	if _, err := tx.ExecContext(ctx, ins, q.ID, q.Chat, q.UserID, q.UserName, q.MessageID, q.Message,
+		pq.Array(iso), q.ExpiresAt); err != nil {
+		return fmt.Errorf("Ask: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM bot_clarification WHERE expires_at < now() - interval '7 days'`); err != nil {
+		return fmt.Errorf("Ask: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Ask: %w", err)
+	}
+	return nil
+}
+
+// Get liefert die Rückfrage mit der Message-ID questionID in chat – auch
+// abgelaufene, damit der Trace das zeigen kann (nil, nil = keine Rückfrage).
+func (s *Store) Get(ctx context.Context, chat, questionID string) (*Question, error) {
+	const q = `
+		SELECT question_id, chat, user_id, user_name, message_id, message, dates::text[], expires_at
+		FROM bot_clarification WHERE question_id = $1 AND chat = $2`
+	var (
+		out Question
+		iso []string
+	)
+	err := s.db.QueryRowContext(ctx, q, questionID, chat).Scan(&out.ID, &out.Chat, &out.UserID, &out.UserName,
+		&out.MessageID, &out.Message, pq.Array(&iso), &out.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	for _, ds := range iso {
+		d, err := time.Parse("2006-01-02", ds)
+		if err != nil {
+			return nil, fmt.Errorf("Get: %w", err)
+		}
+		out.Dates = append(out.Dates, d)
+	}
+	return &out, nil
+}
+
+// Answer vermerkt die Antwort. Eine spätere Antwort (geänderte Reaktion)
+// überschreibt sie, solange die Frage nicht abgelaufen ist.
+func (s *Store) Answer(ctx context.Context, questionID, answer string) error {
+	const q = `UPDATE bot_clarification SET answer = $2, answered_at = now() WHERE question_id = $1`
+	if _, err := s.db.ExecContext(ctx, q, questionID, answer); err != nil {
+		return fmt.Errorf("Answer: %w", err)
+	}
+	return nil
+}
+
+// hedges sind typische Ausweich-Antworten (normalisiert, siehe normalize).
+// Nur danach fragt der Bot nach – ein "invalid" zu "Prost!" bleibt still.
+var hedges = []string{
+	"schau ma mal", "schaun ma mal", "schaun mer mal", "schauen wir mal", "mal schauen", "mal schaun",
+	"mal sehen", "mal sehn", "mal gucken", "mal guggn", "vielleicht", "vllt", "vlt", "evtl", "eventuell",
+	"weiß noch nicht", "weiss noch nicht", "weiß ned", "weiß nicht", "weiss nicht", "kann sein",
+	"wahrscheinlich", "unsicher", "spontan", "wenns geht", "wenn es geht",
+}
+
+// Unsure meldet, ob eine Nachricht nach einer unentschiedenen Ab-/Zusage
+// klingt.
+func Unsure(msg string) bool {
+	norm := " " + normalize(msg) + " "
+	for _, h := range hedges {
+		if strings.Contains(norm, " "+h+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	yes = []string{"👍", "✅", "ja", "jo", "jap", "jup", "yes", "klar", "freilich", "komme", "ich komme", "bin dabei"}
+	no  = []string{"👎", "❌", "nein", "ne", "nö", "noe", "nope", "komme nicht", "ich komme nicht", "bin raus"}
+)
+
+// ParseAnswer deutet eine Antwort auf die Rückfrage: 👍/👎 bzw. ein knappes
+// "ja"/"nein". ok=false: keine eindeutige Antwort (dann entscheidet der
+// Classifier über den Text).
+func ParseAnswer(text string) (classifier.Result, bool) {
+	for _, s := range []string{normalize(text), stripEmoji(text)} {
+		switch {
+		case contains(yes, s):
+			return classifier.Zusage, true
+		case contains(no, s):
+			return classifier.Absage, true
+		}
+	}
+	return "", false
+}
+
+// normalize: klein, Satzzeichen/Emoji zu Leerraum, Leerraum zusammengefasst
+// ("Schau ma mal…" == "schau ma mal").
+func normalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// stripEmoji entfernt Leerraum, Variation Selectors und Hautfarben ("👍🏽"
+// == "👍").
+func stripEmoji(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r), r == '\uFE0F', r == '\uFE0E', r >= 0x1F3FB && r <= 0x1F3FF:
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
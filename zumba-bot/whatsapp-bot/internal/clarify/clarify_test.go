@@ -0,0 +1,40 @@
+package clarify
+
+import (
+	"testing"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+)
+
+func TestUnsure(t *testing.T) {
+	for msg, want := range map[string]bool{
+		"Schau ma mal…":               true,
+		"vielleicht später":           true,
+		"weiß noch nicht ob's klappt": true,
+		"Prost!":                      false,
+		"bin raus":                    false,
+		"Evtl.":                       true,
+	} {
+		if got := Unsure(msg); got != want {
+			t.Errorf("Unsure(%q) = %v, want %v", msg, got, want)
+		}
+	}
+}
+
+func TestParseAnswer(t *testing.T) {
+	for text, want := range map[string]classifier.Result{
+		"👍":           classifier.Zusage,
+		"👍🏽":          classifier.Zusage,
+		"Ja!":         classifier.Zusage,
+		"bin dabei":   classifier.Zusage,
+		"👎":           classifier.Absage,
+		"nö":          classifier.Absage,
+		"Komme nicht": classifier.Absage,
+		"mal sehen":   "",
+	} {
+		got, ok := ParseAnswer(text)
+		if got != want || ok != (want != "") {
+			t.Errorf("ParseAnswer(%q) = %q, %v; want %q", text, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Package classcache ist ein Cache vor dem LLM-Classifier: viele Absagen sind
+// fast wortgleich ("bin raus", "heute nicht", "👎"), und jede kostet sonst
+// einen LLM-Aufruf. Schlüssel ist derselbe Hash des normalisierten Textes,
+// mit dem ml-classifier seine Splits bildet (scripts/export_real.py
+// normalize, sha1). LLM-Einträge laufen nach TTL ab und gelten nur für den
+// System-Prompt, mit dem sie entstanden sind; im Admin-UI geprüfte Label aus
+// ml_messages sind maßgeblich und laufen nicht ab.
+package classcache
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+)
+
+// Normalize entspricht normalize() in ml-classifier/scripts/export_real.py:
+// NFKC, casefold, nur Buchstaben/Ziffern/Whitespace, Whitespace kollabiert.
+func Normalize(text string) string {
+	t := cases.Fold().String(norm.NFKC.String(text))
+	t = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsSpace(r) {
+			return r
+		}
+		return -1
+	}, t)
+	return strings.Join(strings.Fields(t), " ")
+}
+
+// Key ist der Cache-Schlüssel (sha1 hex wie bucket() in common.py). Bleibt
+// nach der Normalisierung nichts übrig (reine Emoji-Nachricht wie "👎"),
+// zählt der Text selbst ohne Whitespace – sonst teilten sich alle
+// Emoji-Nachrichten einen Eintrag. Leer = nicht cachebar.
+func Key(text string) (key, normText string) {
+	normText = Normalize(text)
+	if normText == "" {
+		normText = strings.Join(strings.Fields(norm.NFKC.String(text)), "")
+	}
+	if normText == "" {
+		return "", ""
+	}
+	sum := sha1.Sum([]byte(normText))
+	return hex.EncodeToString(sum[:]), normText
+}
+
+// Cache klassifiziert über next und merkt sich dessen Ergebnisse.
+type Cache struct {
+	db     *sql.DB
+	next   classifier.Backend
+	ttl    time.Duration
+	prompt string
+}
+
+func New(db *sql.DB, next classifier.Backend, ttl time.Duration) *Cache {
+	return &Cache{db: db, next: next, ttl: ttl, prompt: classifier.PromptVersion()}
+}
+
+const schemaSQL = `
+CREATE TABLE IF NOT EXISTS classifier_cache (
+  key         TEXT PRIMARY KEY,
+  norm_text   TEXT NOT NULL,
+  label       TEXT NOT NULL,
+  source      TEXT NOT NULL,
+  model       TEXT NOT NULL DEFAULT '',
+  prompt      TEXT NOT NULL DEFAULT '',
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at  TIMESTAMPTZ,
+  hits        INT NOT NULL DEFAULT 0,
+  last_hit_at TIMESTAMPTZ
+);`
+
+// EnsureSchema legt classifier_cache idempotent an (beim Start aufgerufen).
+func (c *Cache) EnsureSchema(ctx context.Context) error {
+	_, err := c.db.ExecContext(ctx, schemaSQL)
+	return err
+}
+
+// Classify liefert einen gültigen Eintrag oder fragt next. Fehler des Caches
+// selbst (DB) sind nie fatal: dann eben ohne Cache.
+func (c *Cache) Classify(ctx context.Context, message string) (classifier.Classification, error) {
+	key, normText := Key(message)
+	if key == "" {
+		return c.next.Classify(ctx, message)
+	}
+
+	var label, source, model string
+	err := c.db.QueryRowContext(ctx, `
+		UPDATE classifier_cache SET hits = hits + 1, last_hit_at = now()
+		WHERE key = $1 AND (source = $2 OR (prompt = $3 AND expires_at > now()))
+		RETURNING label, source, model`, key, classifier.CacheVerified, c.prompt).Scan(&label, &source, &model)
+	switch {
+	case err == nil:
+		return classifier.Classification{Result: classifier.Result(label), Raw: label, Model: model,
+			Backend: classifier.BackendLLM, Cache: source}, nil
+	case err != sql.ErrNoRows:
+		log.Printf("⚠️  classcache: %v (ohne Cache weiter)", err)
+	}
+
+	cl, err := c.next.Classify(ctx, message)
+	if err != nil || cl.Backend == classifier.BackendML {
+		return cl, err
+	}
+	// Geprüfte Label werden nie von einer LLM-Antwort überschrieben.
+	if _, err := c.db.ExecContext(ctx, `
+		INSERT INTO classifier_cache (key, norm_text, label, source, model, prompt, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now() + $7 * interval '1 second')
+		ON CONFLICT (key) DO UPDATE
+		SET label = EXCLUDED.label, model = EXCLUDED.model, prompt = EXCLUDED.prompt,
+		    created_at = now(), expires_at = EXCLUDED.expires_at, hits = 0, last_hit_at = NULL
+		WHERE classifier_cache.source <> $8`,
+		key, normText, string(cl.Result), classifier.CacheLLM, cl.Model, c.prompt,
+		int64(c.ttl/time.Second), classifier.CacheVerified); err != nil {
+		log.Printf("⚠️  classcache speichern: %v", err)
+	}
+	return cl, nil
+}
+
+// SyncVerified übernimmt die im Admin-UI geprüften Label aus ml_messages als
+// maßgebliche Einträge (bei Widersprüchen gewinnt die jüngste Prüfung) und
+// liefert deren Anzahl. Ohne ml_messages (Shadow-Modus nie aktiv) passiert
+// nichts.
+func (c *Cache) SyncVerified(ctx context.Context) (int, error) {
+	var exists bool
+	if err := c.db.QueryRowContext(ctx, `SELECT to_regclass('ml_messages') IS NOT NULL`).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("SyncVerified: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT message, COALESCE(corrected_label, gemini_label)
+		FROM ml_messages
+		WHERE verified AND COALESCE(corrected_label, gemini_label) IN ('true', 'false', 'invalid')
+		ORDER BY id`)
+	if err != nil {
+		return 0, fmt.Errorf("SyncVerified: %w", err)
+	}
+	latest := map[string][2]string{} // key → normText, label
+	for rows.Next() {
+		var msg, label string
+		if err := rows.Scan(&msg, &label); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("SyncVerified scan: %w", err)
+		}
+		if key, normText := Key(msg); key != "" {
+			latest[key] = [2]string{normText, label}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("SyncVerified: %w", err)
+	}
+
+	for key, v := range latest {
+		if _, err := c.db.ExecContext(ctx, `
+			INSERT INTO classifier_cache (key, norm_text, label, source, model, expires_at)
+			VALUES ($1, $2, $3, $4, 'admin', NULL)
+			ON CONFLICT (key) DO UPDATE
+			SET label = EXCLUDED.label, source = EXCLUDED.source, model = EXCLUDED.model,
+			    prompt = '', expires_at = NULL`,
+			key, v[0], v[1], classifier.CacheVerified); err != nil {
+			return 0, fmt.Errorf("SyncVerified upsert: %w", err)
+		}
+	}
+	return len(latest), nil
+}
+
+// Purge löscht abgelaufene LLM-Einträge und solche eines alten Prompts.
+func (c *Cache) Purge(ctx context.Context) (int64, error) {
+	res, err := c.db.ExecContext(ctx, `
+		DELETE FROM classifier_cache
+		WHERE source <> $1 AND (expires_at <= now() OR prompt <> $2)`, classifier.CacheVerified, c.prompt)
+	if err != nil {
+		return 0, fmt.Errorf("Purge: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// Run gleicht die geprüften Label ab und räumt auf – sofort und dann alle
+// every, bis ctx endet.
+func (c *Cache) Run(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		if n, err := c.SyncVerified(ctx); err != nil {
+			log.Printf("⚠️  classcache: %v", err)
+		} else if n > 0 {
+			log.Printf("🗃  classcache: %d geprüfte Label übernommen", n)
+		}
+		if _, err := c.Purge(ctx); err != nil {
+			log.Printf("⚠️  classcache: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package classcache
+
+import "testing"
+
+// Gleiche Schlüssel wie ml-classifier (scripts/export_real.py normalize +
+// sha1), damit Cache und Trainings-Splits dieselben Nachrichten gleich sehen.
+func TestKeyWieMLClassifier(t *testing.T) {
+	cases := []struct{ in, norm, key string }{
+		{"Bin raus!! 🍻", "bin raus", "da30ece26a83db7608b16b0393b912acecf6a8ad"},
+		{"  Muß   heut ABMELDEN ❌", "muss heut abmelden", "01fbd346fcde96d4f732ad7cdd92972699ece2ff"},
+		{"Ｈｅｕｔｅ ned", "heute ned", "c1fff02fe4d94b81274063982e7357f2b22ed0b0"},
+	}
+	for _, c := range cases {
+		key, norm := Key(c.in)
+		if norm != c.norm || key != c.key {
+			t.Errorf("Key(%q) = %s, %q; want %s, %q", c.in, key, norm, c.key, c.norm)
+		}
+	}
+}
+
+func TestKeyNurEmoji(t *testing.T) {
+	down, _ := Key("👎")
+	up, _ := Key(" 👍 ")
+	if down == "" || up == "" || down == up {
+		t.Errorf("Emoji-Nachrichten brauchen eigene Schlüssel: 👎=%q 👍=%q", down, up)
+	}
+	if again, _ := Key("👎 "); again != down {
+		t.Errorf("Whitespace darf den Schlüssel nicht ändern: %q vs %q", again, down)
+	}
+	if key, _ := Key("  \n "); key != "" {
+		t.Errorf("leere Nachricht: key=%q, want nicht cachebar", key)
+	}
+}
@@ -1,18 +1,17 @@
-// Package classifier portiert den n8n-Node "Absagen Classifier": ein Gemini-Agent,
+// Package classifier portiert den n8n-Node "Absagen Classifier": ein LLM-Agent,
 // der eine WhatsApp-Nachricht als Zusage ("true"), Absage ("false") oder
-// "invalid" klassifiziert. Primärmodell + Fallback wie im Workflow (needsFallback).
+// "invalid" klassifiziert. Primärmodell + Fallback wie im Workflow
+// (needsFallback); welcher Anbieter dahintersteckt (Gemini, OpenAI-kompatibel
+// wie Ollama/llama.cpp), entscheidet der Provider.
 package classifier
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha1"
 	_ "embed"
-	"encoding/json"
+	"encoding/hex"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
-	"time"
 )
 
 //go:embed system-prompt.txt
@@ -27,46 +26,84 @@ const (
 	Invalid Result = "invalid"
 )
 
-const geminiBaseURL = "https://generativelanguage.googleapis.com/v1beta/models"
+// Provider ist ein LLM-Anbieter: er schickt System-Prompt und Nachricht an
+// ein Modell und liefert dessen rohe Antwort.
+type Provider interface {
+	// Model nennt das Modell (für Trace und Logs).
+	Model() string
+	Complete(ctx context.Context, system, message string) (string, error)
+}
 
-type Gemini struct {
-	apiKey        string
-	model         string
-	fallbackModel string
-	http          *http.Client
+// LLM klassifiziert per Sprachmodell: erst Primary, bei Fehler Fallback
+// (nil = keins). Beide können beliebige Provider sein.
+type LLM struct {
+	primary  Provider
+	fallback Provider
 }
 
-func NewGemini(apiKey, model, fallbackModel string) *Gemini {
-	return &Gemini{
-		apiKey:        apiKey,
-		model:         model,
-		fallbackModel: fallbackModel,
-		http:          &http.Client{Timeout: 30 * time.Second},
-	}
+func NewLLM(primary, fallback Provider) *LLM {
+	return &LLM{primary: primary, fallback: fallback}
 }
 
-// Classification ist das Ergebnis eines Classifier-Laufs inkl. Gemini-Roh-Antwort
+// Backends, die eine Klassifikation liefern können.
+const (
+	BackendLLM = "llm" // Sprachmodell (Gemini, Ollama …)
+	BackendML  = "ml"  // eigenes Modell (classifier-service)
+)
+
+// Classification ist das Ergebnis eines Classifier-Laufs inkl. LLM-Roh-Antwort
 // und tatsächlich genutztem Modell (für Trace/Debugging in der Verlauf-Ansicht).
 type Classification struct {
 	Result Result
-	Raw    string // ungetrimmte Antwort von Gemini
+	Raw    string // ungetrimmte Antwort des Modells
 	Model  string // Modell, das die Antwort lieferte (Primär oder Fallback)
+
+	// Backend hat entschieden (BackendLLM, BackendML; leer = LLM).
+	Backend string
+	// Confidence des eigenen Modells (nur BackendML).
+	Confidence float64
+	// Uncertain: Konfidenz unter der Schwelle, Result deshalb Invalid.
+	Uncertain bool
+	// FallbackReason ist der Gemini-Fehler, wegen dem das eigene Modell
+	// entschieden hat.
+	FallbackReason string
+
+	// Route sagt, nach welcher Regel entschieden wurde (RouteShadow …, nur
+	// mit Router gesetzt).
+	Route string
+	// Prediction ist die schon eingeholte Modell-Antwort, wenn Gemini trotz
+	// Canary entschieden hat (spart dem Shadow-Modus den zweiten Aufruf).
+	Prediction *Prediction
+
+	// Cache ist die Quelle eines Cache-Treffers (CacheLLM, CacheVerified;
+	// leer = frisch klassifiziert).
+	Cache string
 }
 
+// Quellen eines Cache-Eintrags (classifier_cache.source).
+const (
+	CacheLLM      = "llm"      // frühere LLM-Antwort, läuft ab
+	CacheVerified = "verified" // im Admin-UI geprüftes Label, maßgeblich
+)
+
 // Classify ruft erst das Primär-, bei Fehler das Fallback-Modell auf. Jede
 // nicht eindeutige Antwort (alles außer "true"/"false") wird zu Invalid – so
 // löst der nachgelagerte Switch (n8n) bei "invalid" keine DB-Aktion aus.
-func (g *Gemini) Classify(ctx context.Context, message string) (Classification, error) {
-	model := g.model
-	raw, err := g.generate(ctx, model, message)
-	if err != nil && g.fallbackModel != "" && g.fallbackModel != g.model {
-		model = g.fallbackModel
-		raw, err = g.generate(ctx, model, message)
+func (l *LLM) Classify(ctx context.Context, message string) (Classification, error) {
+	return l.complete(ctx, systemPrompt, message)
+}
+
+func (l *LLM) complete(ctx context.Context, system, message string) (Classification, error) {
+	p := l.primary
+	raw, err := p.Complete(ctx, system, message)
+	if err != nil && l.fallback != nil {
+		p = l.fallback
+		raw, err = p.Complete(ctx, system, message)
 	}
 	if err != nil {
-		return Classification{Result: Invalid, Model: model}, err
+		return Classification{Result: Invalid, Model: p.Model(), Backend: BackendLLM}, err
 	}
-	return Classification{Result: normalize(raw), Raw: raw, Model: model}, nil
+	return Classification{Result: normalize(raw), Raw: raw, Model: p.Model(), Backend: BackendLLM}, nil
 }
 
 func normalize(raw string) Result {
@@ -80,67 +117,30 @@ func normalize(raw string) Result {
 	}
 }
 
-type geminiRequest struct {
-	SystemInstruction content   `json:"systemInstruction"`
-	Contents          []content `json:"contents"`
-	GenerationConfig  genConfig `json:"generationConfig"`
-}
-
-type content struct {
-	Role  string `json:"role,omitempty"`
-	Parts []part `json:"parts"`
-}
-
-type part struct {
-	Text string `json:"text"`
-}
-
-type genConfig struct {
-	Temperature float64 `json:"temperature"`
-}
-
-type geminiResponse struct {
-	Candidates []struct {
-		Content content `json:"content"`
-	} `json:"candidates"`
-}
-
-func (g *Gemini) generate(ctx context.Context, model, message string) (string, error) {
-	reqBody := geminiRequest{
-		SystemInstruction: content{Parts: []part{{Text: systemPrompt}}},
-		Contents:          []content{{Role: "user", Parts: []part{{Text: message}}}},
-		GenerationConfig:  genConfig{Temperature: 0},
-	}
-	buf, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("marshal: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/%s:generateContent", geminiBaseURL, model)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
-	if err != nil {
-		return "", fmt.Errorf("new request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-goog-api-key", g.apiKey)
-
-	resp, err := g.http.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("gemini %s: %w", model, err)
-	}
-	defer resp.Body.Close()
+// LLM-Anbieter (Env LLM_PROVIDER / LLM_FALLBACK_PROVIDER).
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai" // OpenAI-kompatibel: Ollama, llama.cpp, OpenAI
+	ProviderNone   = "none"   // nur als Fallback: keiner
+)
 
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("gemini %s: status %d: %s", model, resp.StatusCode, string(body))
+// NewProvider baut den Provider name (ProviderGemini, ProviderOpenAI).
+func NewProvider(name, baseURL, apiKey, model string) (Provider, error) {
+	switch name {
+	case ProviderGemini:
+		return NewGemini(baseURL, apiKey, model), nil
+	case ProviderOpenAI:
+		if baseURL == "" {
+			return nil, fmt.Errorf("provider %s: Basis-URL fehlt", name)
+		}
+		return NewOpenAI(baseURL, apiKey, model), nil
 	}
+	return nil, fmt.Errorf("unbekannter provider %q (gemini|openai)", name)
+}
 
-	var out geminiResponse
-	if err := json.Unmarshal(body, &out); err != nil {
-		return "", fmt.Errorf("gemini %s: decode: %w", model, err)
-	}
-	if len(out.Candidates) == 0 || len(out.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("gemini %s: empty response", model)
-	}
-	return out.Candidates[0].Content.Parts[0].Text, nil
+// PromptVersion ist ein kurzer Hash des eingebetteten System-Prompts: ändert
+// sich der Prompt, gelten gecachte Klassifikationen nicht mehr.
+func PromptVersion() string {
+	sum := sha1.Sum([]byte(systemPrompt))
+	return hex.EncodeToString(sum[:6])
 }
@@ -0,0 +1,65 @@
+package classifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Turn ist eine Nachricht aus dem Gesprächskontext.
+type Turn struct {
+	Author string
+	Text   string
+	// Quoted: die Nachricht, auf die direkt geantwortet wurde.
+	Quoted bool
+}
+
+// contextPrompt ergänzt den System-Prompt, wenn Kontext mitgeschickt wird.
+// Bewusst nicht in system-prompt.txt: ohne Kontext bleibt der Prompt (und
+// damit der Classifier-Cache) unverändert.
+const contextPrompt = `
+
+GESPRÄCHSKONTEXT
+--------------------------------
+Vor der Nachricht steht ein Auszug aus dem Gruppenchat und ggf. die Nachricht,
+auf die geantwortet wurde. Klassifiziere NUR die letzte Nachricht. Der Kontext
+hilft, kurze Anschlüsse wie „ich auch“, „same“, „dito“ oder „+1“ zu verstehen:
+Schließt sich die Person damit einer Absage an → false, einer Zusage → true.
+Ist der Bezug nicht eindeutig → invalid.`
+
+// ClassifyInContext klassifiziert message mit dem Gesprächskontext davor
+// (chronologisch, zitierte Nachricht mit Quoted). Ohne Kontext wie Classify.
+func (l *LLM) ClassifyInContext(ctx context.Context, message string, history []Turn) (Classification, error) {
+	if len(history) == 0 {
+		return l.Classify(ctx, message)
+	}
+	return l.complete(ctx, systemPrompt+contextPrompt, withContext(message, history))
+}
+
+func withContext(message string, history []Turn) string {
+	var b strings.Builder
+	var quote *Turn
+	var chat []Turn
+	for i := range history {
+		if history[i].Quoted {
+			quote = &history[i]
+		} else {
+			chat = append(chat, history[i])
+		}
+	}
+	if len(chat) > 0 {
+		b.WriteString("Chatverlauf davor:\n")
+		for _, t := range chat {
+			fmt.Fprintf(&b, "%s: %s\n", t.Author, oneLine(t.Text))
+		}
+		b.WriteString("\n")
+	}
+	if quote != nil {
+		fmt.Fprintf(&b, "Antwort auf %s: %s\n\n", quote.Author, oneLine(quote.Text))
+	}
+	b.WriteString("Zu klassifizierende Nachricht:\n")
+	b.WriteString(message)
+	return b.String()
+}
+
+func oneLine(s string) string { return strings.Join(strings.Fields(s), " ") }
@@ -0,0 +1,49 @@
+package classifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Backend ist ein einzelner Klassifikator (Gemini, eigenes Modell).
+type Backend interface {
+	Classify(ctx context.Context, message string) (Classification, error)
+}
+
+// WithFallback fragt Primary (Gemini inkl. Fallback-Modell) und nur, wenn das
+// scheitert oder länger als Timeout braucht, Fallback (eigenes Modell). Vorher
+// wurde eine Absage an Gemini-Ausfalltagen zu Invalid und ging verloren.
+type WithFallback struct {
+	Primary  Backend
+	Fallback Backend
+	// Timeout begrenzt Primary (0 = nur die HTTP-Timeouts von Primary).
+	Timeout time.Duration
+}
+
+// Classify liefert das Ergebnis von Primary bzw. Fallback. Ist sich das
+// eigene Modell zu unsicher (Invalid wegen Schwelle) oder fällt es ebenfalls
+// aus, bleibt es beim Fehler – die Inbox wiederholt das Event dann, sobald
+// Gemini wieder antwortet, statt die Nachricht stillschweigend zu verwerfen.
+func (f *WithFallback) Classify(ctx context.Context, message string) (Classification, error) {
+	pctx := ctx
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		pctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+	c, err := f.Primary.Classify(pctx, message)
+	if err == nil {
+		return c, nil
+	}
+
+	fc, ferr := f.Fallback.Classify(ctx, message)
+	fc.FallbackReason = err.Error()
+	if ferr != nil {
+		return fc, fmt.Errorf("%w · ML-Fallback: %v", err, ferr)
+	}
+	if fc.Uncertain {
+		return fc, fmt.Errorf("%w · ML-Fallback unsicher (%s, Konfidenz %.2f)", err, fc.Raw, fc.Confidence)
+	}
+	return fc, nil
+}
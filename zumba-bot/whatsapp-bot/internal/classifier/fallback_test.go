@@ -0,0 +1,74 @@
+package classifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeBackend struct {
+	c     Classification
+	err   error
+	calls int
+}
+
+func (f *fakeBackend) Classify(context.Context, string) (Classification, error) {
+	f.calls++
+	return f.c, f.err
+}
+
+func TestWithFallback(t *testing.T) {
+	down := errors.New("gemini: HTTP 503")
+
+	// Gemini antwortet: das eigene Modell wird gar nicht gefragt.
+	ml := &fakeBackend{c: Classification{Result: Absage, Backend: BackendML}}
+	f := &WithFallback{Primary: &fakeBackend{c: Classification{Result: Zusage, Backend: BackendLLM}}, Fallback: ml}
+	if c, err := f.Classify(context.Background(), "komme"); err != nil || c.Result != Zusage || ml.calls != 0 {
+		t.Errorf("Gemini ok: %+v, %v, ML-Aufrufe %d", c, err, ml.calls)
+	}
+
+	// Gemini fällt aus, Modell ist sicher: Modell entscheidet.
+	f = &WithFallback{Primary: &fakeBackend{err: down}, Fallback: ml}
+	c, err := f.Classify(context.Background(), "bin raus")
+	if err != nil || c.Result != Absage || c.Backend != BackendML || c.FallbackReason != down.Error() {
+		t.Errorf("ML sicher: %+v, %v", c, err)
+	}
+
+	// Modell unsicher: Fehler bleibt (Inbox wiederholt später).
+	f.Fallback = &fakeBackend{c: Classification{Result: Invalid, Uncertain: true, Backend: BackendML}}
+	if _, err := f.Classify(context.Background(), "hm"); !errors.Is(err, down) {
+		t.Errorf("ML unsicher: err=%v, want Gemini-Fehler", err)
+	}
+
+	// Modell ebenfalls weg.
+	f.Fallback = &fakeBackend{err: errors.New("classifier-service: HTTP 500")}
+	if _, err := f.Classify(context.Background(), "hm"); err == nil || !strings.Contains(err.Error(), "HTTP 500") {
+		t.Errorf("ML weg: err=%v", err)
+	}
+}
+
+func TestLocalSchwelle(t *testing.T) {
+	conf := "0.95"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/classify" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"label":"false","confidence":` + conf + `}`))
+	}))
+	defer srv.Close()
+	l := NewLocal(srv.URL+"/", 0.8)
+
+	c, err := l.Classify(context.Background(), "bin raus")
+	if err != nil || c.Result != Absage || c.Uncertain || c.Confidence != 0.95 {
+		t.Errorf("über Schwelle: %+v, %v", c, err)
+	}
+	conf = "0.6"
+	c, err = l.Classify(context.Background(), "vielleicht")
+	if err != nil || c.Result != Invalid || !c.Uncertain {
+		t.Errorf("unter Schwelle: %+v, %v", c, err)
+	}
+}
@@ -0,0 +1,102 @@
+package classifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const geminiBaseURL = "https://generativelanguage.googleapis.com/v1beta/models"
+
+// Gemini spricht Googles generateContent-Endpunkt an.
+type Gemini struct {
+	baseURL string
+	apiKey  string
+	model   string
+	http    *http.Client
+}
+
+// NewGemini: baseURL leer = Google AI Studio.
+func NewGemini(baseURL, apiKey, model string) *Gemini {
+	if baseURL == "" {
+		baseURL = geminiBaseURL
+	}
+	return &Gemini{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (g *Gemini) Model() string { return g.model }
+
+type geminiRequest struct {
+	SystemInstruction content   `json:"systemInstruction"`
+	Contents          []content `json:"contents"`
+	GenerationConfig  genConfig `json:"generationConfig"`
+}
+
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+type part struct {
+	Text string `json:"text"`
+}
+
+type genConfig struct {
+	Temperature float64 `json:"temperature"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content content `json:"content"`
+	} `json:"candidates"`
+}
+
+func (g *Gemini) Complete(ctx context.Context, system, message string) (string, error) {
+	reqBody := geminiRequest{
+		SystemInstruction: content{Parts: []part{{Text: system}}},
+		Contents:          []content{{Role: "user", Parts: []part{{Text: message}}}},
+		GenerationConfig:  genConfig{Temperature: 0},
+	}
+	buf, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("marshal: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s:generateContent", g.baseURL, g.model)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", g.apiKey)
+
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gemini %s: %w", g.model, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("gemini %s: status %d: %s", g.model, resp.StatusCode, string(body))
+	}
+
+	var out geminiResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", fmt.Errorf("gemini %s: decode: %w", g.model, err)
+	}
+	if len(out.Candidates) == 0 || len(out.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("gemini %s: empty response", g.model)
+	}
+	return out.Candidates[0].Content.Parts[0].Text, nil
+}
@@ -0,0 +1,126 @@
+package classifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// openAIStandIn ist ein lokaler chat/completions-Server wie Ollama.
+func openAIStandIn(t *testing.T, answer string, status int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		if req.Model != "qwen2.5:3b" || len(req.Messages) != 2 || req.Messages[0].Role != "system" ||
+			req.Messages[0].Content != systemPrompt || req.Messages[1].Content != "bin raus" || req.Stream {
+			t.Errorf("Request: %+v", req)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("Authorization ohne API-Key: %q", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": answer}}},
+		})
+	}))
+}
+
+func TestLLMOpenAI(t *testing.T) {
+	srv := openAIStandIn(t, " False\n", http.StatusOK)
+	defer srv.Close()
+	p, err := NewProvider(ProviderOpenAI, srv.URL+"/v1/", "", "qwen2.5:3b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewLLM(p, nil).Classify(context.Background(), "bin raus")
+	if err != nil || c.Result != Absage || c.Model != "qwen2.5:3b" || c.Backend != BackendLLM {
+		t.Errorf("Classify: %+v, %v", c, err)
+	}
+}
+
+func TestLLMStriktesFormat(t *testing.T) {
+	srv := openAIStandIn(t, "Das ist eine Absage.", http.StatusOK)
+	defer srv.Close()
+	c, err := NewLLM(NewOpenAI(srv.URL+"/v1", "", "qwen2.5:3b"), nil).Classify(context.Background(), "bin raus")
+	if err != nil || c.Result != Invalid {
+		t.Errorf("Freitext: %+v, %v, want invalid", c, err)
+	}
+}
+
+func TestLLMFallbackAufAnderenAnbieter(t *testing.T) {
+	down := openAIStandIn(t, "", http.StatusServiceUnavailable)
+	defer down.Close()
+	gemini := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/gemini-2.5-flash:generateContent") || r.Header.Get("x-goog-api-key") != "key" {
+			t.Errorf("Gemini-Request: %s %v", r.URL.Path, r.Header)
+		}
+		w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"true"}]}}]}`))
+	}))
+	defer gemini.Close()
+
+	l := NewLLM(NewOpenAI(down.URL+"/v1", "", "qwen2.5:3b"), NewGemini(gemini.URL, "key", "gemini-2.5-flash"))
+	c, err := l.Classify(context.Background(), "bin raus")
+	if err != nil || c.Result != Zusage || c.Model != "gemini-2.5-flash" {
+		t.Errorf("Fallback: %+v, %v", c, err)
+	}
+
+	// Beide weg: Invalid + Fehler (die Inbox wiederholt).
+	l = NewLLM(NewOpenAI(down.URL+"/v1", "", "qwen2.5:3b"), nil)
+	if c, err := l.Classify(context.Background(), "bin raus"); err == nil || c.Result != Invalid {
+		t.Errorf("ohne Fallback: %+v, %v", c, err)
+	}
+}
+
+func TestNewProviderUngueltig(t *testing.T) {
+	if _, err := NewProvider(ProviderOpenAI, "", "", "qwen2.5:3b"); err == nil {
+		t.Error("openai ohne Basis-URL: Fehler erwartet")
+	}
+	if _, err := NewProvider("bard", "", "", "x"); err == nil {
+		t.Error("unbekannter Anbieter: Fehler erwartet")
+	}
+}
+
+type captureProvider struct{ system, message string }
+
+func (p *captureProvider) Model() string { return "capture" }
+
+func (p *captureProvider) Complete(_ context.Context, system, message string) (string, error) {
+	p.system, p.message = system, message
+	return "false", nil
+}
+
+func TestLLMMitKontext(t *testing.T) {
+	p := &captureProvider{}
+	c, err := NewLLM(p, nil).ClassifyInContext(context.Background(), "ich auch", []Turn{
+		{Author: "Anna", Text: "bin heute\nraus"},
+		{Author: "Ben", Text: "schade"},
+		{Author: "Anna", Text: "bin heute raus", Quoted: true},
+	})
+	if err != nil || c.Result != Absage {
+		t.Fatalf("ClassifyInContext: %+v, %v", c, err)
+	}
+	want := "Chatverlauf davor:\nAnna: bin heute raus\nBen: schade\n\n" +
+		"Antwort auf Anna: bin heute raus\n\nZu klassifizierende Nachricht:\nich auch"
+	if p.message != want {
+		t.Errorf("Nachricht:\n%s\nwant:\n%s", p.message, want)
+	}
+	if !strings.HasPrefix(p.system, systemPrompt) || !strings.Contains(p.system, "GESPRÄCHSKONTEXT") {
+		t.Errorf("System-Prompt ohne Kontext-Hinweis")
+	}
+
+	// Ohne Kontext: exakt wie Classify (gleicher Prompt → Cache bleibt gültig).
+	NewLLM(p, nil).ClassifyInContext(context.Background(), "bin raus", nil)
+	if p.system != systemPrompt || p.message != "bin raus" {
+		t.Errorf("ohne Kontext: %q / %q", p.system, p.message)
+	}
+}
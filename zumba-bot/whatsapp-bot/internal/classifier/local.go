@@ -0,0 +1,79 @@
+package classifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Local spricht das eigene ML-Modell an (classifier-service, POST /classify).
+// Im Shadow-Modus läuft es nur mit, als Fallback entscheidet es, wenn Gemini
+// ausfällt.
+type Local struct {
+	serviceURL string
+	http       *http.Client
+
+	// Threshold: unter dieser Konfidenz wird das Ergebnis Invalid (lieber
+	// keine Aktion als eine falsche Absage).
+	Threshold float64
+}
+
+func NewLocal(serviceURL string, threshold float64) *Local {
+	return &Local{
+		serviceURL: strings.TrimRight(serviceURL, "/"),
+		http:       &http.Client{Timeout: 5 * time.Second},
+		Threshold:  threshold,
+	}
+}
+
+// Prediction ist die Antwort des classifier-service.
+type Prediction struct {
+	Label      string  `json:"label"` // "true" | "false" | "invalid"
+	Confidence float64 `json:"confidence"`
+}
+
+// Predict liefert Label und Konfidenz des Modells.
+func (l *Local) Predict(ctx context.Context, message string) (Prediction, error) {
+	body, err := json.Marshal(map[string]string{"text": message})
+	if err != nil {
+		return Prediction{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.serviceURL+"/classify", bytes.NewReader(body))
+	if err != nil {
+		return Prediction{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.http.Do(req)
+	if err != nil {
+		return Prediction{}, fmt.Errorf("classifier-service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Prediction{}, fmt.Errorf("classifier-service: HTTP %d", resp.StatusCode)
+	}
+	var pred Prediction
+	if err := json.NewDecoder(resp.Body).Decode(&pred); err != nil {
+		return Prediction{}, fmt.Errorf("classifier-service: decode: %w", err)
+	}
+	return pred, nil
+}
+
+// Classify klassifiziert per Modell; unter Threshold wird es Invalid
+// (Uncertain gesetzt).
+func (l *Local) Classify(ctx context.Context, message string) (Classification, error) {
+	pred, err := l.Predict(ctx, message)
+	if err != nil {
+		return Classification{Result: Invalid, Backend: BackendML}, err
+	}
+	c := Classification{Result: normalize(pred.Label), Raw: pred.Label, Model: "classifier-service",
+		Backend: BackendML, Confidence: pred.Confidence}
+	if c.Result != Invalid && pred.Confidence < l.Threshold {
+		c.Result, c.Uncertain = Invalid, true
+	}
+	return c, nil
+}
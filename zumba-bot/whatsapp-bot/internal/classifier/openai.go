@@ -0,0 +1,94 @@
+package classifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAI spricht einen OpenAI-kompatiblen chat/completions-Endpunkt an –
+// OpenAI selbst, aber vor allem Ollama oder llama.cpp auf dem Pi (baseURL
+// z. B. http://ollama:11434/v1).
+type OpenAI struct {
+	baseURL string
+	apiKey  string // leer = kein Authorization-Header (Ollama, llama.cpp)
+	model   string
+	http    *http.Client
+}
+
+func NewOpenAI(baseURL, apiKey, model string) *OpenAI {
+	return &OpenAI{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		// Lokale Modelle auf dem Pi brauchen beim ersten Aufruf (Laden) länger.
+		http: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (o *OpenAI) Model() string { return o.model }
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+	Stream      bool          `json:"stream"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (o *OpenAI) Complete(ctx context.Context, system, message string) (string, error) {
+	buf, err := json.Marshal(chatRequest{
+		Model: o.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: system},
+			{Role: "user", Content: message},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(buf))
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("openai %s: %w", o.model, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("openai %s: status %d: %s", o.model, resp.StatusCode, string(body))
+	}
+
+	var out chatResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", fmt.Errorf("openai %s: decode: %w", o.model, err)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("openai %s: empty response", o.model)
+	}
+	return out.Choices[0].Message.Content, nil
+}
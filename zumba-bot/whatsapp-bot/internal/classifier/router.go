@@ -0,0 +1,103 @@
+package classifier
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+)
+
+// Routen einer Entscheidung (Spalte ml_messages.route).
+const (
+	RouteShadow   = "shadow"        // Gemini entscheidet, das Modell läuft mit
+	RouteCanary   = "canary"        // Canary: Modell sicher genug, es entscheidet
+	RouteGated    = "canary-gemini" // Canary, Modell unter der Schwelle → Gemini
+	RouteFallback = "fallback"      // Gemini ausgefallen, das Modell entschied
+)
+
+// Policy legt fest, wann das eigene Modell statt Gemini entscheidet.
+type Policy struct {
+	// Share: Anteil der Nachrichten im Canary (0–100 %, 0 = aus, 100 =
+	// reines Konfidenz-Routing).
+	Share int
+	// MinConfidence: im Canary entscheidet das Modell nur ab dieser
+	// Konfidenz, sonst Gemini.
+	MinConfidence float64
+}
+
+// InCanary sagt, ob key (Message-ID) im Canary-Anteil liegt. Der Hash macht
+// die Zuordnung stabil: eine Wiederholung aus der Inbox oder eine Bearbeitung
+// landet auf derselben Route wie das Original.
+func (p Policy) InCanary(key string) bool {
+	if p.Share <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32()%100) < p.Share
+}
+
+// Router verteilt Nachrichten zwischen Gemini und dem eigenen Modell nach
+// Policy. Außerhalb des Canary (oder bei gezogenem Kill-Switch) entscheidet
+// Primary wie bisher.
+type Router struct {
+	Primary Backend // Gemini, ggf. mit ML-Fallback
+	Model   *Local
+	Policy  Policy
+
+	// Killed fragt den Kill-Switch aus dem Admin-UI ab (nil = gibt es
+	// nicht). Ein Fehler zählt als gezogen – im Zweifel entscheidet Gemini.
+	Killed func(ctx context.Context) (bool, error)
+}
+
+// Classify routet ohne Message-ID (Schlüssel ist der Text).
+func (r *Router) Classify(ctx context.Context, message string) (Classification, error) {
+	return r.ClassifyKeyed(ctx, message, message)
+}
+
+// ClassifyKeyed routet die Nachricht mit der Message-ID key und setzt
+// Classification.Route.
+func (r *Router) ClassifyKeyed(ctx context.Context, key, message string) (Classification, error) {
+	if !r.canary(ctx, key) {
+		c, err := r.Primary.Classify(ctx, message)
+		c.Route = primaryRoute(c, RouteShadow)
+		return c, err
+	}
+
+	pred, perr := r.Model.Predict(ctx, message)
+	if perr == nil && pred.Confidence >= r.Policy.MinConfidence {
+		return Classification{Result: normalize(pred.Label), Raw: pred.Label, Model: "classifier-service",
+			Backend: BackendML, Confidence: pred.Confidence, Route: RouteCanary}, nil
+	}
+	if perr != nil {
+		log.Printf("⚠️  canary: Modell nicht erreichbar (%v), Gemini entscheidet", perr)
+	}
+	c, err := r.Primary.Classify(ctx, message)
+	c.Route = primaryRoute(c, RouteGated)
+	if perr == nil {
+		c.Prediction = &pred
+	}
+	return c, err
+}
+
+func (r *Router) canary(ctx context.Context, key string) bool {
+	if !r.Policy.InCanary(key) {
+		return false
+	}
+	if r.Killed == nil {
+		return true
+	}
+	killed, err := r.Killed(ctx)
+	if err != nil {
+		log.Printf("⚠️  canary: Kill-Switch nicht lesbar (%v), Gemini entscheidet", err)
+		return false
+	}
+	return !killed
+}
+
+// primaryRoute: hat im Primary der ML-Fallback entschieden, ist das die Route.
+func primaryRoute(c Classification, route string) string {
+	if c.Backend == BackendML {
+		return RouteFallback
+	}
+	return route
+}
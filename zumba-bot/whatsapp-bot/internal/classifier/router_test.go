@@ -0,0 +1,75 @@
+package classifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPolicyInCanary(t *testing.T) {
+	in := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("3EB0%04d", i)
+		if (Policy{}).InCanary(key) {
+			t.Fatal("Share 0: nichts im Canary")
+		}
+		if !(Policy{Share: 100}).InCanary(key) {
+			t.Fatal("Share 100: alles im Canary")
+		}
+		if (Policy{Share: 30}).InCanary(key) {
+			in++
+		}
+	}
+	if in < 240 || in > 360 {
+		t.Errorf("Share 30: %d von 1000 im Canary", in)
+	}
+	if (Policy{Share: 30}).InCanary("abc") != (Policy{Share: 30}).InCanary("abc") {
+		t.Error("Zuordnung nicht stabil")
+	}
+}
+
+func TestRouter(t *testing.T) {
+	conf := "0.95"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"label":"false","confidence":` + conf + `}`))
+	}))
+	defer srv.Close()
+	gemini := &fakeBackend{c: Classification{Result: Zusage, Backend: BackendLLM}}
+	r := &Router{Primary: gemini, Model: NewLocal(srv.URL, 0.8), Policy: Policy{Share: 100, MinConfidence: 0.9}}
+
+	// Modell sicher: es entscheidet, Gemini wird nicht gefragt.
+	c, err := r.ClassifyKeyed(context.Background(), "id1", "bin raus")
+	if err != nil || c.Route != RouteCanary || c.Result != Absage || gemini.calls != 0 {
+		t.Errorf("Canary: %+v, %v, Gemini-Aufrufe %d", c, err, gemini.calls)
+	}
+
+	// Modell unter der Schwelle: Gemini entscheidet, Modell-Antwort bleibt dran.
+	conf = "0.85"
+	c, err = r.ClassifyKeyed(context.Background(), "id1", "bin raus")
+	if err != nil || c.Route != RouteGated || c.Result != Zusage || c.Prediction == nil || c.Prediction.Confidence != 0.85 {
+		t.Errorf("unter Schwelle: %+v, %v", c, err)
+	}
+
+	// Kill-Switch gezogen bzw. nicht lesbar: Gemini wie ohne Canary.
+	conf = "0.99"
+	for _, killed := range []func(context.Context) (bool, error){
+		func(context.Context) (bool, error) { return true, nil },
+		func(context.Context) (bool, error) { return false, errors.New("db weg") },
+	} {
+		r.Killed = killed
+		c, err = r.ClassifyKeyed(context.Background(), "id1", "bin raus")
+		if err != nil || c.Route != RouteShadow || c.Result != Zusage {
+			t.Errorf("Kill-Switch: %+v, %v", c, err)
+		}
+	}
+
+	// Außerhalb des Canary hat der ML-Fallback im Primary entschieden.
+	r.Killed, r.Policy.Share = nil, 0
+	r.Primary = &fakeBackend{c: Classification{Result: Absage, Backend: BackendML}}
+	if c, _ := r.ClassifyKeyed(context.Background(), "id1", "bin raus"); c.Route != RouteFallback {
+		t.Errorf("Fallback: Route %q", c.Route)
+	}
+}
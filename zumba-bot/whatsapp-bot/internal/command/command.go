@@ -0,0 +1,149 @@
+// Package command ist der Befehls-Router des Bots: Gruppen-Befehle wie
+// "statistik" oder "hilfe" werden hier mit Aliassen, erlaubter Argumentzahl
+// und Berechtigung registriert, statt als weitere Verzweigung in run() zu
+// landen. Die Übersicht für "hilfe" entsteht aus den Registrierungen.
+package command
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Call ist ein erkannter Befehlsaufruf.
+type Call struct {
+	Name     string   // kanonischer Name des Befehls
+	Args     []string // Wörter nach dem Befehl (Original-Schreibweise)
+	UserID   string
+	UserName string
+	Chat     string // remoteJid, in den die Antwort geht
+	AsOf     time.Time
+	DryRun   bool // nichts schreiben (Test-Pfad)
+}
+
+// Reply ist die Antwort eines Befehls. Mit Image geht die Bild-Karte raus
+// (Caption, Text als Fallback), sonst Text.
+type Reply struct {
+	Text    string
+	Caption string
+	Image   []byte
+
+	// Detail landet im Trace-Knoten des Befehls ("12 Nutzer").
+	Detail string
+	// Data trägt befehlsspezifische Zusatzdaten zum Aufrufer (z. B. die
+	// Rangliste für alternative Designs auf der Testseite).
+	Data any
+}
+
+// Handler führt einen Befehl aus.
+type Handler func(ctx context.Context, c Call) (Reply, error)
+
+// Command beschreibt einen Befehl.
+type Command struct {
+	Name    string   // kanonischer Name, darf aus mehreren Wörtern bestehen ("meine statistik")
+	Aliases []string // weitere Schreibweisen ("help", "befehle")
+	Usage   string   // Argument-Hinweis für "hilfe", z. B. "[name]"
+	Help    string   // Einzeiler für "hilfe"
+	MaxArgs int      // mehr Wörter nach dem Befehl → normale Nachricht, kein Befehl
+	Admin   bool     // nur für Admins (BOT_ADMINS)
+	Run     Handler
+}
+
+// Router ordnet Nachrichten registrierten Befehlen zu.
+type Router struct {
+	cmds   []*Command
+	byWord map[string]*Command // normalisierter Name/Alias → Befehl
+	maxLen int                 // längster Name/Alias in Wörtern
+	admins map[string]bool
+}
+
+func NewRouter() *Router {
+	return &Router{byWord: map[string]*Command{}, admins: map[string]bool{}}
+}
+
+// Register fügt einen Befehl hinzu. Doppelte Namen/Aliasse sind ein
+// Programmierfehler und lösen einen Panic aus.
+func (r *Router) Register(c Command) {
+	cmd := &c
+	for _, w := range append([]string{c.Name}, c.Aliases...) {
+		key := strings.Join(strings.Fields(strings.ToLower(w)), " ")
+		if _, dup := r.byWord[key]; dup {
+			panic(fmt.Sprintf("command: %q doppelt registriert", key))
+		}
+		r.byWord[key] = cmd
+		r.maxLen = max(r.maxLen, len(strings.Fields(key)))
+	}
+	r.cmds = append(r.cmds, cmd)
+}
+
+// SetAdmins legt die userIds fest, die Admin-Befehle ausführen dürfen.
+func (r *Router) SetAdmins(userIDs []string) {
+	r.admins = map[string]bool{}
+	for _, id := range userIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			r.admins[id] = true
+		}
+	}
+}
+
+// IsAdmin meldet, ob userID Admin-Befehle ausführen darf.
+func (r *Router) IsAdmin(userID string) bool { return r.admins[userID] }
+
+// Allowed meldet, ob userID den Befehl ausführen darf.
+func (r *Router) Allowed(c *Command, userID string) bool { return !c.Admin || r.IsAdmin(userID) }
+
+// Match erkennt einen Befehl am Anfang der Nachricht (Groß-/Kleinschreibung
+// egal, optional mit "/" oder "!" davor, Satzzeichen am Ende ignoriert). Der
+// längste passende Name gewinnt ("meine statistik" vor "statistik"). Hat die
+// Nachricht mehr Wörter als der Befehl Argumente erlaubt, ist sie kein Befehl.
+func (r *Router) Match(msg string) (*Command, []string, bool) {
+	msg = strings.TrimSpace(msg)
+	msg = strings.TrimLeft(msg, "/!")
+	msg = strings.TrimRight(msg, "?! ")
+	// Schlusspunkt weg – außer er gehört zu einem Datum ("wer kommt 12.3.").
+	if rest, ok := strings.CutSuffix(msg, "."); ok && (rest == "" || !unicode.IsDigit(rune(rest[len(rest)-1]))) {
+		msg = rest
+	}
+	words := strings.Fields(msg)
+	for n := min(r.maxLen, len(words)); n >= 1; n-- {
+		cmd, ok := r.byWord[strings.ToLower(strings.Join(words[:n], " "))]
+		if !ok {
+			continue
+		}
+		args := words[n:]
+		if len(args) > cmd.MaxArgs {
+			return nil, nil, false
+		}
+		return cmd, args, true
+	}
+	return nil, nil, false
+}
+
+// Help baut die Befehlsübersicht für userID (Admin-Befehle nur für Admins).
+func (r *Router) Help(userID string) string {
+	cmds := append([]*Command(nil), r.cmds...)
+	sort.SliceStable(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+
+	var b strings.Builder
+	b.WriteString("🤖 *Befehle*\n")
+	for _, c := range cmds {
+		if !r.Allowed(c, userID) {
+			continue
+		}
+		b.WriteString("\n• *" + c.Name + "*")
+		if c.Usage != "" {
+			b.WriteString(" " + c.Usage)
+		}
+		if c.Admin {
+			b.WriteString(" 🔒")
+		}
+		b.WriteString(" – " + c.Help)
+		if len(c.Aliases) > 0 {
+			b.WriteString(" _(auch: " + strings.Join(c.Aliases, ", ") + ")_")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func testRouter() *Router {
+	noop := func(context.Context, Call) (Reply, error) { return Reply{}, nil }
+	r := NewRouter()
+	r.Register(Command{Name: "statistik", Help: "Rangliste", Run: noop})
+	r.Register(Command{Name: "meine statistik", Help: "eigene Zahlen", Run: noop})
+	r.Register(Command{Name: "wer kommt", Aliases: []string{"wer fehlt"}, Usage: "[datum]", MaxArgs: 1, Help: "Teilnehmer", Run: noop})
+	r.Register(Command{Name: "hilfe", Aliases: []string{"help"}, Help: "Übersicht", Run: noop})
+	r.Register(Command{Name: "strafe erlassen", Usage: "<name>", MaxArgs: 3, Admin: true, Help: "Strafe streichen", Run: noop})
+	return r
+}
+
+func TestMatch(t *testing.T) {
+	r := testRouter()
+	cases := []struct {
+		msg, want, args string
+	}{
+		{"statistik", "statistik", ""},
+		{"  Statistik ", "statistik", ""},
+		{"statistik!", "statistik", ""},
+		{"/statistik", "statistik", ""},
+		{"Meine   Statistik", "meine statistik", ""},
+		{"wer fehlt?", "wer kommt", ""},
+		{"wer kommt 12.3.", "wer kommt", "12.3."},
+		{"HELP", "hilfe", ""},
+		{"strafe erlassen Tobi", "strafe erlassen", "Tobi"},
+		// Zu viele Wörter → normale Nachricht (geht an den Classifier).
+		{"statistik ist mir egal, komme heute nicht", "", ""},
+		{"hilfe ich schaff es heute nicht", "", ""},
+		{"bin raus", "", ""},
+		{"", "", ""},
+		{"?", "", ""},
+	}
+	for _, c := range cases {
+		cmd, args, ok := r.Match(c.msg)
+		got := ""
+		if ok {
+			got = cmd.Name
+		}
+		if got != c.want || strings.Join(args, " ") != c.args {
+			t.Errorf("Match(%q) = %q %v, want %q %q", c.msg, got, args, c.want, c.args)
+		}
+	}
+}
+
+func TestHelpUndAdmins(t *testing.T) {
+	r := testRouter()
+	r.SetAdmins([]string{" admin@s.whatsapp.net ", ""})
+
+	h := r.Help("user@s.whatsapp.net")
+	if strings.Contains(h, "strafe erlassen") {
+		t.Errorf("Admin-Befehl für Nicht-Admin sichtbar:\n%s", h)
+	}
+	for _, want := range []string{"*statistik*", "*wer kommt* [datum]", "(auch: wer fehlt)", "*hilfe*"} {
+		if !strings.Contains(h, want) {
+			t.Errorf("hilfe ohne %q:\n%s", want, h)
+		}
+	}
+	if h := r.Help("admin@s.whatsapp.net"); !strings.Contains(h, "*strafe erlassen* <name> 🔒") {
+		t.Errorf("Admin sieht Admin-Befehl nicht:\n%s", h)
+	}
+
+	cmd, _, _ := r.Match("strafe erlassen Tobi")
+	if r.Allowed(cmd, "user@s.whatsapp.net") || !r.Allowed(cmd, "admin@s.whatsapp.net") {
+		t.Error("Berechtigung falsch")
+	}
+}
+
+func TestRegisterDoppeltPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("doppelter Alias ohne Panic")
+		}
+	}()
+	r := testRouter()
+	r.Register(Command{Name: "Wer  Fehlt"})
+}
@@ -3,7 +3,15 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/michael/zumba-shared/domain"
+	"github.com/michael/zumba-shared/penalty"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+	"github.com/michael/zumba-whatsapp-bot/internal/scheduler"
 )
 
 type Config struct {
@@ -11,7 +19,7 @@ type Config struct {
 
 	DB DBConfig
 
-	Gemini    GeminiConfig
+	LLM       LLMConfig
 	Evolution EvolutionConfig
 
 	// Output steuert, wohin erzeugte WhatsApp-Nachrichten gehen.
@@ -29,6 +37,26 @@ type Config struct {
 	// ML-Shadow-Modus (z.B. http://zumba-classifier:8080). Leer = Shadow aus.
 	ClassifierURL string
 
+	// MLFallback: Das eigene Modell entscheidet, wenn Gemini ausfällt
+	// (braucht ClassifierURL).
+	MLFallback MLFallbackConfig
+
+	// MLRoute: Canary-Routing zum eigenen Modell (braucht ClassifierURL).
+	MLRoute classifier.Policy
+
+	// CacheTTL: so lange gilt eine gecachte LLM-Klassifikation (Env
+	// CLASSIFIER_CACHE_TTL, Default 720h; 0 = Cache aus).
+	CacheTTL time.Duration
+
+	// Context: Gesprächskontext für Anschluss-Nachrichten ("ich auch").
+	Context ContextConfig
+
+	// Clarify: Rückfrage bei unklaren Nachrichten ("schau ma mal").
+	Clarify ClarifyConfig
+
+	// Reaction: Quittung per Emoji-Reaktion an verarbeiteten Ab-/Zusagen.
+	Reaction ReactionConfig
+
 	// RendererURL ist die Basis-URL des renderer-service, der die Statistik
 	// als PNG-Karte rendert (z.B. http://zumba-renderer:8080). Leer = Bild aus.
 	RendererURL string
@@ -38,7 +66,34 @@ type Config struct {
 	// bei Render-Fehlern Fallback auf Text).
 	StatsFormat string
 
-	// Location steuert die Donnerstag-Prüfung und das Tagesdatum für die DB-Writes.
+	// Schedule legt die Stammtisch-Tage fest (Env MEETING_SCHEDULE, z. B.
+	// "do", "mi/2@2026-01-07", "do;aug=di"; Default jeden Donnerstag).
+	Schedule domain.Schedule
+
+	// LeaderboardExcludeExcused nimmt entschuldigte Treffen (Admin-UI,
+	// Mitglieder-Seite) aus Statistik und Rangliste: Nenner und Absagen
+	// zählen sie dann nicht (Env LEADERBOARD_EXCLUDE_EXCUSED, Default false).
+	// Strafen lassen sie unabhängig davon immer aus.
+	LeaderboardExcludeExcused bool
+
+	// Admins sind die userIds (remoteJid/participant), die Admin-Befehle
+	// ausführen dürfen (Env BOT_ADMINS, kommagetrennt; leer = niemand).
+	Admins []string
+
+	// Workers ist die Anzahl paralleler Webhook-Worker (Env WEBHOOK_WORKERS,
+	// Default 4). Nachrichten desselben Chats laufen immer seriell.
+	Workers int
+
+	// WeeklyReport steuert den Wochenreport-Job des Schedulers.
+	WeeklyReport WeeklyReportConfig
+
+	// Reminder steuert die Erinnerung am Stammtisch-Tag (Scheduler-Job).
+	Reminder ReminderConfig
+
+	// Dunning steuert das Mahnwesen für offene Strafen (Scheduler-Job).
+	Dunning DunningConfig
+
+	// Location steuert die Treffen-Tag-Prüfung und das Tagesdatum für die DB-Writes.
 	Location *time.Location
 }
 
@@ -58,10 +113,54 @@ func (d DBConfig) DSN() string {
 	)
 }
 
-type GeminiConfig struct {
-	APIKey        string
-	Model         string // Primärmodell (n8n: "Gemine 2.5-flash", Index 0)
-	FallbackModel string // Fallback (n8n: "Gemini-3-flash-preview", Index 1)
+// LLMConfig: Primär- und Fallback-Modell des Classifiers, jedes bei einem
+// beliebigen Anbieter (n8n: "Gemine 2.5-flash" Index 0, "Gemini-3-flash-preview"
+// Index 1).
+type LLMConfig struct {
+	Primary  ProviderConfig
+	Fallback ProviderConfig // Provider "none" = kein Fallback
+}
+
+// ProviderConfig ist ein Modell bei einem Anbieter (classifier.Provider*).
+type ProviderConfig struct {
+	Provider string
+	Model    string
+	BaseURL  string // leer = Anbieter-Default (nur Gemini)
+	APIKey   string
+}
+
+func (p ProviderConfig) String() string { return p.Provider + ":" + p.Model }
+
+// loadProvider liest LLM_<prefix>PROVIDER, _MODEL, _BASE_URL, _API_KEY. Für
+// Gemini gelten die alten Variablen (GEMINI_API_KEY, geminiModelVar) als
+// Default.
+func loadProvider(prefix, defProvider, geminiModelVar, defGeminiModel string) (ProviderConfig, error) {
+	p := ProviderConfig{
+		Provider: getenv("LLM_"+prefix+"PROVIDER", defProvider),
+		Model:    os.Getenv("LLM_" + prefix + "MODEL"),
+		BaseURL:  os.Getenv("LLM_" + prefix + "BASE_URL"),
+		APIKey:   os.Getenv("LLM_" + prefix + "API_KEY"),
+	}
+	switch p.Provider {
+	case classifier.ProviderGemini:
+		if p.Model == "" {
+			p.Model = getenv(geminiModelVar, defGeminiModel)
+		}
+		if p.APIKey == "" {
+			p.APIKey = os.Getenv("GEMINI_API_KEY")
+		}
+	case classifier.ProviderOpenAI:
+		if p.BaseURL == "" || p.Model == "" {
+			return p, fmt.Errorf("LLM_%sPROVIDER=openai braucht LLM_%sBASE_URL und LLM_%sMODEL", prefix, prefix, prefix)
+		}
+	case classifier.ProviderNone:
+		if prefix == "" {
+			return p, fmt.Errorf("LLM_PROVIDER=none: ohne Primärmodell geht es nicht")
+		}
+	default:
+		return p, fmt.Errorf("LLM_%sPROVIDER %q: gemini, openai oder none erwartet", prefix, p.Provider)
+	}
+	return p, nil
 }
 
 type EvolutionConfig struct {
@@ -70,6 +169,64 @@ type EvolutionConfig struct {
 	Instance string
 }
 
+// MLFallbackConfig steuert den Fallback auf das eigene Modell.
+type MLFallbackConfig struct {
+	Enabled bool // Env ML_FALLBACK (Default true, wirkt nur mit CLASSIFIER_URL)
+	// Threshold: unter dieser Konfidenz wird das Modell-Ergebnis invalid
+	// (Env ML_FALLBACK_THRESHOLD, Default 0.8).
+	Threshold float64
+}
+
+// ContextConfig steuert den Gesprächskontext: ist eine Nachricht allein
+// invalid, klassifiziert das LLM sie mit den Nachrichten davor erneut.
+type ContextConfig struct {
+	Messages int           // Env CONTEXT_MESSAGES: so viele davor (Default 5, 0 = aus)
+	MaxAge   time.Duration // Env CONTEXT_MAX_AGE: nur so alte (Default 2h)
+}
+
+// ClarifyConfig steuert die Rückfrage: klingt eine "invalid"-Nachricht nach
+// Ab-/Zusage, fragt der Bot per Zitat nach und wertet Antwort bzw. Reaktion
+// aus.
+type ClarifyConfig struct {
+	Enabled bool          // Env CLARIFY_ENABLED (Default false)
+	TTL     time.Duration // Env CLARIFY_TTL: so lange gilt die Frage (Default 6h, höchstens bis zum Termin)
+}
+
+// ReactionConfig sind die Emojis, mit denen der Bot auf eine eingetragene
+// Ab-/Zusage reagiert ("none" = keine Reaktion).
+type ReactionConfig struct {
+	Absent  string // Env REACTION_ABSENT (Default ❌)
+	Present string // Env REACTION_PRESENT (Default ✅)
+	Error   string // Env REACTION_ERROR: Eintragen fehlgeschlagen (Default ⚠️)
+}
+
+// WeeklyReportConfig ist der automatische Wochenreport (früher ein k8s-CronJob,
+// jetzt ein Job im Bot-Scheduler).
+type WeeklyReportConfig struct {
+	Enabled bool   // Env WEEKLY_REPORT_ENABLED (Default false)
+	Cron    string // Env WEEKLY_REPORT_CRON, 5-Felder-Cron in TZ (Default "0 21 * * 4")
+	Format  string // Env WEEKLY_REPORT_FORMAT: text (Default) | image
+}
+
+// ReminderConfig ist die Erinnerung an Mitglieder ohne Rückmeldung. Der Job
+// läuft täglich zur angegebenen Zeit und tut nur an Stammtisch-Tagen etwas.
+type ReminderConfig struct {
+	Enabled bool   // Env REMINDER_ENABLED (Default false)
+	Cron    string // Env REMINDER_CRON, 5-Felder-Cron in TZ (Default "0 17 * * *")
+	Mode    string // Env REMINDER_MODE: group (Default, @-Erwähnungen) | dm
+}
+
+// DunningConfig ist das Mahnwesen: Wer eine offene Strafe länger als die
+// Stufen-Tage nicht begleicht, bekommt eine Direktnachricht, ab GroupLevel
+// zusätzlich eine Erwähnung in der Gruppe. Der Job läuft täglich.
+type DunningConfig struct {
+	Enabled    bool   // Env DUNNING_ENABLED (Default false)
+	Cron       string // Env DUNNING_CRON, 5-Felder-Cron in TZ (Default "0 18 * * *")
+	Days       []int  // Env DUNNING_DAYS, Tage je Stufe (Default "14,28,42")
+	GroupLevel int    // Env DUNNING_GROUP_LEVEL: ab dieser Stufe in der Gruppe (Default 3, 0 = nie)
+	Fee        int    // Env DUNNING_FEE: Mahngebühr in Euro auf der letzten Stufe (Default 0 = keine)
+}
+
 // OutputMode bestimmt das Ziel ausgehender WhatsApp-Nachrichten.
 type OutputMode string
 
@@ -90,6 +247,73 @@ func Load() (Config, error) {
 	if err != nil {
 		return Config{}, fmt.Errorf("TZ %q: %w", tz, err)
 	}
+	sched, err := domain.ParseSchedule(os.Getenv("MEETING_SCHEDULE"))
+	if err != nil {
+		return Config{}, fmt.Errorf("MEETING_SCHEDULE: %w", err)
+	}
+	workers, err := strconv.Atoi(getenv("WEBHOOK_WORKERS", "4"))
+	if err != nil || workers < 1 {
+		return Config{}, fmt.Errorf("WEBHOOK_WORKERS %q: positive Zahl erwartet", os.Getenv("WEBHOOK_WORKERS"))
+	}
+	primary, err := loadProvider("", classifier.ProviderGemini, "GEMINI_MODEL", "gemini-2.5-flash")
+	if err != nil {
+		return Config{}, err
+	}
+	// Ohne Angabe: Gemini bekommt wie bisher das zweite Gemini-Modell als
+	// Fallback, ein lokales Modell keinen (keine ungewollte Cloud-Abhängigkeit).
+	defFallback := classifier.ProviderNone
+	if primary.Provider == classifier.ProviderGemini {
+		defFallback = classifier.ProviderGemini
+	}
+	fallback, err := loadProvider("FALLBACK_", defFallback, "GEMINI_FALLBACK_MODEL", "gemini-3-flash-preview")
+	if err != nil {
+		return Config{}, err
+	}
+	if fallback == primary {
+		fallback.Provider = classifier.ProviderNone
+	}
+	llm := LLMConfig{Primary: primary, Fallback: fallback}
+
+	threshold, err := strconv.ParseFloat(getenv("ML_FALLBACK_THRESHOLD", "0.8"), 64)
+	if err != nil || threshold < 0 || threshold > 1 {
+		return Config{}, fmt.Errorf("ML_FALLBACK_THRESHOLD %q: Zahl zwischen 0 und 1 erwartet", os.Getenv("ML_FALLBACK_THRESHOLD"))
+	}
+	share, err := strconv.Atoi(getenv("ML_ROUTE_SHARE", "0"))
+	if err != nil || share < 0 || share > 100 {
+		return Config{}, fmt.Errorf("ML_ROUTE_SHARE %q: Prozent zwischen 0 und 100 erwartet", os.Getenv("ML_ROUTE_SHARE"))
+	}
+	minConf, err := strconv.ParseFloat(getenv("ML_ROUTE_MIN_CONFIDENCE", "0.9"), 64)
+	if err != nil || minConf < 0 || minConf > 1 {
+		return Config{}, fmt.Errorf("ML_ROUTE_MIN_CONFIDENCE %q: Zahl zwischen 0 und 1 erwartet", os.Getenv("ML_ROUTE_MIN_CONFIDENCE"))
+	}
+	cacheTTL, err := time.ParseDuration(getenv("CLASSIFIER_CACHE_TTL", "720h"))
+	if err != nil || cacheTTL < 0 {
+		return Config{}, fmt.Errorf("CLASSIFIER_CACHE_TTL %q: Dauer wie 720h erwartet", os.Getenv("CLASSIFIER_CACHE_TTL"))
+	}
+	ctxMessages, err := strconv.Atoi(getenv("CONTEXT_MESSAGES", "5"))
+	if err != nil || ctxMessages < 0 {
+		return Config{}, fmt.Errorf("CONTEXT_MESSAGES %q: Zahl ≥ 0 erwartet", os.Getenv("CONTEXT_MESSAGES"))
+	}
+	ctxMaxAge, err := time.ParseDuration(getenv("CONTEXT_MAX_AGE", "2h"))
+	if err != nil || ctxMaxAge <= 0 {
+		return Config{}, fmt.Errorf("CONTEXT_MAX_AGE %q: Dauer wie 2h erwartet", os.Getenv("CONTEXT_MAX_AGE"))
+	}
+	clarifyTTL, err := time.ParseDuration(getenv("CLARIFY_TTL", "6h"))
+	if err != nil || clarifyTTL <= 0 {
+		return Config{}, fmt.Errorf("CLARIFY_TTL %q: Dauer wie 6h erwartet", os.Getenv("CLARIFY_TTL"))
+	}
+	dunningDays, err := penalty.ParseMahnTage(getenv("DUNNING_DAYS", "14,28,42"))
+	if err != nil || len(dunningDays) == 0 {
+		return Config{}, fmt.Errorf("DUNNING_DAYS %q: Tage wie 14,28,42 erwartet", os.Getenv("DUNNING_DAYS"))
+	}
+	dunningGroup, err := strconv.Atoi(getenv("DUNNING_GROUP_LEVEL", "3"))
+	if err != nil || dunningGroup < 0 {
+		return Config{}, fmt.Errorf("DUNNING_GROUP_LEVEL %q: Zahl ≥ 0 erwartet", os.Getenv("DUNNING_GROUP_LEVEL"))
+	}
+	dunningFee, err := strconv.Atoi(getenv("DUNNING_FEE", "0"))
+	if err != nil || dunningFee < 0 {
+		return Config{}, fmt.Errorf("DUNNING_FEE %q: Euro-Betrag ≥ 0 erwartet", os.Getenv("DUNNING_FEE"))
+	}
 
 	cfg := Config{
 		Port: getenv("PORT", "8080"),
@@ -101,11 +325,7 @@ func Load() (Config, error) {
 			Name:     getenv("DB_NAME", "zumba"),
 			SSLMode:  getenv("DB_SSLMODE", "disable"),
 		},
-		Gemini: GeminiConfig{
-			APIKey:        os.Getenv("GEMINI_API_KEY"),
-			Model:         getenv("GEMINI_MODEL", "gemini-2.5-flash"),
-			FallbackModel: getenv("GEMINI_FALLBACK_MODEL", "gemini-3-flash-preview"),
-		},
+		LLM: llm,
 		Evolution: EvolutionConfig{
 			URL:      getenv("EVOLUTION_URL", "http://localhost:8090"),
 			APIKey:   os.Getenv("EVOLUTION_API_KEY"),
@@ -115,12 +335,49 @@ func Load() (Config, error) {
 			Mode: OutputMode(getenv("OUTPUT_MODE", string(OutputEvolution))),
 			File: getenv("OUTPUT_FILE", "output.txt"),
 		},
-		GroupJID:      getenv("ZUMBA_GROUP_JID", "000000000000-0000000000@g.us"),
-		PreviewJID:    os.Getenv("PREVIEW_JID"),
-		ClassifierURL: os.Getenv("CLASSIFIER_URL"),
-		RendererURL:   os.Getenv("RENDERER_URL"),
-		StatsFormat:   getenv("STATS_FORMAT", "text"),
-		Location:      loc,
+		GroupJID:                  getenv("ZUMBA_GROUP_JID", "000000000000-0000000000@g.us"),
+		PreviewJID:                os.Getenv("PREVIEW_JID"),
+		ClassifierURL:             os.Getenv("CLASSIFIER_URL"),
+		RendererURL:               os.Getenv("RENDERER_URL"),
+		StatsFormat:               getenv("STATS_FORMAT", "text"),
+		Schedule:                  sched,
+		LeaderboardExcludeExcused: getenv("LEADERBOARD_EXCLUDE_EXCUSED", "false") == "true",
+		Admins:                    splitList(os.Getenv("BOT_ADMINS")),
+		Workers:                   workers,
+		MLFallback: MLFallbackConfig{
+			Enabled:   getenv("ML_FALLBACK", "true") == "true",
+			Threshold: threshold,
+		},
+		MLRoute:  classifier.Policy{Share: share, MinConfidence: minConf},
+		CacheTTL: cacheTTL,
+		Context:  ContextConfig{Messages: ctxMessages, MaxAge: ctxMaxAge},
+		Clarify: ClarifyConfig{
+			Enabled: getenv("CLARIFY_ENABLED", "false") == "true",
+			TTL:     clarifyTTL,
+		},
+		Reaction: ReactionConfig{
+			Absent:  reaction("REACTION_ABSENT", "❌"),
+			Present: reaction("REACTION_PRESENT", "✅"),
+			Error:   reaction("REACTION_ERROR", "⚠\uFE0F"),
+		},
+		WeeklyReport: WeeklyReportConfig{
+			Enabled: getenv("WEEKLY_REPORT_ENABLED", "false") == "true",
+			Cron:    getenv("WEEKLY_REPORT_CRON", "0 21 * * 4"),
+			Format:  getenv("WEEKLY_REPORT_FORMAT", "text"),
+		},
+		Reminder: ReminderConfig{
+			Enabled: getenv("REMINDER_ENABLED", "false") == "true",
+			Cron:    getenv("REMINDER_CRON", "0 17 * * *"),
+			Mode:    getenv("REMINDER_MODE", "group"),
+		},
+		Dunning: DunningConfig{
+			Enabled:    getenv("DUNNING_ENABLED", "false") == "true",
+			Cron:       getenv("DUNNING_CRON", "0 18 * * *"),
+			Days:       dunningDays,
+			GroupLevel: dunningGroup,
+			Fee:        dunningFee,
+		},
+		Location: loc,
 	}
 
 	switch cfg.Output.Mode {
@@ -135,6 +392,26 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("STATS_FORMAT %q: erlaubt sind text|image", cfg.StatsFormat)
 	}
 
+	switch cfg.WeeklyReport.Format {
+	case "text", "image":
+	default:
+		return Config{}, fmt.Errorf("WEEKLY_REPORT_FORMAT %q: erlaubt sind text|image", cfg.WeeklyReport.Format)
+	}
+	if _, err := scheduler.ParseCron(cfg.WeeklyReport.Cron); err != nil {
+		return Config{}, fmt.Errorf("WEEKLY_REPORT_CRON: %w", err)
+	}
+	switch cfg.Reminder.Mode {
+	case "group", "dm":
+	default:
+		return Config{}, fmt.Errorf("REMINDER_MODE %q: erlaubt sind group|dm", cfg.Reminder.Mode)
+	}
+	if _, err := scheduler.ParseCron(cfg.Reminder.Cron); err != nil {
+		return Config{}, fmt.Errorf("REMINDER_CRON: %w", err)
+	}
+	if _, err := scheduler.ParseCron(cfg.Dunning.Cron); err != nil {
+		return Config{}, fmt.Errorf("DUNNING_CRON: %w", err)
+	}
+
 	return cfg, nil
 }
 
@@ -144,3 +421,23 @@ func getenv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// splitList zerlegt eine kommagetrennte Env-Liste (Leereinträge entfallen).
+func splitList(v string) []string {
+	var out []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// reaction liest ein Reaktions-Emoji; "none" schaltet die Reaktion ab.
+func reaction(key, fallback string) string {
+	v := strings.TrimSpace(getenv(key, fallback))
+	if v == "none" {
+		return ""
+	}
+	return v
+}
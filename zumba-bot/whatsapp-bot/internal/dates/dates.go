@@ -0,0 +1,315 @@
+// Package dates zieht aus einer deutschen Chat-Nachricht die Stammtisch-Termine,
+// auf die sich eine Ab- oder Zusage bezieht: "nächste Woche", "am 12.3.",
+// "in zwei Wochen", "bin vom 3. bis 24. im Urlaub", "im August" …
+//
+// Bewusst regelbasiert statt LLM: die Klassifikation (Absage/Zusage) macht
+// weiterhin Gemini, hier wird nur das Ziel bestimmt – deterministisch und
+// testbar. Alle Ergebnisse sind Treffen-Tage des Schedules ab heute.
+package dates
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/michael/zumba-shared/domain"
+)
+
+// HorizonDays begrenzt, wie weit im Voraus Termine aufgelöst werden (ein
+// vertippter Zeitraum soll nicht das halbe Jahr sperren).
+const HorizonDays = 183
+
+// Resolution ist das Ergebnis der Auflösung.
+type Resolution struct {
+	// Dates sind die Ziel-Termine (aufsteigend, ohne Sperrtage).
+	Dates []time.Time
+	// Matched sind die erkannten Zeitausdrücke (leer = kein Ausdruck, Default
+	// "nächster Termin" wurde genommen).
+	Matched []string
+	// Skipped sind Termine, die wegen Sperrtag entfallen sind.
+	Skipped []time.Time
+}
+
+// Explicit meldet, ob die Nachricht einen Zeitausdruck enthielt.
+func (r Resolution) Explicit() bool { return len(r.Matched) > 0 }
+
+// Describe fasst die Auflösung für den Trace zusammen.
+func (r Resolution) Describe() string {
+	var b strings.Builder
+	if r.Explicit() {
+		fmt.Fprintf(&b, "%q", strings.Join(r.Matched, `", "`))
+	} else {
+		b.WriteString("kein Zeitausdruck → nächster Termin")
+	}
+	b.WriteString(" → ")
+	if len(r.Dates) == 0 {
+		b.WriteString("kein Stammtisch-Termin")
+	} else {
+		b.WriteString(joinDates(r.Dates))
+	}
+	if len(r.Skipped) > 0 {
+		b.WriteString(" (Sperrtag: " + joinDates(r.Skipped) + ")")
+	}
+	return b.String()
+}
+
+func joinDates(ds []time.Time) string {
+	parts := make([]string, len(ds))
+	for i, d := range ds {
+		parts[i] = d.Format("02.01.")
+	}
+	return strings.Join(parts, ", ")
+}
+
+var (
+	numWord = `(\d{1,2}|eine[nmr]?|zwei|drei|vier|fünf|sechs|sieben|acht|neun|zehn|elf|zwölf)`
+	// wordStart ersetzt \b am Wortanfang (\b kennt nur ASCII, "übernächste"
+	// würde sonst nicht erkannt).
+	wordStart = `(?:^|[^\p{L}\d])`
+	dateTok   = `(\d{1,2})\.(?:(\d{1,2})\.(\d{2}(?:\d{2})?)?)?`
+
+	reRange      = regexp.MustCompile(`(?:vom|von|ab)?\s*` + dateTok + `\s*(?:bis(?:\s+zum|\s+einschließlich)?|-|–)\s*` + dateTok)
+	reUntil      = regexp.MustCompile(wordStart + `bis\s+(?:zum\s+|einschließlich\s+)?` + dateTok)
+	reDate       = regexp.MustCompile(wordStart + `(\d{1,2})\.(\d{1,2})\.(\d{2}(?:\d{2})?)?`)
+	reDayOnly    = regexp.MustCompile(wordStart + `(?:am|den)\s+(\d{1,2})\.(?:\s|$)`)
+	reNextWeeks  = regexp.MustCompile(`(?:die\s+)?(?:nächsten|kommenden)\s+` + numWord + `\s+wochen`)
+	reNextTimes  = regexp.MustCompile(`(?:die\s+)?(?:nächsten|kommenden)\s+` + numWord + `\s+(?:donnerstage|termine|stammtische|male|mal)`)
+	reInWeeks    = regexp.MustCompile(wordStart + `in\s+` + numWord + `\s+wochen?`)
+	reWeek       = regexp.MustCompile(wordStart + `(diese|nächste|übernächste|kommende)\s+woche`)
+	reWeekday    = regexp.MustCompile(wordStart + `(?:(nächsten|kommenden|übernächsten)\s+)?(montag|dienstag|mittwoch|donnerstag|freitag|samstag|sonntag)\b`)
+	reMonth      = regexp.MustCompile(wordStart + `(?:im|ganzen|den ganzen)\s+(januar|februar|märz|april|mai|juni|juli|august|september|oktober|november|dezember)\b`)
+	reRelDay     = regexp.MustCompile(wordStart + `(heute|übermorgen|morgen)\b`)
+	weekdayNames = map[string]time.Weekday{
+		"montag": time.Monday, "dienstag": time.Tuesday, "mittwoch": time.Wednesday,
+		"donnerstag": time.Thursday, "freitag": time.Friday, "samstag": time.Saturday, "sonntag": time.Sunday,
+	}
+	monthNames = map[string]time.Month{
+		"januar": time.January, "februar": time.February, "märz": time.March, "april": time.April,
+		"mai": time.May, "juni": time.June, "juli": time.July, "august": time.August,
+		"september": time.September, "oktober": time.October, "november": time.November, "dezember": time.December,
+	}
+	numbers = map[string]int{
+		"eine": 1, "einen": 1, "einem": 1, "einer": 1, "zwei": 2, "drei": 3, "vier": 4, "fünf": 5,
+		"sechs": 6, "sieben": 7, "acht": 8, "neun": 9, "zehn": 10, "elf": 11, "zwölf": 12,
+	}
+)
+
+// Resolve löst die Ziel-Termine der Nachricht text relativ zum Tag today
+// auf. excluded sind Sperrtage (ISO-Datum → true), die übersprungen werden.
+// Enthält die Nachricht keinen Zeitausdruck, gilt der nächste reguläre Termin
+// ab heute (heute selbst, wenn heute Stammtisch ist).
+func Resolve(text string, today time.Time, sched domain.Schedule, excluded map[string]bool) Resolution {
+	today = dateOnly(today)
+	sched = sched.OrDefault()
+	p := parser{text: strings.ToLower(text), today: today, sched: sched, days: map[string]time.Time{}}
+	p.parse()
+
+	var res Resolution
+	res.Matched = p.matched
+	horizon := today.AddDate(0, 0, HorizonDays)
+	if !res.Explicit() {
+		d := sched.OnOrAfter(today)
+		for excluded[iso(d)] && d.Before(horizon) {
+			res.Skipped = append(res.Skipped, d)
+			d = sched.Next(d)
+		}
+		res.Dates = []time.Time{d}
+		return res
+	}
+	for _, d := range p.days {
+		if d.Before(today) || d.After(horizon) || !sched.IsMeeting(d) {
+			continue
+		}
+		if excluded[iso(d)] {
+			res.Skipped = append(res.Skipped, d)
+			continue
+		}
+		res.Dates = append(res.Dates, d)
+	}
+	sort.Slice(res.Dates, func(i, j int) bool { return res.Dates[i].Before(res.Dates[j]) })
+	sort.Slice(res.Skipped, func(i, j int) bool { return res.Skipped[i].Before(res.Skipped[j]) })
+	return res
+}
+
+type parser struct {
+	text    string
+	today   time.Time
+	sched   domain.Schedule
+	days    map[string]time.Time
+	matched []string
+}
+
+func (p *parser) parse() {
+	// Reihenfolge zählt: erkannte Ausdrücke werden aus dem Text entfernt,
+	// damit z. B. das "24.8." eines Zeitraums nicht zusätzlich als Einzeldatum
+	// gilt.
+	p.each(reRange, func(m []string) {
+		end, ok := p.date(m[4], m[5], m[6], p.today)
+		if !ok {
+			return
+		}
+		startMonth, startYear := m[2], m[3]
+		if startMonth == "" {
+			startMonth, startYear = strconv.Itoa(int(end.Month())), strconv.Itoa(end.Year())
+		}
+		start, ok := p.date(m[1], startMonth, startYear, time.Time{})
+		if !ok {
+			return
+		}
+		if start.After(end) {
+			start = start.AddDate(0, -1, 0)
+		}
+		p.addRange(start, end)
+	})
+	p.each(reUntil, func(m []string) {
+		if end, ok := p.date(m[1], m[2], m[3], p.today); ok {
+			p.addRange(p.today, end)
+		}
+	})
+	p.each(reDate, func(m []string) {
+		if d, ok := p.date(m[1], m[2], m[3], p.today); ok {
+			p.add(d)
+		}
+	})
+	p.each(reDayOnly, func(m []string) {
+		if d, ok := p.date(m[1], "", "", p.today); ok {
+			p.add(d)
+		}
+	})
+	p.each(reNextWeeks, func(m []string) {
+		p.addRange(p.today, p.today.AddDate(0, 0, 7*number(m[1])-1))
+	})
+	p.each(reNextTimes, func(m []string) {
+		d := p.sched.OnOrAfter(p.today)
+		for i := 0; i < number(m[1]); i++ {
+			p.add(d)
+			d = p.sched.Next(d)
+		}
+	})
+	p.each(reInWeeks, func(m []string) {
+		p.addWeek(number(m[1]))
+	})
+	p.each(reWeek, func(m []string) {
+		switch m[1] {
+		case "diese":
+			p.addWeek(0)
+		case "übernächste":
+			p.addWeek(2)
+		default:
+			p.addWeek(1)
+		}
+	})
+	p.each(reMonth, func(m []string) {
+		month := monthNames[m[1]]
+		year := p.today.Year()
+		if month < p.today.Month() {
+			year++
+		}
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		p.addRange(first, first.AddDate(0, 1, -1))
+	})
+	p.each(reWeekday, func(m []string) {
+		wd := weekdayNames[m[2]]
+		d := p.today
+		for d.Weekday() != wd {
+			d = d.AddDate(0, 0, 1)
+		}
+		switch m[1] {
+		case "nächsten", "kommenden":
+			if d.Equal(p.today) {
+				d = d.AddDate(0, 0, 7)
+			}
+		case "übernächsten":
+			d = d.AddDate(0, 0, 7)
+		}
+		p.add(d)
+	})
+	p.each(reRelDay, func(m []string) {
+		switch m[1] {
+		case "heute":
+			p.add(p.today)
+		case "morgen":
+			p.add(p.today.AddDate(0, 0, 1))
+		case "übermorgen":
+			p.add(p.today.AddDate(0, 0, 2))
+		}
+	})
+}
+
+// each ruft fn für jeden Treffer auf und entfernt ihn aus dem Text.
+func (p *parser) each(re *regexp.Regexp, fn func(m []string)) {
+	for _, m := range re.FindAllStringSubmatch(p.text, -1) {
+		p.matched = append(p.matched, strings.TrimSpace(m[0]))
+		fn(m)
+	}
+	p.text = re.ReplaceAllString(p.text, " ")
+}
+
+func (p *parser) add(d time.Time) { p.days[iso(d)] = d }
+
+func (p *parser) addRange(start, end time.Time) {
+	if end.Sub(start) > HorizonDays*24*time.Hour {
+		end = start.AddDate(0, 0, HorizonDays)
+	}
+	for _, d := range p.sched.Meetings(start, end) {
+		p.add(d)
+	}
+}
+
+// addWeek fügt die Termine der Kalenderwoche (Mo–So) heute + n Wochen hinzu.
+func (p *parser) addWeek(n int) {
+	monday := p.today.AddDate(0, 0, -((int(p.today.Weekday())+6)%7)+7*n)
+	p.addRange(monday, monday.AddDate(0, 0, 6))
+}
+
+// date baut ein Datum aus Tag/Monat/Jahr-Strings. Fehlende Monate/Jahre
+// werden so ergänzt, dass das Datum nicht vor notBefore liegt (nächstes
+// Vorkommen); notBefore.IsZero() = keine Verschiebung.
+func (p *parser) date(dayS, monthS, yearS string, notBefore time.Time) (time.Time, bool) {
+	day, err := strconv.Atoi(dayS)
+	if err != nil || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	month, year := int(p.today.Month()), p.today.Year()
+	if monthS != "" {
+		if month, err = strconv.Atoi(monthS); err != nil || month < 1 || month > 12 {
+			return time.Time{}, false
+		}
+	}
+	if yearS != "" {
+		if year, err = strconv.Atoi(yearS); err != nil {
+			return time.Time{}, false
+		}
+		if year < 100 {
+			year += 2000
+		}
+	}
+	d := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if d.Day() != day {
+		return time.Time{}, false // 31.2. o. Ä.
+	}
+	if !notBefore.IsZero() && d.Before(notBefore) && yearS == "" {
+		if monthS == "" {
+			d = d.AddDate(0, 1, 0)
+		} else {
+			d = d.AddDate(1, 0, 0)
+		}
+	}
+	return d, true
+}
+
+func number(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	return numbers[s]
+}
+
+func iso(t time.Time) string { return t.Format("2006-01-02") }
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
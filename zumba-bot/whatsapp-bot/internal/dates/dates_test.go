@@ -0,0 +1,102 @@
+package dates
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael/zumba-shared/domain"
+)
+
+// Montag, 02.03.2026 – Donnerstage: 05.03., 12.03., 19.03., 26.03., 02.04. …
+var montag = time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+func isoList(ds []time.Time) string {
+	parts := make([]string, len(ds))
+	for i, d := range ds {
+		parts[i] = d.Format("2006-01-02")
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestResolve(t *testing.T) {
+	cases := []struct {
+		msg  string
+		want string
+	}{
+		{"bin raus", "2026-03-05"},
+		{"diese woche klappt nicht", "2026-03-05"},
+		{"nächste Woche bin ich nicht da", "2026-03-12"},
+		{"übernächste Woche bin ich raus", "2026-03-19"},
+		{"in zwei Wochen kann ich nicht", "2026-03-19"},
+		{"in einer Woche kann ich nicht", "2026-03-12"},
+		{"am 26.3. bin ich weg", "2026-03-26"},
+		{"am 26.03.2026 nicht dabei", "2026-03-26"},
+		{"bin vom 10. bis 24. im Urlaub", "2026-03-12,2026-03-19"},
+		{"bin 10.-27.3. im Urlaub", "2026-03-12,2026-03-19,2026-03-26"},
+		{"bis 20.3. krank", "2026-03-05,2026-03-12,2026-03-19"},
+		{"die nächsten 3 Wochen nicht", "2026-03-05,2026-03-12,2026-03-19"},
+		{"die nächsten zwei Donnerstage fallen aus", "2026-03-05,2026-03-12"},
+		{"Donnerstag nicht", "2026-03-05"},
+		{"am Freitag bin ich weg", ""},
+		{"übermorgen nicht", ""},
+		{"im April bin ich auf Reisen", "2026-04-02,2026-04-09,2026-04-16,2026-04-23,2026-04-30"},
+	}
+	for _, c := range cases {
+		got := Resolve(c.msg, montag, domain.Schedule{}, nil)
+		if isoList(got.Dates) != c.want {
+			t.Errorf("%q: got %q (%s), want %q", c.msg, isoList(got.Dates), got.Describe(), c.want)
+		}
+	}
+}
+
+func TestResolveVergangenesDatumNaechstesJahr(t *testing.T) {
+	got := Resolve("am 26.2. nicht", montag, domain.Schedule{}, nil)
+	if isoList(got.Dates) != "2027-02-25" && len(got.Dates) != 0 {
+		t.Errorf("got %s", isoList(got.Dates))
+	}
+	// 26.02.2027 ist ein Freitag → kein Termin, aber nie die Vergangenheit.
+	for _, d := range got.Dates {
+		if d.Before(montag) {
+			t.Errorf("Vergangenheit aufgelöst: %s", d)
+		}
+	}
+}
+
+func TestResolveSperrtage(t *testing.T) {
+	excluded := map[string]bool{"2026-03-05": true, "2026-03-19": true}
+
+	got := Resolve("bin raus", montag, domain.Schedule{}, excluded)
+	if isoList(got.Dates) != "2026-03-12" || isoList(got.Skipped) != "2026-03-05" {
+		t.Errorf("Default: dates=%s skipped=%s", isoList(got.Dates), isoList(got.Skipped))
+	}
+
+	got = Resolve("die nächsten 3 wochen nicht", montag, domain.Schedule{}, excluded)
+	if isoList(got.Dates) != "2026-03-12" || isoList(got.Skipped) != "2026-03-05,2026-03-19" {
+		t.Errorf("Zeitraum: dates=%s skipped=%s", isoList(got.Dates), isoList(got.Skipped))
+	}
+}
+
+func TestResolveEigenerSchedule(t *testing.T) {
+	s, err := domain.ParseSchedule("di")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Resolve("nächste woche nicht", montag, s, nil)
+	if isoList(got.Dates) != "2026-03-10" {
+		t.Errorf("got %s", isoList(got.Dates))
+	}
+	// Am Stammtisch-Tag selbst gilt ohne Zeitausdruck "heute".
+	got = Resolve("heute nicht", montag.AddDate(0, 0, 1), s, nil)
+	if isoList(got.Dates) != "2026-03-03" {
+		t.Errorf("heute: got %s", isoList(got.Dates))
+	}
+}
+
+func TestResolveHorizont(t *testing.T) {
+	got := Resolve("bis 1.1.2030 weg", montag, domain.Schedule{}, nil)
+	last := got.Dates[len(got.Dates)-1]
+	if last.After(montag.AddDate(0, 0, HorizonDays)) {
+		t.Errorf("Horizont überschritten: %s", last)
+	}
+}
@@ -30,20 +30,97 @@ func NewClient(baseURL, apiKey, instance string) *Client {
 }
 
 type sendTextRequest struct {
-	Number string `json:"number"`
-	Text   string `json:"text"`
+	Number    string   `json:"number"`
+	Text      string   `json:"text"`
+	Mentioned []string `json:"mentioned,omitempty"`
+	Quoted    *quoted  `json:"quoted,omitempty"`
+}
+
+// quoted ist die zitierte Nachricht einer Antwort (Evolution braucht Key und
+// Text, sonst zeigt WhatsApp ein leeres Zitat).
+type quoted struct {
+	Key struct {
+		ID string `json:"id"`
+	} `json:"key"`
+	Message struct {
+		Conversation string `json:"conversation"`
+	} `json:"message"`
 }
 
 // SendText: POST {baseURL}/message/sendText/{instance} mit Header apikey,
 // Body {number, text}.
 func (c *Client) SendText(ctx context.Context, number, text string) error {
-	buf, err := json.Marshal(sendTextRequest{Number: number, Text: text})
+	_, err := c.sendText(ctx, sendTextRequest{Number: number, Text: text})
+	return err
+}
+
+// SendTextMentions schickt text mit @-Erwähnungen: mentioned sind Nummern
+// ohne "@s.whatsapp.net", im Text stehen sie als "@<nummer>".
+func (c *Client) SendTextMentions(ctx context.Context, number, text string, mentioned []string) error {
+	_, err := c.sendText(ctx, sendTextRequest{Number: number, Text: text, Mentioned: mentioned})
+	return err
+}
+
+// SendTextQuoted antwortet auf die Nachricht q (Zitat) und liefert die
+// Message-ID der gesendeten Nachricht – Antworten und Reaktionen darauf
+// zeigen auf diese ID.
+func (c *Client) SendTextQuoted(ctx context.Context, number, text string, q Quote) (string, error) {
+	body := sendTextRequest{Number: number, Text: text, Quoted: &quoted{}}
+	body.Quoted.Key.ID = q.MessageID
+	body.Quoted.Message.Conversation = q.Text
+	return c.sendText(ctx, body)
+}
+
+// sendText liefert die Message-ID aus der Antwort von Evolution ("" =
+// nicht mitgeliefert).
+func (c *Client) sendText(ctx context.Context, body sendTextRequest) (string, error) {
+	buf, err := json.Marshal(body)
 	if err != nil {
-		return fmt.Errorf("marshal: %w", err)
+		return "", fmt.Errorf("marshal: %w", err)
 	}
 
 	url := fmt.Sprintf("%s/message/sendText/%s", c.baseURL, c.instance)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apikey", c.apiKey)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sendText: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("sendText: status %d: %s", resp.StatusCode, string(body))
+	}
+	var sent struct {
+		Key struct {
+			ID string `json:"id"`
+		} `json:"key"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&sent) // ältere Versionen antworten ohne Key
+	return sent.Key.ID, nil
+}
+
+type sendReactionRequest struct {
+	Key      MessageKey `json:"key"`
+	Reaction string     `json:"reaction"`
+}
+
+// SendReaction: POST {baseURL}/message/sendReaction/{instance} – reagiert mit
+// emoji auf die Nachricht key (eine spätere Reaktion ersetzt die frühere).
+func (c *Client) SendReaction(ctx context.Context, key MessageKey, emoji string) error {
+	buf, err := json.Marshal(sendReactionRequest{Key: key, Reaction: emoji})
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/message/sendReaction/%s", c.baseURL, c.instance)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
 	if err != nil {
 		return fmt.Errorf("new request: %w", err)
 	}
@@ -52,13 +129,13 @@ func (c *Client) SendText(ctx context.Context, number, text string) error {
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return fmt.Errorf("sendText: %w", err)
+		return fmt.Errorf("sendReaction: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("sendText: status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("sendReaction: status %d: %s", resp.StatusCode, string(body))
 	}
 	return nil
 }
@@ -1,5 +1,12 @@
 package evolution
 
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // WebhookEvent ist der rohe Body, den die Evolution API per Webhook an den Bot
 // schickt (messages.upsert). Felder spiegeln die im n8n-Workflow genutzten Pfade
 // – ohne das n8n-eigene "body."-Prefix, da Go den Body direkt empfängt.
@@ -8,18 +15,161 @@ type WebhookEvent struct {
 	Data   struct {
 		MessageType string `json:"messageType"`
 		PushName    string `json:"pushName"`
-		Key         struct {
+		// MessageTimestamp ist der Sendezeitpunkt (Unix-Sekunden; Baileys
+		// liefert je nach Version Zahl oder String).
+		MessageTimestamp Timestamp `json:"messageTimestamp"`
+		Key              struct {
 			RemoteJid      string `json:"remoteJid"`
 			FromMe         bool   `json:"fromMe"`
+			ID             string `json:"id"` // WhatsApp-Message-ID
 			Participant    string `json:"participant"`
 			ParticipantAlt string `json:"participantAlt"`
 		} `json:"key"`
 		Message struct {
 			Conversation string `json:"conversation"`
+
+			// Antworten/Zitate kommen als extendedTextMessage; contextInfo
+			// trägt die zitierte Nachricht.
+			ExtendedTextMessage *struct {
+				Text        string       `json:"text"`
+				ContextInfo *ContextInfo `json:"contextInfo"`
+			} `json:"extendedTextMessage"`
+
+			// Reaktionen (👍 auf eine Nachricht) kommen als reactionMessage;
+			// key zeigt auf die Nachricht, text ist das Emoji ("" = entfernt).
+			ReactionMessage *struct {
+				Key struct {
+					ID string `json:"id"`
+				} `json:"key"`
+				Text string `json:"text"`
+			} `json:"reactionMessage"`
+
+			// Bearbeiten/Löschen kommt als protocolMessage, die per key.id auf
+			// die Original-Nachricht zeigt – je nach Evolution-Version direkt
+			// oder in editedMessage.message verpackt.
+			ProtocolMessage *ProtocolMessage `json:"protocolMessage"`
+			EditedMessage   *struct {
+				Message struct {
+					ProtocolMessage *ProtocolMessage `json:"protocolMessage"`
+				} `json:"message"`
+			} `json:"editedMessage"`
 		} `json:"message"`
 	} `json:"data"`
 }
 
+// ContextInfo verweist bei einer Antwort auf die zitierte Nachricht.
+type ContextInfo struct {
+	StanzaID      string   `json:"stanzaId"`     // Message-ID der zitierten Nachricht
+	Participant   string   `json:"participant"`  // ihr Absender
+	MentionedJid  []string `json:"mentionedJid"` // @-Erwähnungen im Text
+	QuotedMessage *struct {
+		Conversation        string `json:"conversation"`
+		ExtendedTextMessage struct {
+			Text string `json:"text"`
+		} `json:"extendedTextMessage"`
+	} `json:"quotedMessage"`
+}
+
+// Timestamp ist ein Unix-Zeitpunkt in Sekunden, als Zahl oder String.
+type Timestamp int64
+
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	n, err := strconv.ParseInt(strings.Trim(string(b), `"`), 10, 64)
+	if err != nil {
+		*t = 0 // unbekanntes Format: wie nicht gesetzt
+		return nil
+	}
+	*t = Timestamp(n)
+	return nil
+}
+
+// ProtocolMessage ist die Baileys-Hülle für Bearbeiten (MESSAGE_EDIT) und
+// Löschen für alle (REVOKE).
+type ProtocolMessage struct {
+	Key struct {
+		ID string `json:"id"`
+	} `json:"key"`
+	Type          ProtocolType `json:"type"`
+	EditedMessage *struct {
+		Conversation        string `json:"conversation"`
+		ExtendedTextMessage struct {
+			Text string `json:"text"`
+		} `json:"extendedTextMessage"`
+	} `json:"editedMessage"`
+}
+
+// ProtocolType ist der protocolMessage-Typ. Baileys serialisiert das Enum je
+// nach Version als Namen ("REVOKE") oder als Zahl (0).
+type ProtocolType string
+
+const (
+	ProtocolRevoke ProtocolType = "REVOKE"
+	ProtocolEdit   ProtocolType = "MESSAGE_EDIT"
+)
+
+func (t *ProtocolType) UnmarshalJSON(b []byte) error {
+	var n int
+	if err := json.Unmarshal(b, &n); err == nil {
+		switch n {
+		case 0:
+			*t = ProtocolRevoke
+		case 14:
+			*t = ProtocolEdit
+		default:
+			*t = ProtocolType(strings.TrimSpace(string(b)))
+		}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*t = ProtocolType(strings.ToUpper(s))
+	return nil
+}
+
+// Kind unterscheidet normale Nachrichten von Bearbeitungen und Löschungen.
+type Kind int
+
+const (
+	KindMessage Kind = iota
+	KindEdit
+	KindRevoke
+)
+
+func (e WebhookEvent) protocol() *ProtocolMessage {
+	if pm := e.Data.Message.ProtocolMessage; pm != nil {
+		return pm
+	}
+	if em := e.Data.Message.EditedMessage; em != nil {
+		return em.Message.ProtocolMessage
+	}
+	return nil
+}
+
+// Kind liefert die Art des Events (Bearbeitung/Löschung nur mit Ziel-ID).
+func (e WebhookEvent) Kind() Kind {
+	pm := e.protocol()
+	if pm == nil || pm.Key.ID == "" {
+		return KindMessage
+	}
+	switch pm.Type {
+	case ProtocolEdit:
+		return KindEdit
+	case ProtocolRevoke:
+		return KindRevoke
+	}
+	return KindMessage
+}
+
+// TargetID ist bei Bearbeitung/Löschung die Message-ID der Original-Nachricht.
+func (e WebhookEvent) TargetID() string {
+	if e.Kind() == KindMessage {
+		return ""
+	}
+	return e.protocol().Key.ID
+}
+
 // UserID bildet die n8n-"Edit Fields"-Logik ab:
 // fromMe ? sender : data.key.participantAlt
 func (e WebhookEvent) UserID() string {
@@ -29,7 +179,113 @@ func (e WebhookEvent) UserID() string {
 	return e.Data.Key.ParticipantAlt
 }
 
+// Message liefert den Text; bei Bearbeitungen den neuen Text, bei
+// Reaktionen das Emoji.
+func (e WebhookEvent) Message() string {
+	if r := e.Reaction(); r != nil {
+		return r.Emoji
+	}
+	if e.Kind() == KindEdit {
+		if em := e.protocol().EditedMessage; em != nil {
+			if em.Conversation != "" {
+				return em.Conversation
+			}
+			return em.ExtendedTextMessage.Text
+		}
+		return ""
+	}
+	if e.Data.Message.Conversation == "" && e.Data.Message.ExtendedTextMessage != nil {
+		return e.Data.Message.ExtendedTextMessage.Text
+	}
+	return e.Data.Message.Conversation
+}
+
+// Quote ist die Nachricht, auf die geantwortet wurde.
+type Quote struct {
+	MessageID   string
+	Participant string
+	Text        string
+}
+
+// Quoted liefert die zitierte Nachricht einer Antwort (nil = keine Antwort
+// bzw. zitierte Nachricht ohne Text).
+func (e WebhookEvent) Quoted() *Quote {
+	et := e.Data.Message.ExtendedTextMessage
+	if e.Kind() != KindMessage || et == nil || et.ContextInfo == nil || et.ContextInfo.QuotedMessage == nil {
+		return nil
+	}
+	ci := et.ContextInfo
+	text := ci.QuotedMessage.Conversation
+	if text == "" {
+		text = ci.QuotedMessage.ExtendedTextMessage.Text
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return &Quote{MessageID: ci.StanzaID, Participant: ci.Participant, Text: text}
+}
+
+// QuotedID ist die Message-ID der Nachricht, auf die geantwortet wurde –
+// auch wenn sie keinen Text hat ("" = keine Antwort).
+func (e WebhookEvent) QuotedID() string {
+	et := e.Data.Message.ExtendedTextMessage
+	if e.Kind() != KindMessage || et == nil || et.ContextInfo == nil {
+		return ""
+	}
+	return et.ContextInfo.StanzaID
+}
+
+// Reaction ist eine Emoji-Reaktion auf eine Nachricht.
+type Reaction struct {
+	TargetID string // Message-ID der Nachricht, auf die reagiert wurde
+	Emoji    string
+}
+
+// Reaction liefert die Reaktion (nil = keine Reaktion bzw. entfernte
+// Reaktion).
+func (e WebhookEvent) Reaction() *Reaction {
+	rm := e.Data.Message.ReactionMessage
+	if rm == nil || rm.Key.ID == "" || rm.Text == "" {
+		return nil
+	}
+	return &Reaction{TargetID: rm.Key.ID, Emoji: rm.Text}
+}
+
+// Mentioned liefert die @-erwähnten JIDs einer neuen Nachricht.
+func (e WebhookEvent) Mentioned() []string {
+	et := e.Data.Message.ExtendedTextMessage
+	if e.Kind() != KindMessage || et == nil || et.ContextInfo == nil {
+		return nil
+	}
+	return et.ContextInfo.MentionedJid
+}
+
+// SentAt ist der Sendezeitpunkt laut WhatsApp (Nullwert = nicht mitgeliefert).
+func (e WebhookEvent) SentAt() time.Time {
+	if e.Data.MessageTimestamp <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(e.Data.MessageTimestamp), 0)
+}
+
+// MessageKey adressiert eine WhatsApp-Nachricht (Ziel einer Reaktion).
+type MessageKey struct {
+	RemoteJid   string `json:"remoteJid"`
+	FromMe      bool   `json:"fromMe"`
+	ID          string `json:"id"`
+	Participant string `json:"participant,omitempty"` // Absender in Gruppen
+}
+
+// Key ist der Schlüssel der Nachricht selbst.
+func (e WebhookEvent) Key() MessageKey {
+	k := e.Data.Key
+	return MessageKey{RemoteJid: k.RemoteJid, FromMe: k.FromMe, ID: k.ID, Participant: k.Participant}
+}
+
+// FromMe: die Nachricht kam von der Bot-Nummer selbst.
+func (e WebhookEvent) FromMe() bool { return e.Data.Key.FromMe }
+
 func (e WebhookEvent) UserName() string    { return e.Data.PushName }
-func (e WebhookEvent) Message() string     { return e.Data.Message.Conversation }
+func (e WebhookEvent) MessageID() string   { return e.Data.Key.ID }
 func (e WebhookEvent) RemoteJid() string   { return e.Data.Key.RemoteJid }
 func (e WebhookEvent) MessageType() string { return e.Data.MessageType }
@@ -0,0 +1,127 @@
+// Package inbox ist das dauerhafte Eingangsbuch des Webhooks: jedes
+// Evolution-Event wird per WhatsApp-Message-ID in bot_inbox festgehalten,
+// bevor es verarbeitet wird. Evolution wiederholt Webhooks – ein Duplikat
+// findet seine ID schon vor und wird nur quittiert. Fehlgeschlagene Events
+// bleiben als "failed" liegen und werden vom Retry-Loop erneut verarbeitet,
+// statt nach einem log.Printf verloren zu gehen.
+package inbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// MaxAttempts begrenzt die Verarbeitungsversuche pro Event (danach bleibt es
+// "failed" liegen und muss von Hand angeschaut werden).
+const MaxAttempts = 5
+
+// staleAfter: ein Event, das so lange in "processing" hängt, gilt als
+// abgebrochen (Pod-Neustart mitten in der Verarbeitung) und wird erneut
+// verarbeitet.
+const staleAfter = 5 * time.Minute
+
+// Event ist ein erneut zu verarbeitendes Event.
+type Event struct {
+	MessageID  string
+	Payload    []byte
+	ReceivedAt time.Time
+	Attempts   int
+}
+
+// Store schreibt bot_inbox in die zumba-DB. Status eines Events:
+// processing → done | failed (→ processing beim nächsten Versuch).
+type Store struct{ db *sql.DB }
+
+func New(db *sql.DB) *Store { return &Store{db: db} }
+
+const schemaSQL = `
+CREATE TABLE IF NOT EXISTS bot_inbox (
+  message_id   TEXT PRIMARY KEY,
+  received_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  processed_at TIMESTAMPTZ,
+  status       TEXT NOT NULL,
+  attempts     INT NOT NULL DEFAULT 1,
+  last_error   TEXT,
+  payload      JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS bot_inbox_status_idx ON bot_inbox (status, updated_at);`
+
+// EnsureSchema legt die Tabelle idempotent an (beim Start aufgerufen).
+func (s *Store) EnsureSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, schemaSQL)
+	return err
+}
+
+// claimSQL legt ein neues Event an bzw. übernimmt ein fehlgeschlagenes oder
+// hängengebliebenes erneut – in einem Statement, damit zwei parallele
+// Zustellungen derselben ID nie beide durchkommen. Kein RETURNING-Ergebnis =
+// Duplikat (erledigt oder gerade in Arbeit).
+const claimSQL = `
+INSERT INTO bot_inbox (message_id, status, payload)
+VALUES ($1, 'processing', $2)
+ON CONFLICT (message_id) DO UPDATE
+  SET status = 'processing', attempts = bot_inbox.attempts + 1, updated_at = now()
+  WHERE (bot_inbox.status = 'failed' AND bot_inbox.attempts < $3)
+     OR (bot_inbox.status = 'processing' AND bot_inbox.updated_at < now() - $4 * interval '1 second')
+RETURNING received_at`
+
+// Claim reserviert das Event messageID zur Verarbeitung. ok=false heißt
+// Duplikat: nicht verarbeiten, nur quittieren. receivedAt ist der Zeitpunkt
+// der ERSTEN Zustellung (Bezugstag bei Wiederholungen).
+func (s *Store) Claim(ctx context.Context, messageID string, payload []byte) (receivedAt time.Time, ok bool, err error) {
+	err = s.db.QueryRowContext(ctx, claimSQL, messageID, payload, MaxAttempts, staleAfter.Seconds()).Scan(&receivedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("Claim: %w", err)
+	}
+	return receivedAt, true, nil
+}
+
+const retentionSQL = `DELETE FROM bot_inbox WHERE status = 'done' AND processed_at < now() - interval '30 days'`
+
+// Finish schließt ein Event ab: procErr leer = done, sonst failed (bleibt für
+// den Retry-Loop liegen). Räumt anschließend erledigte Events älter als 30
+// Tage ab (failed bleiben stehen).
+func (s *Store) Finish(ctx context.Context, messageID, procErr string) error {
+	const q = `
+		UPDATE bot_inbox
+		SET status = CASE WHEN $2 = '' THEN 'done' ELSE 'failed' END,
+		    last_error = NULLIF($2, ''), processed_at = now(), updated_at = now()
+		WHERE message_id = $1`
+	if _, err := s.db.ExecContext(ctx, q, messageID, procErr); err != nil {
+		return fmt.Errorf("Finish: %w", err)
+	}
+	_, _ = s.db.ExecContext(ctx, retentionSQL) // best-effort
+	return nil
+}
+
+// Due liefert Events, die erneut verarbeitet werden sollen (failed mit
+// Restversuchen oder hängengeblieben), älteste zuerst.
+func (s *Store) Due(ctx context.Context, limit int) ([]Event, error) {
+	const q = `
+		SELECT message_id, payload, received_at, attempts
+		FROM bot_inbox
+		WHERE (status = 'failed' AND attempts < $1)
+		   OR (status = 'processing' AND updated_at < now() - $2 * interval '1 second')
+		ORDER BY received_at
+		LIMIT $3`
+	rows, err := s.db.QueryContext(ctx, q, MaxAttempts, staleAfter.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("Due: %w", err)
+	}
+	defer rows.Close()
+	var out []Event
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.MessageID, &e.Payload, &e.ReceivedAt, &e.Attempts); err != nil {
+			return nil, fmt.Errorf("Due: %w", err)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
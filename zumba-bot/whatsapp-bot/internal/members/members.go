@@ -0,0 +1,192 @@
+// Package members löst auf, für wen eine Ab-/Zusage gilt: "Tom und ich
+// kommen heute nicht" oder "@Tom kann heute nicht" betreffen nicht (nur) den
+// Absender. Regelbasiert und bewusst vorsichtig – eine falsch eingetragene
+// Absage ist ärgerlicher als eine, die jemand selbst nachschreiben muss:
+//
+//   - @-Erwähnungen (mentionedJid) zählen überall in der Nachricht.
+//   - Namen und Spitznamen ohne @ zählen nur in der Aufzählung am Anfang
+//     ("Tom und ich …", "Sepp, Hiller und i …", "Tom kommt nicht").
+//   - Der Absender zählt, wenn sonst niemand genannt ist oder er sich selbst
+//     einschließt ("ich", "i", "bin", "wir").
+//   - Erwähnte Nicht-Mitglieder (oder @lid-JIDs ohne Nummer) bleiben außen
+//     vor.
+package members
+
+import (
+	"strings"
+	"unicode"
+
+	sharedstore "github.com/michael/zumba-shared/store"
+)
+
+// Wie ein Mitglied gefunden wurde.
+const (
+	ViaSender  = "absender"
+	ViaMention = "erwähnt"
+	ViaName    = "genannt"
+)
+
+// Target ist ein Mitglied, für das die Nachricht gilt.
+type Target struct {
+	UserID string
+	Name   string
+	Via    string // ViaSender, ViaMention, ViaName
+}
+
+// Result sind die Betroffenen einer Nachricht, Absender (falls betroffen)
+// zuerst. Unknown sind Erwähnungen ohne passendes Mitglied; gilt die
+// Nachricht nur ihnen, ist Targets leer.
+type Result struct {
+	Targets []Target
+	Unknown []string
+}
+
+// Others liefert die Betroffenen außer dem Absender.
+func (r Result) Others() []Target {
+	var out []Target
+	for _, t := range r.Targets {
+		if t.Via != ViaSender {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// selfWords schließen den Absender ein, auch wenn andere genannt sind.
+var selfWords = map[string]bool{"ich": true, "i": true, "bin": true, "wir": true}
+
+// listWords dürfen in der Namens-Aufzählung am Anfang stehen.
+var listWords = map[string]bool{"und": true, "&": true, "+": true, ",": true, "ich": true, "i": true, "wir": true, "sowie": true}
+
+// Resolve bestimmt die Betroffenen von text. sender ist die userId des
+// Absenders, mentioned die mentionedJid-Liste, dir alle Mitglieder.
+func Resolve(text, sender, senderName string, mentioned []string, dir []sharedstore.Member) Result {
+	var res Result
+	seen := map[string]bool{}
+	add := func(id, name, via string) {
+		if !seen[id] {
+			seen[id] = true
+			res.Targets = append(res.Targets, Target{UserID: id, Name: name, Via: via})
+		}
+	}
+
+	byNumber := map[string]sharedstore.Member{}
+	for _, m := range dir {
+		byNumber[number(m.UserID)] = m
+	}
+	var others []Target
+	for _, jid := range mentioned {
+		m, ok := byNumber[number(jid)]
+		switch {
+		case !ok:
+			res.Unknown = append(res.Unknown, jid)
+		case m.UserID != sender:
+			others = append(others, Target{UserID: m.UserID, Name: m.UserName, Via: ViaMention})
+		}
+	}
+
+	words := tokens(text)
+	self := false
+	for _, w := range words {
+		if selfWords[w] {
+			self = true
+			break
+		}
+	}
+	names := nameIndex(dir)
+	for i := 0; i < len(words); {
+		if listWords[words[i]] || strings.HasPrefix(words[i], "@") {
+			i++
+			continue
+		}
+		m, n := names.match(words[i:])
+		if n == 0 {
+			break // Ende der Aufzählung
+		}
+		if m.UserID != sender {
+			others = append(others, Target{UserID: m.UserID, Name: m.UserName, Via: ViaName})
+		}
+		i += n
+	}
+
+	// Nur unbekannte Erwähnungen: gemeint ist jemand anderes, aber wer?
+	if self || (len(others) == 0 && len(res.Unknown) == 0) {
+		add(sender, senderName, ViaSender)
+	}
+	for _, t := range others {
+		add(t.UserID, t.Name, t.Via)
+	}
+	return res
+}
+
+// number ist die Telefonnummer einer JID ("49170…@s.whatsapp.net" → "49170…").
+func number(jid string) string {
+	n, _, _ := strings.Cut(jid, "@")
+	return n
+}
+
+// tokens zerlegt text in kleingeschriebene Wörter; Kommas und "&"/"+"
+// bleiben als eigene Tokens (Aufzählung), "@49170…" bleibt ein Token.
+func tokens(text string) []string {
+	var out []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			out = append(out, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || (r == '@' && cur.Len() == 0):
+			cur.WriteRune(r)
+		case r == ',' || r == '&' || r == '+':
+			flush()
+			out = append(out, string(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return out
+}
+
+// names ordnet Wortfolgen (Name, Vorname, Spitzname) Mitgliedern zu.
+type names map[string]sharedstore.Member
+
+// nameIndex: voller Name und Spitznamen immer, der Vorname nur, wenn ihn
+// genau ein Mitglied trägt.
+func nameIndex(dir []sharedstore.Member) names {
+	idx := names{}
+	first := map[string][]sharedstore.Member{}
+	for _, m := range dir {
+		full := strings.Join(tokens(m.UserName), " ")
+		if full != "" {
+			idx[full] = m
+		}
+		for _, a := range m.Aliases {
+			if a := strings.Join(tokens(a), " "); a != "" {
+				idx[a] = m
+			}
+		}
+		if t := tokens(m.UserName); len(t) > 1 {
+			first[t[0]] = append(first[t[0]], m)
+		}
+	}
+	for f, ms := range first {
+		if _, taken := idx[f]; !taken && len(ms) == 1 {
+			idx[f] = ms[0]
+		}
+	}
+	return idx
+}
+
+// match sucht den längsten Namen am Anfang von words; n = Zahl der Wörter.
+func (idx names) match(words []string) (sharedstore.Member, int) {
+	for n := min(len(words), 3); n > 0; n-- {
+		if m, ok := idx[strings.Join(words[:n], " ")]; ok {
+			return m, n
+		}
+	}
+	return sharedstore.Member{}, 0
+}
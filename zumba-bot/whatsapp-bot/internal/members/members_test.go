@@ -0,0 +1,64 @@
+package members
+
+import (
+	"strings"
+	"testing"
+
+	sharedstore "github.com/michael/zumba-shared/store"
+)
+
+var dir = []sharedstore.Member{
+	{UserID: "491701@s.whatsapp.net", UserName: "Michl"},
+	{UserID: "491702@s.whatsapp.net", UserName: "Tom Bauer", Aliases: []string{"tommi"}},
+	{UserID: "491703@s.whatsapp.net", UserName: "Sepp"},
+	{UserID: "491704@s.whatsapp.net", UserName: "Tom Huber"},
+	{UserID: "491705@s.whatsapp.net", UserName: "Hiller"},
+}
+
+func ids(r Result) string {
+	var out []string
+	for _, t := range r.Targets {
+		out = append(out, t.Name+"/"+t.Via)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestResolve(t *testing.T) {
+	const sender = "491701@s.whatsapp.net"
+	cases := []struct {
+		text      string
+		mentioned []string
+		want      string
+	}{
+		{"bin raus", nil, "Michl/absender"},
+		{"Sepp und ich kommen heute nicht", nil, "Michl/absender,Sepp/genannt"},
+		{"Sepp, Hiller & i san raus", nil, "Michl/absender,Sepp/genannt,Hiller/genannt"},
+		{"Sepp kommt heute nicht", nil, "Sepp/genannt"},
+		{"Tommi kann heute nicht", nil, "Tom Bauer/genannt"},
+		{"Tom Huber fällt aus", nil, "Tom Huber/genannt"},
+		// "Tom" allein ist mehrdeutig → nur der Absender.
+		{"Tom und ich sind raus", nil, "Michl/absender"},
+		// Namen mitten im Satz zählen nicht.
+		{"bin raus, Sepp du zahlst", nil, "Michl/absender"},
+		{"@491703 kann heute nicht", []string{"491703@s.whatsapp.net"}, "Sepp/erwähnt"},
+		{"bin raus, @491705 auch", []string{"491705@s.whatsapp.net"}, "Michl/absender,Hiller/erwähnt"},
+		// Selbst-Erwähnung ist der Absender.
+		{"@491701 bin raus", []string{"491701@s.whatsapp.net"}, "Michl/absender"},
+	}
+	for _, c := range cases {
+		if got := ids(Resolve(c.text, sender, "Michl", c.mentioned, dir)); got != c.want {
+			t.Errorf("%q: %s, want %s", c.text, got, c.want)
+		}
+	}
+}
+
+func TestResolveUnbekannteErwaehnung(t *testing.T) {
+	r := Resolve("@123 kann nicht", "491701@s.whatsapp.net", "Michl", []string{"123@lid"}, dir)
+	if len(r.Targets) != 0 || len(r.Unknown) != 1 {
+		t.Errorf("nur unbekannt: %+v, want niemand", r)
+	}
+	r = Resolve("@123 und ich sind raus", "491701@s.whatsapp.net", "Michl", []string{"123@lid"}, dir)
+	if ids(r) != "Michl/absender" || len(r.Others()) != 0 {
+		t.Errorf("mit ich: %+v", r)
+	}
+}
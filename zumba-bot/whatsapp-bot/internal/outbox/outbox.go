@@ -0,0 +1,225 @@
+// Package outbox ist das Ausgangsbuch des Bots: Gruppen-Nachrichten
+// (Statistik, Wochenreport) werden erst in bot_outbox geschrieben und dann
+// zugestellt. Scheitert Evolution, bleibt die Nachricht "pending" und wird mit
+// exponentiellem Backoff erneut versucht, bis sie zu alt ist – ein kurzer
+// Evolution-Ausfall donnerstags um 21:00 kostet so nicht den Wochenreport.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// Sender stellt Nachrichten zu (im Betrieb der Evolution-Client).
+type Sender interface {
+	SendText(ctx context.Context, number, text string) error
+	SendImage(ctx context.Context, number, caption string, png []byte) error
+}
+
+const (
+	// MaxAge: ältere Nachrichten werden nicht mehr zugestellt (Status
+	// failed) – ein Report vom Vorabend ist am Mittag noch sinnvoll, drei
+	// Tage später nicht mehr.
+	MaxAge = 12 * time.Hour
+
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 30 * time.Minute
+	// lease hält eine gerade zugestellte Nachricht vom Sende-Loop fern.
+	lease = 2 * time.Minute
+)
+
+// Message ist eine ausgehende Nachricht. Mit Image geht die Bild-Karte raus
+// (Caption), Text ist dann der Fallback bei Bild-Fehlern; ohne Image der Text.
+type Message struct {
+	Source    string // "statistik" | "wochenreport"
+	Recipient string
+	Text      string
+	Caption   string
+	Image     []byte
+}
+
+// Store schreibt bot_outbox in die zumba-DB. Status einer Nachricht:
+// pending → sent | failed (zu alt).
+type Store struct {
+	db     *sql.DB
+	sender Sender
+
+	// Now ist überschreibbar für Tests.
+	Now func() time.Time
+}
+
+func New(db *sql.DB, snd Sender) *Store {
+	return &Store{db: db, sender: snd, Now: time.Now}
+}
+
+const schemaSQL = `
+CREATE TABLE IF NOT EXISTS bot_outbox (
+  id              BIGSERIAL PRIMARY KEY,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  source          TEXT NOT NULL,
+  recipient       TEXT NOT NULL,
+  text            TEXT NOT NULL DEFAULT '',
+  caption         TEXT NOT NULL DEFAULT '',
+  image           BYTEA,
+  status          TEXT NOT NULL DEFAULT 'pending',
+  attempts        INT NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_error      TEXT,
+  sent_at         TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS bot_outbox_due_idx ON bot_outbox (status, next_attempt_at);`
+
+// EnsureSchema legt die Tabelle idempotent an (beim Start aufgerufen).
+func (s *Store) EnsureSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, schemaSQL)
+	return err
+}
+
+// Send schreibt m in die Outbox und versucht sofort die Zustellung. sent=false
+// ohne Fehler heißt: liegt in der Outbox, der Sende-Loop versucht es weiter.
+// err nur, wenn schon das Schreiben scheitert.
+func (s *Store) Send(ctx context.Context, m Message) (sent bool, err error) {
+	const q = `
+		INSERT INTO bot_outbox (source, recipient, text, caption, image, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, now() + $6 * interval '1 second')
+		RETURNING id, created_at`
+	var it item
+	it.Message = m
+	if err := s.db.QueryRowContext(ctx, q, m.Source, m.Recipient, m.Text, m.Caption, m.Image, lease.Seconds()).
+		Scan(&it.id, &it.createdAt); err != nil {
+		return false, fmt.Errorf("Send: %w", err)
+	}
+	return s.attempt(ctx, it), nil
+}
+
+type item struct {
+	Message
+	id        int64
+	createdAt time.Time
+	attempts  int
+}
+
+// Deliver schickt m direkt über snd: bevorzugt das Bild, bei Fehlern den Text
+// als Fallback – beides im selben Versuch.
+func Deliver(ctx context.Context, snd Sender, m Message) error {
+	if m.Image != nil {
+		err := snd.SendImage(ctx, m.Recipient, m.Caption, m.Image)
+		if err == nil || m.Text == "" {
+			return err
+		}
+		log.Printf("⚠️  SendImage(%s): %v – Fallback auf Text", m.Recipient, err)
+	}
+	return snd.SendText(ctx, m.Recipient, m.Text)
+}
+
+// attempt stellt it einmal zu und hält das Ergebnis fest.
+func (s *Store) attempt(ctx context.Context, it item) bool {
+	err := Deliver(ctx, s.sender, it.Message)
+	it.attempts++
+	if err == nil {
+		if _, uerr := s.db.ExecContext(ctx,
+			`UPDATE bot_outbox SET status = 'sent', attempts = $2, sent_at = now(), last_error = NULL WHERE id = $1`,
+			it.id, it.attempts); uerr != nil {
+			log.Printf("⚠️  outbox: %v", uerr)
+		}
+		return true
+	}
+
+	next := s.Now().Add(Backoff(it.attempts))
+	status := "pending"
+	if next.Sub(it.createdAt) > MaxAge {
+		status = "failed"
+		log.Printf("⚠️  Outbox #%d (%s → %s) aufgegeben nach %d Versuchen: %v", it.id, it.Source, it.Recipient, it.attempts, err)
+	} else {
+		log.Printf("📤 Outbox #%d (%s → %s) Versuch %d fehlgeschlagen: %v – nächster um %s",
+			it.id, it.Source, it.Recipient, it.attempts, err, next.Format("15:04:05"))
+	}
+	if _, uerr := s.db.ExecContext(ctx,
+		`UPDATE bot_outbox SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5 WHERE id = $1`,
+		it.id, status, it.attempts, next, err.Error()); uerr != nil {
+		log.Printf("⚠️  outbox: %v", uerr)
+	}
+	return false
+}
+
+// Backoff ist die Wartezeit nach dem n-ten Fehlversuch: 30s, 1m, 2m, … bis
+// höchstens 30 Minuten.
+func Backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+// claimSQL reserviert fällige Nachrichten (lease), damit ein paralleler
+// Durchlauf sie nicht doppelt zustellt.
+const claimSQL = `
+UPDATE bot_outbox SET next_attempt_at = now() + $2 * interval '1 second'
+WHERE id IN (
+  SELECT id FROM bot_outbox
+  WHERE status = 'pending' AND next_attempt_at <= now()
+  ORDER BY id LIMIT $1
+  FOR UPDATE SKIP LOCKED)
+RETURNING id, created_at, source, recipient, text, caption, image, attempts`
+
+const retentionSQL = `DELETE FROM bot_outbox WHERE status <> 'pending' AND created_at < now() - interval '30 days'`
+
+// Flush stellt fällige Nachrichten zu (älteste zuerst) und liefert die Anzahl
+// erfolgreich zugestellter.
+func (s *Store) Flush(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, claimSQL, 20, lease.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("Flush: %w", err)
+	}
+	var due []item
+	for rows.Next() {
+		var it item
+		if err := rows.Scan(&it.id, &it.createdAt, &it.Source, &it.Recipient, &it.Text, &it.Caption, &it.Image, &it.attempts); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("Flush: %w", err)
+		}
+		due = append(due, it)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("Flush: %w", err)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].id < due[j].id })
+
+	n := 0
+	for _, it := range due {
+		// Bot war länger weg als MaxAge: nicht mehr zustellen.
+		if s.Now().Sub(it.createdAt) > MaxAge {
+			log.Printf("⚠️  Outbox #%d (%s → %s) zu alt – verworfen", it.id, it.Source, it.Recipient)
+			_, _ = s.db.ExecContext(ctx, `UPDATE bot_outbox SET status = 'failed', last_error = 'zu alt (max. 12h)' WHERE id = $1`, it.id)
+			continue
+		}
+		if s.attempt(ctx, it) {
+			log.Printf("📤 Outbox #%d (%s) nach %d Versuchen zugestellt an %s", it.id, it.Source, it.attempts+1, it.Recipient)
+			n++
+		}
+	}
+	_, _ = s.db.ExecContext(ctx, retentionSQL) // best-effort
+	return n, nil
+}
+
+// Run ruft Flush im Abstand every auf, bis ctx endet.
+func (s *Store) Run(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if _, err := s.Flush(ctx); err != nil {
+				log.Printf("⚠️  outbox: %v", err)
+			}
+		}
+	}
+}
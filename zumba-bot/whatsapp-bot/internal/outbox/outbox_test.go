@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1: 30 * time.Second, 2: time.Minute, 3: 2 * time.Minute, 6: 16 * time.Minute, 7: 30 * time.Minute, 20: 30 * time.Minute,
+	}
+	for n, want := range cases {
+		if got := Backoff(n); got != want {
+			t.Errorf("Backoff(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
+type fakeSender struct {
+	imageErr, textErr error
+	sent              []string
+}
+
+func (f *fakeSender) SendText(_ context.Context, _, text string) error {
+	if f.textErr != nil {
+		return f.textErr
+	}
+	f.sent = append(f.sent, "text:"+text)
+	return nil
+}
+
+func (f *fakeSender) SendImage(_ context.Context, _, caption string, _ []byte) error {
+	if f.imageErr != nil {
+		return f.imageErr
+	}
+	f.sent = append(f.sent, "image:"+caption)
+	return nil
+}
+
+func TestDeliverBildMitTextFallback(t *testing.T) {
+	m := Message{Recipient: "g", Text: "rangliste", Caption: "karte", Image: []byte{1}}
+
+	snd := &fakeSender{}
+	if err := Deliver(context.Background(), snd, m); err != nil || len(snd.sent) != 1 || snd.sent[0] != "image:karte" {
+		t.Errorf("Bild: err=%v sent=%v", err, snd.sent)
+	}
+
+	snd = &fakeSender{imageErr: errors.New("413")}
+	if err := Deliver(context.Background(), snd, m); err != nil || len(snd.sent) != 1 || snd.sent[0] != "text:rangliste" {
+		t.Errorf("Fallback: err=%v sent=%v", err, snd.sent)
+	}
+
+	down := errors.New("connection refused")
+	snd = &fakeSender{imageErr: down, textErr: down}
+	if err := Deliver(context.Background(), snd, m); !errors.Is(err, down) {
+		t.Errorf("beides kaputt: err=%v", err)
+	}
+}
@@ -308,18 +308,12 @@ func BuildCardHTMLByStyle(style string, rows []store.Stat, entries []penalty.Ent
 		return oi && !oj
 	})
 	for _, e := range visible {
-		var grund string
-		switch e.Art {
-		case penalty.ArtNoShow:
-			grund = fmt.Sprintf("nicht abgemeldet, %s", fmtDate(e.Datum))
-		default:
-			grund = fmt.Sprintf("%dx in Folge gefehlt", e.Tage)
-		}
+		grund := strafeGrund(e)
 		s := cardStrafe{Name: e.Name, Grund: grund, Betrag: e.Betrag}
 		if e.Status == penalty.StatusBeglichen {
 			s.Icon, s.Beglichen = "✅", true
 		} else {
-			s.Icon = "⚠️"
+			s.Icon, s.Grund, s.Betrag = "⚠️", grund+teilzahlung(e)+einspruch(e), e.Rest()
 		}
 		data.Strafen = append(data.Strafen, s)
 	}
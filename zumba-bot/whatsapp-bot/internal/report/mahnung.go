@@ -0,0 +1,52 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/michael/zumba-shared/penalty"
+)
+
+// mahnung.go baut die Texte des Mahnwesens: die Direktnachricht je Stufe und
+// die Sammel-Erwähnung in der Gruppe ab der Gruppen-Stufe.
+
+// BuildMahnDM erzeugt die Mahnung der Stufe l.Stufe als Direktnachricht.
+// gebuehr > 0 kündigt die dabei angelegte Mahngebühr an (letzte Stufe),
+// gruppe, dass das Mitglied heute zusätzlich in der Gruppe erwähnt wird.
+func BuildMahnDM(l penalty.MahnLage, letzte bool, gebuehr int, gruppe bool) string {
+	var b strings.Builder
+	switch {
+	case letzte:
+		b.WriteString(fmt.Sprintf("🚨 *Letzte Mahnung*, %s!\n\n", l.Name))
+	case l.Stufe == 1:
+		b.WriteString(fmt.Sprintf("💸 Servus %s, kleine Erinnerung an die Strafenkasse.\n\n", l.Name))
+	default:
+		b.WriteString(fmt.Sprintf("💸 *%d. Mahnung*, %s\n\n", l.Stufe, l.Name))
+	}
+	b.WriteString(fmt.Sprintf("Offen sind noch *%d€* – die älteste Strafe (%s) ist seit %d Tagen fällig.",
+		l.Offen, strafeGrund(l.Anker), l.Alter))
+	if gebuehr > 0 {
+		b.WriteString(fmt.Sprintf("\nDafür kommt jetzt eine Mahngebühr von %d€ dazu.", gebuehr))
+	}
+	b.WriteString("\n\nBitte begleiche sie beim nächsten Stammtisch. 🍻")
+	if gruppe {
+		b.WriteString("\n\n_Ab jetzt erwähne ich dich auch in der Gruppe. Lieber nicht? Antworte „mahnung aus“._")
+	}
+	return b.String()
+}
+
+// BuildMahnGroup erzeugt die Erwähnung in der Gruppe für alle Mitglieder,
+// die heute eine Mahnung ab der Gruppen-Stufe bekommen. mentioned sind die zu
+// erwähnenden Nummern (wie BuildGroupReminder).
+func BuildMahnGroup(lagen []penalty.MahnLage) (text string, mentioned []string) {
+	var b strings.Builder
+	b.WriteString("📣 *Strafenkasse* – hier ist noch etwas offen:\n")
+	for _, l := range lagen {
+		id := MentionID(l.UserID)
+		mentioned = append(mentioned, id)
+		b.WriteString(fmt.Sprintf("\n• @%s – %d€ (seit %d Tagen)", id, l.Offen, l.Alter))
+	}
+	b.WriteString("\n\nBitte beim nächsten Stammtisch begleichen. 🍻")
+	b.WriteString("\n\n_Nicht mehr in der Gruppe erwähnt werden? Schreib dem Bot „mahnung aus“._")
+	return b.String(), mentioned
+}
@@ -0,0 +1,189 @@
+package report
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-whatsapp-bot/internal/store"
+)
+
+// personal.go baut die Antwort auf "meine statistik": Platz, Quote, Serie,
+// offene Strafen und wie viele Absagen in Folge noch straffrei sind – als
+// WhatsApp-Text und als persönliche Bild-Karte im Live-Design.
+
+//go:embed card-personal.tmpl
+var cardPersonalSrc string
+
+var cardPersonalTmpl = parseCard("personal", cardPersonalSrc)
+
+// Personal ist die persönliche Statistik eines Mitglieds.
+type Personal struct {
+	Stat store.Stat
+	// Strafen sind die bewerteten Strafen des Users (penalty.Assess);
+	// ausgewiesen werden nur die offenen.
+	Strafen []penalty.Entry
+	// Serie ist die laufende Fehltag-Serie (penalty.CurrentRun).
+	Serie int
+	// Regeln bewerten die laufende bzw. nächste Serie
+	// (penalty.CurrentRules; Nullwert = penalty.DefaultRuleSet).
+	Regeln penalty.RuleSet
+	AsOf   time.Time
+}
+
+// Offen liefert die offenen Strafen.
+func (p Personal) Offen() []penalty.Entry {
+	var out []penalty.Entry
+	for _, e := range p.Strafen {
+		if e.Status == penalty.StatusOffen {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Schulden ist die Summe der offenen Strafen in Euro (nach Teilzahlungen).
+func (p Personal) Schulden() int {
+	sum := 0
+	for _, e := range p.Offen() {
+		sum += e.Rest()
+	}
+	return sum
+}
+
+// Straffrei ist die Zahl weiterer Absagen in Folge, die noch nichts kosten
+// (0 = die nächste Absage löst die Fehltage-Strafe aus bzw. erhöht sie).
+func (p Personal) Straffrei() int {
+	return max(p.Regeln.OrDefault().MinFehltage-1-p.Serie, 0)
+}
+
+// fehltageHinweis ist die Zeile zur Fehltage-Schwelle (ohne Formatierung).
+func (p Personal) fehltageHinweis() string {
+	r := p.Regeln.OrDefault()
+	switch {
+	case p.Serie >= r.MinFehltage:
+		return fmt.Sprintf("Fehltage-Serie läuft (%dx) – jede weitere Absage +%d€", p.Serie, r.ProTagBetrag)
+	case p.Straffrei() == 0:
+		return fmt.Sprintf("Die nächste Absage in Folge kostet %d€", r.BasisBetrag)
+	case p.Straffrei() == 1:
+		return fmt.Sprintf("Noch 1 Absage in Folge straffrei, ab der %d. kostet es %d€", r.MinFehltage, r.BasisBetrag)
+	default:
+		return fmt.Sprintf("Noch %d Absagen in Folge straffrei, ab der %d. kostet es %d€", p.Straffrei(), r.MinFehltage, r.BasisBetrag)
+	}
+}
+
+// platz liefert die Medaille bzw. "4." für den Ranglistenplatz.
+func platz(rank int) string {
+	medals := []string{"🥇", "🥈", "🥉"}
+	if rank >= 1 && rank <= len(medals) {
+		return medals[rank-1]
+	}
+	return fmt.Sprintf("%d.", rank)
+}
+
+// serieText beschreibt die vorzeichenbehaftete Serie ("🔥+4", "❄️-2", ohne Serie "–").
+func serieText(streak int) string {
+	if tag := strings.TrimSpace(hotTag(streak) + coldTag(streak)); tag != "" {
+		return tag
+	}
+	return "–"
+}
+
+// BuildPersonal erzeugt den WhatsApp-Text für "meine statistik".
+func BuildPersonal(p Personal) string {
+	st := p.Stat
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("👤 *MEINE ZUMBA STATS* – %s\n", st.Name))
+	b.WriteString(fmt.Sprintf("_Stand %s_\n\n", p.AsOf.Format("02.01.2006")))
+	medal := platz(st.Rank)
+	if st.Rank > 3 {
+		medal = "🏅"
+	}
+	b.WriteString(fmt.Sprintf("%s *Platz %d* von %d\n", medal, st.Rank, st.Users))
+	b.WriteString(fmt.Sprintf("📊 %s %d-%d (%s%%)\n", barChart(st.Percent, 6), st.Attendance, st.Away, fmtNum(st.Percent)))
+	b.WriteString(fmt.Sprintf("📈 *Serie:* %s\n\n", serieText(st.Streak)))
+
+	b.WriteString("── 💸 *OFFENE STRAFEN* ──\n")
+	offen := p.Offen()
+	if len(offen) == 0 {
+		b.WriteString("\n_Keine offenen Strafen_ 🎉")
+	} else {
+		for _, e := range offen {
+			b.WriteString(fmt.Sprintf("\n⚠️ %d€ (%s%s%s)", e.Rest(), strafeGrund(e), teilzahlung(e), einspruch(e)))
+		}
+		b.WriteString(fmt.Sprintf("\n\n💶 *Offen gesamt: %d€*", p.Schulden()))
+	}
+
+	icon := "🛡️"
+	if p.Straffrei() == 0 {
+		icon = "⚠️"
+	}
+	b.WriteString("\n\n" + icon + " " + p.fehltageHinweis())
+	return b.String()
+}
+
+// strafeGrund ist die Klammer einer Strafe ohne Namen (strafenLine, Karte,
+// persönliche Statistik).
+func strafeGrund(e penalty.Entry) string {
+	switch e.Art {
+	case penalty.ArtNoShow:
+		return fmt.Sprintf("nicht abgemeldet, %s", fmtDate(e.Datum))
+	case penalty.ArtMahngebuehr:
+		return fmt.Sprintf("Mahngebühr, %s", fmtDate(e.Datum))
+	}
+	return fmt.Sprintf("%dx in Folge gefehlt", e.Tage)
+}
+
+type personalCardData struct {
+	Name       string
+	Datum      string
+	Platz      string
+	Users      int
+	Attendance int
+	Away       int
+	Percent    string
+	PercentVal float64
+	Serie      string
+	Strafen    []cardStrafe
+	Schulden   int
+	Hinweis    string
+	Warnung    bool // Hinweis rot statt Biergold (nächste Absage kostet)
+
+	Fonts cardFonts
+	Logo  template.URL
+}
+
+// BuildPersonalCardHTML baut die persönliche Bild-Karte (Live-Design,
+// CardWidth breit) für den renderer-service.
+func BuildPersonalCardHTML(p Personal) (string, error) {
+	st := p.Stat
+	data := personalCardData{
+		Name:       st.Name,
+		Datum:      fmt.Sprintf("%d.%d.%d", p.AsOf.Day(), int(p.AsOf.Month()), p.AsOf.Year()),
+		Platz:      platz(st.Rank),
+		Users:      st.Users,
+		Attendance: st.Attendance,
+		Away:       st.Away,
+		Percent:    fmtNum(st.Percent),
+		PercentVal: st.Percent,
+		Serie:      serieText(st.Streak),
+		Schulden:   p.Schulden(),
+		Hinweis:    p.fehltageHinweis(),
+		Warnung:    p.Straffrei() == 0,
+		Logo:       logoURL(),
+	}
+	withAnton(&data.Fonts)
+	for _, e := range p.Offen() {
+		data.Strafen = append(data.Strafen, cardStrafe{Icon: "⚠️", Grund: strafeGrund(e) + teilzahlung(e) + einspruch(e), Betrag: e.Rest()})
+	}
+
+	var buf bytes.Buffer
+	if err := cardPersonalTmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("card template %q: %w", "personal", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,66 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-whatsapp-bot/internal/store"
+)
+
+func TestBuildPersonal(t *testing.T) {
+	p := Personal{
+		Stat: store.Stat{Name: "Börni", Attendance: 26, Away: 5, Percent: 83.9, Streak: -2, Rank: 2, Users: 12},
+		Strafen: []penalty.Entry{
+			{Art: penalty.ArtNoShow, Datum: time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC), Betrag: 50, Status: penalty.StatusOffen},
+			{Art: penalty.ArtFehltage, Tage: 5, Betrag: 25, Status: penalty.StatusBeglichen},
+		},
+		Serie: 2,
+		AsOf:  time.Date(2026, 8, 6, 0, 0, 0, 0, time.UTC),
+	}
+	got := BuildPersonal(p)
+	for _, want := range []string{
+		"Börni", "_Stand 06.08.2026_",
+		"🥈 *Platz 2* von 12",
+		"26-5 (83.9%)",
+		"*Serie:* ❄️-2",
+		"⚠️ 50€ (nicht abgemeldet, 12.3.)",
+		"Offen gesamt: 50€",
+		"Noch 2 Absagen in Folge straffrei, ab der 5. kostet es 25€",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Text ohne %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "5x in Folge") {
+		t.Errorf("beglichene Strafe darf nicht auftauchen:\n%s", got)
+	}
+}
+
+func TestPersonalStraffrei(t *testing.T) {
+	cases := []struct{ serie, want int }{{0, 4}, {3, 1}, {4, 0}, {7, 0}}
+	for _, c := range cases {
+		if got := (Personal{Serie: c.serie}).Straffrei(); got != c.want {
+			t.Errorf("Straffrei(Serie %d) = %d, want %d", c.serie, got, c.want)
+		}
+	}
+}
+
+func TestBuildPersonalCardHTML(t *testing.T) {
+	p := Personal{
+		Stat:    store.Stat{Name: "Didi", Attendance: 20, Away: 11, Percent: 64.5, Streak: -4, Rank: 5, Users: 12},
+		Strafen: []penalty.Entry{{Art: penalty.ArtFehltage, Tage: 6, Betrag: 30, Status: penalty.StatusOffen}},
+		Serie:   4,
+		AsOf:    time.Date(2026, 8, 6, 0, 0, 0, 0, time.UTC),
+	}
+	html, err := BuildPersonalCardHTML(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Didi", "5.", "Platz von 12", "64.5%", "🧊-4", "6x in Folge gefehlt", "30€", "hinweis warnung", "data:font/woff2;base64,"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Karte enthält %q nicht", want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/michael/zumba-shared/domain"
+	sharedstore "github.com/michael/zumba-shared/store"
+)
+
+// reminder.go baut die Erinnerung am Stammtisch-Tag für Mitglieder ohne
+// Rückmeldung – als Gruppen-Nachricht mit @-Erwähnungen oder als
+// Direktnachricht.
+
+// MentionID ist die Nummer für eine @-Erwähnung: die userId ohne
+// "@s.whatsapp.net".
+func MentionID(userID string) string {
+	n, _, _ := strings.Cut(userID, "@")
+	return n
+}
+
+// BuildGroupReminder erzeugt die Gruppen-Erinnerung für das Treffen am Tag
+// date. mentioned sind die zu erwähnenden Nummern (für Evolution), im Text
+// stehen sie als "@<nummer>" – WhatsApp zeigt dort den Namen. noShow ist die
+// No-Show-Strafe laut aktuellem Regelwerk.
+func BuildGroupReminder(date time.Time, open []sharedstore.RosterEntry, noShow int) (text string, mentioned []string) {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("⏰ *Heute ist Stammtisch!* – %s, %s\n\n", domain.WeekdayNameDE(date.Weekday()), date.Format("02.01.")))
+	b.WriteString("Von euch fehlt noch eine Rückmeldung:\n")
+	tags := make([]string, 0, len(open))
+	for _, e := range open {
+		id := MentionID(e.UserID)
+		mentioned = append(mentioned, id)
+		tags = append(tags, "@"+id)
+	}
+	b.WriteString(strings.Join(tags, " "))
+	b.WriteString(fmt.Sprintf("\n\nWer nicht kann, bitte kurz absagen – sonst wird's eine No-Show-Strafe (%d€). 🍻", noShow))
+	b.WriteString("\n\n_Keine Erinnerung mehr? Schreib „erinnerung aus“._")
+	return b.String(), mentioned
+}
+
+// BuildDMReminder erzeugt die Erinnerung als Direktnachricht an name.
+func BuildDMReminder(date time.Time, name string, noShow int) string {
+	return fmt.Sprintf("⏰ Servus %s, heute ist Stammtisch (%s, %s)! 🍻\n\n"+
+		"Falls du nicht kannst, sag bitte kurz in der Gruppe ab – sonst wird's eine No-Show-Strafe (%d€).\n\n"+
+		"_Keine Erinnerung mehr? Antworte „erinnerung aus“._",
+		name, domain.WeekdayNameDE(date.Weekday()), date.Format("02.01."), noShow)
+}
+
+// BuildOnBehalf bestätigt in der Gruppe eine Ab- bzw. Zusage, die by für
+// andere Mitglieder (userIds) eingetragen hat. Die Betroffenen werden
+// erwähnt, damit sie es mitbekommen und ggf. korrigieren können.
+func BuildOnBehalf(absage bool, by string, userIDs []string, dates []time.Time) (text string, mentioned []string) {
+	tags := make([]string, 0, len(userIDs))
+	for _, u := range userIDs {
+		id := MentionID(u)
+		mentioned = append(mentioned, id)
+		tags = append(tags, "@"+id)
+	}
+	days := make([]string, 0, len(dates))
+	for _, d := range dates {
+		days = append(days, fmt.Sprintf("%s, %s", domain.WeekdayNameDE(d.Weekday()), d.Format("02.01.")))
+	}
+	what := "angemeldet ✅"
+	if absage {
+		what = "abgemeldet ❌"
+	}
+	return fmt.Sprintf("📝 %s hat %s für %s %s\n\n_Stimmt nicht? Einfach selbst kurz Bescheid geben._",
+		by, strings.Join(tags, " "), strings.Join(days, " und "), what), mentioned
+}
+
+// BuildClarify ist die Rückfrage auf eine unklare Nachricht ("schau ma mal")
+// an name. Sie geht als Antwort mit Zitat raus; beantwortet wird per Antwort
+// oder Reaktion.
+func BuildClarify(name string, dates []time.Time, today time.Time) string {
+	when := "heute"
+	if len(dates) != 1 || dates[0].Format("2006-01-02") != today.Format("2006-01-02") {
+		days := make([]string, 0, len(dates))
+		for _, d := range dates {
+			days = append(days, fmt.Sprintf("%s, %s", domain.WeekdayNameDE(d.Weekday()), d.Format("02.01.")))
+		}
+		when = "am " + strings.Join(days, " und ")
+	}
+	return fmt.Sprintf("🤔 %s, kommst du %s? 👍 / 👎\n\n_Antworte auf diese Nachricht oder reagiere mit 👍 bzw. 👎._", name, when)
+}
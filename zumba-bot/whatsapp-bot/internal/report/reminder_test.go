@@ -0,0 +1,73 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael/zumba-shared/penalty"
+	sharedstore "github.com/michael/zumba-shared/store"
+)
+
+func TestBuildGroupReminder(t *testing.T) {
+	open := []sharedstore.RosterEntry{
+		{UserID: "4915112345678@s.whatsapp.net", UserName: "Anna"},
+		{UserID: "4917600000000@s.whatsapp.net", UserName: "Bert"},
+	}
+	text, mentioned := BuildGroupReminder(time.Date(2026, 8, 6, 0, 0, 0, 0, time.UTC), open, 50)
+	if len(mentioned) != 2 || mentioned[0] != "4915112345678" || mentioned[1] != "4917600000000" {
+		t.Errorf("mentioned = %v", mentioned)
+	}
+	for _, want := range []string{"Donnerstag, 06.08.", "@4915112345678 @4917600000000", "50€", "„erinnerung aus“"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text ohne %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestBuildOnBehalf(t *testing.T) {
+	d := time.Date(2026, 8, 6, 0, 0, 0, 0, time.UTC)
+	text, mentioned := BuildOnBehalf(true, "Michl", []string{"4915112345678@s.whatsapp.net"}, []time.Time{d})
+	if len(mentioned) != 1 || mentioned[0] != "4915112345678" {
+		t.Errorf("mentioned = %v", mentioned)
+	}
+	for _, want := range []string{"Michl hat @4915112345678", "Donnerstag, 06.08.", "abgemeldet"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text ohne %q:\n%s", want, text)
+		}
+	}
+	if text, _ := BuildOnBehalf(false, "Michl", []string{"49170@s.whatsapp.net"}, []time.Time{d}); !strings.Contains(text, "angemeldet") {
+		t.Errorf("Zusage-Text:\n%s", text)
+	}
+}
+
+func TestBuildClarify(t *testing.T) {
+	today := time.Date(2026, 8, 6, 0, 0, 0, 0, time.UTC)
+	if text := BuildClarify("Sepp", []time.Time{today}, today); !strings.Contains(text, "Sepp, kommst du heute? 👍 / 👎") {
+		t.Errorf("heute:\n%s", text)
+	}
+	next := today.AddDate(0, 0, 7)
+	if text := BuildClarify("Sepp", []time.Time{next}, today); !strings.Contains(text, "kommst du am Donnerstag, 13.08.?") {
+		t.Errorf("nächste Woche:\n%s", text)
+	}
+}
+
+func TestBuildMahnung(t *testing.T) {
+	l := penalty.MahnLage{
+		UserID: "4915112345678@s.whatsapp.net", Name: "Anna", Offen: 80, Alter: 28, Stufe: 2,
+		Anker: penalty.Entry{Art: penalty.ArtNoShow, Datum: time.Date(2026, 7, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	dm := BuildMahnDM(l, false, 0, false)
+	for _, want := range []string{"2. Mahnung", "*80€*", "nicht abgemeldet, 2.7.", "seit 28 Tagen"} {
+		if !strings.Contains(dm, want) {
+			t.Errorf("DM ohne %q:\n%s", want, dm)
+		}
+	}
+	if strings.Contains(dm, "Mahngebühr") || strings.Contains(dm, "mahnung aus") {
+		t.Errorf("DM ohne Gebühr/Gruppe:\n%s", dm)
+	}
+	text, mentioned := BuildMahnGroup([]penalty.MahnLage{l})
+	if len(mentioned) != 1 || mentioned[0] != "4915112345678" || !strings.Contains(text, "@4915112345678 – 80€ (seit 28 Tagen)") {
+		t.Errorf("mentioned=%v\n%s", mentioned, text)
+	}
+}
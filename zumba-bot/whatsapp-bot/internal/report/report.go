@@ -151,10 +151,12 @@ func BuildWithStrafen(rows []store.Stat, strafenBlock string) string {
 }
 
 // StrafenBlock rendert den Strafen-Abschnitt für den Stichtag asOf: offene
-// Strafen immer, beglichene bis einschließlich zum Folgedonnerstag der
-// Begleichung, gelöschte nie. Ohne sichtbare Strafen gibt es die
-// "Keine offenen Strafen"-Zeile.
-func StrafenBlock(entries []penalty.Entry, asOf time.Time) string {
+// Strafen immer (mit dem Rest nach Teilzahlungen, offene Einsprüche
+// markiert), beglichene bis einschließlich zum nächsten Termin nach der
+// Begleichung, gelöschte nie. Ohne sichtbare Strafen gibt es die "Keine
+// offenen Strafen"-Zeile. Den Kassenstand gibt es erst, wenn das Kassenbuch
+// benutzt wird.
+func StrafenBlock(entries []penalty.Entry, kasse penalty.Kasse, asOf time.Time) string {
 	var visible []penalty.Entry
 	for _, e := range entries {
 		if penalty.VisibleAt(e, asOf) {
@@ -171,28 +173,42 @@ func StrafenBlock(entries []penalty.Entry, asOf time.Time) string {
 	b.WriteString("── 💸 *STRAFEN* ──\n")
 	if len(visible) == 0 {
 		b.WriteString("\n_Keine offenen Strafen_ 🎉")
-		return b.String()
 	}
 	for _, e := range visible {
 		b.WriteString("\n")
 		b.WriteString(strafenLine(e))
 	}
+	if kasse.Buchungen > 0 {
+		b.WriteString(fmt.Sprintf("\n\n💰 *Kasse:* %d€", kasse.Stand()))
+	}
 	return b.String()
 }
 
 // strafenLine baut die Zeile einer Strafe; die Klammer weist die Strafart aus.
 func strafenLine(e penalty.Entry) string {
-	var grund string
-	switch e.Art {
-	case penalty.ArtNoShow:
-		grund = fmt.Sprintf("nicht abgemeldet, %s", fmtDate(e.Datum))
-	default:
-		grund = fmt.Sprintf("%dx in Folge gefehlt", e.Tage)
-	}
+	grund := strafeGrund(e)
 	if e.Status == penalty.StatusBeglichen {
 		return fmt.Sprintf("✅ *%s* – %d€ beglichen (%s)", e.Name, e.Betrag, grund)
 	}
-	return fmt.Sprintf("⚠️ *%s* – %d€ (%s)", e.Name, e.Betrag, grund)
+	return fmt.Sprintf("⚠️ *%s* – %d€ (%s%s%s)", e.Name, e.Rest(), grund, teilzahlung(e), einspruch(e))
+}
+
+// teilzahlung ergänzt die Klammer einer offenen, schon teilweise bezahlten
+// Strafe.
+func teilzahlung(e penalty.Entry) string {
+	if e.Bezahlt == 0 {
+		return ""
+	}
+	return fmt.Sprintf("; %d€ von %d€ bezahlt", e.Bezahlt, e.Betrag)
+}
+
+// einspruch markiert in der Klammer eine offene Strafe, über deren Einspruch
+// noch nicht entschieden ist.
+func einspruch(e penalty.Entry) string {
+	if !e.Einspruch.Offen() {
+		return ""
+	}
+	return "; ⚖️ Einspruch"
 }
 
 // fmtDate rendert "12.3." (DE, ohne führende Nullen).
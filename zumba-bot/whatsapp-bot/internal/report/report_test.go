@@ -96,7 +96,7 @@ func TestBuildWithStrafenPlatzierung(t *testing.T) {
 		{Name: "Carl", Art: penalty.ArtNoShow, Betrag: 50, Status: penalty.StatusOffen,
 			Datum: time.Date(2026, 7, 23, 0, 0, 0, 0, time.UTC)},
 	}
-	got := BuildWithStrafen(rows, StrafenBlock(entries, asOf))
+	got := BuildWithStrafen(rows, StrafenBlock(entries, penalty.Kasse{}, asOf))
 
 	blockIdx := strings.Index(got, "── 💸 *STRAFEN* ──")
 	rangIdx := strings.Index(got, "── *RANGLISTE* ──")
@@ -118,7 +118,7 @@ func TestBuildWithStrafenPlatzierung(t *testing.T) {
 }
 
 func TestStrafenBlockLeer(t *testing.T) {
-	block := StrafenBlock(nil, time.Date(2026, 7, 30, 0, 0, 0, 0, time.UTC))
+	block := StrafenBlock(nil, penalty.Kasse{}, time.Date(2026, 7, 30, 0, 0, 0, 0, time.UTC))
 	if !strings.Contains(block, "_Keine offenen Strafen_") {
 		t.Errorf("Leermeldung fehlt: %q", block)
 	}
@@ -129,12 +129,57 @@ func TestStrafenBlockBeglichenNurImFenster(t *testing.T) {
 	e := penalty.Entry{Name: "Dora", Art: penalty.ArtFehltage, Tage: 5, Betrag: 25,
 		Status: penalty.StatusBeglichen, BeglichenAm: &beglichen}
 
-	inWindow := StrafenBlock([]penalty.Entry{e}, time.Date(2026, 7, 30, 0, 0, 0, 0, time.UTC))
+	inWindow := StrafenBlock([]penalty.Entry{e}, penalty.Kasse{}, time.Date(2026, 7, 30, 0, 0, 0, 0, time.UTC))
 	if !strings.Contains(inWindow, "✅ *Dora* – 25€ beglichen (5x in Folge gefehlt)") {
 		t.Errorf("beglichene Strafe fehlt am Folgedonnerstag: %q", inWindow)
 	}
-	after := StrafenBlock([]penalty.Entry{e}, time.Date(2026, 7, 31, 0, 0, 0, 0, time.UTC))
+	after := StrafenBlock([]penalty.Entry{e}, penalty.Kasse{}, time.Date(2026, 7, 31, 0, 0, 0, 0, time.UTC))
 	if strings.Contains(after, "Dora") {
 		t.Errorf("beglichene Strafe nach dem Folgedonnerstag noch sichtbar: %q", after)
 	}
 }
+
+func TestStrafenBlockTeilzahlungUndKasse(t *testing.T) {
+	asOf := time.Date(2026, 7, 30, 0, 0, 0, 0, time.UTC)
+	entries := []penalty.Entry{
+		{Name: "Ben", Art: penalty.ArtFehltage, Tage: 6, Betrag: 30, Bezahlt: 10, Status: penalty.StatusOffen},
+	}
+	block := StrafenBlock(entries, penalty.Kasse{Einnahmen: 85, Ausgaben: 40, Buchungen: 4}, asOf)
+	for _, want := range []string{
+		"⚠️ *Ben* – 20€ (6x in Folge gefehlt; 10€ von 30€ bezahlt)",
+		"💰 *Kasse:* 45€",
+	} {
+		if !strings.Contains(block, want) {
+			t.Errorf("Zeile fehlt: %q\n%s", want, block)
+		}
+	}
+	if strings.Contains(StrafenBlock(entries, penalty.Kasse{}, asOf), "Kasse") {
+		t.Error("Kassenstand ohne Buchungen ausgewiesen")
+	}
+}
+
+func TestStrafenBlockEinspruch(t *testing.T) {
+	asOf := time.Date(2026, 7, 30, 0, 0, 0, 0, time.UTC)
+	entries := []penalty.Entry{{
+		Name: "Ben", Art: penalty.ArtNoShow, Datum: time.Date(2026, 7, 23, 0, 0, 0, 0, time.UTC), Betrag: 50,
+		Status: penalty.StatusOffen, Einspruch: penalty.Einspruch{Status: penalty.EinspruchOffen},
+	}}
+	if block, want := StrafenBlock(entries, penalty.Kasse{}, asOf), "⚠️ *Ben* – 50€ (nicht abgemeldet, 23.7.; ⚖️ Einspruch)"; !strings.Contains(block, want) {
+		t.Errorf("Zeile fehlt: %q\n%s", want, block)
+	}
+	entries[0].Einspruch.Status = penalty.EinspruchBestaetigt
+	if strings.Contains(StrafenBlock(entries, penalty.Kasse{}, asOf), "Einspruch") {
+		t.Error("entschiedener Einspruch weiter markiert")
+	}
+}
+
+func TestStrafenBlockMahngebuehr(t *testing.T) {
+	asOf := time.Date(2026, 7, 30, 0, 0, 0, 0, time.UTC)
+	entries := []penalty.Entry{{
+		Name: "Ben", Art: penalty.ArtMahngebuehr, Datum: time.Date(2026, 7, 23, 0, 0, 0, 0, time.UTC), Betrag: 5,
+		Status: penalty.StatusOffen,
+	}}
+	if block, want := StrafenBlock(entries, penalty.Kasse{}, asOf), "⚠️ *Ben* – 5€ (Mahngebühr, 23.7.)"; !strings.Contains(block, want) {
+		t.Errorf("Zeile fehlt: %q\n%s", want, block)
+	}
+}
@@ -0,0 +1,112 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/michael/zumba-shared/domain"
+	sharedstore "github.com/michael/zumba-shared/store"
+)
+
+// RosterCounts zählt die Teilnehmerliste aus. Erwartet werden alle, die nicht
+// abgesagt haben (Anwesenheit ist der Default).
+type RosterCounts struct {
+	Zugesagt, Offen, Abgesagt int
+}
+
+func (c RosterCounts) Erwartet() int { return c.Zugesagt + c.Offen }
+
+// CountRoster zählt die Einträge je Status.
+func CountRoster(entries []sharedstore.RosterEntry) RosterCounts {
+	var c RosterCounts
+	for _, e := range entries {
+		switch e.Status {
+		case sharedstore.RosterZugesagt:
+			c.Zugesagt++
+		case sharedstore.RosterAbgesagt:
+			c.Abgesagt++
+		default:
+			c.Offen++
+		}
+	}
+	return c
+}
+
+// BuildRoster erzeugt den WhatsApp-Text für "wer kommt": Zusagen, Mitglieder
+// ohne Rückmeldung und Absagen (mit ihrem Text) für das Treffen am Tag date.
+func BuildRoster(date time.Time, entries []sharedstore.RosterEntry) string {
+	c := CountRoster(entries)
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🙋 *WER KOMMT?* – %s, %s\n", domain.WeekdayNameDE(date.Weekday()), date.Format("02.01.")))
+	b.WriteString(fmt.Sprintf("🍺 Erwartet: *%d* (davon %d fest zugesagt)\n", c.Erwartet(), c.Zugesagt))
+
+	names := func(st sharedstore.RosterStatus) string {
+		var out []string
+		for _, e := range entries {
+			if e.Status == st {
+				out = append(out, e.UserName)
+			}
+		}
+		return strings.Join(out, ", ")
+	}
+	if c.Zugesagt > 0 {
+		b.WriteString(fmt.Sprintf("\n✅ *Zugesagt (%d)*\n%s\n", c.Zugesagt, names(sharedstore.RosterZugesagt)))
+	}
+	if c.Offen > 0 {
+		b.WriteString(fmt.Sprintf("\n🤷 *Keine Rückmeldung (%d)*\n%s\n", c.Offen, names(sharedstore.RosterOffen)))
+	}
+	writeAbsagen(&b, entries, c.Abgesagt)
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// BuildMissing erzeugt den WhatsApp-Text für "wer fehlt": nur Absagen (mit
+// ihrem Text) und Mitglieder ohne Rückmeldung für das Treffen am Tag date.
+func BuildMissing(date time.Time, entries []sharedstore.RosterEntry) string {
+	c := CountRoster(entries)
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🕵️ *WER FEHLT?* – %s, %s\n", domain.WeekdayNameDE(date.Weekday()), date.Format("02.01.")))
+	if c.Abgesagt+c.Offen == 0 {
+		b.WriteString("\nNiemand – alle haben zugesagt. 🍻")
+		return b.String()
+	}
+	writeAbsagen(&b, entries, c.Abgesagt)
+	if c.Offen > 0 {
+		var out []string
+		for _, e := range entries {
+			if e.Status == sharedstore.RosterOffen {
+				out = append(out, e.UserName)
+			}
+		}
+		b.WriteString(fmt.Sprintf("\n🤷 *Keine Rückmeldung (%d)*\n%s\n", c.Offen, strings.Join(out, ", ")))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// writeAbsagen hängt den Absagen-Block (n Absagen, je mit gekürztem Text) an.
+func writeAbsagen(b *strings.Builder, entries []sharedstore.RosterEntry, n int) {
+	if n == 0 {
+		return
+	}
+	b.WriteString(fmt.Sprintf("\n❌ *Abgesagt (%d)*", n))
+	for _, e := range entries {
+		if e.Status != sharedstore.RosterAbgesagt {
+			continue
+		}
+		b.WriteString("\n• " + e.UserName)
+		if msg := strings.TrimSpace(e.Message); msg != "" {
+			b.WriteString(" – _„" + shorten(msg, 60) + "“_")
+		}
+	}
+	b.WriteString("\n")
+}
+
+// shorten kürzt s auf höchstens n Zeichen (Runen) mit "…".
+func shorten(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
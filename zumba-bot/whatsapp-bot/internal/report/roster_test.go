@@ -0,0 +1,47 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	sharedstore "github.com/michael/zumba-shared/store"
+)
+
+func TestBuildRosterKuerztAbsagen(t *testing.T) {
+	entries := []sharedstore.RosterEntry{
+		{UserName: "Didi", Status: sharedstore.RosterAbgesagt, Message: strings.Repeat("sehr lange Ausrede ", 10)},
+		{UserName: "Emil", Status: sharedstore.RosterAbgesagt},
+	}
+	got := BuildRoster(time.Date(2026, 8, 6, 0, 0, 0, 0, time.UTC), entries)
+	for _, want := range []string{"Donnerstag, 06.08.", "Erwartet: *0*", "❌ *Abgesagt (2)*", "…“_", "\n• Emil"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Text ohne %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Zugesagt") || strings.Contains(got, "Keine Rückmeldung") {
+		t.Errorf("leere Gruppen dürfen nicht erscheinen:\n%s", got)
+	}
+}
+
+func TestBuildMissingNurAbsagenUndOffene(t *testing.T) {
+	day := time.Date(2026, 8, 6, 0, 0, 0, 0, time.UTC)
+	entries := []sharedstore.RosterEntry{
+		{UserName: "Anna", Status: sharedstore.RosterZugesagt, Message: "bin dabei"},
+		{UserName: "Börni", Status: sharedstore.RosterOffen},
+		{UserName: "Chris", Status: sharedstore.RosterAbgesagt, Message: "krank"},
+	}
+	got := BuildMissing(day, entries)
+	for _, want := range []string{"WER FEHLT?* – Donnerstag, 06.08.", "❌ *Abgesagt (1)*\n• Chris – _„krank“_", "🤷 *Keine Rückmeldung (1)*\nBörni"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Text ohne %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Anna") || strings.Contains(got, "Zugesagt") {
+		t.Errorf("Zusagen gehören nicht zu „wer fehlt“:\n%s", got)
+	}
+
+	if got := BuildMissing(day, entries[:1]); !strings.Contains(got, "alle haben zugesagt") {
+		t.Errorf("ohne Fehlende:\n%s", got)
+	}
+}
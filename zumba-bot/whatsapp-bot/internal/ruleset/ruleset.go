@@ -0,0 +1,89 @@
+// Package ruleset hält die eingeschalteten Regeln der Vorklassifikation
+// (classifier_rule, gepflegt im Admin-UI) im Speicher und zählt Treffer.
+// Neu geladen wird periodisch – eine eingeschaltete Regel wirkt also nach
+// spätestens einem Intervall.
+package ruleset
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/michael/zumba-shared/rules"
+	sharedstore "github.com/michael/zumba-shared/store"
+)
+
+// Engine prüft Nachrichten gegen die zuletzt geladenen Regeln.
+type Engine struct {
+	db *sql.DB
+
+	mu  sync.RWMutex
+	set *rules.Set
+}
+
+func New(db *sql.DB) *Engine {
+	return &Engine{db: db}
+}
+
+// EnsureSchema legt classifier_rule idempotent an (geteilte DDL im
+// shared-Modul; das Admin-UI ruft dieselbe Funktion).
+func (e *Engine) EnsureSchema(ctx context.Context) error {
+	return sharedstore.EnsureRuleSchema(ctx, e.db)
+}
+
+// Reload lädt die eingeschalteten Regeln und liefert deren Anzahl. Ungültige
+// Regeln werden übersprungen (und geloggt), der Rest gilt weiter.
+func (e *Engine) Reload(ctx context.Context) (int, error) {
+	all, err := sharedstore.ListRules(ctx, e.db)
+	if err != nil {
+		return 0, err
+	}
+	var enabled []rules.Rule
+	for _, r := range all {
+		if r.Enabled {
+			enabled = append(enabled, r)
+		}
+	}
+	set, errs := rules.Compile(enabled)
+	for _, err := range errs {
+		log.Printf("⚠️  ruleset: %v (übersprungen)", err)
+	}
+	e.mu.Lock()
+	e.set = set
+	e.mu.Unlock()
+	return set.Len(), nil
+}
+
+// Match liefert die erste passende eingeschaltete Regel (nil = keine).
+func (e *Engine) Match(text string) *rules.Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.set.Match(text)
+}
+
+// Hit zählt einen Treffer (hits, last_hit_at) für die Anzeige im Admin-UI.
+func (e *Engine) Hit(ctx context.Context, id int64) error {
+	return sharedstore.RecordRuleHit(ctx, e.db, id)
+}
+
+// Run lädt die Regeln – sofort und dann alle every, bis ctx endet.
+func (e *Engine) Run(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	last := -1
+	for {
+		if n, err := e.Reload(ctx); err != nil {
+			log.Printf("⚠️  ruleset: %v", err)
+		} else if n != last {
+			log.Printf("📏 Vorklassifikation: %d Regel(n) aktiv", n)
+			last = n
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
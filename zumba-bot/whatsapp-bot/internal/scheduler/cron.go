@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spec ist ein geparster Cron-Ausdruck im klassischen 5-Felder-Format
+// "Minute Stunde Tag Monat Wochentag" (wie der frühere k8s-CronJob, z. B.
+// "0 21 * * 4" = donnerstags 21:00). Unterstützt *, Werte, Bereiche (1-5),
+// Listen (1,3) und Schritte (*/15, 8-20/2); Wochentag 0 und 7 = Sonntag.
+type Spec struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64 // Bitmasken der erlaubten Werte
+	domAny, dowAny                bool   // Feld war "*" (für die Tag-ODER-Regel)
+}
+
+// ParseCron parst expr.
+func ParseCron(expr string) (Spec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Spec{}, fmt.Errorf("cron %q: 5 Felder erwartet (Minute Stunde Tag Monat Wochentag)", expr)
+	}
+	s := Spec{expr: strings.Join(fields, " ")}
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return Spec{}, fmt.Errorf("cron %q Minute: %w", expr, err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return Spec{}, fmt.Errorf("cron %q Stunde: %w", expr, err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return Spec{}, fmt.Errorf("cron %q Tag: %w", expr, err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return Spec{}, fmt.Errorf("cron %q Monat: %w", expr, err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return Spec{}, fmt.Errorf("cron %q Wochentag: %w", expr, err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 = Sonntag = 0
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return s, nil
+}
+
+func (s Spec) String() string { return s.expr }
+
+// parseField liefert die Bitmaske eines Felds mit Werten aus [lo, hi].
+func parseField(f string, lo, hi int) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(f, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("ungültiger Schritt %q", part)
+			}
+			step = n
+		}
+		from, to := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("ungültiger Wert %q", part)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("ungültiger Bereich %q", part)
+				}
+			} else if hasStep {
+				to = hi // "5/15" = ab 5 alle 15
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return 0, fmt.Errorf("%q außerhalb %d-%d", part, lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			mask |= 1 << v
+		}
+	}
+	return mask, nil
+}
+
+// dayMatches wendet die Cron-Regel für Tag/Wochentag an: sind beide
+// eingeschränkt, genügt eins von beiden ("1 * 1" = am 1. ODER montags).
+func (s Spec) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// maxLookahead begrenzt die Suche (ein "31 2"-Ausdruck trifft nie).
+const maxLookahead = 5 * 366
+
+// Next liefert den ersten Zeitpunkt echt nach after, in loc gerechnet.
+// Zeiten, die es wegen der Sommerzeit-Umstellung nicht gibt (02:30 im
+// März), entfallen; ohne Treffer in fünf Jahren kommt die Nullzeit.
+func (s Spec) Next(after time.Time, loc *time.Location) time.Time {
+	after = after.In(loc)
+	day := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, loc)
+	for i := 0; i < maxLookahead; i++ {
+		d := day.AddDate(0, 0, i)
+		if s.month&(1<<int(d.Month())) == 0 || !s.dayMatches(d) {
+			continue
+		}
+		for h := 0; h < 24; h++ {
+			if s.hour&(1<<h) == 0 {
+				continue
+			}
+			for m := 0; m < 60; m++ {
+				if s.minute&(1<<m) == 0 {
+					continue
+				}
+				t := time.Date(d.Year(), d.Month(), d.Day(), h, m, 0, 0, loc)
+				if t.Hour() != h || t.Minute() != m || !t.After(after) {
+					continue
+				}
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
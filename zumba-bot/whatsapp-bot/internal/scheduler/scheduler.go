@@ -0,0 +1,353 @@
+// Package scheduler führt zeitgesteuerte Jobs im Bot selbst aus (bisher ein
+// k8s-CronJob, der /weekly-report aufrief). Die Job-Definitionen (Cron,
+// Payload) und der nächste Termin liegen in bot_job, jeder Lauf in
+// bot_job_run. Verpasst der Bot einen Termin (Pod-Neustart um 21:00), holt er
+// ihn nach dem Start nach, solange der Termin nicht älter als das
+// Nachhol-Fenster ist. Eine Lease-Spalte sorgt dafür, dass bei mehreren
+// Replicas nur eine den Job ausführt.
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Handler führt einen Job aus. detail landet in der Lauf-Historie
+// ("an Gruppe gesendet"); ein Fehler lässt den Lauf scheitern und wird
+// wiederholt.
+type Handler func(ctx context.Context, payload json.RawMessage) (detail string, err error)
+
+// Job ist die Definition eines zeitgesteuerten Jobs.
+type Job struct {
+	Name    string          // eindeutig, zugleich der Handler ("wochenreport")
+	Cron    string          // 5-Felder-Cron, gerechnet in der Zeitzone des Schedulers
+	Payload json.RawMessage // Parameter für den Handler (JSON, optional)
+	Enabled bool
+	// CatchUp: ein verpasster Termin wird nachgeholt, solange er höchstens
+	// so alt ist; sonst verfällt er (Lauf "skipped"). 0 = DefaultCatchUp.
+	CatchUp time.Duration
+}
+
+const (
+	// DefaultCatchUp: wie outbox.MaxAge – ein Report vom Vorabend ist am
+	// Mittag noch sinnvoll, drei Tage später nicht mehr.
+	DefaultCatchUp = 12 * time.Hour
+
+	// MaxAttempts begrenzt die Versuche je Termin; danach gilt der Lauf als
+	// gescheitert und der nächste Termin laut Cron zählt.
+	MaxAttempts = 3
+
+	// lease hält einen laufenden Job von anderen Replicas fern. Stirbt der
+	// Pod mitten im Lauf, übernimmt nach Ablauf die nächste Replica.
+	lease = 10 * time.Minute
+	// runTimeout begrenzt einen Lauf (Renderer bis 90s, Outbox-Schreiben).
+	runTimeout = 5 * time.Minute
+	retryDelay = time.Minute
+)
+
+// Scheduler schreibt bot_job/bot_job_run in die zumba-DB.
+type Scheduler struct {
+	db     *sql.DB
+	loc    *time.Location
+	runner string // Pod-Name in der Historie und im Lock
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	specs    map[string]Spec
+
+	// Now ist überschreibbar für Tests.
+	Now func() time.Time
+}
+
+// New legt einen Scheduler an; Cron-Ausdrücke gelten in loc (config.Location).
+func New(db *sql.DB, loc *time.Location) *Scheduler {
+	runner, err := os.Hostname()
+	if err != nil || runner == "" {
+		runner = "whatsapp-bot"
+	}
+	return &Scheduler{
+		db: db, loc: loc, runner: runner,
+		handlers: map[string]Handler{}, specs: map[string]Spec{},
+		Now: time.Now,
+	}
+}
+
+const schemaSQL = `
+CREATE TABLE IF NOT EXISTS bot_job (
+  name             TEXT PRIMARY KEY,
+  cron             TEXT NOT NULL,
+  payload          JSONB NOT NULL DEFAULT '{}',
+  enabled          BOOLEAN NOT NULL DEFAULT true,
+  catch_up_seconds INT NOT NULL,
+  next_run_at      TIMESTAMPTZ NOT NULL,
+  retry_at         TIMESTAMPTZ,
+  attempts         INT NOT NULL DEFAULT 0,
+  locked_by        TEXT,
+  locked_until     TIMESTAMPTZ,
+  updated_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS bot_job_run (
+  id            BIGSERIAL PRIMARY KEY,
+  job           TEXT NOT NULL,
+  scheduled_for TIMESTAMPTZ NOT NULL,
+  started_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  finished_at   TIMESTAMPTZ,
+  status        TEXT NOT NULL,
+  attempt       INT NOT NULL DEFAULT 1,
+  runner        TEXT NOT NULL DEFAULT '',
+  detail        TEXT NOT NULL DEFAULT '',
+  error         TEXT
+);
+CREATE INDEX IF NOT EXISTS bot_job_run_job_idx ON bot_job_run (job, id DESC);`
+
+// EnsureSchema legt die Tabellen idempotent an (beim Start aufgerufen).
+func (s *Scheduler) EnsureSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, schemaSQL)
+	return err
+}
+
+// registerSQL schreibt die Definition. Der nächste Termin bleibt stehen,
+// solange sich der Cron nicht ändert – sonst ginge ein während des Neustarts
+// verpasster Termin verloren.
+const registerSQL = `
+INSERT INTO bot_job (name, cron, payload, enabled, catch_up_seconds, next_run_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (name) DO UPDATE SET
+  next_run_at = CASE WHEN bot_job.cron = EXCLUDED.cron THEN bot_job.next_run_at ELSE EXCLUDED.next_run_at END,
+  retry_at    = CASE WHEN bot_job.cron = EXCLUDED.cron THEN bot_job.retry_at END,
+  attempts    = CASE WHEN bot_job.cron = EXCLUDED.cron THEN bot_job.attempts ELSE 0 END,
+  cron = EXCLUDED.cron, payload = EXCLUDED.payload, enabled = EXCLUDED.enabled,
+  catch_up_seconds = EXCLUDED.catch_up_seconds, updated_at = now()`
+
+// Register legt den Job an bzw. aktualisiert seine Definition und hinterlegt
+// den Handler. Ein ungültiger Cron ist ein Fehler.
+func (s *Scheduler) Register(ctx context.Context, j Job, h Handler) error {
+	spec, err := ParseCron(j.Cron)
+	if err != nil {
+		return fmt.Errorf("Register(%s): %w", j.Name, err)
+	}
+	if j.CatchUp <= 0 {
+		j.CatchUp = DefaultCatchUp
+	}
+	payload := j.Payload
+	if len(payload) == 0 {
+		payload = json.RawMessage(`{}`)
+	}
+	next := spec.Next(s.Now(), s.loc)
+	if next.IsZero() {
+		return fmt.Errorf("Register(%s): cron %q trifft nie", j.Name, j.Cron)
+	}
+	if _, err := s.db.ExecContext(ctx, registerSQL,
+		j.Name, spec.String(), []byte(payload), j.Enabled, int(j.CatchUp.Seconds()), next); err != nil {
+		return fmt.Errorf("Register(%s): %w", j.Name, err)
+	}
+	s.mu.Lock()
+	s.handlers[j.Name] = h
+	s.specs[j.Name] = spec
+	s.mu.Unlock()
+	return nil
+}
+
+// claimSQL reserviert fällige Jobs dieser Replica (nur solche mit Handler)
+// per Lease; FOR UPDATE SKIP LOCKED + locked_until halten andere Replicas fern.
+const claimSQL = `
+UPDATE bot_job SET locked_by = $1, locked_until = now() + $2 * interval '1 second',
+                   attempts = attempts + 1, updated_at = now()
+WHERE name IN (
+  SELECT name FROM bot_job
+  WHERE enabled AND name = ANY($3)
+    AND COALESCE(retry_at, next_run_at) <= now()
+    AND (locked_until IS NULL OR locked_until < now())
+  FOR UPDATE SKIP LOCKED)
+RETURNING name, payload, catch_up_seconds, next_run_at, attempts`
+
+type claimed struct {
+	name     string
+	payload  json.RawMessage
+	catchUp  time.Duration
+	slot     time.Time // der Termin, für den gelaufen wird
+	attempts int
+}
+
+const retentionSQL = `DELETE FROM bot_job_run WHERE started_at < now() - interval '90 days'`
+
+// Tick führt alle fälligen Jobs aus und liefert die Anzahl der Läufe.
+func (s *Scheduler) Tick(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.handlers))
+	for n := range s.handlers {
+		names = append(names, n)
+	}
+	s.mu.Unlock()
+	if len(names) == 0 {
+		return 0, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, claimSQL, s.runner, lease.Seconds(), pq.Array(names))
+	if err != nil {
+		return 0, fmt.Errorf("Tick: %w", err)
+	}
+	var due []claimed
+	for rows.Next() {
+		var (
+			c       claimed
+			payload []byte
+			catchUp int
+		)
+		if err := rows.Scan(&c.name, &payload, &catchUp, &c.slot, &c.attempts); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("Tick: %w", err)
+		}
+		c.payload = payload
+		c.catchUp = time.Duration(catchUp) * time.Second
+		due = append(due, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("Tick: %w", err)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].slot.Before(due[j].slot) })
+
+	for _, c := range due {
+		s.run(ctx, c)
+	}
+	_, _ = s.db.ExecContext(ctx, retentionSQL) // best-effort
+	return len(due), nil
+}
+
+// run führt einen reservierten Job aus und hält Lauf und nächsten Termin
+// fest. Die Buchführung läuft auch beim Shutdown noch (WithoutCancel), sonst
+// bliebe der Job bis zum Lease-Ende gesperrt.
+func (s *Scheduler) run(ctx context.Context, c claimed) {
+	bg := context.WithoutCancel(ctx)
+	s.mu.Lock()
+	h, spec := s.handlers[c.name], s.specs[c.name]
+	s.mu.Unlock()
+	now := s.Now()
+
+	if Missed(c.slot, now, c.catchUp) {
+		window := fmt.Sprintf("%gh", c.catchUp.Hours())
+		log.Printf("⏰ Job %s: Termin %s verpasst (älter als %s) – übersprungen",
+			c.name, c.slot.In(s.loc).Format("02.01. 15:04"), window)
+		s.record(bg, c, "skipped", "verpasst, Nachhol-Fenster "+window+" überschritten", "")
+		s.release(bg, c.name, spec.Next(now, s.loc), nil)
+		return
+	}
+
+	var runID int64
+	if err := s.db.QueryRowContext(bg,
+		`INSERT INTO bot_job_run (job, scheduled_for, status, attempt, runner) VALUES ($1, $2, 'running', $3, $4) RETURNING id`,
+		c.name, c.slot, c.attempts, s.runner).Scan(&runID); err != nil {
+		log.Printf("⚠️  scheduler: %v", err)
+	}
+
+	rctx, cancel := context.WithTimeout(bg, runTimeout)
+	detail, err := safeRun(rctx, h, c.payload)
+	cancel()
+
+	status, errText := "ok", ""
+	if err != nil {
+		status, errText = "failed", err.Error()
+	}
+	if runID != 0 {
+		if _, uerr := s.db.ExecContext(bg,
+			`UPDATE bot_job_run SET status = $2, finished_at = now(), detail = $3, error = NULLIF($4, '') WHERE id = $1`,
+			runID, status, detail, errText); uerr != nil {
+			log.Printf("⚠️  scheduler: %v", uerr)
+		}
+	}
+
+	next, retryAt := Plan(spec, c.slot, s.Now(), c.attempts, err != nil, s.loc)
+	switch {
+	case err == nil:
+		log.Printf("⏰ Job %s gelaufen (%s) – nächster Termin %s", c.name, detail, next.In(s.loc).Format("02.01. 15:04"))
+	case retryAt != nil:
+		log.Printf("⚠️  Job %s Versuch %d fehlgeschlagen: %v – nächster Versuch um %s", c.name, c.attempts, err, retryAt.In(s.loc).Format("15:04"))
+	default:
+		log.Printf("⚠️  Job %s nach %d Versuchen aufgegeben: %v", c.name, c.attempts, err)
+	}
+	s.release(bg, c.name, next, retryAt)
+}
+
+// safeRun ruft h auf; ein Panic im Handler wird zum Fehler des Laufs statt
+// den Bot zu beenden.
+func safeRun(ctx context.Context, h Handler, payload json.RawMessage) (detail string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	if h == nil {
+		return "", fmt.Errorf("kein Handler registriert")
+	}
+	return h(ctx, payload)
+}
+
+// record schreibt einen Lauf ohne Ausführung (übersprungen).
+func (s *Scheduler) record(ctx context.Context, c claimed, status, detail, errText string) {
+	if _, err := s.db.ExecContext(ctx,
+		`INSERT INTO bot_job_run (job, scheduled_for, finished_at, status, attempt, runner, detail, error)
+		 VALUES ($1, $2, now(), $3, $4, $5, $6, NULLIF($7, ''))`,
+		c.name, c.slot, status, c.attempts, s.runner, detail, errText); err != nil {
+		log.Printf("⚠️  scheduler: %v", err)
+	}
+}
+
+// release gibt den Lock frei und setzt den nächsten Termin bzw. Retry.
+func (s *Scheduler) release(ctx context.Context, name string, next time.Time, retryAt *time.Time) {
+	const q = `
+		UPDATE bot_job
+		SET next_run_at = $2, retry_at = $3,
+		    attempts = CASE WHEN $3::timestamptz IS NULL THEN 0 ELSE attempts END,
+		    locked_by = NULL, locked_until = NULL, updated_at = now()
+		WHERE name = $1`
+	if _, err := s.db.ExecContext(ctx, q, name, next, retryAt); err != nil {
+		log.Printf("⚠️  scheduler: %v", err)
+	}
+}
+
+// Missed meldet, ob der Termin slot zu alt zum Nachholen ist.
+func Missed(slot, now time.Time, catchUp time.Duration) bool {
+	return now.Sub(slot) > catchUp
+}
+
+// Plan bestimmt nach einem Lauf für den Termin slot den nächsten Stand: nach
+// einem Fehler mit Restversuchen ein Retry desselben Termins (1, 2, … Minuten
+// später), sonst der nächste Termin laut Cron nach now. Mehrere verpasste
+// Termine fallen so zu einem Nachhol-Lauf zusammen.
+func Plan(spec Spec, slot, now time.Time, attempts int, failed bool, loc *time.Location) (next time.Time, retryAt *time.Time) {
+	if failed && attempts < MaxAttempts {
+		r := now.Add(time.Duration(attempts) * retryDelay)
+		return slot, &r
+	}
+	return spec.Next(now, loc), nil
+}
+
+// Run ruft Tick im Abstand every auf, bis ctx endet. Ein laufender Job wird
+// dabei noch zu Ende geführt; Run kehrt erst danach zurück.
+func (s *Scheduler) Run(ctx context.Context, every time.Duration) {
+	if _, err := s.Tick(ctx); err != nil { // verpasste Termine gleich beim Start
+		log.Printf("⚠️  scheduler: %v", err)
+	}
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if _, err := s.Tick(ctx); err != nil {
+				log.Printf("⚠️  scheduler: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func berlin(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("tzdata fehlt: %v", err)
+	}
+	return loc
+}
+
+func TestParseCronUngueltig(t *testing.T) {
+	for _, expr := range []string{"", "0 21 * *", "60 21 * * 4", "0 24 * * 4", "0 21 * * 8", "0 21 0 * *", "*/0 * * * *", "5-1 * * * *", "x 21 * * 4"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q): Fehler erwartet", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	loc := berlin(t)
+	at := func(s string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	cases := []struct {
+		expr, after, want string
+	}{
+		{"0 21 * * 4", "2026-08-06 20:59", "2026-08-06 21:00"}, // Donnerstag
+		{"0 21 * * 4", "2026-08-06 21:00", "2026-08-13 21:00"}, // echt danach
+		{"*/15 8-9 * * *", "2026-08-06 09:50", "2026-08-07 08:00"},
+		{"30 7 1 * *", "2026-08-06 00:00", "2026-09-01 07:30"},
+		{"0 12 1 * 1", "2026-08-06 13:00", "2026-08-10 12:00"}, // Tag ODER Wochentag
+		{"0 9 * * 7", "2026-08-06 00:00", "2026-08-09 09:00"},  // 7 = Sonntag
+		{"30 2 * * *", "2026-03-28 12:00", "2026-03-30 02:30"}, // 29.3. 02:30 gibt es nicht
+	}
+	for _, c := range cases {
+		spec, err := ParseCron(c.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", c.expr, err)
+		}
+		if got := spec.Next(at(c.after), loc); !got.Equal(at(c.want)) {
+			t.Errorf("%q nach %s = %s, want %s", c.expr, c.after, got.Format("2006-01-02 15:04"), c.want)
+		}
+	}
+
+	never, _ := ParseCron("0 0 31 2 *")
+	if got := never.Next(at("2026-01-01 00:00"), loc); !got.IsZero() {
+		t.Errorf("31. Februar: %s, want Nullzeit", got)
+	}
+}
+
+func TestPlanUndNachholen(t *testing.T) {
+	loc := berlin(t)
+	spec, _ := ParseCron("0 21 * * 4")
+	slot := time.Date(2026, 8, 6, 21, 0, 0, 0, loc)
+
+	// Pod war um 21:00 weg, startet 21:20: Termin wird nachgeholt.
+	now := slot.Add(20 * time.Minute)
+	if Missed(slot, now, DefaultCatchUp) {
+		t.Error("20 Minuten zu spät: sollte nachgeholt werden")
+	}
+	if !Missed(slot, slot.Add(DefaultCatchUp+time.Minute), DefaultCatchUp) {
+		t.Error("nach Ablauf des Fensters: sollte verfallen")
+	}
+
+	// Erfolg: nächster Donnerstag, kein Retry.
+	next, retry := Plan(spec, slot, now, 1, false, loc)
+	if retry != nil || !next.Equal(slot.AddDate(0, 0, 7)) {
+		t.Errorf("Erfolg: next=%s retry=%v", next, retry)
+	}
+
+	// Fehler mit Restversuchen: derselbe Termin, Retry in attempts Minuten.
+	next, retry = Plan(spec, slot, now, 2, true, loc)
+	if retry == nil || !retry.Equal(now.Add(2*time.Minute)) || !next.Equal(slot) {
+		t.Errorf("Retry: next=%s retry=%v", next, retry)
+	}
+
+	// Letzter Versuch gescheitert: weiter mit dem nächsten Termin.
+	next, retry = Plan(spec, slot, now, MaxAttempts, true, loc)
+	if retry != nil || !next.Equal(slot.AddDate(0, 0, 7)) {
+		t.Errorf("aufgegeben: next=%s retry=%v", next, retry)
+	}
+
+	// Mehrere verpasste Termine fallen zu einem Lauf zusammen.
+	late := slot.AddDate(0, 0, 14).Add(time.Hour)
+	if next, _ := Plan(spec, slot, late, 1, false, loc); !next.Equal(slot.AddDate(0, 0, 21)) {
+		t.Errorf("nach langer Pause: next=%s", next)
+	}
+}
@@ -2,33 +2,31 @@
 // jede von Gemini klassifizierte Nachricht wird zusätzlich an den
 // classifier-service geschickt und beide Ergebnisse dauerhaft in ml_messages
 // gespeichert (keine Retention — die Tabelle ist zugleich der wachsende
-// Trainings-/Eval-Datenpool). Gemini entscheidet weiterhin allein; alles hier
-// ist best-effort und darf den Webhook-Flow nie ausbremsen oder brechen.
+// Trainings-/Eval-Datenpool). Jede Zeile trägt die Route der Entscheidung
+// (Shadow, Canary, Fallback), damit das Admin-UI Übereinstimmung und
+// Korrekturen je Route vergleichen kann. Alles hier ist best-effort und darf
+// den Webhook-Flow nie ausbremsen oder brechen.
 package shadow
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
 	"time"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
 )
 
 type Shadow struct {
-	db         *sql.DB
-	serviceURL string
-	client     *http.Client
+	db     *sql.DB
+	model  *classifier.Local
+	gemini classifier.Backend
 }
 
-func New(db *sql.DB, serviceURL string) *Shadow {
-	return &Shadow{
-		db:         db,
-		serviceURL: serviceURL,
-		client:     &http.Client{Timeout: 3 * time.Second},
-	}
+// New: gemini liefert im Canary (Modell entschied) das Vergleichs-Label.
+func New(db *sql.DB, model *classifier.Local, gemini classifier.Backend) *Shadow {
+	return &Shadow{db: db, model: model, gemini: gemini}
 }
 
 const schemaSQL = `
@@ -45,82 +43,121 @@ CREATE TABLE IF NOT EXISTS ml_messages (
   verified         BOOLEAN NOT NULL DEFAULT false,
   corrected_label  TEXT
 );
-CREATE INDEX IF NOT EXISTS ml_messages_created_idx ON ml_messages (created_at DESC);`
+CREATE INDEX IF NOT EXISTS ml_messages_created_idx ON ml_messages (created_at DESC);
+-- Route der Entscheidung (classifier.Route*; NULL = vor dem Canary = shadow).
+ALTER TABLE ml_messages ADD COLUMN IF NOT EXISTS route TEXT;
+
+-- Canary-Policy: schreibt der Bot beim Start aus der Config, killed setzt
+-- das Admin-UI (Kill-Switch). Genau eine Zeile.
+CREATE TABLE IF NOT EXISTS ml_route_policy (
+  id             INT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+  share          INT NOT NULL,
+  min_confidence DOUBLE PRECISION NOT NULL,
+  killed         BOOLEAN NOT NULL DEFAULT false,
+  killed_at      TIMESTAMPTZ,
+  updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);`
 
-// EnsureSchema legt ml_messages idempotent an (beim Start aufgerufen).
+// EnsureSchema legt ml_messages und ml_route_policy idempotent an (beim
+// Start aufgerufen).
 func (s *Shadow) EnsureSchema(ctx context.Context) error {
 	_, err := s.db.ExecContext(ctx, schemaSQL)
 	return err
 }
 
-// RecordAsync klassifiziert die Nachricht per classifier-service und schreibt
-// beide Label in ml_messages. Läuft komplett asynchron (eigener Kontext), damit
-// der Webhook-Handler nicht auf Service oder DB wartet.
-func (s *Shadow) RecordAsync(userID, userName, message, geminiLabel string) {
+// SavePolicy hinterlegt die konfigurierte Canary-Policy fürs Admin-UI; der
+// Kill-Switch bleibt, wie er ist.
+func (s *Shadow) SavePolicy(ctx context.Context, p classifier.Policy) error {
+	const q = `
+		INSERT INTO ml_route_policy (id, share, min_confidence) VALUES (1, $1, $2)
+		ON CONFLICT (id) DO UPDATE SET share = $1, min_confidence = $2, updated_at = now()`
+	if _, err := s.db.ExecContext(ctx, q, p.Share, p.MinConfidence); err != nil {
+		return fmt.Errorf("SavePolicy: %w", err)
+	}
+	return nil
+}
+
+// Killed liest den Kill-Switch (ohne Zeile: nicht gezogen).
+func (s *Shadow) Killed(ctx context.Context) (bool, error) {
+	var killed bool
+	err := s.db.QueryRowContext(ctx, `SELECT killed FROM ml_route_policy WHERE id = 1`).Scan(&killed)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("Killed: %w", err)
+	}
+	return killed, nil
+}
+
+// RecordAsync schreibt die Entscheidung c mit beiden Labels und ihrer Route
+// in ml_messages; die fehlende Seite wird nachgeholt (Modell, bzw. im Canary
+// Gemini). Läuft komplett asynchron (eigener Kontext), damit der
+// Webhook-Handler nicht auf Service oder DB wartet.
+func (s *Shadow) RecordAsync(userID, userName, message string, c classifier.Classification) {
 	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
 		defer cancel()
-		if err := s.record(ctx, userID, userName, message, geminiLabel); err != nil {
+		if err := s.record(ctx, userID, userName, message, c); err != nil {
 			log.Printf("⚠️  shadow: %v", err)
 		}
 	}()
 }
 
-type prediction struct {
-	Label      string  `json:"label"`
-	Confidence float64 `json:"confidence"`
-}
-
-func (s *Shadow) record(ctx context.Context, userID, userName, message, geminiLabel string) error {
-	// Modell-Ergebnis holen; bei Fehler trotzdem loggen (Nachricht + Gemini-Label
-	// sind als Trainingsdaten auch ohne Modell-Label wertvoll).
-	var modelLabel sql.NullString
+func (s *Shadow) record(ctx context.Context, userID, userName, message string, c classifier.Classification) error {
+	// Fehlt eine Seite (Dienst nicht erreichbar), wird trotzdem geloggt –
+	// Nachricht + ein Label sind als Trainingsdaten auch allein wertvoll.
+	var geminiLabel, modelLabel sql.NullString
 	var confidence sql.NullFloat64
 	var agree sql.NullBool
 
-	pred, err := s.classify(ctx, message)
-	if err != nil {
-		log.Printf("⚠️  shadow classify: %v", err)
+	route := c.Route
+	if c.Backend == classifier.BackendML {
+		// Das Modell hat entschieden: Gemini nur im Canary nachfragen (beim
+		// Fallback ist es ja gerade ausgefallen).
+		if route == "" {
+			route = classifier.RouteFallback
+		}
+		modelLabel = sql.NullString{String: c.Raw, Valid: true}
+		confidence = sql.NullFloat64{Float64: c.Confidence, Valid: true}
+		if route == classifier.RouteCanary && s.gemini != nil {
+			g, err := s.gemini.Classify(ctx, message)
+			if err != nil {
+				log.Printf("⚠️  shadow gemini: %v", err)
+			} else {
+				geminiLabel = sql.NullString{String: string(g.Result), Valid: true}
+			}
+		}
 	} else {
-		modelLabel = sql.NullString{String: pred.Label, Valid: true}
-		confidence = sql.NullFloat64{Float64: pred.Confidence, Valid: true}
-		agree = sql.NullBool{Bool: pred.Label == geminiLabel, Valid: true}
+		if route == "" {
+			route = classifier.RouteShadow
+		}
+		geminiLabel = sql.NullString{String: string(c.Result), Valid: true}
+		pred := c.Prediction
+		if pred == nil {
+			p, err := s.model.Predict(ctx, message)
+			if err != nil {
+				log.Printf("⚠️  shadow classify: %v", err)
+			} else {
+				pred = &p
+			}
+		}
+		if pred != nil {
+			modelLabel = sql.NullString{String: pred.Label, Valid: true}
+			confidence = sql.NullFloat64{Float64: pred.Confidence, Valid: true}
+		}
+	}
+	if geminiLabel.Valid && modelLabel.Valid {
+		agree = sql.NullBool{Bool: geminiLabel.String == modelLabel.String, Valid: true}
 	}
 
 	const q = `
 		INSERT INTO ml_messages
-		  (user_id, user_name, message, gemini_label, model_label, model_confidence, agree)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+		  (user_id, user_name, message, gemini_label, model_label, model_confidence, agree, route)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 	if _, err := s.db.ExecContext(ctx, q,
-		userID, userName, message, geminiLabel, modelLabel, confidence, agree); err != nil {
+		userID, userName, message, geminiLabel, modelLabel, confidence, agree, route); err != nil {
 		return fmt.Errorf("ml_messages insert: %w", err)
 	}
 	return nil
 }
-
-func (s *Shadow) classify(ctx context.Context, message string) (prediction, error) {
-	body, err := json.Marshal(map[string]string{"text": message})
-	if err != nil {
-		return prediction{}, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
-		s.serviceURL+"/classify", bytes.NewReader(body))
-	if err != nil {
-		return prediction{}, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return prediction{}, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return prediction{}, fmt.Errorf("classifier-service: HTTP %d", resp.StatusCode)
-	}
-	var pred prediction
-	if err := json.NewDecoder(resp.Body).Decode(&pred); err != nil {
-		return prediction{}, err
-	}
-	return pred, nil
-}
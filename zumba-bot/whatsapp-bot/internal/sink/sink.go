@@ -1,8 +1,9 @@
 // Package sink stellt alternative Sender-Implementierungen für lokales Testen
 // bereit: statt an die Evolution API zu senden, wird die erzeugte Nachricht
 // nach stdout oder in eine Datei geschrieben. Implementiert dasselbe Interface
-// wie evolution.Client (SendText/SendImage); Bilder landen als PNG-Datei auf
-// der Platte, im Text-Kanal steht nur ein Verweis darauf.
+// wie evolution.Client (SendText/SendImage/SendReaction); Bilder landen als
+// PNG-Datei auf der Platte, im Text-Kanal steht nur ein Verweis darauf,
+// Reaktionen als Einzeiler.
 package sink
 
 import (
@@ -11,6 +12,8 @@ import (
 	"io"
 	"os"
 	"sync"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/evolution"
 )
 
 func banner(number, text string) string {
@@ -46,6 +49,13 @@ func (s *Writer) SendImage(_ context.Context, number, caption string, png []byte
 	return err
 }
 
+func (s *Writer) SendReaction(_ context.Context, key evolution.MessageKey, emoji string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := io.WriteString(s.w, banner(key.RemoteJid, reactionNote(key, emoji)))
+	return err
+}
+
 // File hängt jede Nachricht an eine Datei an.
 type File struct {
 	mu   sync.Mutex
@@ -76,6 +86,10 @@ func (f *File) SendImage(ctx context.Context, number, caption string, png []byte
 	return f.SendText(ctx, number, imageNote(path, caption, png))
 }
 
+func (f *File) SendReaction(ctx context.Context, key evolution.MessageKey, emoji string) error {
+	return f.SendText(ctx, key.RemoteJid, reactionNote(key, emoji))
+}
+
 // dumpPNG legt das Bild als Temp-Datei ab, damit man es lokal anschauen kann.
 func dumpPNG(png []byte) (string, error) {
 	fh, err := os.CreateTemp("", "zumba-stats-*.png")
@@ -92,3 +106,7 @@ func dumpPNG(png []byte) (string, error) {
 func imageNote(path, caption string, png []byte) string {
 	return fmt.Sprintf("[PNG-Bild, %d Bytes → %s]\n%s", len(png), path, caption)
 }
+
+func reactionNote(key evolution.MessageKey, emoji string) string {
+	return fmt.Sprintf("[Reaktion %s auf Nachricht %s]", emoji, key.ID)
+}
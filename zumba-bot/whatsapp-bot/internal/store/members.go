@@ -0,0 +1,21 @@
+package store
+
+import (
+	"context"
+
+	sharedstore "github.com/michael/zumba-shared/store"
+)
+
+// Member ist ein Mitglied samt Spitznamen (shared-Typ).
+type Member = sharedstore.Member
+
+// EnsureMemberSchema legt user_alias, stammtisch_entschuldigt und
+// stammtisch_abwesenheit.entered_by idempotent an (geteilte DDL im shared-Modul, das Admin-UI ruft dieselbe
+// Funktion).
+func (s *Postgres) EnsureMemberSchema(ctx context.Context) error {
+	return sharedstore.EnsureMemberSchema(ctx, s.db)
+}
+
+func (s *Postgres) Members(ctx context.Context) ([]Member, error) {
+	return sharedstore.Members(ctx, s.db)
+}
@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MessageEffect hält fest, was eine Ab-/Zusage in stammtisch_abwesenheit
+// verändert hat – damit eine spätere Bearbeitung oder Löschung der
+// WhatsApp-Nachricht (verknüpft per Message-ID) genau das zurückdrehen kann.
+type MessageEffect struct {
+	MessageID      string
+	UserID         string
+	Message        string
+	Classification string
+	// SentOn ist der Verarbeitungstag der Original-Nachricht (Bezugstag für
+	// "nächste Woche" & Co. bei späteren Bearbeitungen).
+	SentOn time.Time
+	// Undo: ISO-Datum → Absage-Text VOR der Nachricht ("" = keine Zeile).
+	Undo map[string]string
+	// UndoFor: dasselbe für andere Mitglieder, für die die Nachricht galt
+	// ("Tom und ich …"), je userId.
+	UndoFor map[string]map[string]string
+	Revoked bool
+}
+
+// Undos liefert alle Vorher-Zustände je userId (Absender und andere).
+func (e MessageEffect) Undos() map[string]map[string]string {
+	out := make(map[string]map[string]string, len(e.UndoFor)+1)
+	if len(e.Undo) > 0 {
+		out[e.UserID] = e.Undo
+	}
+	for id, u := range e.UndoFor {
+		if len(u) > 0 {
+			out[id] = u
+		}
+	}
+	return out
+}
+
+const messageEffectSchemaSQL = `
+CREATE TABLE IF NOT EXISTS bot_message_effect (
+  message_id     TEXT PRIMARY KEY,
+  "userId"       TEXT NOT NULL,
+  message        TEXT,
+  classification TEXT,
+  sent_on        DATE NOT NULL,
+  undo           JSONB NOT NULL DEFAULT '{}',
+  revoked_at     TIMESTAMPTZ,
+  created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+ALTER TABLE bot_message_effect ADD COLUMN IF NOT EXISTS undo_for JSONB NOT NULL DEFAULT '{}';`
+
+// EnsureMessageEffectSchema legt bot_message_effect idempotent an.
+func (s *Postgres) EnsureMessageEffectSchema(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, messageEffectSchemaSQL); err != nil {
+		return fmt.Errorf("EnsureMessageEffectSchema: %w", err)
+	}
+	return nil
+}
+
+// AbsenceMessages liefert die bestehenden Absage-Texte des Users an den
+// Tagen dates (ISO-Datum → Text; fehlende Tage = keine Absage).
+func (s *Postgres) AbsenceMessages(ctx context.Context, userID string, dates []time.Time) (map[string]string, error) {
+	iso := make([]string, len(dates))
+	for i, d := range dates {
+		iso[i] = d.Format("2006-01-02")
+	}
+	const q = `
+		SELECT date, COALESCE(message, '')
+		FROM public.stammtisch_abwesenheit
+		WHERE "userId" = $1 AND date = ANY($2::date[])`
+	rows, err := s.db.QueryContext(ctx, q, userID, pq.Array(iso))
+	if err != nil {
+		return nil, fmt.Errorf("AbsenceMessages: %w", err)
+	}
+	defer rows.Close()
+	out := map[string]string{}
+	for rows.Next() {
+		var (
+			d   time.Time
+			msg string
+		)
+		if err := rows.Scan(&d, &msg); err != nil {
+			return nil, fmt.Errorf("AbsenceMessages: %w", err)
+		}
+		out[d.Format("2006-01-02")] = msg
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("AbsenceMessages: %w", err)
+	}
+	return out, nil
+}
+
+// SaveMessageEffect schreibt (UPSERT per Message-ID) den Effekt einer Nachricht.
+func (s *Postgres) SaveMessageEffect(ctx context.Context, e MessageEffect) error {
+	undo, err := json.Marshal(e.Undo)
+	if err != nil {
+		return fmt.Errorf("SaveMessageEffect: %w", err)
+	}
+	undoFor, err := json.Marshal(e.UndoFor)
+	if err != nil {
+		return fmt.Errorf("SaveMessageEffect: %w", err)
+	}
+	if e.UndoFor == nil {
+		undoFor = []byte("{}")
+	}
+	const q = `
+		INSERT INTO bot_message_effect (message_id, "userId", message, classification, sent_on, undo, undo_for, revoked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, CASE WHEN $8 THEN now() END)
+		ON CONFLICT (message_id) DO UPDATE SET
+		  message        = EXCLUDED.message,
+		  classification = EXCLUDED.classification,
+		  undo           = EXCLUDED.undo,
+		  undo_for       = EXCLUDED.undo_for,
+		  revoked_at     = EXCLUDED.revoked_at,
+		  updated_at     = now()`
+	if _, err := s.db.ExecContext(ctx, q, e.MessageID, e.UserID, e.Message, e.Classification,
+		e.SentOn, undo, undoFor, e.Revoked); err != nil {
+		return fmt.Errorf("SaveMessageEffect: %w", err)
+	}
+	return nil
+}
+
+// MessageEffect liefert den gespeicherten Effekt einer Nachricht (nil, nil =
+// unbekannt, z. B. "invalid" oder älter als das Feature).
+func (s *Postgres) MessageEffect(ctx context.Context, messageID string) (*MessageEffect, error) {
+	const q = `
+		SELECT message_id, "userId", COALESCE(message, ''), COALESCE(classification, ''),
+		       sent_on, undo, undo_for, revoked_at IS NOT NULL
+		FROM bot_message_effect WHERE message_id = $1`
+	var (
+		e             MessageEffect
+		undo, undoFor []byte
+	)
+	err := s.db.QueryRowContext(ctx, q, messageID).Scan(&e.MessageID, &e.UserID, &e.Message,
+		&e.Classification, &e.SentOn, &undo, &undoFor, &e.Revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("MessageEffect: %w", err)
+	}
+	if err := json.Unmarshal(undo, &e.Undo); err != nil {
+		return nil, fmt.Errorf("MessageEffect: undo: %w", err)
+	}
+	if err := json.Unmarshal(undoFor, &e.UndoFor); err != nil {
+		return nil, fmt.Errorf("MessageEffect: undo_for: %w", err)
+	}
+	return &e, nil
+}
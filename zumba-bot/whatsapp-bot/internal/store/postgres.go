@@ -13,11 +13,18 @@ import (
 )
 
 type Postgres struct {
-	db *db.Postgres
+	db       *db.Postgres
+	schedule domain.Schedule
+
+	// ExcludeExcused nimmt entschuldigte Treffen aus der Rangliste (von main
+	// gesetzt, LEADERBOARD_EXCLUDE_EXCUSED; false = alle Treffen zählen).
+	ExcludeExcused bool
 }
 
-func NewPostgres(p *db.Postgres) *Postgres {
-	return &Postgres{db: p}
+// NewPostgres bindet den Store an den Stammtisch-Schedule, nach dem
+// Rangliste und Strafen die Treffen-Tage zählen.
+func NewPostgres(p *db.Postgres, sched domain.Schedule) *Postgres {
+	return &Postgres{db: p, schedule: sched}
 }
 
 // UserStats nutzt die geteilte Leaderboard-Query (shared/store/queries/
@@ -25,37 +32,74 @@ func NewPostgres(p *db.Postgres) *Postgres {
 // Domänen-Mindeststart 2025-12-01, Ende der Stichtag asOf.
 func (s *Postgres) UserStats(ctx context.Context, asOf time.Time) ([]Stat, error) {
 	period := domain.Period{Start: penalty.ClampStart(nil), End: asOf}
-	rows, err := sharedstore.Leaderboard(ctx, s.db, period)
+	excused, err := s.excused(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("UserStats: %w", err)
+	}
+	rows, err := sharedstore.Leaderboard(ctx, s.db, period, s.schedule, excused)
 	if err != nil {
 		return nil, fmt.Errorf("UserStats: %w", err)
 	}
 
 	out := make([]Stat, 0, len(rows))
 	for _, r := range rows {
-		out = append(out, Stat{
-			UserID:         r.UserID,
-			Name:           r.UserName,
-			StartDate:      r.StartDate,
-			EffectiveStart: r.EffectiveStart,
-			Attendance:     r.AttendanceCount,
-			Away:           r.AwayCount,
-			Percent:        r.AttendPercent,
-			Streak:         r.Streak,
-		})
+		out = append(out, statFromRow(r))
 	}
 	return out, nil
 }
 
-func (s *Postgres) MarkAbsent(ctx context.Context, userID string, date time.Time, message string) error {
+// UserStat filtert dieselbe Rangliste in SQL auf einen User
+// (sharedstore.UserLeaderboardRow); der Platz bezieht sich auf alle.
+func (s *Postgres) UserStat(ctx context.Context, userID string, asOf time.Time) (Stat, bool, error) {
+	period := domain.Period{Start: penalty.ClampStart(nil), End: asOf}
+	excused, err := s.excused(ctx)
+	if err != nil {
+		return Stat{}, false, fmt.Errorf("UserStat: %w", err)
+	}
+	r, err := sharedstore.UserLeaderboardRow(ctx, s.db, period, s.schedule, excused, userID)
+	if err != nil {
+		return Stat{}, false, fmt.Errorf("UserStat: %w", err)
+	}
+	if r.UserID == "" {
+		return Stat{}, false, nil
+	}
+	return statFromRow(r), true, nil
+}
+
+// excused liefert die Zeiträume, die die Rangliste auslässt (nil, wenn
+// ExcludeExcused aus ist).
+func (s *Postgres) excused(ctx context.Context) (domain.Entschuldigungen, error) {
+	if !s.ExcludeExcused {
+		return nil, nil
+	}
+	return sharedstore.ListEntschuldigungen(ctx, s.db)
+}
+
+func statFromRow(r sharedstore.LeaderboardRow) Stat {
+	return Stat{
+		UserID:         r.UserID,
+		Name:           r.UserName,
+		StartDate:      r.StartDate,
+		EffectiveStart: r.EffectiveStart,
+		Attendance:     r.AttendanceCount,
+		Away:           r.AwayCount,
+		Percent:        r.AttendPercent,
+		Streak:         r.Streak,
+		Rank:           r.Rank,
+		Users:          r.Users,
+	}
+}
+
+func (s *Postgres) MarkAbsent(ctx context.Context, userID string, date time.Time, message, enteredBy string) error {
 	// UPSERT auf (userId, date) – entspricht dem n8n-Node mit matchingColumns
 	// userId+date. Setzt eine eindeutige Constraint/Index auf ("userId", date) voraus.
 	const q = `
-		INSERT INTO public.stammtisch_abwesenheit ("userId", date, message)
-		VALUES ($1, $2, $3)
+		INSERT INTO public.stammtisch_abwesenheit ("userId", date, message, entered_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
 		ON CONFLICT ("userId", date)
-		DO UPDATE SET message = EXCLUDED.message
+		DO UPDATE SET message = EXCLUDED.message, entered_by = EXCLUDED.entered_by
 	`
-	if _, err := s.db.ExecContext(ctx, q, userID, date, message); err != nil {
+	if _, err := s.db.ExecContext(ctx, q, userID, date, message, enteredBy); err != nil {
 		return fmt.Errorf("MarkAbsent: %w", err)
 	}
 	return nil
@@ -71,3 +115,24 @@ func (s *Postgres) MarkPresent(ctx context.Context, userID string, date time.Tim
 	}
 	return nil
 }
+
+func (s *Postgres) ExcludedDays(ctx context.Context, from, to time.Time) ([]time.Time, error) {
+	const q = `SELECT date FROM excluded_days WHERE date >= $1 AND date <= $2 ORDER BY date`
+	rows, err := s.db.QueryContext(ctx, q, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ExcludedDays: %w", err)
+	}
+	defer rows.Close()
+	var out []time.Time
+	for rows.Next() {
+		var d time.Time
+		if err := rows.Scan(&d); err != nil {
+			return nil, fmt.Errorf("ExcludedDays: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ExcludedDays: %w", err)
+	}
+	return out, nil
+}
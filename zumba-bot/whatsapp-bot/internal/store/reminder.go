@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// Erinnerungen am Stammtisch-Tag gehen an alle ohne Rückmeldung – außer an
+// Mitglieder, die sie per "erinnerung aus" abbestellt haben.
+
+const reminderOptOutSchemaSQL = `
+CREATE TABLE IF NOT EXISTS bot_reminder_optout (
+  "userId"   TEXT PRIMARY KEY,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+// EnsureReminderSchema legt bot_reminder_optout idempotent an.
+func (s *Postgres) EnsureReminderSchema(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, reminderOptOutSchemaSQL); err != nil {
+		return fmt.Errorf("EnsureReminderSchema: %w", err)
+	}
+	return nil
+}
+
+// SetReminderOptOut bestellt die Erinnerung für userID ab (optOut) bzw.
+// wieder an.
+func (s *Postgres) SetReminderOptOut(ctx context.Context, userID string, optOut bool) error {
+	q := `INSERT INTO bot_reminder_optout ("userId") VALUES ($1) ON CONFLICT DO NOTHING`
+	if !optOut {
+		q = `DELETE FROM bot_reminder_optout WHERE "userId" = $1`
+	}
+	if _, err := s.db.ExecContext(ctx, q, userID); err != nil {
+		return fmt.Errorf("SetReminderOptOut: %w", err)
+	}
+	return nil
+}
+
+// ReminderOptOuts liefert die userIds, die keine Erinnerung wollen.
+func (s *Postgres) ReminderOptOuts(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT "userId" FROM bot_reminder_optout`)
+	if err != nil {
+		return nil, fmt.Errorf("ReminderOptOuts: %w", err)
+	}
+	defer rows.Close()
+	out := map[string]bool{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ReminderOptOuts: %w", err)
+		}
+		out[id] = true
+	}
+	return out, rows.Err()
+}
@@ -19,27 +19,90 @@ type Stat struct {
 	Away           int
 	Percent        float64
 	Streak         int
+	Rank           int // Platz, Gleichstand teilt sich den Platz
+	Users          int // Zahl der User in der Rangliste
 }
 
 // AutoStrafe ist der Marker einer erkannten Fehltage-Strafe (shared-Typ).
 type AutoStrafe = sharedstore.AutoStrafe
 
+// RosterEntry ist eine Zeile der Teilnehmerliste (shared-Typ).
+type RosterEntry = sharedstore.RosterEntry
+
+// EinspruchBeleg ist die Nachricht, die ein Einspruch als Beleg verlinkt
+// (shared-Typ).
+type EinspruchBeleg = sharedstore.EinspruchBeleg
+
 // Store kapselt die DB-Operationen des Workflows.
 type Store interface {
 	// UserStats liefert die Rangliste zum Stichtag asOf (n8n: "Get Per user
 	// stats"; im Original bis current_date – asOf=heute ist identisch).
 	UserStats(ctx context.Context, asOf time.Time) ([]Stat, error)
+	// UserStat liefert die Ranglisten-Zeile eines Users samt Platz ("meine
+	// statistik"); ok=false, wenn er nicht in der Rangliste steht.
+	UserStat(ctx context.Context, userID string, asOf time.Time) (st Stat, ok bool, err error)
 	// MarkAbsent trägt eine Absage ein (n8n: "Insert or update rows", UPSERT).
-	MarkAbsent(ctx context.Context, userID string, date time.Time, message string) error
+	// enteredBy ist, wer sie für userID eingetragen hat ("" = selbst).
+	MarkAbsent(ctx context.Context, userID string, date time.Time, message, enteredBy string) error
 	// MarkPresent entfernt eine Absage (n8n: "Delete table or rows").
 	MarkPresent(ctx context.Context, userID string, date time.Time) error
+	// MarkConfirmed hält eine ausdrückliche Zusage fest (stammtisch_zusage),
+	// Unconfirm nimmt sie zurück. Für die Anwesenheit zählt weiter nur die
+	// Absage – Zusagen speisen die Teilnehmerliste ("wer kommt").
+	MarkConfirmed(ctx context.Context, userID string, date time.Time, message string) error
+	Unconfirm(ctx context.Context, userID string, date time.Time) error
+	// Roster liefert die Teilnehmerliste des Treffens am Tag date.
+	Roster(ctx context.Context, date time.Time) ([]RosterEntry, error)
+
+	// Erinnerung am Stammtisch-Tag: SetReminderOptOut bestellt sie für
+	// userID ab (bzw. wieder an), ReminderOptOuts liefert alle Abbesteller.
+	SetReminderOptOut(ctx context.Context, userID string, optOut bool) error
+	ReminderOptOuts(ctx context.Context) (map[string]bool, error)
+
+	// Members liefert alle Mitglieder samt Spitznamen (für Ab-/Zusagen im
+	// Namen anderer).
+	Members(ctx context.Context) ([]Member, error)
+
+	// ExcludedDays liefert die Sperrtage in [from, to] (für die Auflösung von
+	// Voraus-Absagen, die Sperrtage überspringt).
+	ExcludedDays(ctx context.Context, from, to time.Time) ([]time.Time, error)
+
+	// AbsenceMessages liefert die bestehenden Absage-Texte des Users an den
+	// Tagen dates (Vorher-Zustand für MessageEffect.Undo).
+	AbsenceMessages(ctx context.Context, userID string, dates []time.Time) (map[string]string, error)
+	// SaveMessageEffect / MessageEffect verknüpfen eine WhatsApp-Nachricht
+	// (Message-ID) mit ihrer Wirkung – Grundlage für Bearbeiten/Löschen.
+	SaveMessageEffect(ctx context.Context, e MessageEffect) error
+	MessageEffect(ctx context.Context, messageID string) (*MessageEffect, error)
 
 	// PenaltyInputs liefert alles, was penalty.Assess zum Stichtag asOf
-	// braucht (User mit Abwesenheiten, Sperrtage, strafen-Zeilen). Die
-	// Queries sind auf [2025-12-01, asOf] begrenzt – außerhalb liegende
-	// Zeilen können das Ergebnis von Assess nicht beeinflussen.
+	// braucht (Regelwerke, User mit Abwesenheiten, Sperrtage,
+	// strafen-Zeilen). Die Queries sind auf [Beginn des ersten Regelwerks,
+	// asOf] begrenzt – außerhalb liegende Zeilen können das Ergebnis von
+	// Assess nicht beeinflussen.
 	PenaltyInputs(ctx context.Context, asOf time.Time) (penalty.Input, error)
+	// PenaltyRules liefert die Strafen-Regelwerke (z. B. für den
+	// No-Show-Betrag in der Erinnerung).
+	PenaltyRules(ctx context.Context) (penalty.RuleSets, error)
 	// InsertAutoStrafen persistiert die Marker erkannter Fehltage-Strafen in
 	// einem Statement (idempotent: userId + erster Fehltag der Serie).
 	InsertAutoStrafen(ctx context.Context, marks []AutoStrafe) error
+
+	// EinspruchBeleg sucht die letzte Nachricht des Users in [von, bis)
+	// (bot_trace/ml_messages) als Beleg für einen Einspruch;
+	// ErhebeEinspruch legt ihn gegen die offene No-Show-Strafe id ein.
+	EinspruchBeleg(ctx context.Context, userID string, von, bis time.Time) (EinspruchBeleg, error)
+	ErhebeEinspruch(ctx context.Context, id int64, userID, grund string, b EinspruchBeleg) error
+
+	// Mahnungen liefert alle verschickten Mahnungen; InsertMahnung
+	// protokolliert eine neue vor dem Versand (mit gebuehr > 0 samt
+	// Mahngebühr als Strafe am Tag datum), DeleteMahnung nimmt sie samt
+	// Gebühr zurück, wenn sie nicht zugestellt wurde.
+	Mahnungen(ctx context.Context) ([]penalty.Mahnung, error)
+	InsertMahnung(ctx context.Context, m penalty.Mahnung, gebuehr int, datum time.Time) (int64, error)
+	DeleteMahnung(ctx context.Context, id int64) error
+	// MahnOptOuts / SetMahnOptOut: Mitglieder, die bei Mahnungen nicht in
+	// der Gruppe erwähnt werden wollen ("mahnung aus").
+	MahnOptOuts(ctx context.Context) (map[string]bool, error)
+	SetMahnOptOut(ctx context.Context, userID string, optOut bool) error
 }
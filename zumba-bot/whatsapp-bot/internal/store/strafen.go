@@ -14,13 +14,53 @@ func (s *Postgres) EnsureStrafenSchema(ctx context.Context) error {
 	return sharedstore.EnsureStrafenSchema(ctx, s.db)
 }
 
-// PenaltyInputs delegiert an das shared-Modul (Queries auf [2025-12-01, asOf]
-// begrenzt, siehe dort).
+// PenaltyInputs delegiert an das shared-Modul (Queries ab dem ersten
+// Regelwerk bis asOf begrenzt, siehe dort).
 func (s *Postgres) PenaltyInputs(ctx context.Context, asOf time.Time) (penalty.Input, error) {
-	return sharedstore.PenaltyInputs(ctx, s.db, asOf)
+	return sharedstore.PenaltyInputs(ctx, s.db, s.schedule, asOf)
+}
+
+// PenaltyRules liefert die Regelwerke (shared).
+func (s *Postgres) PenaltyRules(ctx context.Context) (penalty.RuleSets, error) {
+	return sharedstore.ListRuleSets(ctx, s.db)
 }
 
 // InsertAutoStrafen persistiert alle Marker in einem Statement (shared).
 func (s *Postgres) InsertAutoStrafen(ctx context.Context, marks []AutoStrafe) error {
 	return sharedstore.InsertAutoStrafen(ctx, s.db, marks)
 }
+
+// EinspruchBeleg sucht den Beleg für einen Einspruch (shared).
+func (s *Postgres) EinspruchBeleg(ctx context.Context, userID string, von, bis time.Time) (EinspruchBeleg, error) {
+	return sharedstore.FindEinspruchBeleg(ctx, s.db, userID, von, bis)
+}
+
+// ErhebeEinspruch legt Einspruch gegen eine No-Show-Strafe ein (shared).
+func (s *Postgres) ErhebeEinspruch(ctx context.Context, id int64, userID, grund string, b EinspruchBeleg) error {
+	return sharedstore.ErhebeEinspruch(ctx, s.db, id, userID, grund, b)
+}
+
+// Mahnungen liefert das Mahn-Protokoll (shared).
+func (s *Postgres) Mahnungen(ctx context.Context) ([]penalty.Mahnung, error) {
+	return sharedstore.ListMahnungen(ctx, s.db)
+}
+
+// InsertMahnung protokolliert eine Mahnung samt optionaler Mahngebühr (shared).
+func (s *Postgres) InsertMahnung(ctx context.Context, m penalty.Mahnung, gebuehr int, datum time.Time) (int64, error) {
+	return sharedstore.InsertMahnung(ctx, s.db, m, gebuehr, datum)
+}
+
+// DeleteMahnung nimmt eine nicht zugestellte Mahnung zurück (shared).
+func (s *Postgres) DeleteMahnung(ctx context.Context, id int64) error {
+	return sharedstore.DeleteMahnung(ctx, s.db, id)
+}
+
+// MahnOptOuts liefert, wer bei Mahnungen nicht in der Gruppe erwähnt wird (shared).
+func (s *Postgres) MahnOptOuts(ctx context.Context) (map[string]bool, error) {
+	return sharedstore.MahnOptOuts(ctx, s.db)
+}
+
+// SetMahnOptOut schaltet die Erwähnung in der Gruppe ab bzw. an (shared).
+func (s *Postgres) SetMahnOptOut(ctx context.Context, userID string, optOut bool) error {
+	return sharedstore.SetMahnOptOut(ctx, s.db, userID, optOut)
+}
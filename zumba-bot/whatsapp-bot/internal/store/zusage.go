@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sharedstore "github.com/michael/zumba-shared/store"
+)
+
+// EnsureZusageSchema legt stammtisch_zusage idempotent an (geteilte DDL im
+// shared-Modul, das Admin-UI ruft dieselbe Funktion).
+func (s *Postgres) EnsureZusageSchema(ctx context.Context) error {
+	return sharedstore.EnsureZusageSchema(ctx, s.db)
+}
+
+func (s *Postgres) MarkConfirmed(ctx context.Context, userID string, date time.Time, message string) error {
+	return sharedstore.MarkConfirmed(ctx, s.db, userID, date, message)
+}
+
+func (s *Postgres) Unconfirm(ctx context.Context, userID string, date time.Time) error {
+	return sharedstore.Unconfirm(ctx, s.db, userID, date)
+}
+
+func (s *Postgres) Roster(ctx context.Context, date time.Time) ([]RosterEntry, error) {
+	return sharedstore.Roster(ctx, s.db, date)
+}
@@ -1,5 +1,5 @@
 // Package tracestore persistiert einen Schritt-für-Schritt-Trace jedes
-// aufgezeichneten Webhook-Events (Zumba-Gruppe, donnerstags) in der zumba-DB.
+// aufgezeichneten Webhook-Events (Zumba-Gruppe, jeder Tag) in der zumba-DB.
 // Die Admin-UI liest die Traces und rendert daraus den Flow-Graphen.
 package tracestore
 
@@ -13,13 +13,19 @@ import (
 // Knoten-IDs des festen Bot-Flow-Graphen (die UI mappt sie auf Karten).
 const (
 	NodeReceived       = "received"
-	NodeCheckStatistik = "check_statistik"
-	NodeBuildStats     = "build_stats"
-	NodeSendStats      = "send_stats"
+	NodeUndo           = "undo_original"   // Bearbeitung/Löschung: Wirkung des Originals zurückdrehen
+	NodeCheckStatistik = "check_statistik" // "Befehl?" (ID aus der Zeit, als es nur "statistik" gab)
+	NodeCommand        = "command"         // Befehl ausführen (Label "Befehl: <name>")
+	NodeBuildStats     = "build_stats"     // nur noch in alten Traces (heute NodeCommand)
+	NodeSendStats      = "send_stats"      // Antwort senden
 	NodeGuardType      = "guard_type"
 	NodeGuardGroup     = "guard_group"
-	NodeGuardThursday  = "guard_thursday"
+	NodeGuardThursday  = "guard_thursday" // nur noch in alten Traces (Tages-Guard entfallen)
+	NodeAnswer         = "clarify_answer" // Antwort (Zitat/Reaktion) auf eine Rückfrage des Bots
+	NodeRules          = "rules"          // Vorklassifikation (Regeltabelle aus dem Admin-UI)
 	NodeClassify       = "classify"
+	NodeContext        = "context" // Gesprächskontext für eine zweite Klassifikation
+	NodeResolveDates   = "resolve_dates"
 	NodeMarkAbsent     = "mark_absent"
 	NodeMarkPresent    = "mark_present"
 	NodeNoAction       = "no_action"
@@ -53,6 +59,16 @@ func (r *Recorder) Step(node, outcome, label, detail string) {
 
 func (r *Recorder) Steps() []Step { return r.steps }
 
+// Err fasst den ersten Fehler-Schritt zusammen ("" = kein Fehler).
+func (r *Recorder) Err() string {
+	for _, s := range r.steps {
+		if s.Outcome == OutcomeError {
+			return s.Label + ": " + s.Detail
+		}
+	}
+	return ""
+}
+
 func (r *Recorder) HasError() bool {
 	for _, s := range r.steps {
 		if s.Outcome == OutcomeError {
@@ -77,6 +93,11 @@ type Trace struct {
 	HasError       bool
 	RawPayload     json.RawMessage
 	Steps          []Step
+
+	// MessageID ist die WhatsApp-Message-ID des Events; ParentMessageID bei
+	// Bearbeitung/Löschung die ID der Original-Nachricht (→ parent_id).
+	MessageID       string
+	ParentMessageID string
 }
 
 // Store schreibt Traces in die zumba-DB.
@@ -100,7 +121,10 @@ CREATE TABLE IF NOT EXISTS bot_trace (
   raw_payload    JSONB,
   trace          JSONB NOT NULL
 );
-CREATE INDEX IF NOT EXISTS bot_trace_created_idx ON bot_trace (created_at DESC);`
+CREATE INDEX IF NOT EXISTS bot_trace_created_idx ON bot_trace (created_at DESC);
+ALTER TABLE bot_trace ADD COLUMN IF NOT EXISTS message_id TEXT;
+ALTER TABLE bot_trace ADD COLUMN IF NOT EXISTS parent_id BIGINT;
+CREATE INDEX IF NOT EXISTS bot_trace_message_idx ON bot_trace (message_id);`
 
 // EnsureSchema legt die Tabelle idempotent an (beim Start aufgerufen).
 func (s *Store) EnsureSchema(ctx context.Context) error {
@@ -110,10 +134,14 @@ func (s *Store) EnsureSchema(ctx context.Context) error {
 
 const retentionSQL = `DELETE FROM bot_trace WHERE created_at < now() - interval '21 days'`
 
+// parent_id verweist auf den ersten Trace der Original-Nachricht (Folge-Trace
+// einer Bearbeitung/Löschung); ist der schon weg (Retention), bleibt er NULL.
 const insertSQL = `
 INSERT INTO bot_trace
-  (remote_jid, user_id, user_name, message, message_type, path, classification, action, has_error, raw_payload, trace)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+  (remote_jid, user_id, user_name, message, message_type, path, classification, action, has_error, raw_payload, trace,
+   message_id, parent_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''),
+  (SELECT id FROM bot_trace WHERE $13 <> '' AND message_id = $13 ORDER BY id LIMIT 1))`
 
 // Save schreibt einen Trace und räumt anschließend Einträge älter als 21 Tage ab.
 func (s *Store) Save(ctx context.Context, t Trace) error {
@@ -128,6 +156,7 @@ func (s *Store) Save(ctx context.Context, t Trace) error {
 	if _, err := s.db.ExecContext(ctx, insertSQL,
 		t.RemoteJid, t.UserID, t.UserName, t.Message, t.MessageType,
 		t.Path, t.Classification, t.Action, t.HasError, rawPayload, steps,
+		t.MessageID, t.ParentMessageID,
 	); err != nil {
 		return err
 	}
@@ -0,0 +1,157 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/clarify"
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+	"github.com/michael/zumba-whatsapp-bot/internal/evolution"
+)
+
+type fakeClarifier struct {
+	questions map[string]clarify.Question
+	answers   map[string]string
+}
+
+func (f *fakeClarifier) Ask(_ context.Context, q clarify.Question) error {
+	if f.questions == nil {
+		f.questions = map[string]clarify.Question{}
+	}
+	f.questions[q.ID] = q
+	return nil
+}
+
+func (f *fakeClarifier) Get(_ context.Context, chat, id string) (*clarify.Question, error) {
+	q, ok := f.questions[id]
+	if !ok || q.Chat != chat {
+		return nil, nil
+	}
+	return &q, nil
+}
+
+func (f *fakeClarifier) Answer(_ context.Context, id, answer string) error {
+	if f.answers == nil {
+		f.answers = map[string]string{}
+	}
+	f.answers[id] = answer
+	return nil
+}
+
+// quoteSender kann zitieren und liefert BOT-1 als Message-ID.
+type quoteSender struct {
+	fakeSender
+	quoted *evolution.Quote
+}
+
+func (q *quoteSender) SendTextQuoted(_ context.Context, number, text string, quoted evolution.Quote) (string, error) {
+	q.number, q.text, q.quoted = number, text, &quoted
+	return "BOT-1", nil
+}
+
+func newClarifyServer(t *testing.T) (*Server, *fakeStore, *quoteSender, *fakeClarifier) {
+	t.Helper()
+	s, st, _ := newTestServer(classifier.Invalid, thursday)
+	snd := &quoteSender{}
+	cl := &fakeClarifier{}
+	s.sender, s.Clarify, s.ClarifyTTL = snd, cl, 4*time.Hour
+	return s, st, snd, cl
+}
+
+func eventJSON(t *testing.T, raw string) evolution.WebhookEvent {
+	t.Helper()
+	var ev evolution.WebhookEvent
+	if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+		t.Fatal(err)
+	}
+	return ev
+}
+
+// reactionMsg: user reagiert mit emoji auf die Nachricht targetID.
+func reactionMsg(t *testing.T, user, targetID, emoji string) evolution.WebhookEvent {
+	return eventJSON(t, `{"data":{"messageType":"reactionMessage","pushName":"Tester",
+		"key":{"remoteJid":"`+testGroup+`","id":"R-`+emoji+`","participantAlt":"`+user+`"},
+		"message":{"reactionMessage":{"key":{"remoteJid":"`+testGroup+`","fromMe":true,"id":"`+targetID+`"},"text":"`+emoji+`"}}}}`)
+}
+
+// replyMsg: user-123 antwortet mit text auf die Nachricht quotedID.
+func replyMsg(t *testing.T, quotedID, text string) evolution.WebhookEvent {
+	return eventJSON(t, `{"data":{"messageType":"extendedTextMessage","pushName":"Tester",
+		"key":{"remoteJid":"`+testGroup+`","id":"REPLY-1","participantAlt":"user-123"},
+		"message":{"extendedTextMessage":{"text":"`+text+`","contextInfo":{"stanzaId":"`+quotedID+`",
+		"quotedMessage":{"conversation":"🤔 Tester, kommst du heute? 👍 / 👎"}}}}}}`)
+}
+
+func TestUnklareNachrichtStelltRueckfrage(t *testing.T) {
+	s, st, snd, cl := newClarifyServer(t)
+	s.ClarifyTTL = 24 * time.Hour
+
+	out := s.run(context.Background(), withID(groupMsg("schau ma mal"), "MSG-1"), false, false, s.today())
+	if out.Action != "asked" || len(st.absences) != 0 {
+		t.Fatalf("Outcome %+v, absences %v", out, st.absences)
+	}
+	if snd.quoted == nil || snd.quoted.MessageID != "MSG-1" || !strings.Contains(snd.text, "Tester, kommst du heute?") {
+		t.Errorf("Rückfrage %q (Zitat %+v)", snd.text, snd.quoted)
+	}
+	q := cl.questions["BOT-1"]
+	if q.UserID != "user-123" || isoDates(q.Dates) != "2026-01-01" || q.Message != "schau ma mal" {
+		t.Errorf("Rückfrage gemerkt: %+v", q)
+	}
+	// Offen bis Mitternacht am Termin, nicht die vollen 24 h.
+	if want := time.Date(2026, 1, 2, 0, 0, 0, 0, s.location); !q.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %s, want %s", q.ExpiresAt, want)
+	}
+
+	// Eine belanglose Nachricht bleibt ohne Rückfrage.
+	snd.quoted = nil
+	if out := s.run(context.Background(), withID(groupMsg("Prost!"), "MSG-2"), false, false, s.today()); out.Action != "none" || snd.quoted != nil {
+		t.Errorf("Prost: %+v", out)
+	}
+}
+
+func TestReaktionAufRueckfrageEntscheidet(t *testing.T) {
+	s, st, snd, cl := newClarifyServer(t)
+	s.run(context.Background(), withID(groupMsg("schau ma mal"), "MSG-1"), false, false, s.today())
+
+	// Reaktion eines anderen zählt nicht.
+	if out := s.run(context.Background(), reactionMsg(t, "user-999", "BOT-1", "👎"), false, false, s.today()); out.Path != "ignored" || len(st.absences) != 0 {
+		t.Fatalf("fremde Reaktion: %+v", out)
+	}
+
+	s.Reactions = Reactions{Absent: "❌"}
+	out := s.run(context.Background(), reactionMsg(t, "user-123", "BOT-1", "👎"), false, false, s.today())
+	if out.Action != "marked_absent" || strings.Join(st.absentDates, ",") != "2026-01-01" {
+		t.Fatalf("Outcome %+v, dates %v", out, st.absentDates)
+	}
+	if st.absentMessage != "schau ma mal → 👎" || cl.answers["BOT-1"] != "false" {
+		t.Errorf("message %q, answers %v", st.absentMessage, cl.answers)
+	}
+	// Die Quittung hängt an der unklaren Nachricht, nicht an der Reaktion.
+	if got := strings.Join(snd.reactions, ","); got != "MSG-1|❌" {
+		t.Errorf("Reaktionen = %q", got)
+	}
+}
+
+func TestAntwortMitZitatEntscheidet(t *testing.T) {
+	s, st, _, cl := newClarifyServer(t)
+	s.run(context.Background(), withID(groupMsg("vielleicht"), "MSG-1"), false, false, s.today())
+
+	out := s.run(context.Background(), replyMsg(t, "BOT-1", "Ja!"), false, false, s.today())
+	if out.Action != "marked_present" || strings.Join(st.presentDates, ",") != "2026-01-01" || cl.answers["BOT-1"] != "true" {
+		t.Errorf("Outcome %+v, present %v, answers %v", out, st.presentDates, cl.answers)
+	}
+}
+
+func TestAbgelaufeneRueckfrageIgnoriert(t *testing.T) {
+	s, st, _, _ := newClarifyServer(t)
+	s.run(context.Background(), withID(groupMsg("schau ma mal"), "MSG-1"), false, false, s.today())
+
+	s.Now = func() time.Time { return thursday.Add(24 * time.Hour) }
+	out := s.run(context.Background(), reactionMsg(t, "user-123", "BOT-1", "👍"), false, false, s.today())
+	if out.Path != "ignored" || len(st.presentDates) != 0 {
+		t.Errorf("Outcome %+v, present %v", out, st.presentDates)
+	}
+}
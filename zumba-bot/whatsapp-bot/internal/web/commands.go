@@ -0,0 +1,358 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-whatsapp-bot/internal/command"
+	"github.com/michael/zumba-whatsapp-bot/internal/dates"
+	"github.com/michael/zumba-whatsapp-bot/internal/evolution"
+	"github.com/michael/zumba-whatsapp-bot/internal/report"
+	"github.com/michael/zumba-whatsapp-bot/internal/store"
+	"github.com/michael/zumba-whatsapp-bot/internal/tracestore"
+)
+
+// registerCommands registriert die eingebauten Gruppen-Befehle. Neue Befehle
+// kommen hierher – nicht als weitere Verzweigung in run().
+func (s *Server) registerCommands() {
+	s.Commands.Register(command.Command{
+		Name: "statistik", Help: "Rangliste im laufenden Zeitraum", Run: s.cmdStatistik,
+	})
+	s.Commands.Register(command.Command{
+		Name: "meine statistik", Aliases: []string{"meine stats"},
+		Help: "dein Platz, deine Serie und was du schuldest", Run: s.cmdMeineStatistik,
+	})
+	s.Commands.Register(command.Command{
+		Name: "wer kommt", Aliases: []string{"wer ist dabei"}, Usage: "[wann]", MaxArgs: 2,
+		Help: "Zusagen, Absagen und offene Rückmeldungen fürs nächste Treffen", Run: s.cmdWerKommt,
+	})
+	s.Commands.Register(command.Command{
+		Name: "wer fehlt", Usage: "[wann]", MaxArgs: 2,
+		Help: "nur Absagen und offene Rückmeldungen fürs nächste Treffen", Run: s.cmdWerFehlt,
+	})
+	s.Commands.Register(command.Command{
+		Name: "erinnerung", Usage: "an|aus", MaxArgs: 1,
+		Help: "Erinnerung am Stammtisch-Tag, falls du dich noch nicht gemeldet hast", Run: s.cmdErinnerung,
+	})
+	s.Commands.Register(command.Command{
+		Name: "strafen", Help: "offene und kürzlich beglichene Strafen", Run: s.cmdStrafen,
+	})
+	s.Commands.Register(command.Command{
+		Name: "einspruch", Usage: "[datum] <grund>", MaxArgs: 40,
+		Help: "Einspruch gegen deine No-Show-Strafe, ein Admin entscheidet", Run: s.cmdEinspruch,
+	})
+	s.Commands.Register(command.Command{
+		Name: "mahnung", Usage: "an|aus", MaxArgs: 1,
+		Help: "bei offenen Strafen auch in der Gruppe erwähnt werden", Run: s.cmdMahnung,
+	})
+	s.Commands.Register(command.Command{
+		Name: "wochenreport", Admin: true,
+		Help: "Wochenreport sofort statt zum nächsten Termin", Run: s.cmdWochenreport,
+	})
+	s.Commands.Register(command.Command{
+		Name: "hilfe", Aliases: []string{"help", "befehle"}, Help: "diese Übersicht",
+		Run: func(_ context.Context, c command.Call) (command.Reply, error) {
+			return command.Reply{Text: s.Commands.Help(c.UserID)}, nil
+		},
+	})
+}
+
+// statsData reicht die Rangliste der Statistik-Antwort an die Testseite
+// weiter (alternative Designs, Bild-Karte).
+type statsData struct {
+	stats     []store.Stat
+	penalties []penalty.Entry
+}
+
+func (s *Server) cmdStatistik(ctx context.Context, c command.Call) (command.Reply, error) {
+	stats, err := s.store.UserStats(ctx, c.AsOf)
+	if err != nil {
+		return command.Reply{}, fmt.Errorf("UserStats: %w", err)
+	}
+	entries, kasse, perr := s.penalties(ctx, c.AsOf, !c.DryRun)
+	r := command.Reply{
+		Text:   report.BuildWithStrafen(stats, strafenBlock(entries, kasse, perr, c.AsOf)),
+		Detail: fmt.Sprintf("%d Nutzer", len(stats)),
+		Data:   statsData{stats: stats, penalties: entries},
+	}
+	// STATS_FORMAT=image: PNG-Karte, der Text bleibt Fallback. Ein
+	// Render-Fehler ist kein Fehler des Befehls – sonst würde die Inbox das
+	// Event wiederholen und die Statistik doppelt posten.
+	if s.StatsFormat == "image" && !c.DryRun {
+		if png, err := s.renderCard(ctx, stats, entries, c.AsOf, false); err != nil {
+			r.Detail += " · Bild-Karte: " + err.Error() + " – Fallback auf Text"
+			log.Printf("⚠️  Bild-Karte(%s): %v – Fallback auf Text", c.Chat, err)
+		} else {
+			r.Image, r.Caption = png, "🍻 Zumba Stats · Stand "+c.AsOf.Format("02.01.2006")
+		}
+	}
+	return r, nil
+}
+
+// cmdMeineStatistik antwortet mit der persönlichen Statistik des Absenders.
+// Die Strafen werden nur bewertet, nicht als Marker persistiert – das
+// erledigen "statistik" und der Wochenreport.
+func (s *Server) cmdMeineStatistik(ctx context.Context, c command.Call) (command.Reply, error) {
+	st, ok, err := s.store.UserStat(ctx, c.UserID, c.AsOf)
+	if err != nil {
+		return command.Reply{}, err
+	}
+	if !ok {
+		return command.Reply{
+			Text:   "👤 Für dich gibt es noch keine Statistik – frag einen Admin, ob du als Mitglied eingetragen bist.",
+			Detail: "nicht in der Rangliste: " + c.UserID,
+		}, nil
+	}
+	in, err := s.store.PenaltyInputs(ctx, c.AsOf)
+	if err != nil {
+		return command.Reply{}, fmt.Errorf("PenaltyInputs: %w", err)
+	}
+	p := report.Personal{Stat: st, Serie: penalty.CurrentRun(in, c.UserID, c.AsOf),
+		Regeln: penalty.CurrentRules(in, c.UserID, c.AsOf), AsOf: c.AsOf}
+	for _, e := range penalty.Assess(in, c.AsOf) {
+		if e.UserID == c.UserID {
+			p.Strafen = append(p.Strafen, e)
+		}
+	}
+
+	r := command.Reply{
+		Text:   report.BuildPersonal(p),
+		Detail: fmt.Sprintf("%s: Platz %d/%d · %d€ offen · Fehl-Serie %d", st.Name, st.Rank, st.Users, p.Schulden(), p.Serie),
+	}
+	// Bild-Karte wie bei "statistik": Render-Fehler → Text.
+	if s.StatsFormat == "image" && !c.DryRun {
+		if png, err := s.renderPersonalCard(ctx, p); err != nil {
+			r.Detail += " · Bild-Karte: " + err.Error() + " – Fallback auf Text"
+			log.Printf("⚠️  Bild-Karte(%s, %s): %v – Fallback auf Text", c.Chat, c.UserID, err)
+		} else {
+			r.Image, r.Caption = png, "👤 "+st.Name+" · Stand "+c.AsOf.Format("02.01.2006")
+		}
+	}
+	return r, nil
+}
+
+// renderPersonalCard rendert die persönliche Karte über den renderer-service.
+func (s *Server) renderPersonalCard(ctx context.Context, p report.Personal) ([]byte, error) {
+	if s.Renderer == nil {
+		return nil, fmt.Errorf("kein Renderer konfiguriert (RENDERER_URL)")
+	}
+	html, err := report.BuildPersonalCardHTML(p)
+	if err != nil {
+		return nil, err
+	}
+	return s.Renderer.PNG(ctx, html, report.CardWidth)
+}
+
+// cmdWerKommt listet die Rückmeldungen für das nächste Treffen (heute, falls
+// heute eins ist) bzw. den genannten Termin ("wer kommt nächste woche").
+func (s *Server) cmdWerKommt(ctx context.Context, c command.Call) (command.Reply, error) {
+	return s.roster(ctx, c, report.BuildRoster)
+}
+
+// cmdWerFehlt ist "wer kommt" ohne Zusagen: wer abgesagt oder sich noch
+// nicht gemeldet hat.
+func (s *Server) cmdWerFehlt(ctx context.Context, c command.Call) (command.Reply, error) {
+	return s.roster(ctx, c, report.BuildMissing)
+}
+
+// roster löst die Zeitangabe aus c.Args auf und baut mit build den Text zur
+// Teilnehmerliste des ersten passenden Treffens.
+func (s *Server) roster(ctx context.Context, c command.Call, build func(time.Time, []store.RosterEntry) string) (command.Reply, error) {
+	from := time.Date(c.AsOf.Year(), c.AsOf.Month(), c.AsOf.Day(), 0, 0, 0, 0, time.UTC)
+	excluded, err := s.excludedAhead(ctx, from)
+	if err != nil {
+		log.Printf("⚠️  ExcludedDays: %v (Sperrtage nicht berücksichtigt)", err)
+	}
+	res := dates.Resolve(strings.Join(c.Args, " "), from, s.Schedule, excluded)
+	if len(res.Dates) == 0 {
+		return command.Reply{
+			Text:   "🙋 Für „" + strings.Join(c.Args, " ") + "“ finde ich keinen Stammtisch-Termin.",
+			Detail: res.Describe(),
+		}, nil
+	}
+	day := res.Dates[0]
+	entries, err := s.store.Roster(ctx, day)
+	if err != nil {
+		return command.Reply{}, err
+	}
+	n := report.CountRoster(entries)
+	return command.Reply{
+		Text: build(day, entries),
+		Detail: fmt.Sprintf("%s: %d erwartet · %d zugesagt · %d offen · %d abgesagt",
+			day.Format("2006-01-02"), n.Erwartet(), n.Zugesagt, n.Offen, n.Abgesagt),
+	}, nil
+}
+
+func (s *Server) cmdStrafen(ctx context.Context, c command.Call) (command.Reply, error) {
+	entries, kasse, err := s.penalties(ctx, c.AsOf, !c.DryRun)
+	if err != nil {
+		return command.Reply{}, err
+	}
+	return command.Reply{
+		Text:   report.StrafenBlock(entries, kasse, c.AsOf),
+		Detail: fmt.Sprintf("%d Strafen", len(entries)),
+	}, nil
+}
+
+// cmdWochenreport baut den Wochenreport wie der Scheduler-Job, schickt ihn
+// aber als Antwort in den Chat, aus dem der Befehl kam – per Direktnachricht
+// kann sich ein Admin ihn also vorab ansehen. Bild-Karte bei
+// STATS_FORMAT=image, Strafen-Marker nur bei echten Läufen.
+func (s *Server) cmdWochenreport(ctx context.Context, c command.Call) (command.Reply, error) {
+	wr, err := s.buildWeekly(ctx, c.AsOf, !c.DryRun, s.StatsFormat == "image" && !c.DryRun, "")
+	if err != nil {
+		return command.Reply{}, fmt.Errorf("Statistik: %w", err)
+	}
+	detail := "Text"
+	if wr.png != nil {
+		detail = "Bild-Karte"
+	} else if wr.renderErr != nil {
+		log.Printf("⚠️  Bild-Karte(wochenreport): %v – Fallback auf Text", wr.renderErr)
+		detail = "Text (Bild-Fallback: " + wr.renderErr.Error() + ")"
+	}
+	return command.Reply{
+		Text:    wr.text,
+		Caption: "📅 Wochenreport · Stand " + c.AsOf.Format("02.01.2006"),
+		Image:   wr.png,
+		Detail:  detail,
+	}, nil
+}
+
+// einspruchDatum erkennt ein führendes Datum ("12.3." oder "12.3.2026") im
+// Einspruch.
+var einspruchDatum = regexp.MustCompile(`^(\d{1,2})\.(\d{1,2})\.(\d{4})?$`)
+
+// cmdEinspruch legt Einspruch gegen die neueste offene No-Show-Strafe des
+// Absenders ein (bzw. die vom genannten Tag). Als Beleg hängt die letzte
+// Nachricht der Woche vor dem Treffen dran; findet sich keine, geht der
+// Einspruch trotzdem durch. Entschieden wird im Admin-UI.
+func (s *Server) cmdEinspruch(ctx context.Context, c command.Call) (command.Reply, error) {
+	args := c.Args
+	var day, month, year int
+	if len(args) > 0 {
+		if m := einspruchDatum.FindStringSubmatch(args[0]); m != nil {
+			day, _ = strconv.Atoi(m[1])
+			month, _ = strconv.Atoi(m[2])
+			year, _ = strconv.Atoi(m[3])
+			args = args[1:]
+		}
+	}
+	grund := strings.Join(args, " ")
+	if grund == "" {
+		return command.Reply{
+			Text:   "⚖️ Bitte mit Begründung: *einspruch [datum] <grund>*, z. B. „einspruch 12.3. hab morgens abgesagt“.",
+			Detail: "ohne Begründung",
+		}, nil
+	}
+
+	in, err := s.store.PenaltyInputs(ctx, c.AsOf)
+	if err != nil {
+		return command.Reply{}, fmt.Errorf("PenaltyInputs: %w", err)
+	}
+	var target *penalty.Entry
+	for _, e := range penalty.Assess(in, c.AsOf) {
+		if e.UserID != c.UserID || !penalty.KannEinspruch(e) {
+			continue
+		}
+		if day != 0 && (e.Datum.Day() != day || int(e.Datum.Month()) != month || (year != 0 && e.Datum.Year() != year)) {
+			continue
+		}
+		if target == nil || e.Datum.After(target.Datum) {
+			target = &e
+		}
+	}
+	if target == nil {
+		return command.Reply{
+			Text:   "⚖️ Ich finde keine offene No-Show-Strafe von dir, gegen die noch kein Einspruch läuft.",
+			Detail: "keine Strafe für Einspruch: " + c.UserID,
+		}, nil
+	}
+
+	// Beleg: letzte Nachricht in der Woche vor dem Treffen bis Tagesende.
+	beleg, err := s.store.EinspruchBeleg(ctx, c.UserID, target.Datum.AddDate(0, 0, -7), target.Datum.AddDate(0, 0, 1))
+	if err != nil {
+		log.Printf("⚠️  EinspruchBeleg(%s): %v (Einspruch ohne Beleg)", c.UserID, err)
+	}
+	if !c.DryRun {
+		if err := s.store.ErhebeEinspruch(ctx, target.ID, c.UserID, grund, beleg); err != nil {
+			return command.Reply{}, err
+		}
+		log.Printf("⚖️  Einspruch von %s gegen Strafe %d", c.UserName, target.ID)
+	}
+
+	text := fmt.Sprintf("⚖️ Einspruch gegen deine Strafe vom %s (%d€) ist eingelegt – ein Admin entscheidet.",
+		target.Datum.Format("02.01."), target.Betrag)
+	detail := fmt.Sprintf("Strafe %d", target.ID)
+	if beleg.TraceID != 0 || beleg.MLMessageID != 0 {
+		text += fmt.Sprintf("\nBeleg: deine Nachricht „%s“ vom %s.", beleg.Nachricht, beleg.Am.Format("02.01. 15:04"))
+		detail += fmt.Sprintf(" · Beleg trace=%d ml=%d", beleg.TraceID, beleg.MLMessageID)
+	} else {
+		text += "\nEine Nachricht von dir vor dem Treffen finde ich nicht."
+		detail += " · ohne Beleg"
+	}
+	return command.Reply{Text: text, Detail: detail}, nil
+}
+
+// runCommand führt einen erkannten Befehl aus und schickt die Antwort in den
+// Chat, aus dem er kam. "statistik" behält den Pfad "statistik" (Testseite,
+// alte Traces), alle anderen laufen unter "command".
+func (s *Server) runCommand(ctx context.Context, ev evolution.WebhookEvent, cmd *command.Command, args []string, dryRun bool, asOf time.Time, rec *tracestore.Recorder) Outcome {
+	receiver := ev.RemoteJid()
+	out := Outcome{Path: "command", Command: cmd.Name, Recipient: receiver, UserID: ev.UserID(), DryRun: dryRun}
+	if cmd.Name == "statistik" {
+		out.Path = "statistik"
+	}
+	label := "Befehl: " + cmd.Name
+
+	var reply command.Reply
+	if !s.Commands.Allowed(cmd, ev.UserID()) {
+		rec.Step(tracestore.NodeCommand, tracestore.OutcomeFail, label, "nur für Admins – "+ev.UserID())
+		log.Printf("🔒 %s (%s) darf %q nicht", ev.UserName(), ev.UserID(), cmd.Name)
+		reply.Text = "🔒 *" + cmd.Name + "* ist nur für Admins."
+	} else {
+		var err error
+		reply, err = cmd.Run(ctx, command.Call{Name: cmd.Name, Args: args, UserID: ev.UserID(), UserName: ev.UserName(),
+			Chat: receiver, AsOf: asOf, DryRun: dryRun})
+		if err != nil {
+			rec.Step(tracestore.NodeCommand, tracestore.OutcomeError, label, err.Error())
+			log.Printf("⚠️  Befehl %q: %v", cmd.Name, err)
+			return out
+		}
+		detail := reply.Detail
+		if detail == "" {
+			detail = "ok"
+		}
+		rec.Step(tracestore.NodeCommand, tracestore.OutcomePass, label, detail)
+	}
+	out.Message = reply.Text
+	if d, ok := reply.Data.(statsData); ok {
+		out.stats, out.penalties = d.stats, d.penalties
+	}
+
+	sendLabel := "Antwort senden"
+	if reply.Image != nil {
+		sendLabel += " (Bild)"
+	}
+	if dryRun {
+		rec.Step(tracestore.NodeSendStats, tracestore.OutcomeInfo, sendLabel, "Dry-Run – nicht gesendet")
+		return out
+	}
+	queued, err := s.sendGroup(ctx, cmd.Name, receiver, reply.Text, reply.Caption, reply.Image)
+	switch {
+	case err != nil:
+		rec.Step(tracestore.NodeSendStats, tracestore.OutcomeError, sendLabel, err.Error())
+		log.Printf("⚠️  Antwort %q(%s): %v", cmd.Name, receiver, err)
+	case queued:
+		rec.Step(tracestore.NodeSendStats, tracestore.OutcomeInfo, sendLabel, "Evolution nicht erreichbar – liegt in der Outbox, wird wiederholt")
+	default:
+		rec.Step(tracestore.NodeSendStats, tracestore.OutcomePass, sendLabel, "→ "+receiver)
+		log.Printf("💬 Befehl %q beantwortet an %s", cmd.Name, receiver)
+	}
+	return out
+}
@@ -0,0 +1,221 @@
+package web
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael/zumba-shared/penalty"
+	sharedstore "github.com/michael/zumba-shared/store"
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+	"github.com/michael/zumba-whatsapp-bot/internal/command"
+	"github.com/michael/zumba-whatsapp-bot/internal/store"
+	"github.com/michael/zumba-whatsapp-bot/internal/tracestore"
+)
+
+func TestBefehlHilfe(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Absage, thursday)
+	rec := tracestore.NewRecorder()
+	out := s.run(context.Background(), groupMsg("Hilfe"), false, false, s.today(), rec)
+
+	if out.Path != "command" || out.Command != "hilfe" {
+		t.Fatalf("out = %+v", out)
+	}
+	if !snd.called || !strings.Contains(snd.text, "*statistik*") || !strings.Contains(snd.text, "*strafen*") {
+		t.Errorf("hilfe-Text: %q", snd.text)
+	}
+	if st.absentUserID != "" {
+		t.Error("Befehl darf nicht klassifiziert werden")
+	}
+	steps := rec.Steps()
+	if steps[len(steps)-2].Node != tracestore.NodeCommand || steps[len(steps)-2].Label != "Befehl: hilfe" {
+		t.Errorf("Trace: %+v", steps)
+	}
+}
+
+func TestBefehlStrafen(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Invalid, thursday)
+	st.penaltyInput = penaltyFixture()
+	out := s.run(context.Background(), groupMsg("strafen"), false, false, s.today())
+	if out.Path != "command" || !strings.Contains(snd.text, "STRAFEN") {
+		t.Errorf("out=%+v text=%q", out, snd.text)
+	}
+}
+
+func TestAdminBefehlNurFuerAdmins(t *testing.T) {
+	s, _, snd := newTestServer(classifier.Invalid, thursday)
+	ran := false
+	s.Commands.Register(command.Command{Name: "geheim", Admin: true, Help: "nur Admins",
+		Run: func(context.Context, command.Call) (command.Reply, error) {
+			ran = true
+			return command.Reply{Text: "ok"}, nil
+		}})
+
+	rec := tracestore.NewRecorder()
+	s.run(context.Background(), groupMsg("geheim"), false, false, s.today(), rec)
+	if ran || !strings.Contains(snd.text, "nur für Admins") {
+		t.Errorf("Nicht-Admin: ran=%v text=%q", ran, snd.text)
+	}
+	if e := rec.Err(); e != "" {
+		t.Errorf("Verweigerung ist kein Fehler (Inbox-Retry): %q", e)
+	}
+
+	s.Commands.SetAdmins([]string{groupMsg("x").UserID()})
+	s.run(context.Background(), groupMsg("geheim"), false, false, s.today())
+	if !ran || snd.text != "ok" {
+		t.Errorf("Admin: ran=%v text=%q", ran, snd.text)
+	}
+}
+
+// wochenreport ist der eingebaute Admin-Befehl: Nicht-Admins bekommen den
+// Hinweis (und sehen ihn in „hilfe“ nicht), Admins den Report.
+func TestBefehlWochenreportNurFuerAdmins(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Invalid, thursday)
+	st.penaltyInput = penaltyFixture()
+
+	rec := tracestore.NewRecorder()
+	out := s.run(context.Background(), groupMsg("wochenreport"), false, false, s.today(), rec)
+	if out.Command != "wochenreport" || st.statsCalled || !strings.Contains(snd.text, "nur für Admins") {
+		t.Errorf("Nicht-Admin: out=%+v stats=%v text=%q", out, st.statsCalled, snd.text)
+	}
+	if e := rec.Err(); e != "" {
+		t.Errorf("Verweigerung ist kein Fehler (Inbox-Retry): %q", e)
+	}
+	if strings.Contains(s.Commands.Help(groupMsg("x").UserID()), "wochenreport") {
+		t.Error("hilfe zeigt Nicht-Admins den Admin-Befehl")
+	}
+
+	s.Commands.SetAdmins([]string{groupMsg("x").UserID()})
+	rec = tracestore.NewRecorder()
+	s.run(context.Background(), groupMsg("Wochenreport!"), false, false, s.today(), rec)
+	if !st.statsCalled || !strings.Contains(snd.text, "STRAFEN") || strings.Contains(snd.text, "nur für Admins") {
+		t.Errorf("Admin: stats=%v text=%q", st.statsCalled, snd.text)
+	}
+	if e := rec.Err(); e != "" {
+		t.Errorf("Admin-Lauf: %q", e)
+	}
+	if !strings.Contains(s.Commands.Help(groupMsg("x").UserID()), "*wochenreport* 🔒") {
+		t.Error("hilfe zeigt Admins den Admin-Befehl nicht")
+	}
+}
+
+func TestBefehlMeineStatistik(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Absage, thursday)
+	st.penaltyInput = penaltyFixture()
+	st.userStats = map[string]store.Stat{
+		"user-123": {UserID: "user-123", Name: "Tester", Attendance: 0, Away: 5, Streak: -5, Rank: 4, Users: 9},
+	}
+	out := s.run(context.Background(), groupMsg("Meine Statistik?"), false, false, s.today())
+
+	if out.Command != "meine statistik" || st.absentUserID != "" {
+		t.Fatalf("out = %+v, absent=%q", out, st.absentUserID)
+	}
+	for _, want := range []string{"Tester", "🏅 *Platz 4* von 9", "🧊-5", "25€ (5x in Folge gefehlt)", "Offen gesamt: 25€", "Fehltage-Serie läuft (5x)"} {
+		if !strings.Contains(snd.text, want) {
+			t.Errorf("Antwort ohne %q:\n%s", want, snd.text)
+		}
+	}
+	if len(st.autoStrafen) != 0 {
+		t.Errorf("meine statistik darf keine Marker schreiben: %v", st.autoStrafen)
+	}
+}
+
+func TestBefehlMeineStatistikUnbekannt(t *testing.T) {
+	s, _, snd := newTestServer(classifier.Invalid, thursday)
+	rec := tracestore.NewRecorder()
+	s.run(context.Background(), groupMsg("meine stats"), false, false, s.today(), rec)
+	if !strings.Contains(snd.text, "noch keine Statistik") {
+		t.Errorf("text = %q", snd.text)
+	}
+	if e := rec.Err(); e != "" {
+		t.Errorf("unbekannter User ist kein Fehler: %q", e)
+	}
+}
+
+func TestBefehlWerKommt(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Absage, thursday)
+	st.roster = []store.RosterEntry{
+		{UserName: "Anna", Status: sharedstore.RosterZugesagt, Message: "bin dabei"},
+		{UserName: "Börni", Status: sharedstore.RosterOffen},
+		{UserName: "Chris", Status: sharedstore.RosterAbgesagt, Message: "krank"},
+	}
+	out := s.run(context.Background(), groupMsg("Wer kommt?"), false, false, s.today())
+
+	if out.Command != "wer kommt" || st.absentUserID != "" {
+		t.Fatalf("out = %+v, absent=%q", out, st.absentUserID)
+	}
+	if st.rosterDay != "2026-01-01" {
+		t.Errorf("Roster für %q, want heute (2026-01-01)", st.rosterDay)
+	}
+	for _, want := range []string{"Erwartet: *2* (davon 1 fest zugesagt)", "✅ *Zugesagt (1)*\nAnna", "🤷 *Keine Rückmeldung (1)*\nBörni", "• Chris – _„krank“_"} {
+		if !strings.Contains(snd.text, want) {
+			t.Errorf("Antwort ohne %q:\n%s", want, snd.text)
+		}
+	}
+
+	s.run(context.Background(), groupMsg("wer kommt nächste woche"), false, false, s.today())
+	if st.rosterDay != "2026-01-08" {
+		t.Errorf("Roster für %q, want 2026-01-08", st.rosterDay)
+	}
+
+}
+
+func TestBefehlWerFehlt(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Absage, thursday)
+	st.roster = []store.RosterEntry{
+		{UserName: "Anna", Status: sharedstore.RosterZugesagt, Message: "bin dabei"},
+		{UserName: "Börni", Status: sharedstore.RosterOffen},
+		{UserName: "Chris", Status: sharedstore.RosterAbgesagt, Message: "krank"},
+	}
+	out := s.run(context.Background(), groupMsg("Wer fehlt?"), false, false, s.today())
+
+	if out.Command != "wer fehlt" || st.absentUserID != "" || st.rosterDay != "2026-01-01" {
+		t.Fatalf("out = %+v, absent=%q, day=%q", out, st.absentUserID, st.rosterDay)
+	}
+	for _, want := range []string{"WER FEHLT?", "🤷 *Keine Rückmeldung (1)*\nBörni", "• Chris – _„krank“_"} {
+		if !strings.Contains(snd.text, want) {
+			t.Errorf("Antwort ohne %q:\n%s", want, snd.text)
+		}
+	}
+	if strings.Contains(snd.text, "Anna") {
+		t.Errorf("Zusagen gehören nicht dazu:\n%s", snd.text)
+	}
+
+	s.run(context.Background(), groupMsg("wer fehlt nächste woche"), false, false, s.today())
+	if st.rosterDay != "2026-01-08" {
+		t.Errorf("Roster für %q, want 2026-01-08", st.rosterDay)
+	}
+}
+
+func TestBefehlEinspruch(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Invalid, thursday)
+	st.penaltyInput = penaltyFixture()
+	for i, d := range []time.Time{time.Date(2025, 12, 18, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC)} {
+		st.penaltyInput.Rows = append(st.penaltyInput.Rows, penalty.Row{
+			ID: int64(i + 1), UserID: "user-123", Art: penalty.ArtNoShow, Datum: d, Betrag: 50, Status: penalty.StatusOffen,
+		})
+	}
+	st.beleg = store.EinspruchBeleg{TraceID: 7, Nachricht: "bin raus heute", Am: time.Date(2025, 12, 18, 9, 30, 0, 0, time.UTC)}
+
+	s.run(context.Background(), groupMsg("einspruch"), false, false, s.today())
+	if len(st.einsprueche) != 0 || !strings.Contains(snd.text, "Begründung") {
+		t.Fatalf("ohne Grund: einsprueche=%v text=%q", st.einsprueche, snd.text)
+	}
+
+	s.run(context.Background(), groupMsg("einspruch 18.12. hab doch abgesagt"), false, false, s.today())
+	if len(st.einsprueche) != 1 || st.einsprueche[0] != "1|user-123|hab doch abgesagt|7" {
+		t.Fatalf("einsprueche = %v", st.einsprueche)
+	}
+	for _, want := range []string{"vom 18.12. (50€) ist eingelegt", "„bin raus heute“"} {
+		if !strings.Contains(snd.text, want) {
+			t.Errorf("Antwort ohne %q:\n%s", want, snd.text)
+		}
+	}
+
+	// Ohne Datum: die neueste Strafe.
+	s.run(context.Background(), groupMsg("Einspruch war krank"), false, false, s.today())
+	if len(st.einsprueche) != 2 || !strings.HasPrefix(st.einsprueche[1], "2|") {
+		t.Errorf("einsprueche = %v", st.einsprueche)
+	}
+}
@@ -0,0 +1,142 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/chatlog"
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+	"github.com/michael/zumba-whatsapp-bot/internal/evolution"
+	"github.com/michael/zumba-whatsapp-bot/internal/tracestore"
+)
+
+type fakeHistory struct {
+	msgs  []chatlog.Message
+	added []chatlog.Message
+	at    time.Time // Bezugszeit des letzten Before-Aufrufs
+}
+
+func (f *fakeHistory) Add(_ context.Context, m chatlog.Message) error {
+	f.added = append(f.added, m)
+	return nil
+}
+
+func (f *fakeHistory) Before(_ context.Context, chat string, at time.Time, excludeID string) ([]chatlog.Message, error) {
+	f.at = at
+	var out []chatlog.Message
+	for _, m := range f.msgs {
+		if m.Chat == chat && m.MessageID != excludeID {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeHistory) Get(_ context.Context, chat, id string) (*chatlog.Message, error) {
+	for _, m := range f.msgs {
+		if m.Chat == chat && m.MessageID == id {
+			return &m, nil
+		}
+	}
+	return nil, nil
+}
+
+type fakeContextual struct {
+	turns  []classifier.Turn
+	result classifier.Result
+}
+
+func (f *fakeContextual) ClassifyInContext(_ context.Context, _ string, turns []classifier.Turn) (classifier.Classification, error) {
+	f.turns = turns
+	return classifier.Classification{Result: f.result, Raw: string(f.result), Model: "gemini-2.5-flash"}, nil
+}
+
+func TestIchAuchMitKontext(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Invalid, thursday)
+	hist := &fakeHistory{msgs: []chatlog.Message{
+		{Chat: testGroup, MessageID: "M1", UserName: "Anna", Text: "bin raus"},
+		{Chat: "andere@g.us", MessageID: "X1", UserName: "Fremd", Text: "komme"},
+	}}
+	cc := &fakeContextual{result: classifier.Absage}
+	s.History, s.Contextual = hist, cc
+
+	ev := groupMsg("ich auch")
+	ev.Data.Key.ID = "M2"
+	rec := tracestore.NewRecorder()
+	out := s.run(context.Background(), ev, false, false, s.today(), rec)
+	if out.Classification != "false" || strings.Join(st.absentDates, ",") != "2026-01-01" {
+		t.Fatalf("out=%+v dates=%v, want Absage", out, st.absentDates)
+	}
+	if len(cc.turns) != 1 || cc.turns[0].Author != "Anna" || cc.turns[0].Text != "bin raus" {
+		t.Errorf("Kontext: %+v", cc.turns)
+	}
+	var ctxStep, classify tracestore.Step
+	for _, step := range rec.Steps() {
+		switch step.Node {
+		case tracestore.NodeContext:
+			ctxStep = step
+		case tracestore.NodeClassify:
+			classify = step
+		}
+	}
+	if !strings.Contains(ctxStep.Detail, `Anna: "bin raus"`) {
+		t.Errorf("Kontext-Schritt: %+v", ctxStep)
+	}
+	if classify.Label != "Classifier (LLM · mit Kontext)" || !strings.Contains(classify.Detail, "allein: invalid") {
+		t.Errorf("classify-Schritt: %+v", classify)
+	}
+	if len(hist.added) != 1 || hist.added[0].MessageID != "M2" || hist.added[0].Text != "ich auch" {
+		t.Errorf("Verlauf: %+v", hist.added)
+	}
+}
+
+func TestAntwortMitZitat(t *testing.T) {
+	raw := `{"data":{"messageType":"extendedTextMessage","pushName":"Chris","messageTimestamp":1767268800,
+		"key":{"remoteJid":"` + testGroup + `","id":"M3","participantAlt":"user-456"},
+		"message":{"extendedTextMessage":{"text":"+1","contextInfo":{"stanzaId":"M1",
+			"participant":"4917000@s.whatsapp.net","quotedMessage":{"conversation":"bin raus"}}}}}}`
+	var ev evolution.WebhookEvent
+	if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+		t.Fatal(err)
+	}
+	s, st, _ := newTestServer(classifier.Invalid, thursday)
+	hist := &fakeHistory{msgs: []chatlog.Message{
+		{Chat: testGroup, MessageID: "M1", UserName: "Anna", Text: "bin raus"},
+		{Chat: testGroup, MessageID: "M0", UserName: "Ben", Text: "wer kommt?"},
+	}}
+	cc := &fakeContextual{result: classifier.Absage}
+	s.History, s.Contextual = hist, cc
+
+	s.run(context.Background(), ev, false, false, s.today())
+	if st.absentUserID != "user-456" {
+		t.Fatalf("Absage fehlt: %+v", st)
+	}
+	// Das Zitat steht einmal – als Zitat, nicht zusätzlich im Verlauf.
+	if len(cc.turns) != 2 || cc.turns[0].Author != "Ben" || !cc.turns[1].Quoted || cc.turns[1].Author != "Anna" {
+		t.Errorf("Kontext: %+v", cc.turns)
+	}
+	if !hist.at.Equal(time.Unix(1767268800, 0)) {
+		t.Errorf("Bezugszeit %v, want messageTimestamp", hist.at)
+	}
+}
+
+func TestKontextNurWennAlleinInvalid(t *testing.T) {
+	s, _, _ := newTestServer(classifier.Absage, thursday)
+	hist := &fakeHistory{msgs: []chatlog.Message{{Chat: testGroup, MessageID: "M1", UserName: "Anna", Text: "komme"}}}
+	cc := &fakeContextual{result: classifier.Zusage}
+	s.History, s.Contextual = hist, cc
+	out := s.run(context.Background(), groupMsg("bin raus"), false, false, s.today())
+	if out.Classification != "false" || cc.turns != nil {
+		t.Errorf("eindeutige Nachricht: out=%+v, Kontext-Aufruf %+v", out, cc.turns)
+	}
+
+	// Ohne Verlauf bleibt "ich auch" invalid.
+	s, st, _ := newTestServer(classifier.Invalid, thursday)
+	s.History, s.Contextual = &fakeHistory{}, cc
+	if out := s.run(context.Background(), groupMsg("ich auch"), false, false, s.today()); out.Classification != "invalid" || len(st.absentDates) != 0 {
+		t.Errorf("ohne Verlauf: out=%+v", out)
+	}
+}
@@ -0,0 +1,118 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+	"github.com/michael/zumba-whatsapp-bot/internal/evolution"
+	"github.com/michael/zumba-whatsapp-bot/internal/tracestore"
+)
+
+func withID(ev evolution.WebhookEvent, id string) evolution.WebhookEvent {
+	ev.Data.Key.ID = id
+	return ev
+}
+
+// editMsg baut ein Evolution-Edit-Event (protocolMessage MESSAGE_EDIT, wie
+// Baileys es als Zahl serialisiert).
+func editMsg(t *testing.T, targetID, text string) evolution.WebhookEvent {
+	t.Helper()
+	raw := `{"data":{"messageType":"editedMessage","pushName":"Tester",
+		"key":{"remoteJid":"` + testGroup + `","id":"EDIT-1","participantAlt":"user-123"},
+		"message":{"editedMessage":{"message":{"protocolMessage":{"key":{"id":"` + targetID + `"},"type":14,
+		"editedMessage":{"conversation":"` + text + `"}}}}}}}`
+	var ev evolution.WebhookEvent
+	if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+		t.Fatal(err)
+	}
+	return ev
+}
+
+func revokeMsg(t *testing.T, targetID string) evolution.WebhookEvent {
+	t.Helper()
+	raw := `{"data":{"messageType":"protocolMessage","pushName":"Tester",
+		"key":{"remoteJid":"` + testGroup + `","id":"REVOKE-1","participantAlt":"user-123"},
+		"message":{"protocolMessage":{"key":{"id":"` + targetID + `"},"type":"REVOKE"}}}}`
+	var ev evolution.WebhookEvent
+	if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+		t.Fatal(err)
+	}
+	return ev
+}
+
+func TestEditZusageZuAbsage(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Zusage, friday)
+	st.rows = map[string]string{"2026-01-08": "bin raus"}
+	s.run(context.Background(), withID(groupMsg("komme doch"), "MSG-1"), false, false, s.today())
+	if _, ok := st.rows["2026-01-08"]; ok {
+		t.Fatal("Zusage sollte die Absage löschen")
+	}
+	if st.confirmed["2026-01-08"] != "komme doch" {
+		t.Errorf("Zusage nicht vermerkt: %v", st.confirmed)
+	}
+
+	// "komme doch" → "komme doch nicht": Original zurückdrehen (Absage
+	// "bin raus" wiederherstellen), dann die neue Absage eintragen.
+	s.classifier = fakeClassifier{result: classifier.Absage}
+	rec := tracestore.NewRecorder()
+	out := s.run(context.Background(), editMsg(t, "MSG-1", "komme doch nicht"), false, false, s.today(), rec)
+	if out.Action != "marked_absent" || out.FollowUpOf != "MSG-1" {
+		t.Fatalf("outcome: %+v", out)
+	}
+	if st.rows["2026-01-08"] != "komme doch nicht" {
+		t.Errorf("rows = %v", st.rows)
+	}
+	if _, ok := st.confirmed["2026-01-08"]; ok {
+		t.Errorf("Zusage des Originals muss weg: %v", st.confirmed)
+	}
+	if e := st.effects["MSG-1"]; e.Message != "komme doch nicht" || e.Undo["2026-01-08"] != "bin raus" {
+		t.Errorf("Effekt nach Edit: %+v", e)
+	}
+	var undone bool
+	for _, step := range rec.Steps() {
+		undone = undone || (step.Node == tracestore.NodeUndo && step.Outcome == tracestore.OutcomePass)
+	}
+	if !undone {
+		t.Errorf("undo-Schritt fehlt: %+v", rec.Steps())
+	}
+}
+
+func TestRevokeMachtAbsageRueckgaengig(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Absage, friday)
+	s.run(context.Background(), withID(groupMsg("nächste woche nicht"), "MSG-2"), false, false, s.today())
+	if st.rows["2026-01-08"] == "" {
+		t.Fatal("Absage fehlt")
+	}
+	out := s.run(context.Background(), revokeMsg(t, "MSG-2"), false, false, s.today())
+	if out.Path != "revoke" || out.Action != "reverted" {
+		t.Fatalf("outcome: %+v", out)
+	}
+	if len(st.rows) != 0 {
+		t.Errorf("Absage sollte entfernt sein: %v", st.rows)
+	}
+	if !st.effects["MSG-2"].Revoked {
+		t.Error("Effekt nicht als gelöscht markiert")
+	}
+	// Zweite Löschung (Evolution-Retry) dreht nichts doppelt zurück.
+	if out := s.run(context.Background(), revokeMsg(t, "MSG-2"), false, false, s.today()); out.Action != "none" {
+		t.Errorf("zweite Löschung: %+v", out)
+	}
+}
+
+func TestRevokeUnbekannteNachricht(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Absage, friday)
+	out := s.run(context.Background(), revokeMsg(t, "UNKNOWN"), false, false, s.today())
+	if out.Action != "none" || len(st.absentDates) != 0 || len(st.presentDates) != 0 {
+		t.Errorf("keine Aktion erwartet: %+v", out)
+	}
+}
+
+func TestEditAufStatistikPostetNicht(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Invalid, friday)
+	s.run(context.Background(), editMsg(t, "MSG-3", "statistik"), false, false, s.today())
+	if st.statsCalled || snd.called {
+		t.Error("bearbeitete Nachricht darf keine Statistik auslösen")
+	}
+}
@@ -0,0 +1,132 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+	"github.com/michael/zumba-whatsapp-bot/internal/inbox"
+)
+
+// fakeInbox bildet die Claim-Semantik von bot_inbox im Speicher nach.
+type fakeInbox struct {
+	events map[string]*fakeInboxEntry
+}
+
+type fakeInboxEntry struct {
+	payload    []byte
+	status     string
+	attempts   int
+	lastErr    string
+	receivedAt time.Time
+}
+
+func (f *fakeInbox) Claim(_ context.Context, id string, payload []byte) (time.Time, bool, error) {
+	if f.events == nil {
+		f.events = map[string]*fakeInboxEntry{}
+	}
+	e, ok := f.events[id]
+	if !ok {
+		f.events[id] = &fakeInboxEntry{payload: payload, status: "processing", attempts: 1, receivedAt: friday}
+		return friday, true, nil
+	}
+	if e.status != "failed" || e.attempts >= inbox.MaxAttempts {
+		return time.Time{}, false, nil
+	}
+	e.status = "processing"
+	e.attempts++
+	return e.receivedAt, true, nil
+}
+
+func (f *fakeInbox) Finish(_ context.Context, id, procErr string) error {
+	e := f.events[id]
+	e.status, e.lastErr = "done", procErr
+	if procErr != "" {
+		e.status = "failed"
+	}
+	return nil
+}
+
+func (f *fakeInbox) Due(context.Context, int) ([]inbox.Event, error) {
+	var out []inbox.Event
+	for id, e := range f.events {
+		if e.status == "failed" && e.attempts < inbox.MaxAttempts {
+			out = append(out, inbox.Event{MessageID: id, Payload: e.payload, ReceivedAt: e.receivedAt, Attempts: e.attempts})
+		}
+	}
+	return out, nil
+}
+
+// flakyClassifier fällt aus, bis down=false gesetzt wird.
+type flakyClassifier struct{ down bool }
+
+func (f *flakyClassifier) Classify(context.Context, string) (classifier.Classification, error) {
+	if f.down {
+		return classifier.Classification{Result: classifier.Invalid}, errors.New("gemini: 503")
+	}
+	return classifier.Classification{Result: classifier.Absage, Raw: "false", Model: "fake"}, nil
+}
+
+func postWebhook(t *testing.T, s *Server, body []byte) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	s.Routes().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/webhook/whatsapp", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d", rr.Code)
+	}
+}
+
+func TestWebhookDuplikatWirdNurQuittiert(t *testing.T) {
+	s, _, snd := newTestServer(classifier.Invalid, thursday)
+	ib := &fakeInbox{}
+	s.Inbox = ib
+	body, _ := json.Marshal(withID(groupMsg("statistik"), "MSG-STAT"))
+
+	postWebhook(t, s, body)
+	snd.called = false
+	postWebhook(t, s, body) // Evolution-Retry
+	if snd.called {
+		t.Error("Duplikat hat erneut an die Gruppe gepostet")
+	}
+	if e := ib.events["MSG-STAT"]; e.status != "done" || e.attempts != 1 {
+		t.Errorf("inbox: %+v", e)
+	}
+}
+
+func TestFehlgeschlagenesEventWirdWiederholt(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Absage, thursday)
+	cl := &flakyClassifier{down: true}
+	s.classifier = cl
+	ib := &fakeInbox{}
+	s.Inbox = ib
+	body, _ := json.Marshal(withID(groupMsg("bin raus"), "MSG-ABS"))
+
+	postWebhook(t, s, body)
+	if e := ib.events["MSG-ABS"]; e.status != "failed" || e.lastErr == "" {
+		t.Fatalf("Classifier-Ausfall sollte failed hinterlassen: %+v", e)
+	}
+	if st.absentUserID != "" {
+		t.Fatal("keine Absage bei Classifier-Ausfall erwartet")
+	}
+
+	cl.down = false
+	if n := s.RetryInbox(context.Background()); n != 1 {
+		t.Fatalf("RetryInbox = %d, want 1", n)
+	}
+	// Bezugstag ist die erste Zustellung (Freitag) → nächster Donnerstag.
+	if got := st.absentDates; len(got) != 1 || got[0] != "2026-01-08" {
+		t.Errorf("absentDates = %v, want [2026-01-08]", got)
+	}
+	if e := ib.events["MSG-ABS"]; e.status != "done" || e.attempts != 2 {
+		t.Errorf("inbox nach Retry: %+v", e)
+	}
+	if n := s.RetryInbox(context.Background()); n != 0 {
+		t.Errorf("zweiter RetryInbox = %d, want 0", n)
+	}
+}
@@ -0,0 +1,175 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-whatsapp-bot/internal/command"
+	"github.com/michael/zumba-whatsapp-bot/internal/report"
+)
+
+// MahnJob ist der Scheduler-Job "mahnung": Wer eine offene Strafe länger als
+// die Stufen-Tage nicht begleicht, bekommt eine Direktnachricht mit der
+// fälligen Stufe, ab der Gruppen-Stufe zusätzlich eine Erwähnung in der
+// Gruppe (außer per "mahnung aus" abbestellt). Je Lauf steigt die Stufe um
+// höchstens eins (penalty.Mahnwesen.Lage); auf der letzten Stufe legt der Job
+// die Mahngebühr als eigene Strafe an – nie auf eine Mahngebühr selbst.
+// Payload: {"tage": [14,28,42], "gruppeAb": 3, "gebuehr": 5}.
+//
+// Jede Stufe geht je Anker (älteste offene Strafe) nur einmal raus – das
+// Protokoll strafen_mahnung ist die Grundlage. Der Job darf daher täglich
+// laufen; Fehltage-Strafen ohne Marker persistiert er wie der Wochenreport,
+// gemahnt werden sie ab dem nächsten Lauf.
+func (s *Server) MahnJob(ctx context.Context, payload json.RawMessage) (string, error) {
+	var m penalty.Mahnwesen
+	if len(payload) > 0 {
+		p := struct {
+			Tage     []int `json:"tage"`
+			GruppeAb int   `json:"gruppeAb"`
+			Gebuehr  int   `json:"gebuehr"`
+		}{}
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return "", fmt.Errorf("payload: %w", err)
+		}
+		m = penalty.Mahnwesen{Tage: p.Tage, GruppeAb: p.GruppeAb, Gebuehr: p.Gebuehr}
+	}
+	if len(m.Tage) == 0 {
+		return "", fmt.Errorf("payload: keine Mahnstufen (tage)")
+	}
+
+	today := s.today()
+	entries, _, err := s.penalties(ctx, today, true)
+	if err != nil {
+		return "", fmt.Errorf("PenaltyInputs: %w", err)
+	}
+	verschickt, err := s.store.Mahnungen(ctx)
+	if err != nil {
+		return "", fmt.Errorf("Mahnungen: %w", err)
+	}
+	optOut, err := s.store.MahnOptOuts(ctx)
+	if err != nil {
+		return "", fmt.Errorf("MahnOptOuts: %w", err)
+	}
+
+	var faellig []penalty.MahnLage
+	lagen := m.Lage(entries, verschickt, today)
+	for _, l := range lagen {
+		if l.Faellig() {
+			faellig = append(faellig, l)
+		}
+	}
+	if len(faellig) == 0 {
+		return fmt.Sprintf("nichts fällig (%d mit offenen Strafen)", len(lagen)), nil
+	}
+
+	// Je Mitglied erst protokollieren (samt Gebühr), dann die
+	// Direktnachricht; zum Schluss eine Sammel-Erwähnung in der Gruppe. Ohne
+	// Protokoll geht nichts raus – sonst käme dieselbe Stufe (samt Gebühr)
+	// beim nächsten Lauf noch einmal. Scheitert die Direktnachricht, wird
+	// das Protokoll zurückgenommen und das Mitglied ist beim nächsten Lauf
+	// wieder dran.
+	var sent, gruppe []penalty.MahnLage
+	var failed []string
+	fees := 0
+	for _, l := range faellig {
+		g := m.Gruppe(l.Stufe) && !optOut[l.UserID]
+		fee := m.GebuehrFuer(l)
+		mahnung := penalty.Mahnung{UserID: l.UserID, Stufe: l.Stufe, StrafeID: l.Anker.ID, Offen: l.Offen, Gruppe: g}
+		id, err := s.store.InsertMahnung(ctx, mahnung, fee, today)
+		if err != nil {
+			log.Printf("⚠️  InsertMahnung %s (Stufe %d): %v", l.Name, l.Stufe, err)
+			failed = append(failed, l.Name)
+			continue
+		}
+		text := report.BuildMahnDM(l, m.Letzte(l.Stufe), fee, g)
+		if err := s.sender.SendText(ctx, l.UserID, text); err != nil {
+			log.Printf("⚠️  Mahnung an %s: %v", l.Name, err)
+			failed = append(failed, l.Name)
+			if err := s.store.DeleteMahnung(ctx, id); err != nil {
+				log.Printf("⚠️  DeleteMahnung %s (Stufe %d): %v – Stufe gilt als verschickt", l.Name, l.Stufe, err)
+			}
+			continue
+		}
+		sent = append(sent, l)
+		if g {
+			gruppe = append(gruppe, l)
+		}
+		if fee > 0 {
+			fees++
+		}
+		log.Printf("💸 Mahnung Stufe %d an %s (%d€ offen, seit %d Tagen)", l.Stufe, l.Name, l.Offen, l.Alter)
+	}
+	if len(sent) == 0 {
+		return "", fmt.Errorf("Versand: keine Mahnung zugestellt (%d Versuche)", len(faellig))
+	}
+
+	inGruppe := false
+	if len(gruppe) > 0 {
+		text, mentioned := report.BuildMahnGroup(gruppe)
+		if ms, ok := s.sender.(MentionSender); ok {
+			err = ms.SendTextMentions(ctx, s.groupJID, text, mentioned)
+		} else {
+			err = s.sender.SendText(ctx, s.groupJID, text)
+		}
+		if err != nil {
+			log.Printf("⚠️  Mahnung in der Gruppe: %v", err)
+		} else {
+			inGruppe = true
+		}
+	}
+
+	detail := fmt.Sprintf("%d Mahnungen", len(sent))
+	if inGruppe {
+		detail += fmt.Sprintf(", %d in der Gruppe erwähnt", len(gruppe))
+	}
+	if fees > 0 {
+		detail += fmt.Sprintf(", %d Mahngebühren", fees)
+	}
+	if len(failed) > 0 {
+		detail += " · nicht zugestellt: " + strings.Join(failed, ", ")
+	}
+	return detail, nil
+}
+
+// cmdMahnung schaltet die Erwähnung in der Gruppe bei Mahnungen ab ("mahnung
+// aus") bzw. wieder an; Direktnachrichten kommen weiterhin. Ohne Argument
+// nennt es den aktuellen Stand.
+func (s *Server) cmdMahnung(ctx context.Context, c command.Call) (command.Reply, error) {
+	arg := ""
+	if len(c.Args) > 0 {
+		arg = strings.ToLower(c.Args[0])
+	}
+	switch arg {
+	case "aus", "an":
+		optOut := arg == "aus"
+		if !c.DryRun {
+			if err := s.store.SetMahnOptOut(ctx, c.UserID, optOut); err != nil {
+				return command.Reply{}, err
+			}
+		}
+		if optOut {
+			return command.Reply{
+				Text:   "🔕 Alles klar, " + c.UserName + " – bei offenen Strafen erinnere ich dich nur noch per Direktnachricht, nicht mehr in der Gruppe.",
+				Detail: "abbestellt",
+			}, nil
+		}
+		return command.Reply{
+			Text:   "🔔 Passt, " + c.UserName + " – bei länger offenen Strafen erwähne ich dich wieder in der Gruppe.",
+			Detail: "angemeldet",
+		}, nil
+	case "":
+		optOuts, err := s.store.MahnOptOuts(ctx)
+		if err != nil {
+			return command.Reply{}, err
+		}
+		if optOuts[c.UserID] {
+			return command.Reply{Text: "🔕 Bei Mahnungen wirst du nicht in der Gruppe erwähnt. Mit „mahnung an“ schaltest du es ein.", Detail: "aus"}, nil
+		}
+		return command.Reply{Text: "🔔 Bei Mahnungen erwähne ich dich auch in der Gruppe. Mit „mahnung aus“ schaltest du es ab.", Detail: "an"}, nil
+	}
+	return command.Reply{Text: "🤔 „mahnung an“ oder „mahnung aus“?", Detail: "unbekanntes Argument " + arg}, nil
+}
@@ -0,0 +1,141 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+)
+
+// mahnFixture: vier Mitglieder mit je einer offenen No-Show-Strafe, heute
+// (Do 1.1.2026) 42 Tage (Anna, Chris, Didi) bzw. 28 Tage (Bert) alt.
+func mahnFixture() penalty.Input {
+	start := time.Date(2025, 10, 2, 0, 0, 0, 0, time.UTC)
+	var in penalty.Input
+	for i, u := range []struct{ id, name, datum string }{
+		{"491511@s.whatsapp.net", "Anna", "2025-11-20"},
+		{"491512@s.whatsapp.net", "Bert", "2025-12-04"},
+		{"491513@s.whatsapp.net", "Chris", "2025-11-20"},
+		{"491514@s.whatsapp.net", "Didi", "2025-11-20"},
+	} {
+		d, _ := time.Parse("2006-01-02", u.datum)
+		in.Users = append(in.Users, penalty.UserData{UserID: u.id, Name: u.name, EffectiveStart: start})
+		in.Rows = append(in.Rows, penalty.Row{ID: int64(i + 1), UserID: u.id, Art: penalty.ArtNoShow, Datum: d, Betrag: 50, Status: penalty.StatusOffen})
+	}
+	return in
+}
+
+func TestMahnJob(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Invalid, thursday)
+	snd := &mentionSender{dmErr: map[string]error{"491514@s.whatsapp.net": errors.New("offline")}}
+	s.sender = snd
+	st.penaltyInput = mahnFixture()
+	// Anna und Chris haben Stufe 2 vor 14 Tagen bekommen, Bert gerade eben;
+	// Didi wurde noch nie gemahnt.
+	vor14 := time.Date(2025, 12, 18, 18, 0, 0, 0, time.UTC)
+	st.mahnungen = []penalty.Mahnung{
+		{UserID: "491511@s.whatsapp.net", Stufe: 2, StrafeID: 1, CreatedAt: vor14},
+		{UserID: "491512@s.whatsapp.net", Stufe: 2, StrafeID: 2, CreatedAt: thursday},
+		{UserID: "491513@s.whatsapp.net", Stufe: 2, StrafeID: 3, CreatedAt: vor14},
+	}
+	st.mahnOptOut = map[string]bool{"491513@s.whatsapp.net": true}
+	payload := json.RawMessage(`{"tage":[14,28,42],"gruppeAb":3,"gebuehr":5}`)
+
+	detail, err := s.MahnJob(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bert hat Stufe 2 schon, Didi ist nicht erreichbar.
+	if _, ok := snd.dms["491512@s.whatsapp.net"]; ok || len(snd.dms) != 2 {
+		t.Fatalf("dms = %v", snd.dms)
+	}
+	anna := snd.dms["491511@s.whatsapp.net"]
+	for _, want := range []string{"Letzte Mahnung", "*50€*", "seit 42 Tagen", "Mahngebühr von 5€", "„mahnung aus“"} {
+		if !strings.Contains(anna, want) {
+			t.Errorf("DM ohne %q:\n%s", want, anna)
+		}
+	}
+	if strings.Contains(snd.dms["491513@s.whatsapp.net"], "in der Gruppe") {
+		t.Errorf("Chris hat abbestellt:\n%s", snd.dms["491513@s.whatsapp.net"])
+	}
+	// In der Gruppe nur Anna (Chris abbestellt).
+	if snd.number != testGroup || strings.Join(snd.mentioned, ",") != "491511" || !strings.Contains(snd.text, "@491511 – 50€") {
+		t.Errorf("Gruppe an %q erwähnt %v:\n%s", snd.number, snd.mentioned, snd.text)
+	}
+	if len(st.mahnungen) != 5 || !st.mahnungen[3].Gruppe || st.mahnungen[4].Gruppe || st.mahnungen[3].StrafeID != 1 || st.mahnungen[3].Stufe != 3 {
+		t.Errorf("mahnungen = %+v", st.mahnungen)
+	}
+	if strings.Join(st.gebuehren, ",") != "491511@s.whatsapp.net|5|2026-01-01,491513@s.whatsapp.net|5|2026-01-01" {
+		t.Errorf("gebuehren = %v", st.gebuehren)
+	}
+	if detail != "2 Mahnungen, 1 in der Gruppe erwähnt, 2 Mahngebühren · nicht zugestellt: Didi" {
+		t.Errorf("detail = %q", detail)
+	}
+	// Didis Mahnung wurde vor dem Versand protokolliert und wieder
+	// zurückgenommen.
+	if st.geloeschteMahnungen != 1 {
+		t.Errorf("nicht zugestellte Mahnung nicht zurückgenommen: %d", st.geloeschteMahnungen)
+	}
+
+	// Zweiter Lauf am selben Tag: nur Didi ist noch dran.
+	snd.dmErr, snd.dms = nil, nil
+	if _, err := s.MahnJob(context.Background(), payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(snd.dms) != 1 || snd.dms["491514@s.whatsapp.net"] == "" {
+		t.Errorf("zweiter Lauf: dms = %v", snd.dms)
+	}
+	// Didis Strafe ist 42 Tage alt, trotzdem erst Stufe 1 und keine Gebühr.
+	if didi := snd.dms["491514@s.whatsapp.net"]; !strings.Contains(didi, "kleine Erinnerung") || strings.Contains(didi, "Mahngebühr") {
+		t.Errorf("Didi überspringt Stufen:\n%s", didi)
+	}
+	if len(st.gebuehren) != 2 {
+		t.Errorf("gebuehren = %v", st.gebuehren)
+	}
+}
+
+// Ohne Protokoll keine Direktnachricht: sonst käme dieselbe Stufe samt
+// Gebühr beim nächsten Lauf noch einmal.
+func TestMahnJobOhneProtokollKeinVersand(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Invalid, thursday)
+	snd := &mentionSender{}
+	s.sender = snd
+	st.penaltyInput = mahnFixture()
+	st.mahnungErr = errors.New("db weg")
+
+	if _, err := s.MahnJob(context.Background(), json.RawMessage(`{"tage":[14,28,42]}`)); err == nil {
+		t.Error("Lauf ohne Protokoll muss scheitern (Scheduler wiederholt)")
+	}
+	if len(snd.dms) != 0 || snd.text != "" {
+		t.Errorf("ohne Protokoll verschickt: dms=%v text=%q", snd.dms, snd.text)
+	}
+}
+
+func TestMahnJobNichtsFaellig(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Invalid, thursday)
+	st.penaltyInput = mahnFixture()
+	detail, err := s.MahnJob(context.Background(), json.RawMessage(`{"tage":[60]}`))
+	if err != nil || detail != "nichts fällig (4 mit offenen Strafen)" || snd.text != "" {
+		t.Errorf("detail=%q err=%v text=%q", detail, err, snd.text)
+	}
+}
+
+func TestBefehlMahnungAus(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Absage, thursday)
+	out := s.run(context.Background(), groupMsg("mahnung aus"), false, false, s.today())
+	if out.Command != "mahnung" || !st.mahnOptOut["user-123"] || st.absentUserID != "" {
+		t.Fatalf("out=%+v optOut=%v", out, st.mahnOptOut)
+	}
+	if !strings.Contains(snd.text, "nicht mehr in der Gruppe") {
+		t.Errorf("Antwort: %q", snd.text)
+	}
+	s.run(context.Background(), groupMsg("Mahnung an"), false, false, s.today())
+	if st.mahnOptOut["user-123"] {
+		t.Error("mahnung an: Abbestellung muss weg sein")
+	}
+}
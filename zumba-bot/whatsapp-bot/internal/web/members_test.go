@@ -0,0 +1,87 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+	"github.com/michael/zumba-whatsapp-bot/internal/evolution"
+	"github.com/michael/zumba-whatsapp-bot/internal/store"
+)
+
+const seppID = "4917011@s.whatsapp.net"
+
+func testMembers() []store.Member {
+	return []store.Member{
+		{UserID: "user-123", UserName: "Tester"},
+		{UserID: seppID, UserName: "Sepp Huber", Aliases: []string{"seppi"}},
+	}
+}
+
+// mentionMsg baut eine Nachricht mit @-Erwähnungen (extendedTextMessage).
+func mentionMsg(t *testing.T, id, text string, mentioned ...string) evolution.WebhookEvent {
+	t.Helper()
+	jids, _ := json.Marshal(mentioned)
+	raw := `{"data":{"messageType":"extendedTextMessage","pushName":"Tester",
+		"key":{"remoteJid":"` + testGroup + `","id":"` + id + `","participantAlt":"user-123"},
+		"message":{"extendedTextMessage":{"text":"` + text + `","contextInfo":{"mentionedJid":` + string(jids) + `}}}}}`
+	var ev evolution.WebhookEvent
+	if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+		t.Fatal(err)
+	}
+	return ev
+}
+
+func TestAbsageFuerSichUndAndere(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Absage, thursday)
+	snd := &mentionSender{}
+	s.sender = snd
+	st.members = testMembers()
+
+	out := s.run(context.Background(), groupMsg("Seppi und ich kommen heute nicht"), false, false, s.today())
+	want := "user-123|2026-01-01|," + seppID + "|2026-01-01|user-123"
+	if got := strings.Join(st.absences, ","); got != want {
+		t.Errorf("absences = %s, want %s", got, want)
+	}
+	if strings.Join(out.For, ",") != seppID {
+		t.Errorf("For = %v", out.For)
+	}
+	// Bestätigung in der Gruppe, Sepp erwähnt.
+	if snd.number != testGroup || strings.Join(snd.mentioned, ",") != "4917011" ||
+		!strings.Contains(snd.text, "Tester hat @4917011") || !strings.Contains(snd.text, "abgemeldet") {
+		t.Errorf("Bestätigung an %q (%v):\n%s", snd.number, snd.mentioned, snd.text)
+	}
+}
+
+func TestAbsageNurFuerErwaehnteUndRueckgaengig(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Absage, thursday)
+	snd := &mentionSender{}
+	s.sender = snd
+	st.members = testMembers()
+
+	s.run(context.Background(), mentionMsg(t, "MSG-1", "@4917011 kann heute nicht", seppID), false, false, s.today())
+	if got := strings.Join(st.absences, ","); got != seppID+"|2026-01-01|user-123" {
+		t.Fatalf("absences = %s (Absender darf nicht abgemeldet werden)", got)
+	}
+	if e := st.effects["MSG-1"]; len(e.Undo) != 0 || len(e.UndoFor[seppID]) != 1 {
+		t.Errorf("Effekt: %+v", e)
+	}
+
+	// Löschen dreht die Absage für Sepp zurück, nicht für den Absender.
+	s.run(context.Background(), revokeMsg(t, "MSG-1"), false, false, s.today())
+	if st.presentUserID != seppID {
+		t.Errorf("MarkPresent für %q, want %s", st.presentUserID, seppID)
+	}
+}
+
+func TestErwaehnungOhneMitgliedTutNichts(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Absage, thursday)
+	st.members = testMembers()
+
+	out := s.run(context.Background(), mentionMsg(t, "MSG-2", "@4999 kann heute nicht", "4999@s.whatsapp.net"), false, false, s.today())
+	if len(st.absences) != 0 || out.Action != "none" || snd.called {
+		t.Errorf("absences=%v action=%s gesendet=%v", st.absences, out.Action, snd.called)
+	}
+}
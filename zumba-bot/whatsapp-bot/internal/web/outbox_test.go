@@ -0,0 +1,99 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+	"github.com/michael/zumba-whatsapp-bot/internal/outbox"
+)
+
+// fakeOutbox nimmt alles an; sent steuert, ob der Sofort-Versuch klappt.
+type fakeOutbox struct {
+	sent bool
+	msgs []outbox.Message
+}
+
+func (f *fakeOutbox) Send(_ context.Context, m outbox.Message) (bool, error) {
+	f.msgs = append(f.msgs, m)
+	return f.sent, nil
+}
+
+func TestStatistikBeiEvolutionAusfallInOutbox(t *testing.T) {
+	s, _, snd := newTestServer(classifier.Invalid, thursday)
+	ob := &fakeOutbox{sent: false}
+	s.Outbox = ob
+	ib := &fakeInbox{}
+	s.Inbox = ib
+
+	body, _ := json.Marshal(withID(groupMsg("statistik"), "MSG-OB"))
+	postWebhook(t, s, body)
+
+	if len(ob.msgs) != 1 || ob.msgs[0].Source != "statistik" || ob.msgs[0].Recipient != testGroup {
+		t.Fatalf("outbox: %+v", ob.msgs)
+	}
+	if snd.called {
+		t.Error("mit Outbox darf nicht zusätzlich direkt gesendet werden")
+	}
+	// Zustellung ist Sache der Outbox – das Event selbst ist erledigt und
+	// wird nicht wiederholt (sonst doppelte Statistik).
+	if e := ib.events["MSG-OB"]; e.status != "done" {
+		t.Errorf("inbox: %+v", e)
+	}
+}
+
+func TestWochenreportUeberOutboxVorschauDirekt(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Invalid, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	st.penaltyInput = penaltyFixture()
+	ob := &fakeOutbox{sent: true}
+	s.Outbox = ob
+
+	rr := httptest.NewRecorder()
+	s.Routes().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/weekly-report", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("code = %d", rr.Code)
+	}
+	if len(ob.msgs) != 1 || ob.msgs[0].Source != "wochenreport" || ob.msgs[0].Text == "" {
+		t.Fatalf("outbox: %+v", ob.msgs)
+	}
+
+	s.PreviewJID = "preview@s.whatsapp.net"
+	rr = httptest.NewRecorder()
+	s.Routes().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/weekly-report?preview=true", nil))
+	if len(ob.msgs) != 1 || !snd.called || snd.number != s.PreviewJID {
+		t.Errorf("Vorschau muss direkt gehen: outbox=%d sender=%+v", len(ob.msgs), snd)
+	}
+}
+
+func TestWochenreportJob(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Invalid, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	st.penaltyInput = penaltyFixture()
+	ob := &fakeOutbox{sent: false}
+	s.Outbox = ob
+
+	detail, err := s.WeeklyJob(context.Background(), json.RawMessage(`{"format":"text"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ob.msgs) != 1 || ob.msgs[0].Source != "wochenreport" || ob.msgs[0].Recipient != testGroup || ob.msgs[0].Image != nil {
+		t.Fatalf("outbox: %+v", ob.msgs)
+	}
+	if detail != "Text liegt in der Outbox" {
+		t.Errorf("detail = %q", detail)
+	}
+
+	// Bild verlangt, aber kein Renderer: Text-Fallback, Lauf trotzdem ok.
+	detail, err = s.WeeklyJob(context.Background(), json.RawMessage(`{"format":"image"}`))
+	if err != nil || len(ob.msgs) != 2 || ob.msgs[1].Image != nil || !strings.HasPrefix(detail, "Text (Bild-Fallback") {
+		t.Errorf("Fallback: err=%v detail=%q", err, detail)
+	}
+
+	if _, err := s.WeeklyJob(context.Background(), json.RawMessage(`{`)); err == nil {
+		t.Error("kaputter Payload: Fehler erwartet")
+	}
+}
@@ -0,0 +1,42 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+	"github.com/michael/zumba-whatsapp-bot/internal/worker"
+)
+
+func TestWebhookAsynchronUeberQueue(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Absage, thursday)
+	pool := worker.New(2, 10)
+	s.Queue = pool
+	ib := &fakeInbox{}
+	s.Inbox = ib
+
+	// Der erste Worker hängt, bis der Handler geantwortet hat.
+	gate := make(chan struct{})
+	pool.Submit(s.groupJID, func(context.Context) { <-gate })
+
+	body, _ := json.Marshal(withID(groupMsg("bin raus"), "MSG-Q"))
+	postWebhook(t, s, body)
+	if st.absentUserID != "" {
+		t.Fatal("Verarbeitung lief synchron im Request")
+	}
+	if e := ib.events["MSG-Q"]; e.status != "processing" {
+		t.Errorf("inbox vor Verarbeitung: %+v", e)
+	}
+
+	close(gate)
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if st.absentUserID == "" {
+		t.Error("Absage nach Drain nicht geschrieben")
+	}
+	if e := ib.events["MSG-Q"]; e.status != "done" {
+		t.Errorf("inbox nach Drain: %+v", e)
+	}
+}
@@ -0,0 +1,62 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+)
+
+// brokenStore lässt jedes Eintragen einer Absage fehlschlagen.
+type brokenStore struct{ *fakeStore }
+
+func (brokenStore) MarkAbsent(context.Context, string, time.Time, string, string) error {
+	return errors.New("db weg")
+}
+
+var testReactions = Reactions{Absent: "❌", Present: "✅", Error: "⚠\uFE0F"}
+
+func TestReaktionQuittiertAbUndZusage(t *testing.T) {
+	s, _, snd := newTestServer(classifier.Absage, thursday)
+	s.Reactions = testReactions
+	if out := s.run(context.Background(), withID(groupMsg("bin raus"), "MSG-1"), false, false, s.today()); out.Action != "marked_absent" {
+		t.Fatalf("Outcome %+v", out)
+	}
+
+	s.classifier = fakeClassifier{result: classifier.Zusage}
+	s.run(context.Background(), withID(groupMsg("bin doch dabei"), "MSG-2"), false, false, s.today())
+
+	if got := strings.Join(snd.reactions, ","); got != "MSG-1|❌,MSG-2|✅" {
+		t.Errorf("Reaktionen = %q", got)
+	}
+}
+
+func TestReaktionBeiFehlerUndDryRun(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Absage, thursday)
+	s.Reactions = testReactions
+
+	// Dry-Run reagiert nicht.
+	s.run(context.Background(), withID(groupMsg("bin raus"), "MSG-1"), false, true, s.today())
+	if len(snd.reactions) != 0 {
+		t.Fatalf("Dry-Run: %v", snd.reactions)
+	}
+
+	s.store = brokenStore{st}
+	if out := s.run(context.Background(), withID(groupMsg("bin raus"), "MSG-2"), false, false, s.today()); out.Action == "marked_absent" {
+		t.Fatalf("Outcome %+v", out)
+	}
+	if got := strings.Join(snd.reactions, ","); got != "MSG-2|⚠\uFE0F" {
+		t.Errorf("Reaktionen = %q", got)
+	}
+}
+
+func TestReaktionAusOhneEmoji(t *testing.T) {
+	s, _, snd := newTestServer(classifier.Absage, thursday)
+	s.run(context.Background(), withID(groupMsg("bin raus"), "MSG-1"), false, false, s.today())
+	if len(snd.reactions) != 0 {
+		t.Errorf("Reaktionen ohne Konfiguration: %v", snd.reactions)
+	}
+}
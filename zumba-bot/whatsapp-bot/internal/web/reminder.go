@@ -0,0 +1,164 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	sharedstore "github.com/michael/zumba-shared/store"
+	"github.com/michael/zumba-whatsapp-bot/internal/command"
+	"github.com/michael/zumba-whatsapp-bot/internal/report"
+)
+
+// MentionSender kann in einem Gruppen-Text Mitglieder erwähnen (@-Mention mit
+// Benachrichtigung). Optional: Sender ohne Mentions (stdout/file-Sink)
+// bekommen den reinen Text.
+type MentionSender interface {
+	SendTextMentions(ctx context.Context, number, text string, mentioned []string) error
+}
+
+// Erinnerungs-Modi (Payload des Jobs "erinnerung").
+const (
+	ReminderGroup = "group" // eine Gruppen-Nachricht mit @-Erwähnungen
+	ReminderDM    = "dm"    // je Mitglied eine Direktnachricht
+)
+
+// ReminderJob ist der Scheduler-Job "erinnerung": Am Stammtisch-Tag bekommen
+// alle ohne Rückmeldung (keine Absage, keine Zusage) einen Schubs, außer sie
+// haben ihn abbestellt. Payload: {"mode": "group"|"dm"}. An anderen Tagen und
+// an Sperrtagen passiert nichts – der Job darf daher täglich laufen.
+//
+// Die Erinnerung geht direkt raus, nicht über die Outbox: sie ist nur bis zum
+// Treffen sinnvoll, und bei einem Fehler wiederholt der Scheduler den Lauf.
+// Bei Direktnachrichten gilt der Lauf als gelungen, sobald eine zugestellt
+// ist – sonst bekämen die anderen sie bei der Wiederholung doppelt.
+func (s *Server) ReminderJob(ctx context.Context, payload json.RawMessage) (string, error) {
+	p := struct {
+		Mode string `json:"mode"`
+	}{Mode: ReminderGroup}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return "", fmt.Errorf("payload: %w", err)
+		}
+	}
+
+	today := s.today()
+	if !s.Schedule.IsMeeting(today) {
+		return "heute kein Stammtisch", nil
+	}
+	excluded, err := s.excludedAhead(ctx, today)
+	if err != nil {
+		return "", fmt.Errorf("ExcludedDays: %w", err)
+	}
+	if excluded[today.Format("2006-01-02")] {
+		return "heute Sperrtag", nil
+	}
+
+	entries, err := s.store.Roster(ctx, today)
+	if err != nil {
+		return "", fmt.Errorf("Roster: %w", err)
+	}
+	optOut, err := s.store.ReminderOptOuts(ctx)
+	if err != nil {
+		return "", fmt.Errorf("ReminderOptOuts: %w", err)
+	}
+	var open []sharedstore.RosterEntry
+	skipped := 0
+	for _, e := range entries {
+		if e.Status != sharedstore.RosterOffen {
+			continue
+		}
+		if optOut[e.UserID] {
+			skipped++
+			continue
+		}
+		open = append(open, e)
+	}
+	if len(open) == 0 {
+		return fmt.Sprintf("alle haben sich gemeldet (%d abbestellt)", skipped), nil
+	}
+
+	// No-Show-Betrag laut aktuellem Regelwerk; ohne DB der Default.
+	rules, err := s.store.PenaltyRules(ctx)
+	if err != nil {
+		log.Printf("⚠️  PenaltyRules: %v (Default-Regeln)", err)
+	}
+	noShow := rules.Sorted().At(today).NoShowDefault
+
+	switch p.Mode {
+	case ReminderGroup:
+		text, mentioned := report.BuildGroupReminder(today, open, noShow)
+		if ms, ok := s.sender.(MentionSender); ok {
+			err = ms.SendTextMentions(ctx, s.groupJID, text, mentioned)
+		} else {
+			err = s.sender.SendText(ctx, s.groupJID, text)
+		}
+		if err != nil {
+			return "", fmt.Errorf("Versand: %w", err)
+		}
+		log.Printf("⏰ Erinnerung an die Gruppe: %d ohne Rückmeldung erwähnt", len(open))
+		return fmt.Sprintf("Gruppe, %d erwähnt (%d abbestellt)", len(open), skipped), nil
+
+	case ReminderDM:
+		sent := 0
+		var failed []string
+		for _, e := range open {
+			if err := s.sender.SendText(ctx, e.UserID, report.BuildDMReminder(today, e.UserName, noShow)); err != nil {
+				log.Printf("⚠️  Erinnerung an %s: %v", e.UserName, err)
+				failed = append(failed, e.UserName)
+				continue
+			}
+			sent++
+		}
+		if sent == 0 {
+			return "", fmt.Errorf("Versand: keine Direktnachricht zugestellt (%d Versuche)", len(open))
+		}
+		log.Printf("⏰ Erinnerung per Direktnachricht an %d Mitglieder", sent)
+		detail := fmt.Sprintf("%d Direktnachrichten (%d abbestellt)", sent, skipped)
+		if len(failed) > 0 {
+			detail += " · nicht zugestellt: " + strings.Join(failed, ", ")
+		}
+		return detail, nil
+	}
+	return "", fmt.Errorf("payload: unbekannter mode %q (group|dm)", p.Mode)
+}
+
+// cmdErinnerung bestellt die Erinnerung am Stammtisch-Tag ab ("erinnerung
+// aus") bzw. wieder an; ohne Argument nennt es den aktuellen Stand.
+func (s *Server) cmdErinnerung(ctx context.Context, c command.Call) (command.Reply, error) {
+	arg := ""
+	if len(c.Args) > 0 {
+		arg = strings.ToLower(c.Args[0])
+	}
+	switch arg {
+	case "aus", "an":
+		optOut := arg == "aus"
+		if !c.DryRun {
+			if err := s.store.SetReminderOptOut(ctx, c.UserID, optOut); err != nil {
+				return command.Reply{}, err
+			}
+		}
+		if optOut {
+			return command.Reply{
+				Text:   "🔕 Alles klar, " + c.UserName + " – keine Erinnerung mehr am Stammtisch-Tag. Mit „erinnerung an“ kommt sie wieder.",
+				Detail: "abbestellt",
+			}, nil
+		}
+		return command.Reply{
+			Text:   "🔔 Passt, " + c.UserName + " – am Stammtisch-Tag erinnere ich dich wieder, falls du dich noch nicht gemeldet hast.",
+			Detail: "angemeldet",
+		}, nil
+	case "":
+		optOuts, err := s.store.ReminderOptOuts(ctx)
+		if err != nil {
+			return command.Reply{}, err
+		}
+		if optOuts[c.UserID] {
+			return command.Reply{Text: "🔕 Deine Erinnerung ist aus. Mit „erinnerung an“ schaltest du sie ein.", Detail: "aus"}, nil
+		}
+		return command.Reply{Text: "🔔 Deine Erinnerung ist an. Mit „erinnerung aus“ schaltest du sie ab.", Detail: "an"}, nil
+	}
+	return command.Reply{Text: "🤔 „erinnerung an“ oder „erinnerung aus“?", Detail: "unbekanntes Argument " + arg}, nil
+}
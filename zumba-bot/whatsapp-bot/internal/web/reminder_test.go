@@ -0,0 +1,137 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael/zumba-shared/penalty"
+	sharedstore "github.com/michael/zumba-shared/store"
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+	"github.com/michael/zumba-whatsapp-bot/internal/store"
+)
+
+// mentionSender zeichnet Gruppen-Texte mit Erwähnungen und Direktnachrichten auf.
+type mentionSender struct {
+	fakeSender
+	mentioned []string
+	dms       map[string]string
+	dmErr     map[string]error
+}
+
+func (m *mentionSender) SendTextMentions(_ context.Context, number, text string, mentioned []string) error {
+	m.number, m.text, m.mentioned = number, text, mentioned
+	return nil
+}
+
+func (m *mentionSender) SendText(ctx context.Context, number, text string) error {
+	if err := m.dmErr[number]; err != nil {
+		return err
+	}
+	if m.dms == nil {
+		m.dms = map[string]string{}
+	}
+	m.dms[number] = text
+	return m.fakeSender.SendText(ctx, number, text)
+}
+
+func reminderRoster() []store.RosterEntry {
+	return []store.RosterEntry{
+		{UserID: "491511@s.whatsapp.net", UserName: "Anna", Status: sharedstore.RosterOffen},
+		{UserID: "491512@s.whatsapp.net", UserName: "Bert", Status: sharedstore.RosterZugesagt},
+		{UserID: "491513@s.whatsapp.net", UserName: "Chris", Status: sharedstore.RosterAbgesagt},
+		{UserID: "491514@s.whatsapp.net", UserName: "Didi", Status: sharedstore.RosterOffen},
+		{UserID: "491515@s.whatsapp.net", UserName: "Emil", Status: sharedstore.RosterOffen},
+	}
+}
+
+func TestErinnerungGruppeMitErwaehnungen(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Invalid, thursday)
+	snd := &mentionSender{}
+	s.sender = snd
+	st.roster = reminderRoster()
+	st.optOut = map[string]bool{"491515@s.whatsapp.net": true}
+	// Seit Dezember gilt ein neuer No-Show-Betrag.
+	st.penaltyInput.Rules = penalty.RuleSets{penalty.DefaultRuleSet,
+		{GiltAb: time.Date(2025, 12, 18, 0, 0, 0, 0, time.UTC), MinFehltage: 5, BasisBetrag: 25, ProTagBetrag: 5, NoShowDefault: 40}}
+
+	detail, err := s.ReminderJob(context.Background(), json.RawMessage(`{"mode":"group"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.rosterDay != "2026-01-01" {
+		t.Errorf("Roster für %q, want heute", st.rosterDay)
+	}
+	// Nur ohne Rückmeldung und nicht abbestellt: Anna + Didi.
+	if snd.number != testGroup || strings.Join(snd.mentioned, ",") != "491511,491514" {
+		t.Errorf("an %q erwähnt %v", snd.number, snd.mentioned)
+	}
+	if !strings.Contains(snd.text, "@491511 @491514") || strings.Contains(snd.text, "491515") || !strings.Contains(snd.text, "(40€)") {
+		t.Errorf("Text:\n%s", snd.text)
+	}
+	if detail != "Gruppe, 2 erwähnt (1 abbestellt)" {
+		t.Errorf("detail = %q", detail)
+	}
+}
+
+func TestErinnerungDirektnachrichten(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Invalid, thursday)
+	snd := &mentionSender{dmErr: map[string]error{"491514@s.whatsapp.net": errors.New("not on whatsapp")}}
+	s.sender = snd
+	st.roster = reminderRoster()
+
+	detail, err := s.ReminderJob(context.Background(), json.RawMessage(`{"mode":"dm"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snd.dms) != 2 || !strings.Contains(snd.dms["491511@s.whatsapp.net"], "Servus Anna") {
+		t.Errorf("dms = %v", snd.dms)
+	}
+	// Teilweise zugestellt zählt als Erfolg (keine doppelten DMs per Retry).
+	if !strings.HasSuffix(detail, "nicht zugestellt: Didi") {
+		t.Errorf("detail = %q", detail)
+	}
+
+	snd.dmErr = map[string]error{}
+	for _, e := range st.roster {
+		snd.dmErr[e.UserID] = errors.New("evolution down")
+	}
+	if _, err := s.ReminderJob(context.Background(), json.RawMessage(`{"mode":"dm"}`)); err == nil {
+		t.Error("keine DM zugestellt: Fehler erwartet (Retry)")
+	}
+}
+
+func TestErinnerungNurAmStammtischTag(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Invalid, friday)
+	st.roster = reminderRoster()
+	detail, err := s.ReminderJob(context.Background(), nil)
+	if err != nil || detail != "heute kein Stammtisch" || snd.called || st.rosterDay != "" {
+		t.Errorf("Freitag: detail=%q err=%v gesendet=%v", detail, err, snd.called)
+	}
+
+	s, st, snd = newTestServer(classifier.Invalid, thursday)
+	st.roster = reminderRoster()
+	st.excluded = append(st.excluded, s.today())
+	if detail, _ := s.ReminderJob(context.Background(), nil); detail != "heute Sperrtag" || snd.called {
+		t.Errorf("Sperrtag: detail=%q gesendet=%v", detail, snd.called)
+	}
+}
+
+func TestBefehlErinnerungAus(t *testing.T) {
+	s, st, snd := newTestServer(classifier.Absage, thursday)
+	out := s.run(context.Background(), groupMsg("erinnerung aus"), false, false, s.today())
+	if out.Command != "erinnerung" || !st.optOut["user-123"] || st.absentUserID != "" {
+		t.Fatalf("out=%+v optOut=%v", out, st.optOut)
+	}
+	if !strings.Contains(snd.text, "keine Erinnerung mehr") {
+		t.Errorf("Antwort: %q", snd.text)
+	}
+
+	s.run(context.Background(), groupMsg("Erinnerung an"), false, false, s.today())
+	if st.optOut["user-123"] {
+		t.Error("erinnerung an: Abbestellung muss weg sein")
+	}
+}
@@ -0,0 +1,99 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/michael/zumba-shared/rules"
+	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+	"github.com/michael/zumba-whatsapp-bot/internal/tracestore"
+)
+
+type fakeRules struct {
+	set  *rules.Set
+	hits []int64
+}
+
+func newFakeRules(rs ...rules.Rule) *fakeRules {
+	set, _ := rules.Compile(rs)
+	return &fakeRules{set: set}
+}
+
+func (f *fakeRules) Match(text string) *rules.Rule { return f.set.Match(text) }
+func (f *fakeRules) Hit(_ context.Context, id int64) error {
+	f.hits = append(f.hits, id)
+	return nil
+}
+
+// failingClassifier darf nicht gefragt werden.
+type failingClassifier struct{}
+
+func (failingClassifier) Classify(context.Context, string) (classifier.Classification, error) {
+	return classifier.Classification{}, errors.New("Classifier trotz Regel-Treffer")
+}
+
+func TestRegelEntscheidetOhneClassifier(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Invalid, thursday)
+	fr := newFakeRules(rules.Rule{ID: 7, Kind: rules.KindEmoji, Pattern: "👎", Label: "false", Note: "Daumen runter"})
+	sh := &fakeShadow{}
+	s.classifier, s.Rules, s.Shadow = failingClassifier{}, fr, sh
+	rec := tracestore.NewRecorder()
+
+	out := s.run(context.Background(), groupMsg("👎🏼"), false, false, s.today(), rec)
+	if out.Action != "marked_absent" || len(st.absentDates) != 1 {
+		t.Fatalf("Outcome %+v, dates %v", out, st.absentDates)
+	}
+	if len(fr.hits) != 1 || fr.hits[0] != 7 {
+		t.Errorf("hits = %v", fr.hits)
+	}
+	if len(sh.got) != 0 {
+		t.Errorf("Regel-Treffer im Shadow-Modus protokolliert: %+v", sh.got)
+	}
+	var ruleStep, classifyStep bool
+	for _, step := range rec.Steps() {
+		switch step.Node {
+		case tracestore.NodeRules:
+			ruleStep = step.Outcome == tracestore.OutcomePass && strings.Contains(step.Detail, "#7") && strings.Contains(step.Detail, "Daumen runter")
+		case tracestore.NodeClassify:
+			classifyStep = true
+		}
+	}
+	if !ruleStep || classifyStep {
+		t.Errorf("Trace: %+v", rec.Steps())
+	}
+}
+
+func TestOhnePassendeRegelFragtClassifier(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Absage, thursday)
+	fr := newFakeRules(rules.Rule{ID: 1, Kind: rules.KindEmoji, Pattern: "👎", Label: "false"})
+	s.Rules = fr
+	rec := tracestore.NewRecorder()
+
+	s.run(context.Background(), groupMsg("bin raus"), false, false, s.today(), rec)
+	if len(st.absentDates) != 1 || len(fr.hits) != 0 {
+		t.Errorf("dates %v, hits %v", st.absentDates, fr.hits)
+	}
+	steps := rec.Steps()
+	var sawRule bool
+	for _, step := range steps {
+		if step.Node == tracestore.NodeRules {
+			sawRule = step.Outcome == tracestore.OutcomeInfo
+		}
+	}
+	if !sawRule {
+		t.Errorf("Regel-Schritt fehlt: %+v", steps)
+	}
+}
+
+func TestRegelTrefferImDryRunNichtGezaehlt(t *testing.T) {
+	s, _, _ := newTestServer(classifier.Invalid, thursday)
+	fr := newFakeRules(rules.Rule{ID: 3, Kind: rules.KindExact, Pattern: "bin raus", Label: "false"})
+	s.classifier, s.Rules = failingClassifier{}, fr
+
+	out := s.run(context.Background(), groupMsg("Bin raus!"), true, true, s.today())
+	if out.Action != "would_mark_absent" || len(fr.hits) != 0 {
+		t.Errorf("Outcome %+v, hits %v", out, fr.hits)
+	}
+}
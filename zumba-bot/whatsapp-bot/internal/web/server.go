@@ -11,15 +11,26 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/michael/zumba-shared/domain"
+	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-shared/rules"
+	"github.com/michael/zumba-whatsapp-bot/internal/chatlog"
+	"github.com/michael/zumba-whatsapp-bot/internal/clarify"
 	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
+	"github.com/michael/zumba-whatsapp-bot/internal/command"
+	"github.com/michael/zumba-whatsapp-bot/internal/dates"
 	"github.com/michael/zumba-whatsapp-bot/internal/evolution"
-	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-whatsapp-bot/internal/inbox"
+	"github.com/michael/zumba-whatsapp-bot/internal/members"
+	"github.com/michael/zumba-whatsapp-bot/internal/outbox"
 	"github.com/michael/zumba-whatsapp-bot/internal/report"
 	"github.com/michael/zumba-whatsapp-bot/internal/store"
 	"github.com/michael/zumba-whatsapp-bot/internal/tracestore"
+	"github.com/michael/zumba-whatsapp-bot/internal/worker"
 )
 
 // Classifier klassifiziert eine Nachricht (entkoppelt für Tests).
@@ -31,6 +42,15 @@ type Classifier interface {
 type Sender interface {
 	SendText(ctx context.Context, number, text string) error
 	SendImage(ctx context.Context, number, caption string, png []byte) error
+	SendReaction(ctx context.Context, key evolution.MessageKey, emoji string) error
+}
+
+// Reactions sind die Quittungen an der Originalnachricht nach einer Ab-/Zusage
+// (leer = keine Reaktion).
+type Reactions struct {
+	Absent  string // Absage eingetragen, z. B. ❌
+	Present string // Zusage eingetragen, z. B. ✅
+	Error   string // Eintragen fehlgeschlagen, z. B. ⚠️
 }
 
 // Renderer rendert HTML zu einem PNG (renderer-service; nil = Bild-Karte aus).
@@ -43,10 +63,72 @@ type Tracer interface {
 	Save(ctx context.Context, t tracestore.Trace) error
 }
 
-// ShadowRecorder loggt Gemini- vs. ML-Modell-Klassifikation (Shadow-Modus,
-// optional, nil = aus). Muss selbst asynchron/best-effort arbeiten.
+// Inbox hält eingehende Events per Message-ID dauerhaft fest und
+// dedupliziert Webhook-Wiederholungen (optional, nil = aus).
+type Inbox interface {
+	Claim(ctx context.Context, messageID string, payload []byte) (time.Time, bool, error)
+	Finish(ctx context.Context, messageID, procErr string) error
+	Due(ctx context.Context, limit int) ([]inbox.Event, error)
+}
+
+// Outbox stellt Gruppen-Nachrichten dauerhaft zu: sofortiger Versuch, bei
+// Fehlern Wiederholung mit Backoff (optional, nil = direkt über den Sender).
+type Outbox interface {
+	Send(ctx context.Context, m outbox.Message) (sent bool, err error)
+}
+
+// Queue verarbeitet Events asynchron, je key (Chat) in Eingangsreihenfolge
+// (optional, nil = synchron im Request). false = nicht angenommen.
+type Queue interface {
+	Submit(key string, job worker.Job) bool
+}
+
+// KeyedClassifier routet nach Message-ID (Canary, optional): Wiederholung und
+// Bearbeitung einer Nachricht landen so auf derselben Route.
+type KeyedClassifier interface {
+	ClassifyKeyed(ctx context.Context, key, message string) (classifier.Classification, error)
+}
+
+// History ist der kurze Gesprächsverlauf der Gruppe (optional, nil = ohne
+// Kontext).
+type History interface {
+	Add(ctx context.Context, m chatlog.Message) error
+	Before(ctx context.Context, chat string, at time.Time, excludeID string) ([]chatlog.Message, error)
+	Get(ctx context.Context, chat, messageID string) (*chatlog.Message, error)
+}
+
+// ContextClassifier klassifiziert eine Nachricht mit Gesprächskontext (im
+// Betrieb das LLM direkt – Cache und eigenes Modell kennen keinen Kontext).
+type ContextClassifier interface {
+	ClassifyInContext(ctx context.Context, message string, history []classifier.Turn) (classifier.Classification, error)
+}
+
+// RuleMatcher ist die Vorklassifikation (optional, nil = aus): feste Regeln
+// aus dem Admin-UI, die vor dem Classifier entscheiden. Hit zählt Treffer.
+type RuleMatcher interface {
+	Match(text string) *rules.Rule
+	Hit(ctx context.Context, id int64) error
+}
+
+// Clarifier hält die offenen Rückfragen bei unklaren Nachrichten (optional,
+// nil = keine Rückfragen).
+type Clarifier interface {
+	Ask(ctx context.Context, q clarify.Question) error
+	Get(ctx context.Context, chat, questionID string) (*clarify.Question, error)
+	Answer(ctx context.Context, questionID, answer string) error
+}
+
+// QuoteSender antwortet mit Zitat und liefert die Message-ID der gesendeten
+// Nachricht. Optional: ohne (stdout/file-Sink) fragt der Bot nicht nach.
+type QuoteSender interface {
+	SendTextQuoted(ctx context.Context, number, text string, q evolution.Quote) (string, error)
+}
+
+// ShadowRecorder loggt Gemini- vs. ML-Modell-Klassifikation samt Route
+// (Shadow-Modus, optional, nil = aus). Muss selbst asynchron/best-effort
+// arbeiten.
 type ShadowRecorder interface {
-	RecordAsync(userID, userName, message, geminiLabel string)
+	RecordAsync(userID, userName, message string, c classifier.Classification)
 }
 
 type Server struct {
@@ -56,12 +138,28 @@ type Server struct {
 	groupJID   string
 	location   *time.Location
 
-	// Now ist überschreibbar für Tests (Donnerstag-Prüfung / Tagesdatum).
+	// Now ist überschreibbar für Tests (Tagesdatum).
 	Now func() time.Time
 
-	// Tracer zeichnet Gruppen-/Donnerstag-Events auf (von main gesetzt; nil = aus).
+	// Schedule bestimmt die Stammtisch-Tage, auf die Ab-/Zusagen aufgelöst
+	// werden (von main gesetzt; Nullwert = jeden Donnerstag).
+	Schedule domain.Schedule
+
+	// Tracer zeichnet Gruppen-Events auf (von main gesetzt; nil = aus).
 	Tracer Tracer
 
+	// Inbox dedupliziert Webhooks und hält fehlgeschlagene Events zur
+	// Wiederholung fest (von main gesetzt; nil = aus).
+	Inbox Inbox
+
+	// Queue verarbeitet Webhooks asynchron, damit Evolution sofort sein
+	// 200 bekommt (von main gesetzt; nil = synchron im Request).
+	Queue Queue
+
+	// Outbox hält Statistik und Wochenreport fest, bis Evolution sie
+	// angenommen hat (von main gesetzt; nil = direkt senden).
+	Outbox Outbox
+
 	// PreviewJID ist das Ziel des "Vorschau"-Modus der Bot-Test-Seite (von main
 	// gesetzt; leer = Vorschau aus).
 	PreviewJID string
@@ -69,23 +167,51 @@ type Server struct {
 	// Shadow protokolliert den ML-Shadow-Modus (von main gesetzt; nil = aus).
 	Shadow ShadowRecorder
 
+	// Rules entscheiden eindeutige Fälle (👎, feste Sprüche) ohne Classifier
+	// (von main gesetzt; nil = aus).
+	Rules RuleMatcher
+
+	// History und Contextual: ist eine Nachricht allein "invalid", fragt der
+	// Bot das LLM noch einmal mit den Nachrichten davor bzw. der zitierten
+	// Nachricht ("ich auch", "+1"). Von main gesetzt; nil = ohne Kontext.
+	History    History
+	Contextual ContextClassifier
+
+	// Clarify: klingt eine "invalid"-Nachricht nach Ab-/Zusage ("schau ma
+	// mal"), fragt der Bot per Zitat nach; die Antwort bzw. Reaktion darauf
+	// entscheidet bis ClarifyTTL (höchstens bis zum Termin). Von main
+	// gesetzt; nil = keine Rückfragen.
+	Clarify    Clarifier
+	ClarifyTTL time.Duration
+
+	// Reactions quittiert eingetragene Ab-/Zusagen mit einem Emoji an der
+	// Nachricht (von main gesetzt; Nullwert = keine Reaktionen).
+	Reactions Reactions
+
 	// Renderer rendert die Statistik-Bild-Karte (von main gesetzt; nil = aus).
 	Renderer Renderer
 
 	// StatsFormat steuert die Antwort auf "statistik" in der Gruppe:
 	// "image" schickt die PNG-Karte (Fallback Text), sonst Text.
 	StatsFormat string
+
+	// Commands sind die Gruppen-Befehle ("statistik", "hilfe" …); New
+	// registriert die eingebauten, main setzt die Admins.
+	Commands *command.Router
 }
 
 func New(st store.Store, cl Classifier, snd Sender, groupJID string, loc *time.Location) *Server {
-	return &Server{
+	s := &Server{
 		store:      st,
 		classifier: cl,
 		sender:     snd,
 		groupJID:   groupJID,
 		location:   loc,
 		Now:        time.Now,
+		Commands:   command.NewRouter(),
 	}
+	s.registerCommands()
+	return s
 }
 
 func (s *Server) Routes() http.Handler {
@@ -102,16 +228,20 @@ func (s *Server) Routes() http.Handler {
 
 // Outcome beschreibt das Ergebnis eines Webhook-/Test-Durchlaufs.
 type Outcome struct {
-	Path           string `json:"path"`           // "statistik" | "classify" | "ignored"
-	Classification string `json:"classification"` // "true"|"false"|"invalid"
-	Action         string `json:"action"`         // marked_absent|marked_present|would_mark_absent|would_mark_present|none
-	Message        string `json:"message"`        // Statistik-Text bzw. Eingabe-Text
-	Recipient      string `json:"recipient"`
-	Date           string `json:"date"`
-	UserID         string `json:"userId"`
-	Reason         string `json:"reason"`
-	DryRun         bool   `json:"dryRun"`              // true: nichts gesendet/geschrieben, nur berechnet
-	PreviewTo      string `json:"previewTo,omitempty"` // gesetzt: Nachricht wurde als Vorschau an diese Nummer geschickt
+	Path           string   `json:"path"`              // "statistik" | "command" | "classify" | "revoke" | "ignored"
+	Command        string   `json:"command,omitempty"` // erkannter Befehl
+	Classification string   `json:"classification"`    // "true"|"false"|"invalid"
+	Action         string   `json:"action"`            // marked_absent|marked_present|would_mark_absent|would_mark_present|reverted|would_revert|asked|would_ask|none
+	Message        string   `json:"message"`           // Statistik-Text bzw. Eingabe-Text
+	Recipient      string   `json:"recipient"`
+	Date           string   `json:"date"`            // erster Ziel-Termin (bzw. Verarbeitungstag)
+	Dates          []string `json:"dates,omitempty"` // alle Ziel-Termine der Ab-/Zusage
+	UserID         string   `json:"userId"`
+	Reason         string   `json:"reason"`
+	FollowUpOf     string   `json:"followUpOf,omitempty"` // Bearbeitung/Löschung: Message-ID des Originals
+	For            []string `json:"for,omitempty"`        // weitere Mitglieder, für die die Ab-/Zusage gilt (userIds)
+	DryRun         bool     `json:"dryRun"`               // true: nichts gesendet/geschrieben, nur berechnet
+	PreviewTo      string   `json:"previewTo,omitempty"`  // gesetzt: Nachricht wurde als Vorschau an diese Nummer geschickt
 
 	// ImageBase64 ist die als PNG gerenderte Statistik-Karte (nur bei
 	// ?format=image; base64 ohne data:-Präfix).
@@ -125,7 +255,7 @@ type Outcome struct {
 }
 
 // run verarbeitet ein Event und protokolliert jeden Entscheidungspunkt im
-// Recorder. bypassGuards überspringt die Donnerstag-/Gruppen-Prüfung (Test-Pfad).
+// Recorder. bypassGuards überspringt die Typ-/Gruppen-Prüfung (Test-Pfad).
 // dryRun berechnet das Ergebnis, ohne zu senden oder in die DB zu schreiben.
 // asOf ist der (ggf. simulierte) Verarbeitungstag – im echten Betrieb heute.
 func (s *Server) run(ctx context.Context, ev evolution.WebhookEvent, bypassGuards, dryRun bool, asOf time.Time, recs ...*tracestore.Recorder) Outcome {
@@ -134,25 +264,37 @@ func (s *Server) run(ctx context.Context, ev evolution.WebhookEvent, bypassGuard
 		rec = recs[0]
 	}
 	msg := ev.Message()
+	kind := ev.Kind()
 	rec.Step(tracestore.NodeReceived, tracestore.OutcomeInfo, "Webhook empfangen",
-		fmt.Sprintf("%s (%s) · Typ %q", ev.UserName(), ev.UserID(), ev.MessageType()))
+		fmt.Sprintf("%s (%s) · Typ %q%s", ev.UserName(), ev.UserID(), ev.MessageType(), kindSuffix(kind, ev.TargetID())))
 
-	// Verzweigung 1: "statistik"
-	if strings.EqualFold(strings.TrimSpace(msg), "statistik") {
-		rec.Step(tracestore.NodeCheckStatistik, tracestore.OutcomePass, `"statistik"?`, "ja")
-		text, stats, entries := s.runStats(ctx, ev.RemoteJid(), dryRun, asOf, rec)
-		return Outcome{Path: "statistik", Message: text, Recipient: ev.RemoteJid(), DryRun: dryRun, stats: stats, penalties: entries}
+	// Verzweigung 1: Befehle wie "statistik" (nur neue Nachrichten – ein
+	// nachträglich auf einen Befehl bearbeiteter Text löst keinen zweiten
+	// Post aus)
+	if kind == evolution.KindMessage {
+		if cmd, args, ok := s.Commands.Match(msg); ok {
+			rec.Step(tracestore.NodeCheckStatistik, tracestore.OutcomePass, "Befehl?", "ja: "+cmd.Name)
+			return s.runCommand(ctx, ev, cmd, args, dryRun, asOf, rec)
+		}
 	}
-	rec.Step(tracestore.NodeCheckStatistik, tracestore.OutcomeInfo, `"statistik"?`, "nein")
+	rec.Step(tracestore.NodeCheckStatistik, tracestore.OutcomeInfo, "Befehl?", "nein")
 
-	// Verzweigung 2: Guards (messageType / Gruppe / Donnerstag)
+	// Verzweigung 2: Guards (messageType / Gruppe). Einen Tages-Guard gibt es
+	// nicht mehr: Ab-/Zusagen kommen an jedem Tag an und werden unten auf
+	// die gemeinten Stammtisch-Termine aufgelöst.
 	if !bypassGuards {
-		if ev.MessageType() != "conversation" {
-			rec.Step(tracestore.NodeGuardType, tracestore.OutcomeFail, "messageType == conversation?", "nein: "+ev.MessageType())
-			rec.Step(tracestore.NodeIgnored, tracestore.OutcomeInfo, "Ignoriert", "kein conversation-Event")
-			return Outcome{Path: "ignored", Reason: "guard: messageType != conversation"}
+		// Antworten/Zitate kommen als extendedTextMessage, Reaktionen zählen
+		// nur als Antwort auf eine Rückfrage (siehe unten).
+		if !isText(ev.MessageType()) && kind == evolution.KindMessage && ev.Reaction() == nil {
+			rec.Step(tracestore.NodeGuardType, tracestore.OutcomeFail, "Textnachricht?", "nein: "+ev.MessageType())
+			rec.Step(tracestore.NodeIgnored, tracestore.OutcomeInfo, "Ignoriert", "keine Textnachricht")
+			return Outcome{Path: "ignored", Reason: "guard: keine Textnachricht"}
+		}
+		detail := "ja" + kindSuffix(kind, "")
+		if ev.Reaction() != nil {
+			detail = "Reaktion"
 		}
-		rec.Step(tracestore.NodeGuardType, tracestore.OutcomePass, "messageType == conversation?", "ja")
+		rec.Step(tracestore.NodeGuardType, tracestore.OutcomePass, "Textnachricht?", detail)
 
 		if ev.RemoteJid() != s.groupJID {
 			rec.Step(tracestore.NodeGuardGroup, tracestore.OutcomeFail, "Zumba-Gruppe?", "nein")
@@ -160,35 +302,141 @@ func (s *Server) run(ctx context.Context, ev evolution.WebhookEvent, bypassGuard
 			return Outcome{Path: "ignored", Reason: "guard: andere Gruppe"}
 		}
 		rec.Step(tracestore.NodeGuardGroup, tracestore.OutcomePass, "Zumba-Gruppe?", "ja")
-
-		if !s.isThursday() {
-			rec.Step(tracestore.NodeGuardThursday, tracestore.OutcomeFail, "Donnerstag?", "nein")
-			rec.Step(tracestore.NodeIgnored, tracestore.OutcomeInfo, "Ignoriert", "nicht Donnerstag")
-			return Outcome{Path: "ignored", Reason: "guard: nicht Donnerstag"}
-		}
-		rec.Step(tracestore.NodeGuardThursday, tracestore.OutcomePass, "Donnerstag?", s.today().Format("Mon, 2006-01-02"))
 	} else {
 		rec.Step(tracestore.NodeGuardType, tracestore.OutcomeInfo, "Guards", "übersprungen (Test-Pfad)")
 	}
 
-	// Classifier (Gemini)
-	c, err := s.classifier.Classify(ctx, msg)
-	if err != nil {
-		rec.Step(tracestore.NodeClassify, tracestore.OutcomeError, "Classifier (Gemini)", err.Error())
+	// Bearbeitung/Löschung: erst die Wirkung des Originals zurückdrehen. Eine
+	// Bearbeitung wird danach wie eine neue Nachricht klassifiziert – bezogen
+	// auf den Tag des Originals und unter dessen Message-ID.
+	userID := ev.UserID()
+	messageID := ev.MessageID()
+	if kind != evolution.KindMessage {
+		messageID = ev.TargetID()
+		orig, reverted := s.undoOriginal(ctx, messageID, dryRun, rec)
+		if orig != nil {
+			asOf = orig.SentOn
+		}
+		if kind == evolution.KindRevoke {
+			out := Outcome{Path: "revoke", Action: "none", Recipient: ev.RemoteJid(), UserID: userID,
+				FollowUpOf: messageID, DryRun: dryRun}
+			if reverted {
+				out.Action = "reverted"
+				if dryRun {
+					out.Action = "would_revert"
+				}
+			}
+			if orig != nil {
+				out.UserID, out.Message = orig.UserID, orig.Message
+				if !dryRun && !orig.Revoked {
+					s.saveEffect(ctx, store.MessageEffect{MessageID: messageID, UserID: orig.UserID,
+						Message: orig.Message, Classification: orig.Classification, SentOn: orig.SentOn, Revoked: true})
+				}
+			}
+			if !reverted {
+				rec.Step(tracestore.NodeNoAction, tracestore.OutcomeInfo, "keine Aktion", "Löschung ohne Wirkung")
+			}
+			return out
+		}
+	}
+
+	// Antwort auf eine Rückfrage des Bots (Zitat oder Reaktion)? 👍/👎 bzw.
+	// "ja"/"nein" entscheiden direkt, anderen Text klassifiziert der
+	// Classifier – die Termine kommen dann aus der Rückfrage.
+	var q *clarify.Question
+	var answer classifier.Result
+	if kind == evolution.KindMessage {
+		q, answer = s.matchAnswer(ctx, ev, msg, rec)
+	}
+	if ev.Reaction() != nil && answer == "" {
+		rec.Step(tracestore.NodeIgnored, tracestore.OutcomeInfo, "Ignoriert", "Reaktion, aber keine Antwort auf eine offene Rückfrage")
+		return Outcome{Path: "ignored", Reason: "reaktion: keine offene Rückfrage"}
+	}
+
+	// Vorklassifikation: eine passende Regel entscheidet ohne Classifier.
+	var rule *rules.Rule
+	if answer == "" {
+		rule = s.matchRule(ctx, msg, dryRun, rec)
+	}
+
+	// Classifier (LLM, im Canary bzw. bei Ausfall ggf. das eigene Modell).
+	// Der Label zeigt, wer entschieden hat.
+	var c classifier.Classification
+	var err error
+	label := "Classifier (LLM)"
+	switch {
+	case answer != "":
+		c = classifier.Classification{Result: answer, Raw: msg, Model: "rückfrage"}
+		label = ""
+	case rule != nil:
+		c = classifier.Classification{Result: classifier.Result(rule.Label), Raw: rule.Label, Model: fmt.Sprintf("regel #%d", rule.ID)}
+		label = ""
+	default:
+		if kc, ok := s.classifier.(KeyedClassifier); ok && messageID != "" {
+			c, err = kc.ClassifyKeyed(ctx, messageID, msg)
+		} else {
+			c, err = s.classifier.Classify(ctx, msg)
+		}
+		switch {
+		case c.Route == classifier.RouteCanary:
+			label = "Classifier (Modell · Canary)"
+		case c.Backend == classifier.BackendML:
+			label = "Classifier (ML-Fallback)"
+		case c.Cache != "":
+			label = "Classifier (Cache)"
+		}
+		if err == nil && c.Result == classifier.Invalid && kind == evolution.KindMessage {
+			if cc, ok := s.classifyInContext(ctx, ev, msg, rec); ok {
+				rec.Step(tracestore.NodeClassify, tracestore.OutcomeInfo, "Classifier (LLM · mit Kontext)",
+					fmt.Sprintf("→ %s  (roh: %q · %s · allein: %s)", cc.Result, cc.Raw, cc.Model, c.Result))
+				c, label = cc, ""
+			}
+		}
+	}
+	switch {
+	case label == "":
+		// schon protokolliert (Rückfrage, Regel bzw. mit Kontext)
+	case err != nil:
+		rec.Step(tracestore.NodeClassify, tracestore.OutcomeError, label, err.Error())
 		log.Printf("⚠️  classifier: %v (→ %s)", err, c.Result)
-	} else {
-		rec.Step(tracestore.NodeClassify, tracestore.OutcomeInfo, "Classifier (Gemini)",
+	case c.Route == classifier.RouteCanary:
+		rec.Step(tracestore.NodeClassify, tracestore.OutcomeInfo, label,
+			fmt.Sprintf("→ %s  (Konfidenz %.2f)", c.Result, c.Confidence))
+	case c.Backend == classifier.BackendML:
+		rec.Step(tracestore.NodeClassify, tracestore.OutcomeInfo, label,
+			fmt.Sprintf("→ %s  (Konfidenz %.2f · LLM: %s)", c.Result, c.Confidence, c.FallbackReason))
+		log.Printf("🛟 ML-Fallback entschied %s (Konfidenz %.2f), LLM: %s", c.Result, c.Confidence, c.FallbackReason)
+	case c.Route == classifier.RouteGated && c.Prediction != nil:
+		rec.Step(tracestore.NodeClassify, tracestore.OutcomeInfo, label,
+			fmt.Sprintf("→ %s  (roh: %q · %s · Modell unsicher: %s %.2f)", c.Result, c.Raw, c.Model, c.Prediction.Label, c.Prediction.Confidence))
+	case c.Cache == classifier.CacheVerified:
+		rec.Step(tracestore.NodeClassify, tracestore.OutcomeInfo, label,
+			fmt.Sprintf("→ %s  (geprüftes Label aus dem Admin-UI)", c.Result))
+	case c.Cache != "":
+		rec.Step(tracestore.NodeClassify, tracestore.OutcomeInfo, label,
+			fmt.Sprintf("→ %s  (LLM-Antwort von %s, gecacht)", c.Result, c.Model))
+	default:
+		rec.Step(tracestore.NodeClassify, tracestore.OutcomeInfo, label,
 			fmt.Sprintf("→ %s  (roh: %q · %s)", c.Result, c.Raw, c.Model))
 	}
 
-	// Shadow-Modus: eigenes Modell parallel klassifizieren lassen und beide
-	// Ergebnisse festhalten. Nur für echte Durchläufe, nie für Test/Dry-Run.
-	if s.Shadow != nil && !dryRun {
-		s.Shadow.RecordAsync(ev.UserID(), ev.UserName(), msg, string(c.Result))
+	// Verlauf: die Nachricht ist Kontext für die nächsten (nur echte,
+	// neue Nachrichten; Wiederholungen zählen einmal).
+	if s.History != nil && !dryRun && kind == evolution.KindMessage && messageID != "" && ev.Reaction() == nil {
+		m := chatlog.Message{Chat: ev.RemoteJid(), MessageID: messageID, UserID: userID,
+			UserName: ev.UserName(), Text: msg, SentAt: s.sentAt(ev)}
+		if err := s.History.Add(ctx, m); err != nil {
+			log.Printf("⚠️  chatlog: %v", err)
+		}
+	}
+
+	// Shadow-Modus: die Entscheidung mit beiden Labels und ihrer Route
+	// festhalten. Nur für echte, gelungene Durchläufe, nie für Test/Dry-Run
+	// (ein Fehler wird von der Inbox wiederholt und dann protokolliert).
+	if s.Shadow != nil && !dryRun && err == nil && rule == nil && answer == "" {
+		s.Shadow.RecordAsync(ev.UserID(), ev.UserName(), msg, c)
 	}
 
-	userID := ev.UserID()
-	today := asOf
 	out := Outcome{
 		Path:           "classify",
 		Classification: string(c.Result),
@@ -196,90 +444,595 @@ func (s *Server) run(ctx context.Context, ev evolution.WebhookEvent, bypassGuard
 		Message:        msg,
 		Recipient:      ev.RemoteJid(),
 		UserID:         userID,
-		Date:           today.Format("2006-01-02"),
+		Date:           asOf.Format("2006-01-02"),
 		DryRun:         dryRun,
+		FollowUpOf:     ev.TargetID(),
+	}
+	// Eine Bearbeitung hinterlässt immer einen (ggf. leeren) Effekt, damit
+	// eine weitere Bearbeitung/Löschung nichts doppelt zurückdreht.
+	effect := store.MessageEffect{MessageID: messageID, UserID: userID, Message: msg,
+		Classification: string(c.Result), SentOn: asOf, Undo: map[string]string{}}
+	track := !dryRun && messageID != ""
+	if c.Result != classifier.Absage && c.Result != classifier.Zusage {
+		// Klingt es nach Ab-/Zusage ("schau ma mal"), fragt der Bot nach.
+		if q == nil && rule == nil && kind == evolution.KindMessage {
+			if action, ok := s.askClarification(ctx, ev, msg, asOf, dryRun, rec); ok {
+				out.Action = action
+				return out
+			}
+		}
+		rec.Step(tracestore.NodeNoAction, tracestore.OutcomeInfo, "keine Aktion", "classification invalid")
+		if track && kind == evolution.KindEdit {
+			s.saveEffect(ctx, effect)
+		}
+		return out
+	}
+
+	// Ziel-Termine: "nächste Woche", "vom 3. bis 24." … bzw. ohne
+	// Zeitausdruck der nächste Stammtisch (heute, falls heute einer ist).
+	// Bei einer Antwort auf die Rückfrage gelten deren Termine.
+	var targets []time.Time
+	if q != nil {
+		targets = q.Dates
+		rec.Step(tracestore.NodeResolveDates, tracestore.OutcomePass, "Zieltermine", "aus der Rückfrage: "+isoDates(targets))
+	} else {
+		targets = s.resolveTargets(ctx, msg, asOf, rec)
 	}
+	if len(targets) == 0 {
+		rec.Step(tracestore.NodeNoAction, tracestore.OutcomeInfo, "keine Aktion", "kein Stammtisch-Termin im genannten Zeitraum")
+		return out
+	}
+	for _, d := range targets {
+		out.Dates = append(out.Dates, d.Format("2006-01-02"))
+	}
+	out.Date = out.Dates[0]
+	list := strings.Join(out.Dates, ", ")
+
+	// Für wen? "Tom und ich …", "@Tom kann nicht" – sonst der Absender
+	// (eine Antwort auf die Rückfrage gilt nur für den Gefragten).
+	var who []members.Target
+	if q != nil {
+		who = []members.Target{{UserID: userID, Name: ev.UserName(), Via: members.ViaSender}}
+	} else {
+		who = s.resolveMembers(ctx, ev, msg)
+	}
+	if len(who) == 0 {
+		rec.Step(tracestore.NodeNoAction, tracestore.OutcomeInfo, "keine Aktion", "erwähnte Person ist kein Mitglied")
+		return out
+	}
+	names := make([]string, len(who))
+	var others []members.Target
+	for i, t := range who {
+		names[i] = t.Name + " (" + t.Via + ")"
+		if t.Via != members.ViaSender {
+			others = append(others, t)
+			out.For = append(out.For, t.UserID)
+		}
+	}
+	if len(others) > 0 {
+		list += " · für " + strings.Join(names, ", ")
+	}
+
+	// Vorher-Zustand merken (Grundlage für spätere Bearbeitung/Löschung).
+	if track {
+		for _, t := range who {
+			prev, err := s.store.AbsenceMessages(ctx, t.UserID, targets)
+			if err != nil {
+				log.Printf("⚠️  AbsenceMessages(%s): %v (Bearbeiten/Löschen nicht nachverfolgt)", t.UserID, err)
+				track = false
+				break
+			}
+			undo := effect.Undo
+			if t.Via != members.ViaSender {
+				if effect.UndoFor == nil {
+					effect.UndoFor = map[string]map[string]string{}
+				}
+				undo = map[string]string{}
+				effect.UndoFor[t.UserID] = undo
+			}
+			for _, d := range out.Dates {
+				undo[d] = prev[d]
+			}
+		}
+	}
+
+	// each führt fn für jedes betroffene Mitglied an jedem Ziel-Termin aus;
+	// enteredBy ist bei anderen der Absender.
+	each := func(fn func(t members.Target, d time.Time, enteredBy string) error) error {
+		for _, t := range who {
+			by := ""
+			if t.Via != members.ViaSender {
+				by = userID
+			}
+			if err := markAll(targets, func(d time.Time) error { return fn(t, d, by) }); err != nil {
+				return fmt.Errorf("%s: %w", t.Name, err)
+			}
+		}
+		return nil
+	}
+
+	// Quittung per Reaktion an der Nachricht (bei einer Bearbeitung am
+	// Original). Auf eine Reaktion lässt sich nicht reagieren – bei einer
+	// Reaktion auf die Rückfrage bekommt die unklare Nachricht die Quittung.
+	ack := ev.Key()
+	ack.ID = messageID
+	if q != nil && ev.Reaction() != nil {
+		ack = evolution.MessageKey{RemoteJid: q.Chat, ID: q.MessageID, Participant: q.UserID}
+	}
+
+	// Text der Ab-/Zusage; bei einer Antwort auf die Rückfrage die unklare
+	// Nachricht samt Antwort ("schau ma mal → 👎").
+	note := msg
+	if q != nil {
+		note = q.Message + " → " + msg
+	}
+
 	switch c.Result {
 	case classifier.Absage:
 		if dryRun {
 			out.Action = "would_mark_absent"
-			rec.Step(tracestore.NodeMarkAbsent, tracestore.OutcomeInfo, "Absage: DB-Insert", "Dry-Run – nicht geschrieben")
-		} else if err := s.store.MarkAbsent(ctx, userID, today, msg); err != nil {
-			rec.Step(tracestore.NodeMarkAbsent, tracestore.OutcomeError, "Absage: DB-Insert", err.Error())
+			rec.Step(tracestore.NodeMarkAbsent, tracestore.OutcomeInfo, "Absage: DB-Insert", "Dry-Run – nicht geschrieben ("+list+")")
+		} else if err := each(func(t members.Target, d time.Time, by string) error {
+			return s.store.MarkAbsent(ctx, t.UserID, d, note, by)
+		}); err != nil {
+			rec.Step(tracestore.NodeMarkAbsent, tracestore.OutcomeError, "Absage: DB-Insert", err.Error()+s.react(ctx, ack, s.Reactions.Error))
 			log.Printf("⚠️  MarkAbsent(%s): %v", userID, err)
 		} else {
 			out.Action = "marked_absent"
-			rec.Step(tracestore.NodeMarkAbsent, tracestore.OutcomePass, "Absage: DB-Insert", "eingetragen für "+out.Date)
-			log.Printf("📝 Absage: %s (%s)", ev.UserName(), userID)
+			rec.Step(tracestore.NodeMarkAbsent, tracestore.OutcomePass, "Absage: DB-Insert", "eingetragen für "+list+s.confirmOnBehalf(ctx, true, ev.UserName(), others, targets)+s.react(ctx, ack, s.Reactions.Absent))
+			log.Printf("📝 Absage: %s (%s) → %s", ev.UserName(), userID, list)
+			if track {
+				s.saveEffect(ctx, effect)
+			}
 		}
 	case classifier.Zusage:
 		if dryRun {
 			out.Action = "would_mark_present"
-			rec.Step(tracestore.NodeMarkPresent, tracestore.OutcomeInfo, "Zusage: DB-Delete", "Dry-Run – nicht geschrieben")
-		} else if err := s.store.MarkPresent(ctx, userID, today); err != nil {
-			rec.Step(tracestore.NodeMarkPresent, tracestore.OutcomeError, "Zusage: DB-Delete", err.Error())
+			rec.Step(tracestore.NodeMarkPresent, tracestore.OutcomeInfo, "Zusage: DB-Delete", "Dry-Run – nicht geschrieben ("+list+")")
+		} else if err := each(func(t members.Target, d time.Time, _ string) error {
+			// Absage weg (Anwesenheit ist der Default), Zusage für "wer kommt" merken.
+			if err := s.store.MarkPresent(ctx, t.UserID, d); err != nil {
+				return err
+			}
+			return s.store.MarkConfirmed(ctx, t.UserID, d, note)
+		}); err != nil {
+			rec.Step(tracestore.NodeMarkPresent, tracestore.OutcomeError, "Zusage: DB-Delete", err.Error()+s.react(ctx, ack, s.Reactions.Error))
 			log.Printf("⚠️  MarkPresent(%s): %v", userID, err)
 		} else {
 			out.Action = "marked_present"
-			rec.Step(tracestore.NodeMarkPresent, tracestore.OutcomePass, "Zusage: DB-Delete", "entfernt für "+out.Date)
-			log.Printf("📝 Zusage: %s (%s)", ev.UserName(), userID)
+			rec.Step(tracestore.NodeMarkPresent, tracestore.OutcomePass, "Zusage: DB-Delete", "Absage entfernt, Zusage vermerkt für "+list+s.confirmOnBehalf(ctx, false, ev.UserName(), others, targets)+s.react(ctx, ack, s.Reactions.Present))
+			log.Printf("📝 Zusage: %s (%s) → %s", ev.UserName(), userID, list)
+			if track {
+				s.saveEffect(ctx, effect)
+			}
+		}
+	}
+	if q != nil && (out.Action == "marked_absent" || out.Action == "marked_present") {
+		if err := s.Clarify.Answer(ctx, q.ID, string(c.Result)); err != nil {
+			log.Printf("⚠️  Rückfrage %s: %v", q.ID, err)
 		}
-	default:
-		rec.Step(tracestore.NodeNoAction, tracestore.OutcomeInfo, "keine Aktion", "classification invalid")
 	}
 	return out
 }
 
-// runStats baut den Ranglisten-Text zum Stichtag asOf und protokolliert
-// Berechnung + Versand.
-func (s *Server) runStats(ctx context.Context, receiver string, dryRun bool, asOf time.Time, rec *tracestore.Recorder) (string, []store.Stat, []penalty.Entry) {
-	stats, err := s.store.UserStats(ctx, asOf)
+// matchRule prüft die Vorklassifikation und protokolliert das Ergebnis im
+// Trace. Treffer zählen nur bei echten Nachrichten (kein Dry-Run).
+func (s *Server) matchRule(ctx context.Context, msg string, dryRun bool, rec *tracestore.Recorder) *rules.Rule {
+	if s.Rules == nil {
+		return nil
+	}
+	r := s.Rules.Match(msg)
+	if r == nil {
+		rec.Step(tracestore.NodeRules, tracestore.OutcomeInfo, "Regel?", "keine passende Regel")
+		return nil
+	}
+	detail := fmt.Sprintf("#%d (%s %q) → %s", r.ID, r.Kind, r.Pattern, r.Label)
+	if r.Note != "" {
+		detail += " · " + r.Note
+	}
+	rec.Step(tracestore.NodeRules, tracestore.OutcomePass, "Regel?", detail)
+	if !dryRun {
+		if err := s.Rules.Hit(ctx, r.ID); err != nil {
+			log.Printf("⚠️  Regel-Treffer #%d: %v", r.ID, err)
+		}
+	}
+	log.Printf("📏 Regel #%d entschied %s", r.ID, r.Label)
+	return r
+}
+
+// matchAnswer erkennt eine Antwort auf eine Rückfrage: eine Reaktion auf die
+// Nachricht des Bots bzw. eine Antwort mit Zitat darauf, jeweils vom
+// Gefragten und vor Ablauf. answer ist leer, wenn der Text keine eindeutige
+// Antwort ist – q gilt dann trotzdem (der Classifier entscheidet).
+func (s *Server) matchAnswer(ctx context.Context, ev evolution.WebhookEvent, msg string, rec *tracestore.Recorder) (*clarify.Question, classifier.Result) {
+	if s.Clarify == nil {
+		return nil, ""
+	}
+	id, via := ev.QuotedID(), "Antwort"
+	if r := ev.Reaction(); r != nil {
+		id, via = r.TargetID, "Reaktion"
+	}
+	if id == "" {
+		return nil, ""
+	}
+	const label = "Antwort auf Rückfrage"
+	q, err := s.Clarify.Get(ctx, ev.RemoteJid(), id)
+	switch {
+	case err != nil:
+		rec.Step(tracestore.NodeAnswer, tracestore.OutcomeError, label, err.Error())
+		log.Printf("⚠️  Rückfrage(%s): %v", id, err)
+		return nil, ""
+	case q == nil:
+		return nil, "" // Antwort auf eine andere Nachricht
+	case q.UserID != ev.UserID():
+		rec.Step(tracestore.NodeAnswer, tracestore.OutcomeFail, label,
+			fmt.Sprintf("%s von %s – gefragt war %s", via, ev.UserName(), q.UserName))
+		return nil, ""
+	case s.Now().After(q.ExpiresAt):
+		rec.Step(tracestore.NodeAnswer, tracestore.OutcomeFail, label,
+			fmt.Sprintf("Rückfrage zu %q abgelaufen (%s)", q.Message, q.ExpiresAt.In(s.location).Format("02.01. 15:04")))
+		return nil, ""
+	}
+	answer, ok := clarify.ParseAnswer(msg)
+	if !ok {
+		rec.Step(tracestore.NodeAnswer, tracestore.OutcomeInfo, label,
+			fmt.Sprintf("%s %q auf Rückfrage zu %q – keine eindeutige Antwort", via, msg, q.Message))
+		return q, ""
+	}
+	rec.Step(tracestore.NodeAnswer, tracestore.OutcomePass, label,
+		fmt.Sprintf("%s %s auf Rückfrage zu %q → %s", via, msg, q.Message, answer))
+	log.Printf("🤔 Rückfrage beantwortet: %s (%s) → %s", ev.UserName(), q.Message, answer)
+	return q, answer
+}
+
+// askClarification fragt bei einer unklaren Nachricht ("schau ma mal") per
+// Antwort mit Zitat nach und merkt sich die offene Frage. ok=false: keine
+// Rückfrage (aus, klingt nicht nach Ab-/Zusage, kein Termin) – dann bleibt es
+// bei "keine Aktion". Ein Versandfehler ist kein Fehler des Events: eine
+// Wiederholung würde sonst die ganze Nachricht erneut verarbeiten.
+func (s *Server) askClarification(ctx context.Context, ev evolution.WebhookEvent, msg string, asOf time.Time, dryRun bool, rec *tracestore.Recorder) (action string, ok bool) {
+	qs, canQuote := s.sender.(QuoteSender)
+	if s.Clarify == nil || !canQuote || ev.FromMe() || !clarify.Unsure(msg) {
+		return "", false
+	}
+	res, _ := s.resolveDates(ctx, msg, asOf)
+	if len(res.Dates) == 0 {
+		return "", false
+	}
+	const label = "Rückfrage"
+	text := report.BuildClarify(ev.UserName(), res.Dates, asOf)
+	question, _, _ := strings.Cut(text, "\n")
+	if dryRun {
+		rec.Step(tracestore.NodeNoAction, tracestore.OutcomeInfo, label, "Dry-Run – nicht gesendet: "+question)
+		return "would_ask", true
+	}
+
+	id, err := qs.SendTextQuoted(ctx, ev.RemoteJid(), text, evolution.Quote{MessageID: ev.MessageID(), Participant: ev.UserID(), Text: msg})
+	if err == nil && id == "" {
+		err = fmt.Errorf("keine Message-ID von Evolution")
+	}
 	if err != nil {
-		rec.Step(tracestore.NodeBuildStats, tracestore.OutcomeError, "Statistik berechnen", err.Error())
-		log.Printf("⚠️  UserStats: %v", err)
-		return "", nil, nil
+		rec.Step(tracestore.NodeNoAction, tracestore.OutcomeFail, label, "nicht gesendet: "+err.Error())
+		log.Printf("⚠️  Rückfrage an %s: %v", ev.UserName(), err)
+		return "none", true
+	}
+	// Nach dem (ersten) Termin ist die Frage hinfällig.
+	expires := s.Now().Add(s.ClarifyTTL)
+	first := res.Dates[0]
+	if end := time.Date(first.Year(), first.Month(), first.Day()+1, 0, 0, 0, 0, s.location); end.Before(expires) {
+		expires = end
+	}
+	q := clarify.Question{ID: id, Chat: ev.RemoteJid(), UserID: ev.UserID(), UserName: ev.UserName(),
+		MessageID: ev.MessageID(), Message: msg, Dates: res.Dates, ExpiresAt: expires}
+	if err := s.Clarify.Ask(ctx, q); err != nil {
+		rec.Step(tracestore.NodeNoAction, tracestore.OutcomeFail, label, "gesendet, aber nicht gespeichert: "+err.Error())
+		log.Printf("⚠️  Rückfrage speichern: %v", err)
+		return "asked", true
+	}
+	rec.Step(tracestore.NodeNoAction, tracestore.OutcomePass, label,
+		fmt.Sprintf("%s (offen bis %s)", question, expires.In(s.location).Format("02.01. 15:04")))
+	log.Printf("🤔 Rückfrage an %s (%q) → %s", ev.UserName(), msg, isoDates(res.Dates))
+	return "asked", true
+}
+
+// resolveMembers bestimmt, für wen die Nachricht gilt (Absender, erwähnte
+// bzw. am Anfang genannte Mitglieder). Ohne Mitgliederliste gilt sie wie
+// früher nur für den Absender.
+func (s *Server) resolveMembers(ctx context.Context, ev evolution.WebhookEvent, msg string) []members.Target {
+	sender := []members.Target{{UserID: ev.UserID(), Name: ev.UserName(), Via: members.ViaSender}}
+	dir, err := s.store.Members(ctx)
+	if err != nil {
+		log.Printf("⚠️  Members: %v (nur Absender)", err)
+		return sender
+	}
+	res := members.Resolve(msg, ev.UserID(), ev.UserName(), ev.Mentioned(), dir)
+	if len(res.Unknown) > 0 {
+		log.Printf("👥 Erwähnung ohne Mitglied: %s", strings.Join(res.Unknown, ", "))
+	}
+	return res.Targets
+}
+
+// confirmOnBehalf bestätigt in der Gruppe eine Ab-/Zusage, die jemand für
+// andere eingetragen hat (mit @-Erwähnung, damit die Betroffenen es sehen),
+// und liefert den Trace-Zusatz. Ein Versandfehler ändert nichts an der
+// Eintragung.
+func (s *Server) confirmOnBehalf(ctx context.Context, absage bool, by string, others []members.Target, dates []time.Time) string {
+	if len(others) == 0 {
+		return ""
+	}
+	ids := make([]string, len(others))
+	for i, t := range others {
+		ids[i] = t.UserID
+	}
+	text, mentioned := report.BuildOnBehalf(absage, by, ids, dates)
+	var err error
+	if ms, ok := s.sender.(MentionSender); ok {
+		err = ms.SendTextMentions(ctx, s.groupJID, text, mentioned)
+	} else {
+		err = s.sender.SendText(ctx, s.groupJID, text)
+	}
+	if err != nil {
+		log.Printf("⚠️  Bestätigung (für andere): %v", err)
+		return " · Bestätigung nicht gesendet: " + err.Error()
 	}
-	entries, perr := s.penalties(ctx, asOf, !dryRun)
-	text := report.BuildWithStrafen(stats, strafenBlock(entries, perr, asOf))
-	rec.Step(tracestore.NodeBuildStats, tracestore.OutcomePass, "Statistik berechnen", fmt.Sprintf("%d Nutzer", len(stats)))
+	return " · Bestätigung gesendet"
+}
+
+// react quittiert eine Ab-/Zusage mit emoji an der Nachricht key und liefert
+// den Trace-Zusatz (leeres emoji = aus). Ein Fehler ändert nichts an der
+// Eintragung.
+func (s *Server) react(ctx context.Context, key evolution.MessageKey, emoji string) string {
+	if emoji == "" || key.ID == "" {
+		return ""
+	}
+	if err := s.sender.SendReaction(ctx, key, emoji); err != nil {
+		log.Printf("⚠️  Reaktion %s auf %s: %v", emoji, key.ID, err)
+		return " · Reaktion nicht gesendet: " + err.Error()
+	}
+	return " · Reaktion " + emoji
+}
+
+// isText: Textnachrichten, die klassifiziert werden (Antworten mit Zitat
+// kommen als extendedTextMessage).
+func isText(messageType string) bool {
+	return messageType == "conversation" || messageType == "extendedTextMessage"
+}
+
+// sentAt ist der Sendezeitpunkt laut WhatsApp, sonst jetzt.
+func (s *Server) sentAt(ev evolution.WebhookEvent) time.Time {
+	if t := ev.SentAt(); !t.IsZero() {
+		return t
+	}
+	return s.Now()
+}
+
+// classifyInContext fragt das LLM erneut, diesmal mit den Nachrichten davor
+// und der zitierten Nachricht. ok=false: kein Kontext vorhanden bzw. das LLM
+// hat nicht geantwortet – dann bleibt es beim Ergebnis ohne Kontext (kein
+// Fehler, die Nachricht war ja klassifiziert).
+func (s *Server) classifyInContext(ctx context.Context, ev evolution.WebhookEvent, msg string, rec *tracestore.Recorder) (classifier.Classification, bool) {
+	if s.History == nil || s.Contextual == nil {
+		return classifier.Classification{}, false
+	}
+	chat := ev.RemoteJid()
+	before, err := s.History.Before(ctx, chat, s.sentAt(ev), ev.MessageID())
+	if err != nil {
+		log.Printf("⚠️  chatlog: %v (ohne Verlauf)", err)
+	}
+	var turns []classifier.Turn
+	var lines []string
+	q := ev.Quoted()
+	for _, m := range before {
+		if q != nil && m.MessageID == q.MessageID {
+			continue // steht unten als Zitat
+		}
+		turns = append(turns, classifier.Turn{Author: m.UserName, Text: m.Text})
+		lines = append(lines, fmt.Sprintf("%s: %q", m.UserName, m.Text))
+	}
+	if q != nil {
+		author := shortJID(q.Participant)
+		if m, err := s.History.Get(ctx, chat, q.MessageID); err == nil && m != nil && m.UserName != "" {
+			author = m.UserName
+		}
+		turns = append(turns, classifier.Turn{Author: author, Text: q.Text, Quoted: true})
+		lines = append(lines, fmt.Sprintf("↪ Antwort auf %s: %q", author, q.Text))
+	}
+	if len(turns) == 0 {
+		return classifier.Classification{}, false
+	}
+
+	c, err := s.Contextual.ClassifyInContext(ctx, msg, turns)
+	detail := strings.Join(lines, " · ")
+	if err != nil {
+		rec.Step(tracestore.NodeContext, tracestore.OutcomeInfo, "Gesprächskontext",
+			detail+" (LLM-Fehler: "+err.Error()+", bleibt ohne Kontext)")
+		log.Printf("⚠️  classifier mit Kontext: %v", err)
+		return classifier.Classification{}, false
+	}
+	rec.Step(tracestore.NodeContext, tracestore.OutcomeInfo, "Gesprächskontext", detail)
+	return c, true
+}
+
+// shortJID macht aus "4917...@s.whatsapp.net" die Nummer.
+func shortJID(jid string) string {
+	if i := strings.IndexByte(jid, '@'); i >= 0 {
+		return jid[:i]
+	}
+	return jid
+}
+
+// kindSuffix ergänzt Trace-Details um die Art des Folge-Events.
+func kindSuffix(kind evolution.Kind, target string) string {
+	var out string
+	switch kind {
+	case evolution.KindEdit:
+		out = " · Bearbeitung"
+	case evolution.KindRevoke:
+		out = " · Löschung"
+	default:
+		return ""
+	}
+	if target != "" {
+		out += " von " + target
+	}
+	return out
+}
+
+// undoOriginal dreht die gespeicherte Wirkung der Original-Nachricht zurück
+// (Bearbeitung/Löschung). Liefert den Effekt (nil = Original unbekannt, z. B.
+// "invalid" oder vor diesem Feature) und ob tatsächlich etwas zurückgedreht
+// wurde bzw. im Dry-Run würde.
+func (s *Server) undoOriginal(ctx context.Context, targetID string, dryRun bool, rec *tracestore.Recorder) (*store.MessageEffect, bool) {
+	const label = "Original rückgängig"
+	orig, err := s.store.MessageEffect(ctx, targetID)
+	switch {
+	case err != nil:
+		rec.Step(tracestore.NodeUndo, tracestore.OutcomeError, label, err.Error())
+		log.Printf("⚠️  MessageEffect(%s): %v", targetID, err)
+		return nil, false
+	case orig == nil:
+		rec.Step(tracestore.NodeUndo, tracestore.OutcomeInfo, label, "keine gespeicherte Wirkung für "+targetID)
+		return nil, false
+	case orig.Revoked:
+		rec.Step(tracestore.NodeUndo, tracestore.OutcomeInfo, label, "Original wurde bereits gelöscht")
+		return orig, false
+	case len(orig.Undos()) == 0:
+		rec.Step(tracestore.NodeUndo, tracestore.OutcomeInfo, label, "Original ohne Wirkung ("+orig.Classification+")")
+		return orig, false
+	}
+
+	undos := orig.Undos()
+	users := make([]string, 0, len(undos))
+	for u := range undos {
+		users = append(users, u)
+	}
+	sort.Strings(users)
+	var days []string
+	for d := range undos[users[0]] {
+		days = append(days, d)
+	}
+	sort.Strings(days)
 	if dryRun {
-		rec.Step(tracestore.NodeSendStats, tracestore.OutcomeInfo, "An Gruppe senden", "Dry-Run – nicht gesendet")
-		return text, stats, entries
-	}
-
-	// STATS_FORMAT=image: PNG-Karte senden; bei Render-/Versand-Fehlern
-	// fällt der Report auf den Text zurück (er muss immer rausgehen).
-	if s.StatsFormat == "image" {
-		if png, err := s.renderCard(ctx, stats, entries, asOf, false); err != nil {
-			rec.Step(tracestore.NodeSendStats, tracestore.OutcomeError, "Bild-Karte rendern", err.Error()+" – Fallback auf Text")
-			log.Printf("⚠️  Bild-Karte(%s): %v – Fallback auf Text", receiver, err)
-		} else if err := s.sender.SendImage(ctx, receiver, "🍻 Zumba Stats · Stand "+asOf.Format("02.01.2006"), png); err != nil {
-			rec.Step(tracestore.NodeSendStats, tracestore.OutcomeError, "An Gruppe senden (Bild)", err.Error()+" – Fallback auf Text")
-			log.Printf("⚠️  SendImage(%s): %v – Fallback auf Text", receiver, err)
-		} else {
-			rec.Step(tracestore.NodeSendStats, tracestore.OutcomePass, "An Gruppe senden (Bild)", "→ "+receiver)
-			log.Printf("📊 Statistik-Bild gesendet an %s", receiver)
-			return text, stats, entries
+		rec.Step(tracestore.NodeUndo, tracestore.OutcomeInfo, label, "Dry-Run – nicht geschrieben ("+strings.Join(days, ", ")+")")
+		return orig, true
+	}
+	for _, u := range users {
+		for ds, prev := range undos[u] {
+			d, err := time.Parse("2006-01-02", ds)
+			if err == nil {
+				if prev == "" {
+					err = s.store.MarkPresent(ctx, u, d)
+				} else {
+					err = s.store.MarkAbsent(ctx, u, d, prev, "")
+				}
+				// Die Zusage des Originals gilt nicht mehr.
+				if err == nil && orig.Classification == string(classifier.Zusage) {
+					err = s.store.Unconfirm(ctx, u, d)
+				}
+			}
+			if err != nil {
+				rec.Step(tracestore.NodeUndo, tracestore.OutcomeError, label, ds+": "+err.Error())
+				log.Printf("⚠️  Rückgängig(%s, %s, %s): %v", targetID, u, ds, err)
+				return orig, false
+			}
 		}
 	}
+	rec.Step(tracestore.NodeUndo, tracestore.OutcomePass, label,
+		fmt.Sprintf("%q (%s) zurückgedreht für %s", orig.Message, orig.Classification, strings.Join(days, ", ")))
+	log.Printf("↩️  Rückgängig: %s (%s) → %s", targetID, orig.UserID, strings.Join(days, ", "))
+	return orig, true
+}
+
+// saveEffect speichert den Effekt einer Nachricht (best-effort: ohne Eintrag
+// lässt sich die Nachricht später nur nicht mehr rückgängig machen).
+func (s *Server) saveEffect(ctx context.Context, e store.MessageEffect) {
+	if err := s.store.SaveMessageEffect(ctx, e); err != nil {
+		log.Printf("⚠️  SaveMessageEffect(%s): %v", e.MessageID, err)
+	}
+}
 
-	if err := s.sender.SendText(ctx, receiver, text); err != nil {
-		rec.Step(tracestore.NodeSendStats, tracestore.OutcomeError, "An Gruppe senden", err.Error())
-		log.Printf("⚠️  SendText(%s): %v", receiver, err)
-	} else {
-		rec.Step(tracestore.NodeSendStats, tracestore.OutcomePass, "An Gruppe senden", "→ "+receiver)
-		log.Printf("📊 Statistik gesendet an %s", receiver)
+// resolveTargets löst die Stammtisch-Termine auf, für die eine Ab-/Zusage
+// gilt (Sperrtage übersprungen), und protokolliert das Ergebnis im Trace.
+func (s *Server) resolveTargets(ctx context.Context, msg string, asOf time.Time, rec *tracestore.Recorder) []time.Time {
+	res, err := s.resolveDates(ctx, msg, asOf)
+	if err != nil {
+		// Ohne Sperrtage weiterarbeiten: eine Absage an einem Sperrtag zählt
+		// ohnehin nirgends.
+		rec.Step(tracestore.NodeResolveDates, tracestore.OutcomeError, "Sperrtage laden", err.Error())
+		log.Printf("⚠️  ExcludedDays: %v (Sperrtage nicht berücksichtigt)", err)
 	}
-	return text, stats, entries
+	outcome := tracestore.OutcomePass
+	if len(res.Dates) == 0 {
+		outcome = tracestore.OutcomeFail
+	}
+	rec.Step(tracestore.NodeResolveDates, outcome, "Zieltermine", res.Describe())
+	return res.Dates
+}
+
+// resolveDates löst die Termine ohne Trace auf; err meldet nur, dass die
+// Sperrtage fehlen (das Ergebnis gilt trotzdem).
+func (s *Server) resolveDates(ctx context.Context, msg string, asOf time.Time) (dates.Resolution, error) {
+	from := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+	excluded, err := s.excludedAhead(ctx, from)
+	return dates.Resolve(msg, from, s.Schedule, excluded), err
 }
 
-// handleWeekly versendet den automatischen Wochenreport an die Zumba-Gruppe
-// (per CronJob donnerstags 21:00 aufgerufen). ?dryRun=true berechnet den Text
-// nur und sendet nicht – für den Test-Button im Admin-UI. ?date=YYYY-MM-DD
-// simuliert den Stichtag des Strafenblocks (nur Strafen – die Rangliste
-// rechnet weiterhin bis heute) und erzwingt Dry-Run, sofern nicht Vorschau.
-// ?format=image rendert die Statistik zusätzlich als PNG-Karte und verschickt
-// bei echtem Versand/Vorschau das Bild statt des Texts.
+// isoDates listet Termine als "2026-01-01, 2026-01-08".
+func isoDates(ds []time.Time) string {
+	iso := make([]string, len(ds))
+	for i, d := range ds {
+		iso[i] = d.Format("2006-01-02")
+	}
+	return strings.Join(iso, ", ")
+}
+
+// excludedAhead liefert die Sperrtage von from bis zum Auflösungs-Horizont
+// (ISO-Datum → true); bei einem Fehler leer.
+func (s *Server) excludedAhead(ctx context.Context, from time.Time) (map[string]bool, error) {
+	excluded := map[string]bool{}
+	days, err := s.store.ExcludedDays(ctx, from, from.AddDate(0, 0, dates.HorizonDays))
+	for _, d := range days {
+		excluded[d.Format("2006-01-02")] = true
+	}
+	return excluded, err
+}
+
+// markAll schreibt alle Ziel-Termine und bricht beim ersten Fehler ab (die
+// Statements sind idempotent – ein erneutes Senden holt den Rest nach).
+func markAll(targets []time.Time, write func(time.Time) error) error {
+	for _, d := range targets {
+		if err := write(d); err != nil {
+			return fmt.Errorf("%s: %w", d.Format("2006-01-02"), err)
+		}
+	}
+	return nil
+}
+
+// sendGroup stellt eine Gruppen-Nachricht zu: png != nil schickt die
+// Bild-Karte (Fallback text), sonst text. Mit Outbox heißt queued=true: noch
+// nicht zugestellt, der Sende-Loop versucht es weiter. Ohne Outbox – oder wenn
+// sie nicht schreiben kann – geht es direkt über den Sender.
+func (s *Server) sendGroup(ctx context.Context, source, number, text, caption string, png []byte) (queued bool, err error) {
+	m := outbox.Message{Source: source, Recipient: number, Text: text, Caption: caption, Image: png}
+	if s.Outbox != nil {
+		sent, err := s.Outbox.Send(ctx, m)
+		if err == nil {
+			return !sent, nil
+		}
+		log.Printf("⚠️  outbox: %v – sende direkt", err)
+	}
+	return false, outbox.Deliver(ctx, s.sender, m)
+}
+
+// handleWeekly berechnet den Wochenreport bzw. versendet ihn an die
+// Zumba-Gruppe. Regulär löst ihn der Scheduler aus (WeeklyJob); der Endpoint
+// bleibt für manuelle Läufe und die Bot-Test-Seite. ?dryRun=true berechnet
+// den Text nur und sendet nicht – für den Test-Button im Admin-UI.
+// ?date=YYYY-MM-DD simuliert den Stichtag des Strafenblocks (nur Strafen –
+// die Rangliste rechnet weiterhin bis heute) und erzwingt Dry-Run, sofern
+// nicht Vorschau. ?format=image rendert die Statistik zusätzlich als
+// PNG-Karte und verschickt bei echtem Versand/Vorschau das Bild statt des
+// Texts.
 func (s *Server) handleWeekly(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	dryRun := q.Get("dryRun") == "true"
@@ -301,62 +1054,31 @@ func (s *Server) handleWeekly(w http.ResponseWriter, r *http.Request) {
 	send := !(dryRun || preview)
 	ctx := r.Context()
 
-	stats, err := s.store.UserStats(ctx, asOf)
+	wr, err := s.buildWeekly(ctx, asOf, send, asImage, q.Get("cardStyle"))
 	if err != nil {
 		log.Printf("⚠️  UserStats: %v", err)
-		stats = nil
-	}
-	var (
-		text    string
-		entries []penalty.Entry
-	)
-	if stats != nil {
-		var perr error
-		entries, perr = s.penalties(ctx, asOf, send)
-		text = report.BuildWeeklyWithStrafen(stats, strafenBlock(entries, perr, asOf))
 	}
-
-	out := Outcome{Path: "statistik", Message: text, Recipient: s.groupJID, DryRun: !send}
-
-	var png []byte
-	if asImage && stats != nil {
-		png, err = s.renderCardStyled(ctx, q.Get("cardStyle"), stats, entries, asOf, true)
-		if err != nil {
-			// Reiner Dry-Run (Admin-UI): Fehler sichtbar machen. Bei echtem
-			// Versand/Vorschau geht der Report als Text-Fallback trotzdem raus.
-			if !send && !preview {
-				http.Error(w, "Bild-Rendering fehlgeschlagen: "+err.Error(), http.StatusBadGateway)
-				return
-			}
-			log.Printf("⚠️  Bild-Karte(Wochenreport): %v – Fallback auf Text", err)
-			png = nil
-		} else {
-			out.ImageBase64 = base64.StdEncoding.EncodeToString(png)
+	out := Outcome{Path: "statistik", Message: wr.text, Recipient: s.groupJID, DryRun: !send}
+	if wr.renderErr != nil {
+		// Reiner Dry-Run (Admin-UI): Fehler sichtbar machen. Bei echtem
+		// Versand/Vorschau geht der Report als Text-Fallback trotzdem raus.
+		if !send && !preview {
+			http.Error(w, "Bild-Rendering fehlgeschlagen: "+wr.renderErr.Error(), http.StatusBadGateway)
+			return
 		}
+		log.Printf("⚠️  Bild-Karte(Wochenreport): %v – Fallback auf Text", wr.renderErr)
 	}
-
-	caption := "📅 Automatischer Wochenreport · Stand " + asOf.Format("02.01.2006")
-	// deliver schickt bevorzugt die Bild-Karte und fällt bei Fehlern auf den
-	// Text zurück – der Wochenreport muss immer rausgehen.
-	deliver := func(number string) error {
-		if png != nil {
-			if err := s.sender.SendImage(ctx, number, caption, png); err == nil {
-				return nil
-			} else {
-				log.Printf("⚠️  SendImage(%s): %v – Fallback auf Text", number, err)
-			}
-		}
-		return s.sender.SendText(ctx, number, text)
+	if wr.png != nil {
+		out.ImageBase64 = base64.StdEncoding.EncodeToString(wr.png)
 	}
-	if send && text != "" {
-		if err := deliver(s.groupJID); err != nil {
+
+	if send && wr.text != "" {
+		if _, err := s.sendWeekly(ctx, wr); err != nil {
 			log.Printf("⚠️  Wochenreport-Versand(%s): %v", s.groupJID, err)
-		} else {
-			log.Printf("📅 Wochenreport gesendet an %s", s.groupJID)
 		}
 	}
-	if preview && text != "" {
-		if err := deliver(s.PreviewJID); err != nil {
+	if preview && wr.text != "" {
+		if err := outbox.Deliver(ctx, s.sender, outbox.Message{Recipient: s.PreviewJID, Text: wr.text, Caption: wr.caption, Image: wr.png}); err != nil {
 			log.Printf("⚠️  Vorschau-Versand(%s): %v", s.PreviewJID, err)
 		} else {
 			out.PreviewTo = s.PreviewJID
@@ -368,6 +1090,85 @@ func (s *Server) handleWeekly(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(out)
 }
 
+// weeklyReport ist ein berechneter Wochenreport.
+type weeklyReport struct {
+	text    string // leer = keine Statistik (DB-Fehler)
+	caption string
+	png     []byte // nil = Text (kein Bild verlangt oder Rendering gescheitert)
+	// renderErr: Bild verlangt, aber Rendering gescheitert (png nil).
+	renderErr error
+}
+
+// buildWeekly berechnet Rangliste + Strafenblock zum Stichtag asOf und mit
+// asImage die Bild-Karte. persist schreibt die Strafen-Marker (nur echter
+// Versand). err nur, wenn die Statistik nicht lesbar ist.
+func (s *Server) buildWeekly(ctx context.Context, asOf time.Time, persist, asImage bool, cardStyle string) (weeklyReport, error) {
+	wr := weeklyReport{caption: "📅 Automatischer Wochenreport · Stand " + asOf.Format("02.01.2006")}
+	stats, err := s.store.UserStats(ctx, asOf)
+	if err != nil {
+		return wr, err
+	}
+	entries, kasse, perr := s.penalties(ctx, asOf, persist)
+	wr.text = report.BuildWeeklyWithStrafen(stats, strafenBlock(entries, kasse, perr, asOf))
+	if asImage {
+		wr.png, wr.renderErr = s.renderCardStyled(ctx, cardStyle, stats, entries, asOf, true)
+		if wr.renderErr != nil {
+			wr.png = nil
+		}
+	}
+	return wr, nil
+}
+
+// sendWeekly schickt den Report an die Gruppe. Er muss immer rausgehen: Bild
+// mit Text-Fallback, über die Outbox (Wiederholung bei Evolution-Ausfall).
+// Die Vorschau geht dagegen direkt – wer im Admin-UI klickt, will sofort
+// sehen, ob es geklappt hat.
+func (s *Server) sendWeekly(ctx context.Context, wr weeklyReport) (queued bool, err error) {
+	queued, err = s.sendGroup(ctx, "wochenreport", s.groupJID, wr.text, wr.caption, wr.png)
+	switch {
+	case err != nil:
+		return false, err
+	case queued:
+		log.Printf("📤 Wochenreport liegt in der Outbox (Evolution nicht erreichbar) – wird wiederholt")
+	default:
+		log.Printf("📅 Wochenreport gesendet an %s", s.groupJID)
+	}
+	return queued, nil
+}
+
+// WeeklyJob ist der Scheduler-Job "wochenreport". Payload: {"format":
+// "text"|"image"}. Ein Fehler (Statistik nicht lesbar, Versand und Outbox
+// beide kaputt) lässt den Lauf scheitern – der Scheduler wiederholt ihn.
+func (s *Server) WeeklyJob(ctx context.Context, payload json.RawMessage) (string, error) {
+	var p struct {
+		Format string `json:"format"`
+	}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return "", fmt.Errorf("payload: %w", err)
+		}
+	}
+	wr, err := s.buildWeekly(ctx, s.today(), true, p.Format == "image", "")
+	if err != nil {
+		return "", fmt.Errorf("Statistik: %w", err)
+	}
+	detail := "Text"
+	if wr.png != nil {
+		detail = "Bild-Karte"
+	} else if wr.renderErr != nil {
+		log.Printf("⚠️  Bild-Karte(Wochenreport): %v – Fallback auf Text", wr.renderErr)
+		detail = "Text (Bild-Fallback: " + wr.renderErr.Error() + ")"
+	}
+	queued, err := s.sendWeekly(ctx, wr)
+	if err != nil {
+		return "", fmt.Errorf("Versand: %w", err)
+	}
+	if queued {
+		return detail + " liegt in der Outbox", nil
+	}
+	return detail + " an die Gruppe gesendet", nil
+}
+
 // renderCard baut die Bild-Karte im Live-Design und lässt sie vom
 // renderer-service als PNG schießen.
 func (s *Server) renderCard(ctx context.Context, stats []store.Stat, entries []penalty.Entry, asOf time.Time, weekly bool) ([]byte, error) {
@@ -388,14 +1189,14 @@ func (s *Server) renderCardStyled(ctx context.Context, style string, stats []sto
 	return s.Renderer.PNG(ctx, html, report.CardWidth)
 }
 
-// penalties berechnet alle Strafen zum Stichtag asOf. persist=true schreibt
-// Marker für neu erkannte Fehltage-Strafen (nur echte Läufe – nie
-// Dry-Run/Vorschau).
-func (s *Server) penalties(ctx context.Context, asOf time.Time, persist bool) ([]penalty.Entry, error) {
+// penalties berechnet alle Strafen und den Kassenstand zum Stichtag asOf.
+// persist=true schreibt Marker für neu erkannte Fehltage-Strafen (nur echte
+// Läufe – nie Dry-Run/Vorschau).
+func (s *Server) penalties(ctx context.Context, asOf time.Time, persist bool) ([]penalty.Entry, penalty.Kasse, error) {
 	in, err := s.store.PenaltyInputs(ctx, asOf)
 	if err != nil {
 		log.Printf("⚠️  PenaltyInputs: %v (Strafenblock entfällt)", err)
-		return nil, err
+		return nil, penalty.Kasse{}, err
 	}
 	entries := penalty.Assess(in, asOf)
 	if persist {
@@ -411,16 +1212,16 @@ func (s *Server) penalties(ctx context.Context, asOf time.Time, persist bool) ([
 			log.Printf("⚠️  InsertAutoStrafen (%d Marker): %v", len(marks), err)
 		}
 	}
-	return entries, nil
+	return entries, penalty.Kassenstand(in.Buchungen), nil
 }
 
 // strafenBlock rendert den Report-Abschnitt; bei einem Berechnungsfehler
 // entfällt der Block komplett (der Report geht trotzdem raus).
-func strafenBlock(entries []penalty.Entry, err error, asOf time.Time) string {
+func strafenBlock(entries []penalty.Entry, kasse penalty.Kasse, err error, asOf time.Time) string {
 	if err != nil {
 		return ""
 	}
-	return report.StrafenBlock(entries, asOf)
+	return report.StrafenBlock(entries, kasse, asOf)
 }
 
 func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
@@ -435,16 +1236,123 @@ func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
-	rec := tracestore.NewRecorder()
-	out := s.run(r.Context(), ev, false, false, s.today(), rec)
+	// Dedupe: Evolution wiederholt Webhooks. Kennt die Inbox die Message-ID
+	// schon (erledigt oder gerade in Arbeit), wird nur quittiert. Fällt die
+	// Inbox selbst aus, verarbeiten wir lieber ohne Dedupe als gar nicht.
+	id := ev.MessageID()
+	claimed := false
+	if s.Inbox != nil && id != "" {
+		_, ok, err := s.Inbox.Claim(r.Context(), id, body)
+		switch {
+		case err != nil:
+			log.Printf("⚠️  inbox: %v (verarbeite ohne Dedupe)", err)
+		case !ok:
+			log.Printf("🔁 Duplikat %s quittiert", id)
+			w.WriteHeader(http.StatusOK)
+			return
+		default:
+			claimed = true
+		}
+	}
+
+	// Bezugstag ist der Tag des Eingangs, auch wenn die Queue erst nach
+	// Mitternacht drankommt.
+	asOf := s.today()
+	s.dispatch(r.Context(), ev.RemoteJid(), func(ctx context.Context) {
+		rec := tracestore.NewRecorder()
+		out := s.run(ctx, ev, false, false, asOf, rec)
+		s.recordTrace(ctx, ev, body, out, rec)
+		if claimed {
+			s.finishInbox(ctx, id, rec)
+		}
+	})
 	w.WriteHeader(http.StatusOK)
-	s.recordTrace(r.Context(), ev, body, out, rec)
 }
 
-// recordTrace persistiert den Trace, aber nur für Events aus der Zumba-Gruppe an
-// einem Donnerstag (best-effort: Fehler werden nur geloggt).
+// dispatch übergibt job an die Queue (Gemini, DB und ggf. der Renderer laufen
+// dann nach der Antwort an Evolution). Ohne Queue – oder wenn sie voll ist –
+// läuft job synchron mit ctx: lieber langsam als verloren.
+func (s *Server) dispatch(ctx context.Context, key string, job worker.Job) {
+	if s.Queue != nil {
+		if s.Queue.Submit(key, job) {
+			return
+		}
+		log.Printf("⚠️  Warteschlange voll/geschlossen – verarbeite synchron (%s)", key)
+	}
+	job(ctx)
+}
+
+// finishInbox schließt das Event ab; ein Fehler-Schritt im Trace lässt es als
+// "failed" für den Retry-Loop liegen.
+func (s *Server) finishInbox(ctx context.Context, id string, rec *tracestore.Recorder) {
+	procErr := rec.Err()
+	if err := s.Inbox.Finish(ctx, id, procErr); err != nil {
+		log.Printf("⚠️  inbox: %v", err)
+	}
+	if procErr != "" {
+		log.Printf("⚠️  Event %s fehlgeschlagen (%s) – wird wiederholt", id, procErr)
+	}
+}
+
+// RetryInbox verarbeitet fällige Events aus der Inbox erneut (failed mit
+// Restversuchen oder hängengeblieben). Bezugstag ist der Tag der ersten
+// Zustellung, nicht der Tag der Wiederholung. Mit Queue laufen die
+// Wiederholungen dort ein (Reihenfolge je Chat). Liefert die Anzahl.
+func (s *Server) RetryInbox(ctx context.Context) int {
+	if s.Inbox == nil {
+		return 0
+	}
+	due, err := s.Inbox.Due(ctx, 20)
+	if err != nil {
+		log.Printf("⚠️  inbox: %v", err)
+		return 0
+	}
+	n := 0
+	for _, e := range due {
+		receivedAt, ok, err := s.Inbox.Claim(ctx, e.MessageID, e.Payload)
+		if err != nil || !ok {
+			continue // inzwischen von jemand anderem übernommen
+		}
+		var ev evolution.WebhookEvent
+		if err := json.Unmarshal(e.Payload, &ev); err != nil {
+			_ = s.Inbox.Finish(ctx, e.MessageID, "invalid JSON: "+err.Error())
+			continue
+		}
+		at := receivedAt.In(s.location)
+		asOf := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, s.location)
+		log.Printf("🔁 Wiederhole Event %s (Versuch %d, empfangen %s)", e.MessageID, e.Attempts+1, at.Format("2006-01-02 15:04"))
+
+		id, payload := e.MessageID, e.Payload
+		s.dispatch(ctx, ev.RemoteJid(), func(ctx context.Context) {
+			rec := tracestore.NewRecorder()
+			out := s.run(ctx, ev, false, false, asOf, rec)
+			s.recordTrace(ctx, ev, payload, out, rec)
+			s.finishInbox(ctx, id, rec)
+		})
+		n++
+	}
+	return n
+}
+
+// RunInboxRetry ruft RetryInbox im Abstand every auf, bis ctx endet.
+func (s *Server) RunInboxRetry(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.RetryInbox(ctx)
+		}
+	}
+}
+
+// recordTrace persistiert den Trace, aber nur für Events aus der Zumba-Gruppe
+// (best-effort: Fehler werden nur geloggt). Seit Voraus-Absagen an jedem Tag
+// ankommen, wird jeder Tag aufgezeichnet.
 func (s *Server) recordTrace(ctx context.Context, ev evolution.WebhookEvent, body []byte, out Outcome, rec *tracestore.Recorder) {
-	if s.Tracer == nil || ev.RemoteJid() != s.groupJID || !s.isThursday() {
+	if s.Tracer == nil || ev.RemoteJid() != s.groupJID {
 		return
 	}
 	t := tracestore.Trace{
@@ -459,6 +1367,9 @@ func (s *Server) recordTrace(ctx context.Context, ev evolution.WebhookEvent, bod
 		HasError:       rec.HasError(),
 		RawPayload:     body,
 		Steps:          rec.Steps(),
+
+		MessageID:       ev.MessageID(),
+		ParentMessageID: ev.TargetID(),
 	}
 	if err := s.Tracer.Save(ctx, t); err != nil {
 		log.Printf("⚠️  trace save: %v", err)
@@ -530,10 +1441,6 @@ func (s *Server) handleTest(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(out)
 }
 
-func (s *Server) isThursday() bool {
-	return s.Now().In(s.location).Weekday() == time.Thursday
-}
-
 // today liefert das heutige Datum (Mitternacht) in der konfigurierten Zeitzone –
 // entspricht dem n8n-Ausdruck $today.
 func (s *Server) today() time.Time {
@@ -2,13 +2,18 @@ package web
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/michael/zumba-shared/domain"
 	"github.com/michael/zumba-whatsapp-bot/internal/classifier"
 	"github.com/michael/zumba-whatsapp-bot/internal/evolution"
 	"github.com/michael/zumba-shared/penalty"
 	"github.com/michael/zumba-whatsapp-bot/internal/store"
+	"github.com/michael/zumba-whatsapp-bot/internal/tracestore"
 )
 
 const testGroup = "000000000000-0000000000@g.us"
@@ -18,29 +23,128 @@ type fakeStore struct {
 	absentUserID  string
 	absentMessage string
 	presentUserID string
+	absentDates   []string // "YYYY-MM-DD" aller MarkAbsent-Aufrufe
+	presentDates  []string
+	excluded      []time.Time // von ExcludedDays geliefert
+
+	rows    map[string]string // aktuelle Absagen "YYYY-MM-DD" → Text
+	effects map[string]store.MessageEffect
 
 	penaltyInput      penalty.Input // von PenaltyInputs geliefert
 	autoStrafen       []string      // "userID|YYYY-MM-DD" der InsertAutoStrafe-Aufrufe
 	penaltyInputCalls int
+
+	userStats map[string]store.Stat // von UserStat geliefert (userID → Zeile)
+
+	confirmed map[string]string  // aktuelle Zusagen "YYYY-MM-DD" → Text
+	roster    []store.RosterEntry // von Roster geliefert
+	rosterDay string              // Datum des letzten Roster-Aufrufs
+	optOut    map[string]bool     // Erinnerung abbestellt
+
+	members  []store.Member // von Members geliefert
+	absences []string       // "userID|YYYY-MM-DD|enteredBy" aller MarkAbsent-Aufrufe
+
+	beleg       store.EinspruchBeleg // von EinspruchBeleg geliefert
+	einsprueche []string             // "id|userID|grund|traceID" der ErhebeEinspruch-Aufrufe
+
+	mahnungen  []penalty.Mahnung // Mahn-Protokoll (InsertMahnung hängt an)
+	gebuehren  []string          // "userID|betrag|YYYY-MM-DD" der angelegten Mahngebühren
+	mahnOptOut map[string]bool   // bei Mahnungen nicht in der Gruppe erwähnen
+	mahnungErr error             // InsertMahnung scheitert
+	// geloeschteMahnungen zählt DeleteMahnung (nicht zugestellt).
+	geloeschteMahnungen int
 }
 
 func (f *fakeStore) UserStats(context.Context, time.Time) ([]store.Stat, error) {
 	f.statsCalled = true
 	return []store.Stat{{Name: "A", Attendance: 1, Away: 0, Percent: 100}}, nil
 }
-func (f *fakeStore) MarkAbsent(_ context.Context, userID string, _ time.Time, msg string) error {
+func (f *fakeStore) UserStat(_ context.Context, userID string, _ time.Time) (store.Stat, bool, error) {
+	st, ok := f.userStats[userID]
+	return st, ok, nil
+}
+func (f *fakeStore) MarkConfirmed(_ context.Context, _ string, date time.Time, msg string) error {
+	if f.confirmed == nil {
+		f.confirmed = map[string]string{}
+	}
+	f.confirmed[date.Format("2006-01-02")] = msg
+	return nil
+}
+func (f *fakeStore) Unconfirm(_ context.Context, _ string, date time.Time) error {
+	delete(f.confirmed, date.Format("2006-01-02"))
+	return nil
+}
+func (f *fakeStore) Roster(_ context.Context, date time.Time) ([]store.RosterEntry, error) {
+	f.rosterDay = date.Format("2006-01-02")
+	return f.roster, nil
+}
+func (f *fakeStore) SetReminderOptOut(_ context.Context, userID string, optOut bool) error {
+	if f.optOut == nil {
+		f.optOut = map[string]bool{}
+	}
+	if optOut {
+		f.optOut[userID] = true
+	} else {
+		delete(f.optOut, userID)
+	}
+	return nil
+}
+func (f *fakeStore) ReminderOptOuts(context.Context) (map[string]bool, error) {
+	return f.optOut, nil
+}
+func (f *fakeStore) Members(context.Context) ([]store.Member, error) {
+	return f.members, nil
+}
+func (f *fakeStore) MarkAbsent(_ context.Context, userID string, date time.Time, msg, enteredBy string) error {
+	f.absences = append(f.absences, userID+"|"+date.Format("2006-01-02")+"|"+enteredBy)
 	f.absentUserID = userID
 	f.absentMessage = msg
+	f.absentDates = append(f.absentDates, date.Format("2006-01-02"))
+	if f.rows == nil {
+		f.rows = map[string]string{}
+	}
+	f.rows[date.Format("2006-01-02")] = msg
 	return nil
 }
-func (f *fakeStore) MarkPresent(_ context.Context, userID string, _ time.Time) error {
+func (f *fakeStore) MarkPresent(_ context.Context, userID string, date time.Time) error {
 	f.presentUserID = userID
+	f.presentDates = append(f.presentDates, date.Format("2006-01-02"))
+	delete(f.rows, date.Format("2006-01-02"))
 	return nil
 }
+func (f *fakeStore) AbsenceMessages(_ context.Context, _ string, dates []time.Time) (map[string]string, error) {
+	out := map[string]string{}
+	for _, d := range dates {
+		if msg, ok := f.rows[d.Format("2006-01-02")]; ok {
+			out[d.Format("2006-01-02")] = msg
+		}
+	}
+	return out, nil
+}
+func (f *fakeStore) SaveMessageEffect(_ context.Context, e store.MessageEffect) error {
+	if f.effects == nil {
+		f.effects = map[string]store.MessageEffect{}
+	}
+	f.effects[e.MessageID] = e
+	return nil
+}
+func (f *fakeStore) MessageEffect(_ context.Context, id string) (*store.MessageEffect, error) {
+	if e, ok := f.effects[id]; ok {
+		return &e, nil
+	}
+	return nil, nil
+}
+func (f *fakeStore) ExcludedDays(context.Context, time.Time, time.Time) ([]time.Time, error) {
+	return f.excluded, nil
+}
 func (f *fakeStore) PenaltyInputs(context.Context, time.Time) (penalty.Input, error) {
 	f.penaltyInputCalls++
 	return f.penaltyInput, nil
 }
+func (f *fakeStore) PenaltyRules(context.Context) (penalty.RuleSets, error) {
+	return f.penaltyInput.Rules, nil
+}
+
 func (f *fakeStore) InsertAutoStrafen(_ context.Context, marks []store.AutoStrafe) error {
 	for _, m := range marks {
 		f.autoStrafen = append(f.autoStrafen, m.UserID+"|"+m.Datum.Format("2006-01-02"))
@@ -48,6 +152,53 @@ func (f *fakeStore) InsertAutoStrafen(_ context.Context, marks []store.AutoStraf
 	return nil
 }
 
+func (f *fakeStore) EinspruchBeleg(context.Context, string, time.Time, time.Time) (store.EinspruchBeleg, error) {
+	return f.beleg, nil
+}
+
+func (f *fakeStore) ErhebeEinspruch(_ context.Context, id int64, userID, grund string, b store.EinspruchBeleg) error {
+	f.einsprueche = append(f.einsprueche, fmt.Sprintf("%d|%s|%s|%d", id, userID, grund, b.TraceID))
+	return nil
+}
+func (f *fakeStore) Mahnungen(context.Context) ([]penalty.Mahnung, error) {
+	return f.mahnungen, nil
+}
+func (f *fakeStore) InsertMahnung(_ context.Context, m penalty.Mahnung, gebuehr int, datum time.Time) (int64, error) {
+	if f.mahnungErr != nil {
+		return 0, f.mahnungErr
+	}
+	if gebuehr > 0 {
+		f.gebuehren = append(f.gebuehren, fmt.Sprintf("%s|%d|%s", m.UserID, gebuehr, datum.Format("2006-01-02")))
+	}
+	m.ID, m.CreatedAt = int64(len(f.mahnungen)+1000), datum
+	f.mahnungen = append(f.mahnungen, m)
+	return m.ID, nil
+}
+func (f *fakeStore) DeleteMahnung(_ context.Context, id int64) error {
+	for i, m := range f.mahnungen {
+		if m.ID == id {
+			f.mahnungen = append(f.mahnungen[:i], f.mahnungen[i+1:]...)
+			f.geloeschteMahnungen++
+			return nil
+		}
+	}
+	return fmt.Errorf("DeleteMahnung: %d nicht gefunden", id)
+}
+func (f *fakeStore) MahnOptOuts(context.Context) (map[string]bool, error) {
+	return f.mahnOptOut, nil
+}
+func (f *fakeStore) SetMahnOptOut(_ context.Context, userID string, optOut bool) error {
+	if f.mahnOptOut == nil {
+		f.mahnOptOut = map[string]bool{}
+	}
+	if optOut {
+		f.mahnOptOut[userID] = true
+	} else {
+		delete(f.mahnOptOut, userID)
+	}
+	return nil
+}
+
 type fakeClassifier struct{ result classifier.Result }
 
 func (f fakeClassifier) Classify(context.Context, string) (classifier.Classification, error) {
@@ -64,6 +215,8 @@ type fakeSender struct {
 	imagePNG     []byte
 	imageErr     error
 	imageCalled  bool
+
+	reactions []string // "messageID|emoji" aller SendReaction-Aufrufe
 }
 
 func (f *fakeSender) SendText(_ context.Context, number, text string) error {
@@ -81,6 +234,11 @@ func (f *fakeSender) SendImage(_ context.Context, number, caption string, png []
 	return f.imageErr
 }
 
+func (f *fakeSender) SendReaction(_ context.Context, key evolution.MessageKey, emoji string) error {
+	f.reactions = append(f.reactions, key.ID+"|"+emoji)
+	return nil
+}
+
 func newTestServer(result classifier.Result, now time.Time) (*Server, *fakeStore, *fakeSender) {
 	st := &fakeStore{}
 	snd := &fakeSender{}
@@ -146,11 +304,169 @@ func TestInvalidDoesNothing(t *testing.T) {
 	}
 }
 
-func TestNotThursdayDoesNothing(t *testing.T) {
+func TestAbsageAmDonnerstagGiltHeute(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Absage, thursday)
+	out := s.run(context.Background(), groupMsg("bin raus"), false, false, s.today())
+	if strings.Join(st.absentDates, ",") != "2026-01-01" || out.Date != "2026-01-01" {
+		t.Errorf("dates=%v out.Date=%s", st.absentDates, out.Date)
+	}
+}
+
+func TestAbsageAmFreitagGiltNaechstenDonnerstag(t *testing.T) {
 	s, st, _ := newTestServer(classifier.Absage, friday)
 	s.run(context.Background(), groupMsg("bin raus"), false, false, s.today())
-	if st.absentUserID != "" {
-		t.Error("no action expected on non-Thursday")
+	if strings.Join(st.absentDates, ",") != "2026-01-08" {
+		t.Errorf("dates=%v, want 2026-01-08", st.absentDates)
+	}
+}
+
+func TestVorausAbsageMehrereTermine(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Absage, friday)
+	st.excluded = []time.Time{time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)}
+	rec := tracestore.NewRecorder()
+	out := s.run(context.Background(), groupMsg("bin vom 10. bis 24. im Urlaub"), false, false, s.today(), rec)
+	if got := strings.Join(st.absentDates, ","); got != "2026-01-22" {
+		t.Errorf("dates=%s, want 2026-01-22 (15.01. Sperrtag)", got)
+	}
+	if out.Action != "marked_absent" || strings.Join(out.Dates, ",") != "2026-01-22" {
+		t.Errorf("outcome: %+v", out)
+	}
+	var resolved bool
+	for _, step := range rec.Steps() {
+		if step.Node == tracestore.NodeResolveDates {
+			resolved = strings.Contains(step.Detail, "22.01.") && strings.Contains(step.Detail, "Sperrtag: 15.01.")
+		}
+	}
+	if !resolved {
+		t.Errorf("resolve_dates-Schritt fehlt/unvollständig: %+v", rec.Steps())
+	}
+}
+
+type mlClassifier struct{}
+
+func (mlClassifier) Classify(context.Context, string) (classifier.Classification, error) {
+	return classifier.Classification{Result: classifier.Absage, Raw: "false", Model: "classifier-service",
+		Backend: classifier.BackendML, Confidence: 0.93, FallbackReason: "gemini: HTTP 503"}, nil
+}
+
+func TestMLFallbackImTrace(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Absage, thursday)
+	s.classifier = mlClassifier{}
+	rec := tracestore.NewRecorder()
+	s.run(context.Background(), groupMsg("bin raus"), false, false, s.today(), rec)
+	if strings.Join(st.absentDates, ",") != "2026-01-01" {
+		t.Errorf("dates=%v, want 2026-01-01", st.absentDates)
+	}
+	var found bool
+	for _, step := range rec.Steps() {
+		if step.Node == tracestore.NodeClassify {
+			found = step.Label == "Classifier (ML-Fallback)" && step.Outcome == tracestore.OutcomeInfo &&
+				strings.Contains(step.Detail, "Konfidenz 0.93") && strings.Contains(step.Detail, "HTTP 503")
+		}
+	}
+	if !found {
+		t.Errorf("classify-Schritt fehlt/unvollständig: %+v", rec.Steps())
+	}
+}
+
+type cachedClassifier struct{ source string }
+
+func (c cachedClassifier) Classify(context.Context, string) (classifier.Classification, error) {
+	return classifier.Classification{Result: classifier.Absage, Raw: "false", Model: "gemini-2.5-flash",
+		Backend: classifier.BackendLLM, Cache: c.source}, nil
+}
+
+func TestCacheTrefferImTrace(t *testing.T) {
+	for source, want := range map[string]string{
+		classifier.CacheLLM:      "LLM-Antwort von gemini-2.5-flash",
+		classifier.CacheVerified: "geprüftes Label",
+	} {
+		s, st, _ := newTestServer(classifier.Absage, thursday)
+		s.classifier = cachedClassifier{source: source}
+		rec := tracestore.NewRecorder()
+		s.run(context.Background(), groupMsg("bin raus"), false, false, s.today(), rec)
+		if len(st.absentDates) != 1 {
+			t.Errorf("%s: dates=%v, want eine Absage", source, st.absentDates)
+		}
+		var found bool
+		for _, step := range rec.Steps() {
+			if step.Node == tracestore.NodeClassify {
+				found = step.Label == "Classifier (Cache)" && strings.Contains(step.Detail, want)
+			}
+		}
+		if !found {
+			t.Errorf("%s: classify-Schritt fehlt/unvollständig: %+v", source, rec.Steps())
+		}
+	}
+}
+
+type keyedClassifier struct{ key string }
+
+func (k *keyedClassifier) Classify(context.Context, string) (classifier.Classification, error) {
+	return classifier.Classification{}, errors.New("Classify statt ClassifyKeyed")
+}
+
+func (k *keyedClassifier) ClassifyKeyed(_ context.Context, key, _ string) (classifier.Classification, error) {
+	k.key = key
+	return classifier.Classification{Result: classifier.Absage, Raw: "false", Backend: classifier.BackendML,
+		Confidence: 0.97, Route: classifier.RouteCanary}, nil
+}
+
+type fakeShadow struct{ got []classifier.Classification }
+
+func (f *fakeShadow) RecordAsync(_, _, _ string, c classifier.Classification) { f.got = append(f.got, c) }
+
+func TestCanaryNachMessageID(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Absage, thursday)
+	kc := &keyedClassifier{}
+	sh := &fakeShadow{}
+	s.classifier, s.Shadow = kc, sh
+	rec := tracestore.NewRecorder()
+	ev := groupMsg("bin raus")
+	ev.Data.Key.ID = "3EB0CANARY"
+	s.run(context.Background(), ev, false, false, s.today(), rec)
+	if kc.key != "3EB0CANARY" {
+		t.Errorf("Schlüssel %q, want Message-ID", kc.key)
+	}
+	if strings.Join(st.absentDates, ",") != "2026-01-01" {
+		t.Errorf("dates=%v", st.absentDates)
+	}
+	if len(sh.got) != 1 || sh.got[0].Route != classifier.RouteCanary {
+		t.Errorf("Shadow: %+v", sh.got)
+	}
+	for _, step := range rec.Steps() {
+		if step.Node == tracestore.NodeClassify && step.Label != "Classifier (Modell · Canary)" {
+			t.Errorf("classify-Label %q", step.Label)
+		}
+	}
+
+	// Dry-Run protokolliert nichts.
+	s.run(context.Background(), ev, false, true, s.today())
+	if len(sh.got) != 1 {
+		t.Errorf("Dry-Run im Shadow: %d Einträge", len(sh.got))
+	}
+}
+
+func TestVorausAbsageOhneTerminTutNichts(t *testing.T) {
+	s, st, _ := newTestServer(classifier.Absage, thursday)
+	out := s.run(context.Background(), groupMsg("am Samstag bin ich nicht da"), false, false, s.today())
+	if st.absentUserID != "" || out.Action != "none" {
+		t.Errorf("keine Aktion erwartet: %+v / %+v", st, out)
+	}
+}
+
+func TestScheduleDienstagsGruppe(t *testing.T) {
+	tuesday := time.Date(2026, 1, 6, 12, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		now  time.Time
+		want string
+	}{{tuesday, "2026-01-06"}, {thursday, "2026-01-06"}} {
+		s, st, _ := newTestServer(classifier.Absage, c.now)
+		s.Schedule, _ = domain.ParseSchedule("di")
+		s.run(context.Background(), groupMsg("bin raus"), false, false, s.today())
+		if got := strings.Join(st.absentDates, ","); got != c.want {
+			t.Errorf("%s: dates=%s, want %s", c.now.Weekday(), got, c.want)
+		}
 	}
 }
 
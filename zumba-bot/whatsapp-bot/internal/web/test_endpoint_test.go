@@ -22,7 +22,7 @@ func TestRunStatistikReturnsText(t *testing.T) {
 }
 
 func TestRunClassifyBypassMarksAbsent(t *testing.T) {
-	s, st, _ := newTestServer(classifier.Absage, friday) // not Thursday, but bypass=true
+	s, st, _ := newTestServer(classifier.Absage, friday) // bypass=true
 	out := s.run(context.Background(), groupMsg("bin raus"), true, false, s.today())
 	if out.Path != "classify" || out.Classification != "false" || out.Action != "marked_absent" {
 		t.Fatalf("bad outcome: %+v", out)
@@ -42,7 +42,9 @@ func TestRunClassifyZusageMarksPresent(t *testing.T) {
 
 func TestRunGuardsBlockWithoutBypass(t *testing.T) {
 	s, st, _ := newTestServer(classifier.Absage, friday)
-	out := s.run(context.Background(), groupMsg("bin raus"), false, false, s.today())
+	ev := groupMsg("bin raus")
+	ev.Data.Key.RemoteJid = "someone-else@g.us"
+	out := s.run(context.Background(), ev, false, false, s.today())
 	if out.Path != "ignored" || out.Reason == "" {
 		t.Fatalf("expected ignored with reason: %+v", out)
 	}
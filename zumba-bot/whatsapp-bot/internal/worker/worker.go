@@ -0,0 +1,113 @@
+// Package worker verarbeitet Webhook-Events asynchron in einem begrenzten
+// Pool. Jeder Key (bei uns die remoteJid des Chats) landet immer beim selben
+// Worker – so bleiben Nachrichten eines Chats in Eingangsreihenfolge
+// ("komme doch nicht" nach "bin dabei"), während verschiedene Chats parallel
+// laufen.
+package worker
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+// Job ist eine Verarbeitungseinheit. ctx endet erst, wenn Shutdown die
+// Frist überschreitet – nicht mit dem HTTP-Request.
+type Job func(ctx context.Context)
+
+// Pool ist ein Worker-Pool mit einer Warteschlange je Worker.
+type Pool struct {
+	queues []chan Job
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New startet workers Worker mit je queueSize Plätzen Warteschlange.
+func New(workers, queueSize int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{queues: make([]chan Job, workers), ctx: ctx, cancel: cancel}
+	for i := range p.queues {
+		q := make(chan Job, queueSize)
+		p.queues[i] = q
+		p.wg.Add(1)
+		go p.loop(q)
+	}
+	return p
+}
+
+func (p *Pool) loop(q chan Job) {
+	defer p.wg.Done()
+	for job := range q {
+		p.do(job)
+	}
+}
+
+// do führt einen Job aus; ein Panic reißt nicht den ganzen Worker mit.
+func (p *Pool) do(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️  worker: panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	job(p.ctx)
+}
+
+// Submit reiht job in die Warteschlange des Workers für key ein. false =
+// Warteschlange voll oder Pool im Shutdown – der Aufrufer entscheidet, was
+// mit dem Event passiert.
+func (p *Pool) Submit(key string, job Job) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return false
+	}
+	select {
+	case p.queues[p.shard(key)] <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Pool) shard(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
+
+// Shutdown nimmt keine Jobs mehr an und arbeitet die Warteschlangen ab. Läuft
+// ctx vorher ab, wird der Context der laufenden Jobs abgebrochen und
+// ctx.Err() geliefert.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, q := range p.queues {
+			close(q)
+		}
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
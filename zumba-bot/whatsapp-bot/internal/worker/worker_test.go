@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReihenfolgeJeKey(t *testing.T) {
+	p := New(4, 100)
+	var mu sync.Mutex
+	got := map[string][]int{}
+	for i := 0; i < 50; i++ {
+		for _, key := range []string{"a@g.us", "b@g.us", "c@s.whatsapp.net"} {
+			if !p.Submit(key, func(context.Context) {
+				mu.Lock()
+				got[key] = append(got[key], i)
+				mu.Unlock()
+			}) {
+				t.Fatal("Submit abgelehnt")
+			}
+		}
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for key, seq := range got {
+		if len(seq) != 50 {
+			t.Errorf("%s: %d Jobs, want 50", key, len(seq))
+		}
+		for i, v := range seq {
+			if v != i {
+				t.Fatalf("%s: Reihenfolge verletzt: %v", key, seq)
+			}
+		}
+	}
+}
+
+func TestShutdownArbeitetAbUndNimmtNichtsMehrAn(t *testing.T) {
+	p := New(1, 10)
+	done := 0
+	for i := 0; i < 5; i++ {
+		p.Submit("k", func(context.Context) { time.Sleep(5 * time.Millisecond); done++ })
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if done != 5 {
+		t.Errorf("done = %d, want 5", done)
+	}
+	if p.Submit("k", func(context.Context) {}) {
+		t.Error("Submit nach Shutdown angenommen")
+	}
+}
+
+func TestShutdownFristBrichtJobsAb(t *testing.T) {
+	p := New(1, 10)
+	cancelled := make(chan struct{})
+	p.Submit("k", func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown = %v, want DeadlineExceeded", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("Job-Context nicht abgebrochen")
+	}
+}
+
+func TestVolleWarteschlangeUndPanic(t *testing.T) {
+	p := New(1, 1)
+	block := make(chan struct{})
+	p.Submit("k", func(context.Context) { <-block; panic("boom") })
+	for p.Submit("k", func(context.Context) {}) {
+		// bis die Warteschlange voll ist
+	}
+	close(block)
+	ran := false
+	for !p.Submit("k", func(context.Context) { ran = true }) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Error("Worker nach Panic nicht weitergelaufen")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/michael/zumba-shared/domain"
+
 	"github.com/michael/stammtisch-wrapped/assets"
 	"github.com/michael/stammtisch-wrapped/internal/database"
 	"github.com/michael/stammtisch-wrapped/internal/handlers"
@@ -33,8 +35,22 @@ func main() {
 		defer db.Close()
 	}
 
+	// Stammtisch-Tage (gleiches Format wie Bot und Admin-UI; Default donnerstags)
+	sched, err := domain.ParseSchedule(os.Getenv("MEETING_SCHEDULE"))
+	if err != nil {
+		log.Fatalf("MEETING_SCHEDULE: %v", err)
+	}
+	log.Printf("📅 Stammtisch: %s", sched.Describe())
+
+	// Entschuldigte Treffen aus Rangliste und Quoten nehmen (wie Bot und
+	// Admin-UI; Default aus)
+	excludeExcused := os.Getenv("LEADERBOARD_EXCLUDE_EXCUSED") == "true"
+	if excludeExcused {
+		log.Printf("🩹 Rangliste ohne entschuldigte Treffen")
+	}
+
 	// Create handler with optional database
-	handler := handlers.NewWrappedHandler(db)
+	handler := handlers.NewWrappedHandler(db, sched, excludeExcused)
 
 	// Routes
 	http.HandleFunc("/", handler.HandleIndex)
@@ -325,8 +325,8 @@ func GetStrafenStats() models.StrafenStats {
 	// Two manual no-show penalties for variety
 	noShowDate := thursdays[len(thursdays)/2]
 	rows := []penalty.Row{
-		{ID: 1, UserID: "8", Art: penalty.ArtNoShow, Datum: noShowDate, Betrag: penalty.NoShowDefault, Status: penalty.StatusOffen},
-		{ID: 2, UserID: "13", Art: penalty.ArtNoShow, Datum: noShowDate, Betrag: penalty.NoShowDefault, Status: penalty.StatusBeglichen, BeglichenAm: &asOf},
+		{ID: 1, UserID: "8", Art: penalty.ArtNoShow, Datum: noShowDate, Betrag: penalty.DefaultRuleSet.NoShowDefault, Status: penalty.StatusOffen},
+		{ID: 2, UserID: "13", Art: penalty.ArtNoShow, Datum: noShowDate, Betrag: penalty.DefaultRuleSet.NoShowDefault, Status: penalty.StatusBeglichen, BeglichenAm: &asOf},
 	}
 
 	entries := penalty.Assess(penalty.Input{Users: userData, Rows: rows}, asOf)
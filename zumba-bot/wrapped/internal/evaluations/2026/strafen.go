@@ -4,6 +4,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/michael/zumba-shared/domain"
 	"github.com/michael/zumba-shared/penalty"
 	"github.com/michael/stammtisch-wrapped/pkg/models"
 )
@@ -36,8 +37,9 @@ func (e *Evaluator) calculateStrafenStats() models.StrafenStats {
 		users = append(users, penalty.UserData{
 			UserID:         u.UserID,
 			Name:           u.UserName,
-			EffectiveStart: penalty.ClampStart(u.StartDate),
+			EffectiveStart: e.rawData.RuleSets.ClampStart(u.StartDate),
 			Absences:       absencesByUser[u.UserID],
+			Entschuldigt:   e.rawData.Entschuldigt.Of(u.UserID),
 		})
 	}
 
@@ -63,6 +65,8 @@ func (e *Evaluator) calculateStrafenStats() models.StrafenStats {
 		Users:    users,
 		Excluded: excluded,
 		Rows:     rows,
+		Schedule: e.rawData.Schedule,
+		Rules:    e.rawData.RuleSets,
 	}, asOf)
 
 	byUser := make(map[string]*models.StrafenUserTotal)
@@ -82,7 +86,7 @@ func (e *Evaluator) calculateStrafenStats() models.StrafenStats {
 			Status:   string(entry.Status),
 		}
 		if entry.Art == penalty.ArtFehltage {
-			me.End = streakEnd(entry.Datum, entry.Tage, asOf, excludedSet)
+			me.End = streakEnd(e.rawData.Schedule, entry.Datum, entry.Tage, asOf, excludedSet)
 		}
 
 		ut, ok := byUser[entry.UserID]
@@ -93,7 +97,7 @@ func (e *Evaluator) calculateStrafenStats() models.StrafenStats {
 		ut.Total += me.Betrag
 		if entry.Art == penalty.ArtFehltage {
 			ut.FehltageCount++
-		} else {
+		} else if entry.Art == penalty.ArtNoShow {
 			ut.NoShowCount++
 		}
 		ut.Entries = append(ut.Entries, me)
@@ -118,10 +122,10 @@ func (e *Evaluator) calculateStrafenStats() models.StrafenStats {
 	return stats
 }
 
-// streakEnd returns the last Thursday of a fehltage streak that starts at
-// start and spans tage valid Thursdays (excluded days don't count).
-func streakEnd(start time.Time, tage int, asOf time.Time, excluded map[string]bool) time.Time {
-	thursdays := penalty.Thursdays(start, asOf, excluded)
+// streakEnd returns the last meeting day of a fehltage streak that starts at
+// start and spans tage valid meeting days (excluded days don't count).
+func streakEnd(sched domain.Schedule, start time.Time, tage int, asOf time.Time, excluded map[string]bool) time.Time {
+	thursdays := penalty.Meetings(sched.OrDefault(), start, asOf, excluded)
 	if tage <= 0 || len(thursdays) == 0 {
 		return start
 	}
@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/michael/zumba-shared/domain"
+
 	"github.com/michael/stammtisch-wrapped/internal/repository"
 )
 
@@ -90,3 +92,52 @@ func TestCalculateStrafenStatsExcludesDeleted(t *testing.T) {
 		t.Errorf("deleted penalties must not appear, got %d entries", stats.TotalCount)
 	}
 }
+
+func TestCalculateStrafenStatsEigenerSchedule(t *testing.T) {
+	sched, err := domain.ParseSchedule("mi/2@2025-12-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meetings := sched.Meetings(day(2025, 12, 1), day(2026, 3, 1))[:5]
+
+	rawData := &repository.RawData{
+		Users:     []repository.RawUser{{UserID: "a", UserName: "Anna"}},
+		Thursdays: meetings,
+		Schedule:  sched,
+	}
+	for _, m := range meetings {
+		rawData.Rejections = append(rawData.Rejections, repository.RawRejection{UserID: "a", Date: m})
+	}
+
+	stats := NewEvaluator(rawData).calculateStrafenStats()
+	if stats.TotalCount != 1 || stats.TotalSum != 25 {
+		t.Fatalf("expected one 25 € penalty over 5 bi-weekly Wednesdays, got %d/%d", stats.TotalCount, stats.TotalSum)
+	}
+	if entry := stats.UserTotals[0].Entries[0]; !entry.End.Equal(meetings[4]) {
+		t.Errorf("expected streak end %v, got %v", meetings[4], entry.End)
+	}
+}
+
+// Entschuldigte Treffen sind neutral: Anna fehlt 6 Donnerstage, zwei davon
+// in ihrer Reha – Serie 4, keine Strafe; sie zählen als entschuldigt.
+func TestCalculateStrafenStatsEntschuldigt(t *testing.T) {
+	thursdays := consecutiveThursdays(6)
+	rawData := &repository.RawData{
+		Users:     []repository.RawUser{{UserID: "a", UserName: "Anna"}},
+		Thursdays: thursdays,
+		Entschuldigt: domain.Entschuldigungen{
+			{UserID: "a", Von: thursdays[2], Bis: thursdays[3].AddDate(0, 0, 2), Grund: "Reha"},
+		},
+	}
+	for _, d := range thursdays {
+		rawData.Rejections = append(rawData.Rejections, repository.RawRejection{UserID: "a", Date: d})
+	}
+
+	ev := NewEvaluator(rawData)
+	if stats := ev.calculateStrafenStats(); stats.TotalCount != 0 {
+		t.Errorf("expected no penalty, got %+v", stats)
+	}
+	if n := ev.excusedCount("a", thursdays[0]); n != 2 {
+		t.Errorf("excusedCount = %d, want 2", n)
+	}
+}
@@ -2,6 +2,7 @@ package eval2026
 
 import (
 	"sort"
+	"time"
 
 	"github.com/michael/stammtisch-wrapped/pkg/models"
 )
@@ -84,6 +85,7 @@ func (e *Evaluator) calculateUserStats(userLookup map[string]int, cancellations
 			MaxCancellationStreakStart: canc.Start,
 			MaxCancellationStreakEnd:   canc.End,
 			NeverCancelled:             row.AwayCount == 0,
+			ExcusedCount:               e.excusedCount(row.UserID, row.EffectiveStart),
 			FavoriteExcuseCategory:     findFavoriteCategory(msgs),
 			Title:                      title,
 			TitleEmoji:                 titleEmoji,
@@ -108,6 +110,25 @@ func (e *Evaluator) calculateUserStats(userLookup map[string]int, cancellations
 	return userStats
 }
 
+// excusedCount zählt die Treffen-Tage ab dem effektiven Start, die in einem
+// entschuldigten Zeitraum des Users liegen.
+func (e *Evaluator) excusedCount(userID string, start time.Time) int {
+	own := e.rawData.Entschuldigt.Of(userID)
+	if len(own) == 0 {
+		return 0
+	}
+	n := 0
+	for _, t := range e.rawData.Thursdays {
+		if t.Before(start) {
+			continue
+		}
+		if _, ok := own.Covering(userID, t); ok {
+			n++
+		}
+	}
+	return n
+}
+
 // findFavoriteCategory returns the most common category for a user's cancellations
 func findFavoriteCategory(cancellations []models.Cancellation) string {
 	if len(cancellations) == 0 {
@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/michael/zumba-shared/domain"
+
 	"github.com/michael/stammtisch-wrapped/data"
 	"github.com/michael/stammtisch-wrapped/internal/database"
 	eval2026 "github.com/michael/stammtisch-wrapped/internal/evaluations/2026"
@@ -37,13 +39,16 @@ type WrappedHandler struct {
 	cachedAt time.Time
 }
 
-// NewWrappedHandler creates a new handler with optional database connection
-func NewWrappedHandler(db *database.PostgresDB) *WrappedHandler {
+// NewWrappedHandler creates a new handler with optional database connection.
+// sched is the meeting schedule the evaluation counts (MEETING_SCHEDULE);
+// excludeExcused drops excused meetings from the leaderboard
+// (LEADERBOARD_EXCLUDE_EXCUSED).
+func NewWrappedHandler(db *database.PostgresDB, sched domain.Schedule, excludeExcused bool) *WrappedHandler {
 	if db == nil {
 		return &WrappedHandler{useDB: false}
 	}
 	return &WrappedHandler{
-		repo:  repository.NewRejectionRepository(db),
+		repo:  repository.NewRejectionRepository(db, sched, excludeExcused),
 		useDB: true,
 	}
 }
@@ -3,6 +3,8 @@ package repository
 import (
 	"time"
 
+	"github.com/michael/zumba-shared/domain"
+	"github.com/michael/zumba-shared/penalty"
 	sharedstore "github.com/michael/zumba-shared/store"
 )
 
@@ -60,11 +62,18 @@ type RawData struct {
 	Users        []RawUser
 	Rejections   []RawRejection
 	ExcludedDays []ExcludedDay
-	Thursdays    []time.Time  // All valid Thursdays for the year (excluding excluded_days)
-	StrafenRows  []StrafenRow // All penalty rows (incl. beglichen/geloescht — needed as reset markers)
+	Thursdays    []time.Time      // All valid meeting days for the year (excluding excluded_days)
+	StrafenRows  []StrafenRow     // All penalty rows (incl. beglichen/geloescht — needed as reset markers)
+	RuleSets     penalty.RuleSets // Penalty rule sets (empty = default rules)
+	// Entschuldigt sind die entschuldigten Zeiträume (Admin-UI): neutral für
+	// Strafen, eigene Zahl je User; leer, solange es die Tabelle nicht gibt.
+	Entschuldigt domain.Entschuldigungen
 
 	// In SQL vorberechnete Auswertungen (gleiche Snapshot-Transaktion):
 	Leaderboard   []sharedstore.LeaderboardRow // geteilte Rangliste-Query (shared/store)
 	MaxStreaks    []MaxStreak                  // längste Serien je User
-	ThursdayStats []ThursdayAttendance         // Anwesenheit je Donnerstag
+	ThursdayStats []ThursdayAttendance         // Anwesenheit je Treffen-Tag
+
+	// Schedule bestimmt die Treffen-Tage (Nullwert = jeden Donnerstag).
+	Schedule domain.Schedule
 }
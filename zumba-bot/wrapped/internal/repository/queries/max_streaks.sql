@@ -1,8 +1,9 @@
 -- Längste Anwesenheits- bzw. Absage-Serie je User (gaps-and-islands):
 -- pro User und Zustand (anwesend/abwesend) die längste zusammenhängende
--- Donnerstags-Serie mit Start-/Enddatum. Donnerstage zählen ab dem
+-- Serie von Treffen-Tagen mit Start-/Enddatum. Treffen zählen ab dem
 -- geklemmten Start des Users; bei Gleichstand gewinnt die frühere Serie.
--- $1 = Periodenstart (Domänen-Minimum), $2 = Periodenende (an current_date gekappt).
+-- $1 = Periodenstart (Domänen-Minimum), $2 = Periodenende (an current_date gekappt),
+-- $3 = Treffen-Tage laut domain.Schedule (date[], früher fest ISODOW = 4).
 WITH startdates AS (
     SELECT
         u."userId",
@@ -15,7 +16,7 @@ days AS (
     CROSS JOIN LATERAL generate_series(
         s.start, LEAST($2::date, current_date), interval '1 day'
     ) d(day)
-    WHERE EXTRACT(ISODOW FROM d.day) = 4
+    WHERE d.day::date = ANY($3::date[])
       AND d.day::date NOT IN (SELECT date FROM excluded_days)
 ),
 marked AS (
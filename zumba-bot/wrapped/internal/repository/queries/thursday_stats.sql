@@ -1,7 +1,8 @@
--- Anwesenheit je Donnerstag: aktiv = User, deren geklemmter Start erreicht
+-- Anwesenheit je Treffen-Tag ("Donnerstag" aus historischen Gründen): aktiv = User, deren geklemmter Start erreicht
 -- ist; Absagen zählen nur für zu dem Zeitpunkt aktive User
 -- (attendance-by-default: anwesend = aktiv - abgemeldet).
--- $1 = Periodenstart (Domänen-Minimum), $2 = Periodenende (an current_date gekappt).
+-- $1 = Periodenstart (Domänen-Minimum), $2 = Periodenende (an current_date gekappt),
+-- $3 = Treffen-Tage laut domain.Schedule (date[], früher fest ISODOW = 4).
 WITH startdates AS (
     SELECT
         u."userId",
@@ -11,7 +12,7 @@ WITH startdates AS (
 days AS (
     SELECT d.day::date AS day
     FROM generate_series($1::date, LEAST($2::date, current_date), interval '1 day') d(day)
-    WHERE EXTRACT(ISODOW FROM d.day) = 4
+    WHERE d.day::date = ANY($3::date[])
       AND d.day::date NOT IN (SELECT date FROM excluded_days)
 ),
 active AS (
@@ -25,7 +26,7 @@ absent AS (
     FROM public.stammtisch_abwesenheit a
     JOIN startdates s ON s."userId" = a."userId" AND a.date >= s.start
     WHERE a.date >= $1 AND a.date <= LEAST($2::date, current_date)
-      AND EXTRACT(ISODOW FROM a.date) = 4
+      AND a.date = ANY($3::date[])
       AND a.date NOT IN (SELECT date FROM excluded_days)
     GROUP BY a.date
 )
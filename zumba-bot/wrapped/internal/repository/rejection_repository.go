@@ -12,6 +12,7 @@ import (
 	"github.com/lib/pq"
 
 	"github.com/michael/zumba-shared/domain"
+	"github.com/michael/zumba-shared/penalty"
 	sharedstore "github.com/michael/zumba-shared/store"
 
 	"github.com/michael/stammtisch-wrapped/internal/database"
@@ -35,12 +36,19 @@ type queryer interface {
 
 // RejectionRepository handles data access for rejection/absence data
 type RejectionRepository struct {
-	db *database.PostgresDB
+	db       *database.PostgresDB
+	schedule domain.Schedule
+
+	// excludeExcused nimmt entschuldigte Treffen aus der Rangliste
+	// (LEADERBOARD_EXCLUDE_EXCUSED, wie Bot und Admin-UI).
+	excludeExcused bool
 }
 
-// NewRejectionRepository creates a new RejectionRepository
-func NewRejectionRepository(db *database.PostgresDB) *RejectionRepository {
-	return &RejectionRepository{db: db}
+// NewRejectionRepository creates a new RejectionRepository. sched decides
+// which days count as Stammtisch (zero value = every Thursday);
+// excludeExcused drops excused meetings from the leaderboard.
+func NewRejectionRepository(db *database.PostgresDB, sched domain.Schedule, excludeExcused bool) *RejectionRepository {
+	return &RejectionRepository{db: db, schedule: sched, excludeExcused: excludeExcused}
 }
 
 func getAllUsers(ctx context.Context, q queryer) ([]RawUser, error) {
@@ -72,19 +80,19 @@ func getAllUsers(ctx context.Context, q queryer) ([]RawUser, error) {
 	return users, nil
 }
 
-// getRejections fetches all rejections within the date range (only Thursdays,
-// excluding excluded_days).
-func getRejections(ctx context.Context, q queryer, start, end time.Time) ([]RawRejection, error) {
+// getRejections fetches all rejections within the date range (only meeting
+// days of the schedule, excluding excluded_days).
+func getRejections(ctx context.Context, q queryer, sched domain.Schedule, start, end time.Time) ([]RawRejection, error) {
 	query := `
 		SELECT "userId", date, message
 		FROM stammtisch_abwesenheit
 		WHERE date >= $1 AND date <= $2
-		  AND EXTRACT(DOW FROM date) = 4
+		  AND date = ANY($3::date[])
 		  AND date NOT IN (SELECT date FROM excluded_days)
 		ORDER BY date, "userId"
 	`
 
-	rows, err := q.QueryContext(ctx, query, start, end)
+	rows, err := q.QueryContext(ctx, query, start, end, sharedstore.MeetingDates(sched, start, end))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query rejections: %w", err)
 	}
@@ -136,13 +144,14 @@ func getExcludedDays(ctx context.Context, q queryer, start, end time.Time) ([]Ex
 	return excludedDays, nil
 }
 
-// getThursdays returns all Thursdays within the date range, excluding excluded_days.
-func getThursdays(ctx context.Context, q queryer, start, end time.Time) ([]time.Time, error) {
+// getThursdays returns all meeting days of the schedule within the date range
+// (historically Thursdays), excluding excluded_days.
+func getThursdays(ctx context.Context, q queryer, sched domain.Schedule, start, end time.Time) ([]time.Time, error) {
 	query := `
 		WITH all_thursdays AS (
 			SELECT d::date AS thursday
 			FROM generate_series($1::date, $2::date, interval '1 day') AS d
-			WHERE EXTRACT(DOW FROM d) = 4
+			WHERE d::date = ANY($3::date[])
 		)
 		SELECT thursday
 		FROM all_thursdays
@@ -150,7 +159,7 @@ func getThursdays(ctx context.Context, q queryer, start, end time.Time) ([]time.
 		ORDER BY thursday
 	`
 
-	rows, err := q.QueryContext(ctx, query, start, end)
+	rows, err := q.QueryContext(ctx, query, start, end, sharedstore.MeetingDates(sched, start, end))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query thursdays: %w", err)
 	}
@@ -212,9 +221,44 @@ func getStrafenRows(ctx context.Context, q queryer, end time.Time) ([]StrafenRow
 	return strafen, nil
 }
 
+// getRuleSets liefert die Strafen-Regelwerke (shared). Gibt es die Tabelle
+// noch nicht, rechnet penalty.Assess mit dem Default-Regelwerk.
+func getRuleSets(ctx context.Context, q queryer) (penalty.RuleSets, error) {
+	rs, err := sharedstore.ListRuleSets(ctx, q)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P01" { // undefined_table
+		log.Printf("⚠️ strafen_regelwerk table does not exist yet, using default penalty rules")
+		return nil, nil
+	}
+	return rs, err
+}
+
+// getEntschuldigungen liefert die entschuldigten Zeiträume (shared). Gibt es
+// die Tabelle noch nicht, gibt es keine – geprüft per to_regclass, weil ein
+// Fehler die Snapshot-Transaktion abbrechen würde.
+func getEntschuldigungen(ctx context.Context, q queryer) (domain.Entschuldigungen, error) {
+	rows, err := q.QueryContext(ctx, `SELECT to_regclass('stammtisch_entschuldigt') IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check stammtisch_entschuldigt: %w", err)
+	}
+	var exists bool
+	for rows.Next() {
+		if err := rows.Scan(&exists); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to check stammtisch_entschuldigt: %w", err)
+		}
+	}
+	rows.Close()
+	if !exists {
+		log.Printf("⚠️ stammtisch_entschuldigt table does not exist yet, no excused periods")
+		return nil, nil
+	}
+	return sharedstore.ListEntschuldigungen(ctx, q)
+}
+
 // getMaxStreaks liefert die längsten Serien je User (max_streaks.sql).
-func getMaxStreaks(ctx context.Context, q queryer, start, end time.Time) ([]MaxStreak, error) {
-	rows, err := q.QueryContext(ctx, maxStreaksQ, start, end)
+func getMaxStreaks(ctx context.Context, q queryer, sched domain.Schedule, start, end time.Time) ([]MaxStreak, error) {
+	rows, err := q.QueryContext(ctx, maxStreaksQ, start, end, sharedstore.MeetingDates(sched, start, end))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query max streaks: %w", err)
 	}
@@ -231,9 +275,9 @@ func getMaxStreaks(ctx context.Context, q queryer, start, end time.Time) ([]MaxS
 	return out, rows.Err()
 }
 
-// getThursdayStats liefert die Anwesenheit je Donnerstag (thursday_stats.sql).
-func getThursdayStats(ctx context.Context, q queryer, start, end time.Time) ([]ThursdayAttendance, error) {
-	rows, err := q.QueryContext(ctx, thursdayStatsQ, start, end)
+// getThursdayStats liefert die Anwesenheit je Treffen-Tag (thursday_stats.sql).
+func getThursdayStats(ctx context.Context, q queryer, sched domain.Schedule, start, end time.Time) ([]ThursdayAttendance, error) {
+	rows, err := q.QueryContext(ctx, thursdayStatsQ, start, end, sharedstore.MeetingDates(sched, start, end))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query thursday stats: %w", err)
 	}
@@ -267,7 +311,7 @@ func (r *RejectionRepository) GetRawDataByDateRange(ctx context.Context, dateRan
 		return nil, fmt.Errorf("failed to get users: %w", err)
 	}
 
-	rejections, err := getRejections(ctx, tx, dateRange.Start, effectiveEnd)
+	rejections, err := getRejections(ctx, tx, r.schedule, dateRange.Start, effectiveEnd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get rejections: %w", err)
 	}
@@ -277,7 +321,7 @@ func (r *RejectionRepository) GetRawDataByDateRange(ctx context.Context, dateRan
 		return nil, fmt.Errorf("failed to get excluded days: %w", err)
 	}
 
-	thursdays, err := getThursdays(ctx, tx, dateRange.Start, effectiveEnd)
+	thursdays, err := getThursdays(ctx, tx, r.schedule, dateRange.Start, effectiveEnd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get thursdays: %w", err)
 	}
@@ -287,17 +331,31 @@ func (r *RejectionRepository) GetRawDataByDateRange(ctx context.Context, dateRan
 		return nil, fmt.Errorf("failed to get strafen rows: %w", err)
 	}
 
-	leaderboard, err := sharedstore.Leaderboard(ctx, tx, domain.Period{Start: dateRange.Start, End: dateRange.End})
+	ruleSets, err := getRuleSets(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get penalty rule sets: %w", err)
+	}
+
+	entschuldigt, err := getEntschuldigungen(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get excused periods: %w", err)
+	}
+
+	var excused domain.Entschuldigungen
+	if r.excludeExcused {
+		excused = entschuldigt
+	}
+	leaderboard, err := sharedstore.Leaderboard(ctx, tx, domain.Period{Start: dateRange.Start, End: dateRange.End}, r.schedule, excused)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
 	}
 
-	maxStreaks, err := getMaxStreaks(ctx, tx, dateRange.Start, effectiveEnd)
+	maxStreaks, err := getMaxStreaks(ctx, tx, r.schedule, dateRange.Start, effectiveEnd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get max streaks: %w", err)
 	}
 
-	thursdayStats, err := getThursdayStats(ctx, tx, dateRange.Start, effectiveEnd)
+	thursdayStats, err := getThursdayStats(ctx, tx, r.schedule, dateRange.Start, effectiveEnd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get thursday stats: %w", err)
 	}
@@ -312,8 +370,11 @@ func (r *RejectionRepository) GetRawDataByDateRange(ctx context.Context, dateRan
 		ExcludedDays:  excludedDays,
 		Thursdays:     thursdays,
 		StrafenRows:   strafenRows,
+		RuleSets:      ruleSets,
+		Entschuldigt:  entschuldigt,
 		Leaderboard:   leaderboard,
 		MaxStreaks:    maxStreaks,
 		ThursdayStats: thursdayStats,
+		Schedule:      r.schedule,
 	}, nil
 }
@@ -414,6 +414,10 @@ func buildStrafen(stats models.StrafenStats, users []models.UserStats) viewmodel
 				ev.ArtEmoji = "🪑"
 				ev.Label = fmt.Sprintf("%d Wochen gefehlt", e.Tage)
 				ev.DateRange = formatDateRange(e.Start, e.End)
+			} else if e.Art == "mahngebuehr" {
+				ev.ArtEmoji = "📨"
+				ev.Label = "Mahngebühr"
+				ev.DateRange = formatDateWithYear(e.Start)
 			} else {
 				ev.ArtEmoji = "👻"
 				ev.Label = "No-Show"
@@ -808,6 +812,8 @@ func getFunFact(user models.UserStats) string {
 		return "👑 Nie abgesagt!"
 	case user.MaxAttendanceStreak >= 10:
 		return fmt.Sprintf("🔥 %der Serie", user.MaxAttendanceStreak)
+	case user.ExcusedCount > 0:
+		return fmt.Sprintf("🩹 %dx entschuldigt", user.ExcusedCount)
 	case user.MaxCancellationStreak >= 4:
 		return fmt.Sprintf("🧊 %d Wochen Pause", user.MaxCancellationStreak)
 	case user.FavoriteExcuseCategory == "kreativ":
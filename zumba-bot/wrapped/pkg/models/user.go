@@ -22,6 +22,7 @@ type UserStats struct {
 	MaxCancellationStreakStart time.Time      `json:"maxCancellationStreakStart"`
 	MaxCancellationStreakEnd   time.Time      `json:"maxCancellationStreakEnd"`
 	NeverCancelled             bool           `json:"neverCancelled"`
+	ExcusedCount               int            `json:"excusedCount"` // Treffen in entschuldigten Zeiträumen
 	FavoriteExcuseCategory     string         `json:"favoriteExcuseCategory"`
 	Rank                       int            `json:"rank"`
 	Title                      string         `json:"title"`
@@ -32,11 +32,12 @@ func main() {
 	pg, err := db.Open(cfg.DB)
 	if err != nil {
 		log.Printf("⚠️  DB unreachable (%v) – falling back to mock data", err)
-		st = store.NewMock(period)
+		st = store.NewMock(period, cfg.Schedule)
 		mockMode = true
 	} else {
 		log.Printf("✅ Connected to PostgreSQL '%s' on %s:%s", cfg.DB.Name, cfg.DB.Host, cfg.DB.Port)
-		pgStore := store.NewPostgres(pg)
+		pgStore := store.NewPostgres(pg, cfg.Schedule)
+		pgStore.ExcludeExcused = cfg.LeaderboardExcludeExcused
 		// Tabelle für den manuellen ML-Test (Schreiber ist das Admin-UI).
 		if err := pgStore.EnsureMLTestSchema(context.Background()); err != nil {
 			log.Printf("⚠️  ml_test_messages Schema: %v", err)
@@ -45,10 +46,24 @@ func main() {
 		if err := pgStore.EnsureStrafenSchema(context.Background()); err != nil {
 			log.Printf("⚠️  strafen Schema: %v", err)
 		}
+		// Zusagen-Tabelle (Schreiber ist der Bot, "Wer kommt?" liest sie).
+		if err := pgStore.EnsureZusageSchema(context.Background()); err != nil {
+			log.Printf("⚠️  stammtisch_zusage Schema: %v", err)
+		}
+		// Spitznamen, entered_by und entschuldigte Zeiträume (der Bot
+		// liest/schreibt sie, das UI pflegt Spitznamen und Zeiträume).
+		if err := pgStore.EnsureMemberSchema(context.Background()); err != nil {
+			log.Printf("⚠️  user_alias/stammtisch_entschuldigt Schema: %v", err)
+		}
+		// Regeltabelle der Vorklassifikation (das UI pflegt, der Bot liest).
+		if err := pgStore.EnsureRuleSchema(context.Background()); err != nil {
+			log.Printf("⚠️  classifier_rule Schema: %v", err)
+		}
 		st = pgStore
 		defer pg.Close()
 	}
 
+	log.Printf("📅 Stammtisch: %s (MEETING_SCHEDULE=%s)", cfg.Schedule.Describe(), cfg.Schedule)
 	srv := web.New(st, cfg, mockMode)
 
 	addr := fmt.Sprintf(":%s", cfg.Port)
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"time"
+
+	"github.com/michael/zumba-shared/domain"
 )
 
 type Config struct {
@@ -14,6 +16,14 @@ type Config struct {
 	EvalPeriodStart time.Time
 	EvalPeriodEnd   time.Time
 
+	// Schedule legt die Stammtisch-Tage fest (Env MEETING_SCHEDULE, gleiches
+	// Format wie im Bot; Default jeden Donnerstag).
+	Schedule domain.Schedule
+
+	// LeaderboardExcludeExcused nimmt entschuldigte Treffen aus Rangliste und
+	// Quoten (Env LEADERBOARD_EXCLUDE_EXCUSED, wie im Bot; Default false).
+	LeaderboardExcludeExcused bool
+
 	// BotURL ist die Basis-URL des whatsapp-bot (für die Bot-Test-Seite).
 	BotURL string
 
@@ -49,8 +59,9 @@ func Load() (Config, error) {
 			Name:     getenv("DB_NAME", "zumba"),
 			SSLMode:  getenv("DB_SSLMODE", "disable"),
 		},
-		BotURL:        getenv("BOT_URL", "http://localhost:8080"),
-		ClassifierURL: os.Getenv("CLASSIFIER_URL"),
+		BotURL:                    getenv("BOT_URL", "http://localhost:8080"),
+		ClassifierURL:             os.Getenv("CLASSIFIER_URL"),
+		LeaderboardExcludeExcused: getenv("LEADERBOARD_EXCLUDE_EXCUSED", "false") == "true",
 	}
 
 	start, err := parseDate(getenv("EVAL_PERIOD_START", "2025-12-01"))
@@ -64,6 +75,12 @@ func Load() (Config, error) {
 	cfg.EvalPeriodStart = start
 	cfg.EvalPeriodEnd = end
 
+	sched, err := domain.ParseSchedule(os.Getenv("MEETING_SCHEDULE"))
+	if err != nil {
+		return cfg, fmt.Errorf("MEETING_SCHEDULE: %w", err)
+	}
+	cfg.Schedule = sched
+
 	return cfg, nil
 }
 
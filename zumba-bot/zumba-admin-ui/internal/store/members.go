@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+
+	"github.com/michael/zumba-shared/domain"
+	sharedstore "github.com/michael/zumba-shared/store"
+)
+
+// EnsureMemberSchema legt user_alias, stammtisch_entschuldigt und
+// stammtisch_abwesenheit.entered_by idempotent an (geteilte DDL im shared-Modul; der whatsapp-bot ruft
+// dieselbe Funktion).
+func (s *Postgres) EnsureMemberSchema(ctx context.Context) error {
+	return sharedstore.EnsureMemberSchema(ctx, s.db)
+}
+
+func (s *Postgres) ListAliases(ctx context.Context, userID string) ([]string, error) {
+	return sharedstore.Aliases(ctx, s.db, userID)
+}
+
+func (s *Postgres) AddAlias(ctx context.Context, userID, alias string) error {
+	return sharedstore.AddAlias(ctx, s.db, userID, alias)
+}
+
+func (s *Postgres) DeleteAlias(ctx context.Context, userID, alias string) error {
+	return sharedstore.DeleteAlias(ctx, s.db, userID, alias)
+}
+
+func (s *Postgres) ListEntschuldigungen(ctx context.Context) (domain.Entschuldigungen, error) {
+	return sharedstore.ListEntschuldigungen(ctx, s.db)
+}
+
+func (s *Postgres) InsertEntschuldigung(ctx context.Context, e domain.Entschuldigung) error {
+	return sharedstore.InsertEntschuldigung(ctx, s.db, e)
+}
+
+func (s *Postgres) DeleteEntschuldigung(ctx context.Context, id int64) error {
+	return sharedstore.DeleteEntschuldigung(ctx, s.db, id)
+}
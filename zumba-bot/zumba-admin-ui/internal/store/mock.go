@@ -7,7 +7,10 @@ import (
 	"sort"
 	"time"
 
+	"github.com/michael/zumba-shared/domain"
 	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-shared/rules"
+	sharedstore "github.com/michael/zumba-shared/store"
 	"github.com/michael/zumba-admin-ui/internal/timeutil"
 )
 
@@ -16,13 +19,23 @@ import (
 // across reloads.
 type Mock struct {
 	users        []User
-	absences     []Absence   // only entries for valid Thursdays
-	excludedDays []time.Time // Thursdays
+	absences     []Absence   // only entries for valid meeting days
+	excludedDays []time.Time // meeting days
 	strafen      []penalty.Row
 	nextStrafeID int64
+	schedule     domain.Schedule
+	mlKilledAt   *time.Time        // Kill-Switch des Canary (nil = nicht gezogen)
+	aliases      map[string]string // Spitzname → userId
+	rules        []rules.Rule
+	ruleSets     penalty.RuleSets
+	buchungen    []penalty.Buchung
+	mahnungen    []penalty.Mahnung
+	mahnOptOut   map[string]bool
+	entschuldigt domain.Entschuldigungen
+	nextEntschID int64
 }
 
-func NewMock(p timeutil.Period) *Mock {
+func NewMock(p timeutil.Period, sched domain.Schedule) *Mock {
 	users := []User{
 		{ID: "u01", Name: "Max", Emoji: "🍺"},
 		{ID: "u02", Name: "Thomas", Emoji: "🎸"},
@@ -41,7 +54,7 @@ func NewMock(p timeutil.Period) *Mock {
 		{ID: "u15", Name: "Jan", Emoji: "🏀"},
 	}
 
-	thursdays := generateThursdays(p.Start, p.EffectiveEnd())
+	thursdays := sched.Meetings(p.Start, p.EffectiveEnd())
 	rng := rand.New(rand.NewSource(42))
 
 	// Excluded days: pick a couple of Thursdays in the future-ish range
@@ -85,17 +98,50 @@ func NewMock(p timeutil.Period) *Mock {
 		}
 	}
 
-	return &Mock{users: users, absences: absences, excludedDays: excluded}
-}
+	// Eine Absage hat ein anderer eingetragen ("Stefan und ich kommen nicht").
+	for i := range absences {
+		if absences[i].UserID == "u03" {
+			by := "u01"
+			absences[i].EnteredBy = &by
+			break
+		}
+	}
+	aliases := map[string]string{"maxl": "u01", "stevie": "u03", "michl": "u05"}
 
-func generateThursdays(start, end time.Time) []time.Time {
-	var out []time.Time
-	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
-		if d.Weekday() == time.Thursday {
-			out = append(out, d)
+	// Philipp war ein paar Wochen in Elternzeit.
+	var entschuldigt domain.Entschuldigungen
+	if len(thursdays) > 10 {
+		entschuldigt = domain.Entschuldigungen{{ID: 1, UserID: "u14", Von: thursdays[len(thursdays)-8],
+			Bis: thursdays[len(thursdays)-5].AddDate(0, 0, 3), Grund: "Elternzeit"}}
+	}
+
+	// Eine No-Show-Strafe mit offenem Einspruch (per Bot-Befehl eingelegt).
+	var strafen []penalty.Row
+	if len(thursdays) > 2 {
+		d := thursdays[len(thursdays)-2]
+		am := d.AddDate(0, 0, 2)
+		strafen = []penalty.Row{{ID: 1, UserID: "u07", Art: penalty.ArtNoShow, Datum: d, Betrag: 50,
+			Status: penalty.StatusOffen, CreatedAt: d.AddDate(0, 0, 1),
+			Einspruch: penalty.Einspruch{Status: penalty.EinspruchOffen, Grund: "hab morgens abgesagt, der Bot hat's nicht erkannt",
+				Am: &am, TraceID: 1042, MLMessageID: 317}}}
+	}
+	// Stefan zahlt seine No-Show-Strafe nicht und ist schon zweimal gemahnt
+	// (in der Gruppe will er nicht erwähnt werden).
+	var mahnungen []penalty.Mahnung
+	if len(thursdays) > 5 {
+		d := thursdays[len(thursdays)-5]
+		strafen = append(strafen, penalty.Row{ID: int64(len(strafen) + 1), UserID: "u03", Art: penalty.ArtNoShow, Datum: d,
+			Betrag: 50, Status: penalty.StatusOffen, CreatedAt: d.AddDate(0, 0, 1)})
+		for i, tage := range []int{14, 28} {
+			mahnungen = append(mahnungen, penalty.Mahnung{ID: int64(i + 1), UserID: "u03", Stufe: i + 1,
+				StrafeID: int64(len(strafen)), Offen: 50, CreatedAt: d.AddDate(0, 0, tage).Add(18 * time.Hour)})
 		}
 	}
-	return out
+
+	return &Mock{users: users, absences: absences, excludedDays: excluded, schedule: sched, aliases: aliases,
+		rules: sampleRules(), ruleSets: sampleRuleSets(), buchungen: sampleBuchungen(),
+		entschuldigt: entschuldigt, nextEntschID: 2, strafen: strafen, nextStrafeID: int64(len(strafen)),
+		mahnungen: mahnungen, mahnOptOut: map[string]bool{"u03": true}}
 }
 
 func (m *Mock) ListUsers(_ context.Context) ([]User, error) {
@@ -119,7 +165,7 @@ func (m *Mock) ListThursdays(_ context.Context, p timeutil.Period) ([]time.Time,
 	for _, d := range m.excludedDays {
 		excluded[timeutil.FormatISO(d)] = true
 	}
-	all := generateThursdays(p.Start, p.EffectiveEnd())
+	all := m.schedule.Meetings(p.Start, p.EffectiveEnd())
 	out := make([]time.Time, 0, len(all))
 	for _, d := range all {
 		if !excluded[timeutil.FormatISO(d)] {
@@ -242,6 +288,34 @@ func (m *Mock) AbsencesOn(_ context.Context, date time.Time) ([]Absence, error)
 	return out, nil
 }
 
+// Roster baut die Teilnehmerliste aus den Mock-Absagen; Zusagen gibt es im
+// Mock keine echten – jedes dritte Mitglied ohne Absage gilt als zugesagt.
+func (m *Mock) Roster(_ context.Context, date time.Time) ([]RosterEntry, error) {
+	iso := timeutil.FormatISO(date)
+	absent := map[string]string{}
+	for _, a := range m.absences {
+		if timeutil.FormatISO(a.Date) == iso {
+			msg := ""
+			if a.Message != nil {
+				msg = *a.Message
+			}
+			absent[a.UserID] = msg
+		}
+	}
+	out := make([]RosterEntry, 0, len(m.users))
+	for i, u := range m.users {
+		e := RosterEntry{UserID: u.ID, UserName: u.Name, Status: sharedstore.RosterOffen}
+		if msg, ok := absent[u.ID]; ok {
+			e.Status, e.Message = sharedstore.RosterAbgesagt, msg
+		} else if i%3 == 0 {
+			e.Status, e.Message = sharedstore.RosterZugesagt, "bin dabei"
+		}
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UserName < out[j].UserName })
+	return out, nil
+}
+
 func (m *Mock) IsExcludedDay(_ context.Context, date time.Time) (bool, error) {
 	iso := timeutil.FormatISO(date)
 	for _, d := range m.excludedDays {
@@ -264,7 +338,7 @@ func (m *Mock) ThursdayStrip(_ context.Context, p timeutil.Period, limit int) ([
 
 	today := timeutil.StartOfDay(time.Now())
 	var days []time.Time
-	for _, d := range generateThursdays(p.Start, p.EffectiveEnd()) {
+	for _, d := range m.schedule.Meetings(p.Start, p.EffectiveEnd()) {
 		if !d.After(today) {
 			days = append(days, d)
 		}
@@ -328,6 +402,60 @@ func (m *Mock) DeleteAbsence(_ context.Context, userID string, date time.Time) e
 	return nil
 }
 
+func (m *Mock) ListAliases(_ context.Context, userID string) ([]string, error) {
+	var out []string
+	for a, u := range m.aliases {
+		if u == userID {
+			out = append(out, a)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func (m *Mock) AddAlias(_ context.Context, userID, alias string) error {
+	alias = sharedstore.NormalizeAlias(alias)
+	if _, taken := m.aliases[alias]; taken {
+		return sharedstore.ErrAliasTaken
+	}
+	m.aliases[alias] = userID
+	return nil
+}
+
+func (m *Mock) DeleteAlias(_ context.Context, userID, alias string) error {
+	alias = sharedstore.NormalizeAlias(alias)
+	if m.aliases[alias] == userID {
+		delete(m.aliases, alias)
+	}
+	return nil
+}
+
+func (m *Mock) ListEntschuldigungen(_ context.Context) (domain.Entschuldigungen, error) {
+	out := make(domain.Entschuldigungen, len(m.entschuldigt))
+	copy(out, m.entschuldigt)
+	return out, nil
+}
+
+func (m *Mock) InsertEntschuldigung(_ context.Context, e domain.Entschuldigung) error {
+	if err := domain.ValidateEntschuldigung(e); err != nil {
+		return err
+	}
+	e.ID, e.CreatedAt = m.nextEntschID, time.Now()
+	m.nextEntschID++
+	m.entschuldigt = append(m.entschuldigt, e)
+	return nil
+}
+
+func (m *Mock) DeleteEntschuldigung(_ context.Context, id int64) error {
+	for i, e := range m.entschuldigt {
+		if e.ID == id {
+			m.entschuldigt = append(m.entschuldigt[:i], m.entschuldigt[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("Zeitraum %d nicht gefunden", id)
+}
+
 func (m *Mock) ToggleAbsence(ctx context.Context, userID string, date time.Time) (bool, error) {
 	iso := timeutil.FormatISO(date)
 	for _, a := range m.absences {
@@ -365,7 +493,79 @@ func (m *Mock) DeleteExcludedDay(_ context.Context, date time.Time) error {
 // auch ohne DB (Mock-Modus) etwas Sinnvolles zeigt.
 func sampleTraces() []Trace {
 	base := time.Date(2026, 6, 25, 20, 12, 0, 0, time.Local) // ein Donnerstag
+	parent := int64(3)
 	return []Trace{
+		{
+			ID: 8, CreatedAt: base.Add(14 * time.Minute), UserName: "Sepp", Message: "👎",
+			MessageType: "reactionMessage", Path: "classify", Classification: "false", Action: "marked_absent",
+			RemoteJid: "000000000000-0000000000@g.us", UserID: "49151...@s.whatsapp.net",
+			Steps: []TraceStep{
+				{Node: "received", Outcome: "info", Label: "Webhook empfangen", Detail: "Sepp · Typ \"reactionMessage\""},
+				{Node: "check_statistik", Outcome: "info", Label: "Befehl?", Detail: "nein"},
+				{Node: "guard_type", Outcome: "pass", Label: "Textnachricht?", Detail: "Reaktion"},
+				{Node: "guard_group", Outcome: "pass", Label: "Zumba-Gruppe?", Detail: "ja"},
+				{Node: "clarify_answer", Outcome: "pass", Label: "Antwort auf Rückfrage", Detail: "Reaktion 👎 auf Rückfrage zu \"schau ma mal\" → false"},
+				{Node: "resolve_dates", Outcome: "pass", Label: "Zieltermine", Detail: "aus der Rückfrage: 2026-06-25"},
+				{Node: "mark_absent", Outcome: "pass", Label: "Absage: DB-Insert", Detail: "eingetragen für 2026-06-25"},
+			},
+		},
+		{
+			ID: 7, CreatedAt: base.Add(12 * time.Minute), UserName: "Sepp", Message: "schau ma mal",
+			MessageType: "conversation", Path: "classify", Classification: "invalid", Action: "asked",
+			RemoteJid: "000000000000-0000000000@g.us", UserID: "49151...@s.whatsapp.net",
+			Steps: []TraceStep{
+				{Node: "received", Outcome: "info", Label: "Webhook empfangen", Detail: "Sepp · Typ \"conversation\""},
+				{Node: "check_statistik", Outcome: "info", Label: "Befehl?", Detail: "nein"},
+				{Node: "guard_type", Outcome: "pass", Label: "Textnachricht?", Detail: "ja"},
+				{Node: "guard_group", Outcome: "pass", Label: "Zumba-Gruppe?", Detail: "ja"},
+				{Node: "rules", Outcome: "info", Label: "Regel?", Detail: "keine passende Regel"},
+				{Node: "classify", Outcome: "info", Label: "Classifier (LLM)", Detail: "→ invalid  (roh: \"invalid\" · gemini-2.5-flash)"},
+				{Node: "no_action", Outcome: "pass", Label: "Rückfrage", Detail: "🤔 Sepp, kommst du heute? 👍 / 👎 (offen bis 26.06. 00:00)"},
+			},
+		},
+		{
+			ID: 6, CreatedAt: base.Add(9 * time.Minute), UserName: "Hiller", Message: "👎",
+			MessageType: "conversation", Path: "classify", Classification: "false", Action: "marked_absent",
+			RemoteJid: "000000000000-0000000000@g.us", UserID: "49172...@s.whatsapp.net",
+			Steps: []TraceStep{
+				{Node: "received", Outcome: "info", Label: "Webhook empfangen", Detail: "Hiller · Typ \"conversation\""},
+				{Node: "check_statistik", Outcome: "info", Label: "Befehl?", Detail: "nein"},
+				{Node: "guard_type", Outcome: "pass", Label: "Textnachricht?", Detail: "ja"},
+				{Node: "guard_group", Outcome: "pass", Label: "Zumba-Gruppe?", Detail: "ja"},
+				{Node: "rules", Outcome: "pass", Label: "Regel?", Detail: "#1 (emoji \"👎\") → false · Daumen runter = Absage"},
+				{Node: "resolve_dates", Outcome: "pass", Label: "Zieltermine", Detail: "nächster Stammtisch → 25.06."},
+				{Node: "mark_absent", Outcome: "pass", Label: "Absage: DB-Insert", Detail: "eingetragen für 2026-06-25"},
+			},
+		},
+		{
+			ID: 5, CreatedAt: base.Add(6 * time.Minute), UserName: "Sepp", Message: "ich auch",
+			MessageType: "extendedTextMessage", Path: "classify", Classification: "false", Action: "marked_absent",
+			RemoteJid: "000000000000-0000000000@g.us", UserID: "49171...@s.whatsapp.net",
+			Steps: []TraceStep{
+				{Node: "received", Outcome: "info", Label: "Webhook empfangen", Detail: "Sepp · Typ \"extendedTextMessage\""},
+				{Node: "check_statistik", Outcome: "info", Label: "Befehl?", Detail: "nein"},
+				{Node: "guard_type", Outcome: "pass", Label: "Textnachricht?", Detail: "ja"},
+				{Node: "guard_group", Outcome: "pass", Label: "Zumba-Gruppe?", Detail: "ja"},
+				{Node: "rules", Outcome: "info", Label: "Regel?", Detail: "keine passende Regel"},
+				{Node: "classify", Outcome: "info", Label: "Classifier (LLM)", Detail: "→ invalid  (roh: \"invalid\" · gemini-2.5-flash)"},
+				{Node: "context", Outcome: "info", Label: "Gesprächskontext", Detail: "Hiller: \"statistik\" · ↪ Antwort auf Tobi: \"bin heute leider raus\""},
+				{Node: "classify", Outcome: "info", Label: "Classifier (LLM · mit Kontext)", Detail: "→ false  (roh: \"false\" · gemini-2.5-flash · allein: invalid)"},
+				{Node: "resolve_dates", Outcome: "pass", Label: "Zieltermine", Detail: "nächster Stammtisch → 25.06."},
+				{Node: "mark_absent", Outcome: "pass", Label: "Absage: DB-Insert", Detail: "eingetragen für 2026-06-25"},
+			},
+		},
+		{
+			ID: 4, CreatedAt: base.Add(4 * time.Minute), UserName: "Tobi", Message: "bin heute leider raus",
+			MessageType: "protocolMessage", Path: "revoke", Action: "reverted", ParentID: &parent,
+			RemoteJid: "000000000000-0000000000@g.us", UserID: "49170...@s.whatsapp.net",
+			Steps: []TraceStep{
+				{Node: "received", Outcome: "info", Label: "Webhook empfangen", Detail: "Tobi · Typ \"protocolMessage\" · Löschung"},
+				{Node: "check_statistik", Outcome: "info", Label: "\"statistik\"?", Detail: "nein"},
+				{Node: "guard_type", Outcome: "pass", Label: "messageType == conversation?", Detail: "ja · Löschung"},
+				{Node: "guard_group", Outcome: "pass", Label: "Zumba-Gruppe?", Detail: "ja"},
+				{Node: "undo_original", Outcome: "pass", Label: "Original rückgängig", Detail: "\"bin heute leider raus\" (false) zurückgedreht für 2026-06-25"},
+			},
+		},
 		{
 			ID: 3, CreatedAt: base, UserName: "Tobi", Message: "bin heute leider raus",
 			MessageType: "conversation", Path: "classify", Classification: "false", Action: "marked_absent",
@@ -376,8 +576,8 @@ func sampleTraces() []Trace {
 				{Node: "check_statistik", Outcome: "info", Label: "\"statistik\"?", Detail: "nein"},
 				{Node: "guard_type", Outcome: "pass", Label: "messageType == conversation?", Detail: "ja"},
 				{Node: "guard_group", Outcome: "pass", Label: "Zumba-Gruppe?", Detail: "ja"},
-				{Node: "guard_thursday", Outcome: "pass", Label: "Donnerstag?", Detail: "Thu, 2026-06-25"},
 				{Node: "classify", Outcome: "info", Label: "Classifier (Gemini)", Detail: "→ false (roh: \"false\" · gemini-2.5-flash)"},
+				{Node: "resolve_dates", Outcome: "pass", Label: "Zieltermine", Detail: "\"heute\" → 25.06."},
 				{Node: "mark_absent", Outcome: "pass", Label: "Absage: DB-Insert", Detail: "eingetragen für 2026-06-25"},
 			},
 		},
@@ -386,9 +586,9 @@ func sampleTraces() []Trace {
 			MessageType: "conversation", Path: "statistik", RemoteJid: "000000000000-0000000000@g.us",
 			Steps: []TraceStep{
 				{Node: "received", Outcome: "info", Label: "Webhook empfangen", Detail: "Hiller · Typ \"conversation\""},
-				{Node: "check_statistik", Outcome: "pass", Label: "\"statistik\"?", Detail: "ja"},
-				{Node: "build_stats", Outcome: "pass", Label: "Statistik berechnen", Detail: "15 Nutzer"},
-				{Node: "send_stats", Outcome: "pass", Label: "An Gruppe senden", Detail: "→ Zumba-Gruppe"},
+				{Node: "check_statistik", Outcome: "pass", Label: "Befehl?", Detail: "ja: statistik"},
+				{Node: "command", Outcome: "pass", Label: "Befehl: statistik", Detail: "15 Nutzer"},
+				{Node: "send_stats", Outcome: "pass", Label: "Antwort senden", Detail: "→ Zumba-Gruppe"},
 			},
 		},
 		{
@@ -413,14 +613,76 @@ func (m *Mock) ListTraces(_ context.Context, limit int) ([]Trace, error) {
 }
 
 func (m *Mock) GetTrace(_ context.Context, id int64) (*Trace, error) {
-	for _, t := range sampleTraces() {
-		if t.ID == id {
-			return &t, nil
+	traces := sampleTraces()
+	for _, t := range traces {
+		if t.ID != id {
+			continue
 		}
+		for _, c := range traces {
+			if c.ParentID != nil && *c.ParentID == id {
+				t.FollowUps = append(t.FollowUps, c.ID)
+			}
+		}
+		return &t, nil
 	}
 	return nil, fmt.Errorf("GetTrace: trace %d nicht gefunden", id)
 }
 
+func (m *Mock) ListOutbox(_ context.Context, limit int) ([]OutboxMessage, error) {
+	base := time.Date(2026, 6, 25, 21, 0, 0, 0, time.Local) // Donnerstag, Wochenreport
+	lastErr := "sendText: connection refused"
+	sent := base.Add(90 * time.Second)
+	msgs := []OutboxMessage{
+		{ID: 3, CreatedAt: base.Add(10 * time.Minute), Source: "statistik", Recipient: "000000000000-0000000000@g.us",
+			Text: "🍻 *Zumba Stats*\n…", Status: "pending", Attempts: 2, NextAttemptAt: base.Add(12 * time.Minute), LastError: &lastErr},
+		{ID: 2, CreatedAt: base, Source: "wochenreport", Recipient: "000000000000-0000000000@g.us",
+			Text: "📅 *Wochenreport*\n…", Caption: "📅 Automatischer Wochenreport · Stand 25.06.2026", HasImage: true,
+			Status: "sent", Attempts: 3, NextAttemptAt: base, SentAt: &sent},
+		{ID: 1, CreatedAt: base.AddDate(0, 0, -7), Source: "wochenreport", Recipient: "000000000000-0000000000@g.us",
+			Text: "📅 *Wochenreport*\n…", Status: "failed", Attempts: 14, NextAttemptAt: base.AddDate(0, 0, -7).Add(12 * time.Hour), LastError: &lastErr},
+	}
+	if limit > 0 && limit < len(msgs) {
+		msgs = msgs[:limit]
+	}
+	return msgs, nil
+}
+
+func (m *Mock) ListJobs(_ context.Context, runsPerJob int) ([]Job, error) {
+	base := time.Date(2026, 6, 25, 21, 0, 0, 0, time.Local) // Donnerstag
+	at := func(d time.Time) *time.Time { return &d }
+	lastErr := "Statistik: connection refused"
+	runs := []JobRun{
+		{ID: 4, ScheduledFor: base, StartedAt: base.Add(2 * time.Second), FinishedAt: at(base.Add(9 * time.Second)),
+			Status: "ok", Attempt: 2, Runner: "zumba-whatsapp-bot-6c9f7-x2k4p", Detail: "Bild-Karte an die Gruppe gesendet"},
+		{ID: 3, ScheduledFor: base, StartedAt: base.Add(time.Second), FinishedAt: at(base.Add(2 * time.Second)),
+			Status: "failed", Attempt: 1, Runner: "zumba-whatsapp-bot-6c9f7-x2k4p", Error: &lastErr},
+		{ID: 2, ScheduledFor: base.AddDate(0, 0, -7), StartedAt: base.AddDate(0, 0, -7).Add(17 * time.Minute),
+			FinishedAt: at(base.AddDate(0, 0, -7).Add(17*time.Minute + 8*time.Second)),
+			Status: "ok", Attempt: 1, Runner: "zumba-whatsapp-bot-6c9f7-h8q2m", Detail: "Bild-Karte liegt in der Outbox"},
+		{ID: 1, ScheduledFor: base.AddDate(0, 0, -14), StartedAt: base.AddDate(0, 0, -13).Add(11 * time.Hour),
+			FinishedAt: at(base.AddDate(0, 0, -13).Add(11 * time.Hour)),
+			Status: "skipped", Attempt: 1, Runner: "zumba-whatsapp-bot-6c9f7-h8q2m", Detail: "verpasst, Nachhol-Fenster 12h überschritten"},
+	}
+	if runsPerJob > 0 && runsPerJob < len(runs) {
+		runs = runs[:runsPerJob]
+	}
+	remind := base.Add(-4 * time.Hour) // 17:00
+	return []Job{{
+		Name: "erinnerung", Cron: "0 17 * * *", Payload: `{"mode": "group"}`, Enabled: true,
+		NextRunAt: remind.AddDate(0, 0, 1),
+		Runs: []JobRun{
+			{ID: 6, ScheduledFor: remind, StartedAt: remind.Add(time.Second), FinishedAt: at(remind.Add(2 * time.Second)),
+				Status: "ok", Attempt: 1, Runner: "zumba-whatsapp-bot-6c9f7-x2k4p", Detail: "Gruppe, 4 erwähnt (1 abbestellt)"},
+			{ID: 5, ScheduledFor: remind.AddDate(0, 0, -1), StartedAt: remind.AddDate(0, 0, -1).Add(time.Second),
+				FinishedAt: at(remind.AddDate(0, 0, -1).Add(time.Second)),
+				Status: "ok", Attempt: 1, Runner: "zumba-whatsapp-bot-6c9f7-x2k4p", Detail: "heute kein Stammtisch"},
+		},
+	}, {
+		Name: "wochenreport", Cron: "0 21 * * 4", Payload: `{"format": "image"}`, Enabled: true,
+		NextRunAt: base.AddDate(0, 0, 7), Runs: runs,
+	}}, nil
+}
+
 // computeStreakMock walks Thursdays newest-first; returns +N for an attendance
 // run from now, -N for an absence run.
 func computeStreakMock(thursdaysDesc []time.Time, absenceDates []time.Time) int {
@@ -453,23 +715,30 @@ func sampleMLMessages() []MLMessage {
 	b := func(v bool) *bool { return &v }
 	base := time.Date(2026, 7, 23, 18, 30, 0, 0, time.Local)
 	return []MLMessage{
+		{ID: 7, CreatedAt: base.Add(150 * time.Minute), UserID: "u11", UserName: "Vroni",
+			Message: "Heid ned, sorry 🙈", GeminiLabel: "",
+			ModelLabel: s("false"), ModelConfidence: f(0.91), Agree: nil, Route: RouteFallback},
+		{ID: 6, CreatedAt: base.Add(120 * time.Minute), UserID: "u09", UserName: "Wast",
+			Message: "Bin raus für heid", GeminiLabel: "false",
+			ModelLabel: s("false"), ModelConfidence: f(0.96), Agree: b(true), Route: RouteCanary},
 		{ID: 5, CreatedAt: base.Add(90 * time.Minute), UserID: "u03", UserName: "Sepp",
 			Message: "Muss mi heut abmelden ❌", GeminiLabel: "false",
-			ModelLabel: s("false"), ModelConfidence: f(0.97), Agree: b(true)},
+			ModelLabel: s("false"), ModelConfidence: f(0.97), Agree: b(true), Route: RouteCanary,
+			Verified: true, CorrectedLabel: s("false")},
 		{ID: 4, CreatedAt: base.Add(60 * time.Minute), UserID: "u07", UserName: "Tobi",
 			Message: "Bei mir wirds bissi später ✌🏻", GeminiLabel: "true",
-			ModelLabel: s("invalid"), ModelConfidence: f(0.48), Agree: b(false)},
+			ModelLabel: s("invalid"), ModelConfidence: f(0.48), Agree: b(false), Route: RouteGated},
 		{ID: 3, CreatedAt: base.Add(40 * time.Minute), UserID: "u01", UserName: "Max",
 			Message: "Schau ma moi wie's Wetter wird", GeminiLabel: "invalid",
 			ModelLabel: s("invalid"), ModelConfidence: f(0.88), Agree: b(true),
-			Verified: true},
+			Verified: true, Route: RouteShadow},
 		{ID: 2, CreatedAt: base.Add(20 * time.Minute), UserID: "u05", UserName: "Flo",
 			Message: "Gruzefix.... Nullinger", GeminiLabel: "false",
 			ModelLabel: s("invalid"), ModelConfidence: f(0.61), Agree: b(false),
-			Verified: true, CorrectedLabel: s("false")},
+			Verified: true, CorrectedLabel: s("false"), Route: RouteShadow},
 		{ID: 1, CreatedAt: base, UserID: "u02", UserName: "Basti",
 			Message: "I bin dabei heit 🍻", GeminiLabel: "true",
-			ModelLabel: nil, ModelConfidence: nil, Agree: nil},
+			ModelLabel: nil, ModelConfidence: nil, Agree: nil, Route: RouteShadow},
 	}
 }
 
@@ -513,9 +782,53 @@ func (m *Mock) MLShadowStats(_ context.Context) (MLShadowStats, error) {
 			st.PerLabel = append(st.PerLabel, *ls)
 		}
 	}
+	routes := map[string]*MLRouteStat{}
+	for _, msg := range sampleMLMessages() {
+		rs, ok := routes[msg.Route]
+		if !ok {
+			rs = &MLRouteStat{Route: msg.Route}
+			routes[msg.Route] = rs
+		}
+		rs.Total++
+		if msg.Agree != nil {
+			rs.Compared++
+			if *msg.Agree {
+				rs.Agree++
+			}
+		}
+		if msg.Verified {
+			rs.Verified++
+			correct := msg.GeminiLabel
+			if msg.CorrectedLabel != nil {
+				correct = *msg.CorrectedLabel
+			}
+			if correct != msg.DecidedLabel() {
+				rs.Corrected++
+			}
+		}
+	}
+	for _, route := range []string{RouteCanary, RouteGated, RouteFallback, RouteShadow} {
+		if rs, ok := routes[route]; ok {
+			st.PerRoute = append(st.PerRoute, *rs)
+		}
+	}
 	return st, nil
 }
 
+func (m *Mock) MLRoutePolicy(_ context.Context) (*MLRoutePolicy, error) {
+	return &MLRoutePolicy{Share: 20, MinConfidence: 0.9, Killed: m.mlKilledAt != nil,
+		KilledAt: m.mlKilledAt, UpdatedAt: time.Now().Add(-2 * time.Hour)}, nil
+}
+
+func (m *Mock) SetMLRouteKilled(ctx context.Context, killed bool) (*MLRoutePolicy, error) {
+	m.mlKilledAt = nil
+	if killed {
+		now := time.Now()
+		m.mlKilledAt = &now
+	}
+	return m.MLRoutePolicy(ctx)
+}
+
 func (m *Mock) VerifyMLMessage(_ context.Context, id int64, correctedLabel *string) (*MLMessage, error) {
 	for _, msg := range sampleMLMessages() {
 		if msg.ID == id {
@@ -527,6 +840,86 @@ func (m *Mock) VerifyMLMessage(_ context.Context, id int64, correctedLabel *stri
 	return nil, fmt.Errorf("VerifyMLMessage: Eintrag %d nicht gefunden", id)
 }
 
+// --- Vorklassifikation: Mock ---
+
+func sampleRules() []rules.Rule {
+	hit := time.Now().Add(-26 * time.Hour)
+	return []rules.Rule{
+		{ID: 1, Kind: rules.KindEmoji, Pattern: "👎", Label: "false", Priority: 10, Enabled: true,
+			Note: "Daumen runter = Absage", Hits: 14, LastHitAt: &hit, CreatedAt: time.Date(2026, 8, 20, 9, 0, 0, 0, time.Local)},
+		{ID: 2, Kind: rules.KindEmoji, Pattern: "👍", Label: "true", Priority: 10, Enabled: true,
+			Hits: 9, LastHitAt: &hit, CreatedAt: time.Date(2026, 8, 20, 9, 1, 0, 0, time.Local)},
+		{ID: 3, Kind: rules.KindRegex, Pattern: `^st?a?t?i?s?t?i?k+$`, Label: "invalid", Priority: 50,
+			Note: "Tippfehler von „statistik“", CreatedAt: time.Date(2026, 9, 2, 18, 30, 0, 0, time.Local)},
+	}
+}
+
+func (m *Mock) ListRules(_ context.Context) ([]rules.Rule, error) {
+	out := make([]rules.Rule, len(m.rules))
+	copy(out, m.rules)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Priority != out[j].Priority {
+			return out[i].Priority < out[j].Priority
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (m *Mock) InsertRule(_ context.Context, r rules.Rule) (int64, error) {
+	if err := rules.Validate(r); err != nil {
+		return 0, fmt.Errorf("InsertRule: %w", err)
+	}
+	var maxID int64
+	for _, x := range m.rules {
+		maxID = max(maxID, x.ID)
+	}
+	r.ID, r.Enabled, r.Hits, r.LastHitAt, r.CreatedAt = maxID+1, false, 0, nil, time.Now()
+	m.rules = append(m.rules, r)
+	return r.ID, nil
+}
+
+func (m *Mock) SetRuleEnabled(_ context.Context, id int64, enabled bool) error {
+	for i := range m.rules {
+		if m.rules[i].ID == id {
+			m.rules[i].Enabled = enabled
+		}
+	}
+	return nil
+}
+
+func (m *Mock) DeleteRule(_ context.Context, id int64) error {
+	out := m.rules[:0]
+	for _, r := range m.rules {
+		if r.ID != id {
+			out = append(out, r)
+		}
+	}
+	m.rules = out
+	return nil
+}
+
+// VerifiedCorpus: die geprüften Beispiel-ml_messages plus ein paar typische
+// Kurz-Antworten.
+func (m *Mock) VerifiedCorpus(_ context.Context) ([]rules.Sample, error) {
+	out := []rules.Sample{
+		{Message: "👎", Label: "false"}, {Message: "👎👎", Label: "false"},
+		{Message: "👍", Label: "true"}, {Message: "👍🏼", Label: "true"},
+		{Message: "👎", Label: "invalid"}, {Message: "statisitk", Label: "invalid"},
+	}
+	for _, msg := range sampleMLMessages() {
+		if !msg.Verified {
+			continue
+		}
+		label := msg.GeminiLabel
+		if msg.CorrectedLabel != nil {
+			label = *msg.CorrectedLabel
+		}
+		out = append(out, rules.Sample{Message: msg.Message, Label: label})
+	}
+	return out, nil
+}
+
 // --- Manueller ML-Test: Mock ---
 
 func (m *Mock) InsertMLTest(_ context.Context, _, _ string, _ float64) (int64, error) {
@@ -623,3 +1016,193 @@ func (m *Mock) LoescheStrafe(_ context.Context, id int64) error {
 	}
 	return fmt.Errorf("LoescheStrafe: Strafe %d nicht gefunden", id)
 }
+
+func (m *Mock) EntscheideEinspruch(_ context.Context, id int64, status penalty.EinspruchStatus, notiz string) error {
+	if err := penalty.ValidateEntscheidung(status, notiz); err != nil {
+		return fmt.Errorf("EntscheideEinspruch: %w", err)
+	}
+	for i := range m.strafen {
+		r := &m.strafen[i]
+		if r.ID == id && r.Einspruch.Offen() {
+			now := time.Now()
+			r.Einspruch.Status, r.Einspruch.Notiz, r.Einspruch.EntschiedenAm = status, notiz, &now
+			if status == penalty.EinspruchAufgehoben {
+				r.Status, r.GeloeschtAm = penalty.StatusGeloescht, &now
+				m.loescheMahngebuehren(id, now)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("EntscheideEinspruch: kein offener Einspruch gegen Strafe %d", id)
+}
+
+// loescheMahngebuehren löscht die offenen Mahngebühren, die für die Strafe
+// id angefallen sind (wie EntscheideEinspruch in Postgres).
+func (m *Mock) loescheMahngebuehren(id int64, now time.Time) {
+	for _, mh := range m.mahnungen {
+		if mh.StrafeID != id || mh.GebuehrID == 0 {
+			continue
+		}
+		for i := range m.strafen {
+			if r := &m.strafen[i]; r.ID == mh.GebuehrID && r.Status == penalty.StatusOffen {
+				r.Status, r.GeloeschtAm = penalty.StatusGeloescht, &now
+			}
+		}
+	}
+}
+
+// --- Strafen-Regelwerke: Mock ---
+
+// sampleRuleSets: die ursprünglichen Regeln und eine spätere Abstimmung.
+func sampleRuleSets() penalty.RuleSets {
+	first := penalty.DefaultRuleSet
+	first.ID, first.CreatedAt = 1, first.GiltAb
+	return penalty.RuleSets{first, {
+		ID: 2, GiltAb: time.Date(2026, 9, 3, 0, 0, 0, 0, time.UTC), MinFehltage: 4, BasisBetrag: 30,
+		ProTagBetrag: 5, NoShowDefault: 50, Notiz: "Abstimmung Sommerfest: 4 Wochen reichen",
+		CreatedAt: time.Date(2026, 8, 28, 21, 0, 0, 0, time.Local),
+	}}
+}
+
+func (m *Mock) ListRuleSets(_ context.Context) (penalty.RuleSets, error) {
+	return m.ruleSets.Sorted(), nil
+}
+
+func (m *Mock) SaveRuleSet(_ context.Context, r penalty.RuleSet) error {
+	if err := penalty.ValidateRuleSet(r); err != nil {
+		return fmt.Errorf("SaveRuleSet: %w", err)
+	}
+	if err := penalty.ValidateRuleSetSave(m.ruleSets, r, m.strafen, timeutil.StartOfDay(time.Now())); err != nil {
+		return fmt.Errorf("SaveRuleSet: %w", err)
+	}
+	var maxID int64
+	for _, x := range m.ruleSets {
+		if x.ID != r.ID && timeutil.FormatISO(x.GiltAb) == timeutil.FormatISO(r.GiltAb) {
+			return fmt.Errorf("SaveRuleSet: ab %s gilt schon ein Regelwerk", timeutil.FormatISO(r.GiltAb))
+		}
+		maxID = max(maxID, x.ID)
+	}
+	if r.ID == 0 {
+		r.ID, r.CreatedAt = maxID+1, time.Now()
+		m.ruleSets = append(m.ruleSets, r)
+		return nil
+	}
+	for i := range m.ruleSets {
+		if m.ruleSets[i].ID == r.ID {
+			r.CreatedAt = m.ruleSets[i].CreatedAt
+			m.ruleSets[i] = r
+			return nil
+		}
+	}
+	return fmt.Errorf("SaveRuleSet: Regelwerk %d nicht gefunden", r.ID)
+}
+
+func (m *Mock) DeleteRuleSet(_ context.Context, id int64) error {
+	if len(m.ruleSets) <= 1 {
+		return fmt.Errorf("DeleteRuleSet: Regelwerk %d nicht gefunden oder das letzte", id)
+	}
+	if err := penalty.ValidateRuleSetChange(m.ruleSets, id, m.strafen, timeutil.StartOfDay(time.Now())); err != nil {
+		return fmt.Errorf("DeleteRuleSet: %w", err)
+	}
+	out := m.ruleSets[:0]
+	for _, r := range m.ruleSets {
+		if r.ID != id {
+			out = append(out, r)
+		}
+	}
+	m.ruleSets = out
+	return nil
+}
+
+// --- Kassenbuch: Mock ---
+
+// sampleBuchungen: Übertrag aus der Bargeld-Kasse und eine Runde. Zahlungen
+// auf Strafen entstehen erst im UI (die Strafen-IDs vergibt der Mock zur
+// Laufzeit).
+func sampleBuchungen() []penalty.Buchung {
+	return []penalty.Buchung{
+		{ID: 1, Art: penalty.BuchungZahlung, Betrag: 120, Datum: time.Date(2026, 8, 6, 0, 0, 0, 0, time.UTC),
+			Notiz: "Übertrag aus der Bargeld-Kasse", CreatedAt: time.Date(2026, 8, 6, 22, 0, 0, 0, time.Local)},
+		{ID: 2, Art: penalty.BuchungAusgabe, Betrag: 45, Datum: time.Date(2026, 9, 3, 0, 0, 0, 0, time.UTC),
+			Notiz: "Runde nach dem Sommerfest", CreatedAt: time.Date(2026, 9, 3, 23, 0, 0, 0, time.Local)},
+	}
+}
+
+func (m *Mock) ListBuchungen(_ context.Context) ([]penalty.Buchung, error) {
+	out := append([]penalty.Buchung(nil), m.buchungen...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Datum.Equal(out[j].Datum) {
+			return out[i].Datum.After(out[j].Datum)
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out, nil
+}
+
+func (m *Mock) ListMahnungen(_ context.Context) ([]penalty.Mahnung, error) {
+	return append([]penalty.Mahnung(nil), m.mahnungen...), nil
+}
+
+func (m *Mock) ListMahnOptOuts(_ context.Context) (map[string]bool, error) {
+	return m.mahnOptOut, nil
+}
+
+func (m *Mock) InsertBuchung(ctx context.Context, b penalty.Buchung, begleicht bool) error {
+	if err := penalty.ValidateBuchung(b); err != nil {
+		return fmt.Errorf("InsertBuchung: %w", err)
+	}
+	var maxID int64
+	for _, x := range m.buchungen {
+		maxID = max(maxID, x.ID)
+	}
+	b.ID, b.CreatedAt = maxID+1, time.Now()
+	m.buchungen = append(m.buchungen, b)
+	if !begleicht {
+		return nil
+	}
+	if err := m.BegleicheStrafe(ctx, b.StrafeID); err != nil {
+		return err
+	}
+	// Wie Postgres: beglichen am Buchungstag.
+	for i := range m.strafen {
+		if m.strafen[i].ID == b.StrafeID {
+			datum := b.Datum
+			m.strafen[i].BeglichenAm = &datum
+		}
+	}
+	return nil
+}
+
+func (m *Mock) DeleteBuchung(_ context.Context, id int64, betrag int) error {
+	for i, b := range m.buchungen {
+		if b.ID == id {
+			m.buchungen = append(m.buchungen[:i], m.buchungen[i+1:]...)
+			m.oeffneUngedeckt(b.StrafeID, betrag)
+			return nil
+		}
+	}
+	return fmt.Errorf("DeleteBuchung: Buchung %d nicht gefunden", id)
+}
+
+// oeffneUngedeckt setzt die beglichene Strafe id wieder auf offen, wenn die
+// übrigen Zahlungen ihren Betrag nicht mehr decken (wie Postgres).
+func (m *Mock) oeffneUngedeckt(id int64, betrag int) {
+	var bezahlt int
+	for _, b := range m.buchungen {
+		if b.StrafeID == id && b.Art == penalty.BuchungZahlung {
+			bezahlt += b.Betrag
+		}
+	}
+	for i := range m.strafen {
+		r := &m.strafen[i]
+		if id == 0 || r.ID != id || r.Status != penalty.StatusBeglichen {
+			continue
+		}
+		if r.Betrag != 0 {
+			betrag = r.Betrag
+		}
+		if bezahlt < betrag {
+			r.Status, r.BeglichenAm = penalty.StatusOffen, nil
+		}
+	}
+}
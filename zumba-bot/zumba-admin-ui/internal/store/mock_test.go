@@ -2,9 +2,13 @@ package store
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/michael/zumba-shared/domain"
+	"github.com/michael/zumba-shared/penalty"
+
 	"github.com/michael/zumba-admin-ui/internal/timeutil"
 )
 
@@ -19,7 +23,7 @@ func thursdayIn(p timeutil.Period) time.Time {
 
 func TestMockInsertDeleteAbsence(t *testing.T) {
 	p := timeutil.Period{Start: mustDate("2025-12-01"), End: mustDate("2026-11-30")}
-	m := NewMock(p)
+	m := NewMock(p, domain.DefaultSchedule)
 	ctx := context.Background()
 	day := thursdayIn(p)
 	uid := m.users[0].ID
@@ -49,7 +53,7 @@ func TestMockInsertDeleteAbsence(t *testing.T) {
 
 func TestMockInsertDeleteExcluded(t *testing.T) {
 	p := timeutil.Period{Start: mustDate("2025-12-01"), End: mustDate("2026-11-30")}
-	m := NewMock(p)
+	m := NewMock(p, domain.DefaultSchedule)
 	ctx := context.Background()
 	day := thursdayIn(p)
 
@@ -100,3 +104,38 @@ func mustDate(s string) time.Time {
 	}
 	return d
 }
+
+// Geltende Regelwerke sind unveränderlich; künftige lassen sich ändern und
+// löschen.
+func TestMockRegelwerkAppendOnly(t *testing.T) {
+	m := NewMock(timeutil.Period{Start: mustDate("2025-12-01"), End: mustDate("2026-11-30")}, domain.DefaultSchedule)
+	ctx := context.Background()
+	rs, _ := m.ListRuleSets(ctx)
+	geltend := rs[0]
+
+	geltend.BasisBetrag = 99
+	if err := m.SaveRuleSet(ctx, geltend); !errors.Is(err, penalty.ErrRuleSetInKraft) {
+		t.Errorf("geltendes ändern: err = %v", err)
+	}
+	if err := m.DeleteRuleSet(ctx, geltend.ID); !errors.Is(err, penalty.ErrRuleSetInKraft) {
+		t.Errorf("geltendes löschen: err = %v", err)
+	}
+	neu := penalty.RuleSet{GiltAb: mustDate("2025-12-04"), MinFehltage: 3, BasisBetrag: 20, ProTagBetrag: 5, NoShowDefault: 40}
+	if err := m.SaveRuleSet(ctx, neu); !errors.Is(err, penalty.ErrRuleSetInKraft) {
+		t.Errorf("rückwirkend anlegen: err = %v", err)
+	}
+
+	neu.GiltAb = timeutil.StartOfDay(time.Now()).AddDate(0, 1, 0)
+	if err := m.SaveRuleSet(ctx, neu); err != nil {
+		t.Fatalf("künftiges anlegen: %v", err)
+	}
+	rs, _ = m.ListRuleSets(ctx)
+	kuenftig := rs[len(rs)-1]
+	kuenftig.BasisBetrag = 22
+	if err := m.SaveRuleSet(ctx, kuenftig); err != nil {
+		t.Errorf("künftiges ändern: %v", err)
+	}
+	if err := m.DeleteRuleSet(ctx, kuenftig.ID); err != nil {
+		t.Errorf("künftiges löschen: %v", err)
+	}
+}
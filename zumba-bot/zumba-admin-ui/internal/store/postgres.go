@@ -10,6 +10,7 @@ import (
 
 	"github.com/lib/pq"
 
+	"github.com/michael/zumba-shared/domain"
 	sharedstore "github.com/michael/zumba-shared/store"
 
 	"github.com/michael/zumba-admin-ui/internal/db"
@@ -17,11 +18,23 @@ import (
 )
 
 type Postgres struct {
-	db *db.Postgres
+	db       *db.Postgres
+	schedule domain.Schedule
+
+	// ExcludeExcused nimmt entschuldigte Treffen aus der Rangliste (von main
+	// gesetzt, LEADERBOARD_EXCLUDE_EXCUSED; false = alle Treffen zählen).
+	ExcludeExcused bool
+}
+
+// NewPostgres bindet den Store an den Stammtisch-Schedule: alle
+// "Donnerstags"-Queries filtern auf dessen Treffen-Tage.
+func NewPostgres(p *db.Postgres, sched domain.Schedule) *Postgres {
+	return &Postgres{db: p, schedule: sched}
 }
 
-func NewPostgres(p *db.Postgres) *Postgres {
-	return &Postgres{db: p}
+// meetings liefert die Treffen-Tage in [start, end] als date[]-Parameter.
+func (s *Postgres) meetings(start, end time.Time) any {
+	return sharedstore.MeetingDates(s.schedule, start, end)
 }
 
 func (s *Postgres) ListUsers(ctx context.Context) ([]User, error) {
@@ -65,13 +78,14 @@ func (s *Postgres) ListThursdays(ctx context.Context, p timeutil.Period) ([]time
 		WITH all_thursdays AS (
 			SELECT d::date AS thursday
 			FROM generate_series($1::date, $2::date, interval '1 day') AS d
-			WHERE EXTRACT(DOW FROM d) = 4
+			WHERE d::date = ANY($3::date[])
 		)
 		SELECT thursday FROM all_thursdays
 		WHERE thursday NOT IN (SELECT date FROM excluded_days WHERE date >= $1 AND date <= $2)
 		ORDER BY thursday DESC
 	`
-	rows, err := s.db.QueryContext(ctx, q, p.Start, p.EffectiveEnd())
+	end := p.EffectiveEnd()
+	rows, err := s.db.QueryContext(ctx, q, p.Start, end, s.meetings(p.Start, end))
 	if err != nil {
 		return nil, fmt.Errorf("ListThursdays: %w", err)
 	}
@@ -116,11 +130,12 @@ func (s *Postgres) ListAbsences(ctx context.Context, p timeutil.Period) ([]Absen
 		SELECT "userId", date, message
 		FROM stammtisch_abwesenheit
 		WHERE date >= $1 AND date <= $2
-		  AND EXTRACT(DOW FROM date) = 4
+		  AND date = ANY($3::date[])
 		  AND date NOT IN (SELECT date FROM excluded_days)
 		ORDER BY date DESC, "userId"
 	`
-	rows, err := s.db.QueryContext(ctx, q, p.Start, p.EffectiveEnd())
+	end := p.EffectiveEnd()
+	rows, err := s.db.QueryContext(ctx, q, p.Start, end, s.meetings(p.Start, end))
 	if err != nil {
 		return nil, fmt.Errorf("ListAbsences: %w", err)
 	}
@@ -140,22 +155,52 @@ func (s *Postgres) ListAbsences(ctx context.Context, p timeutil.Period) ([]Absen
 // Leaderboard nutzt die geteilte Rangliste-Query aus dem shared-Modul
 // (queries/leaderboard.sql – früher hier als leaderboardQ dupliziert).
 func (s *Postgres) Leaderboard(ctx context.Context, p timeutil.Period) ([]LeaderboardRow, error) {
-	return sharedstore.Leaderboard(ctx, s.db, p)
+	excused, err := s.excused(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sharedstore.Leaderboard(ctx, s.db, p, s.schedule, excused)
 }
 
 // UserLeaderboardRow filtert die Leaderboard-CTE in SQL auf einen User.
 func (s *Postgres) UserLeaderboardRow(ctx context.Context, p timeutil.Period, userID string) (LeaderboardRow, error) {
-	return sharedstore.UserLeaderboardRow(ctx, s.db, p, userID)
+	excused, err := s.excused(ctx)
+	if err != nil {
+		return LeaderboardRow{}, err
+	}
+	return sharedstore.UserLeaderboardRow(ctx, s.db, p, s.schedule, excused, userID)
 }
 
-// ThursdayStrip aggregiert die Strip-Kacheln komplett in SQL: Donnerstage bis
-// heute (inkl. Sperrtage), Abmelde-Zahl je Tag, jüngste N, aufsteigend.
+// excused liefert die Zeiträume, die die Rangliste auslässt (nil, wenn
+// ExcludeExcused aus ist).
+func (s *Postgres) excused(ctx context.Context) (domain.Entschuldigungen, error) {
+	if !s.ExcludeExcused {
+		return nil, nil
+	}
+	return sharedstore.ListEntschuldigungen(ctx, s.db)
+}
+
+// EnsureZusageSchema legt stammtisch_zusage idempotent an (der Bot schreibt
+// die Zusagen, "Wer kommt?" liest sie).
+func (s *Postgres) EnsureZusageSchema(ctx context.Context) error {
+	return sharedstore.EnsureZusageSchema(ctx, s.db)
+}
+
+// Roster nutzt die geteilte Teilnehmerlisten-Query (wie der Bot-Befehl
+// "wer kommt").
+func (s *Postgres) Roster(ctx context.Context, date time.Time) ([]RosterEntry, error) {
+	return sharedstore.Roster(ctx, s.db, date)
+}
+
+// ThursdayStrip aggregiert die Strip-Kacheln komplett in SQL: Treffen-Tage des
+// Schedules bis heute (inkl. Sperrtage), Abmelde-Zahl je Tag, jüngste N,
+// aufsteigend.
 func (s *Postgres) ThursdayStrip(ctx context.Context, p timeutil.Period, limit int) ([]StripDay, error) {
 	const q = `
 		WITH days AS (
 			SELECT d::date AS day
 			FROM generate_series($1::date, LEAST($2::date, current_date), interval '1 day') AS d
-			WHERE EXTRACT(DOW FROM d) = 4
+			WHERE d::date = ANY($4::date[])
 		),
 		recent AS (
 			SELECT day,
@@ -166,7 +211,7 @@ func (s *Postgres) ThursdayStrip(ctx context.Context, p timeutil.Period, limit i
 			LIMIT CASE WHEN $3 > 0 THEN $3 END
 		)
 		SELECT day, excluded, away FROM recent ORDER BY day ASC`
-	rows, err := s.db.QueryContext(ctx, q, p.Start, p.End, limit)
+	rows, err := s.db.QueryContext(ctx, q, p.Start, p.End, limit, s.meetings(p.Start, p.EffectiveEnd()))
 	if err != nil {
 		return nil, fmt.Errorf("ThursdayStrip: %w", err)
 	}
@@ -183,14 +228,14 @@ func (s *Postgres) ThursdayStrip(ctx context.Context, p timeutil.Period, limit i
 	return out, rows.Err()
 }
 
-// ListDayAbsences gruppiert Abmeldungen je gültigem Donnerstag in SQL
+// ListDayAbsences gruppiert Abmeldungen je gültigem Treffen-Tag in SQL
 // (GROUP BY + array_agg statt Go-Maps), neueste zuerst.
 func (s *Postgres) ListDayAbsences(ctx context.Context, p timeutil.Period) ([]DayAbsences, error) {
 	const q = `
 		WITH days AS (
 			SELECT d::date AS day
 			FROM generate_series($1::date, LEAST($2::date, current_date), interval '1 day') AS d
-			WHERE EXTRACT(DOW FROM d) = 4
+			WHERE d::date = ANY($3::date[])
 			  AND d::date NOT IN (SELECT date FROM excluded_days)
 		)
 		SELECT day,
@@ -200,7 +245,8 @@ func (s *Postgres) ListDayAbsences(ctx context.Context, p timeutil.Period) ([]Da
 		LEFT JOIN stammtisch_abwesenheit a ON a.date = day
 		GROUP BY day
 		ORDER BY day DESC`
-	rows, err := s.db.QueryContext(ctx, q, p.Start, p.EffectiveEnd())
+	end := p.EffectiveEnd()
+	rows, err := s.db.QueryContext(ctx, q, p.Start, end, s.meetings(p.Start, end))
 	if err != nil {
 		return nil, fmt.Errorf("ListDayAbsences: %w", err)
 	}
@@ -244,14 +290,15 @@ func (s *Postgres) AbsencesOn(ctx context.Context, date time.Time) ([]Absence, e
 // ListUserAbsences liefert die Abmeldungen eines Users im Zeitraum.
 func (s *Postgres) ListUserAbsences(ctx context.Context, p timeutil.Period, userID string) ([]Absence, error) {
 	const q = `
-		SELECT "userId", date, message
+		SELECT "userId", date, message, entered_by
 		FROM stammtisch_abwesenheit
 		WHERE "userId" = $3
 		  AND date >= $1 AND date <= $2
-		  AND EXTRACT(DOW FROM date) = 4
+		  AND date = ANY($4::date[])
 		  AND date NOT IN (SELECT date FROM excluded_days)
 		ORDER BY date DESC`
-	rows, err := s.db.QueryContext(ctx, q, p.Start, p.EffectiveEnd(), userID)
+	end := p.EffectiveEnd()
+	rows, err := s.db.QueryContext(ctx, q, p.Start, end, userID, s.meetings(p.Start, end))
 	if err != nil {
 		return nil, fmt.Errorf("ListUserAbsences: %w", err)
 	}
@@ -260,7 +307,7 @@ func (s *Postgres) ListUserAbsences(ctx context.Context, p timeutil.Period, user
 	var out []Absence
 	for rows.Next() {
 		var a Absence
-		if err := rows.Scan(&a.UserID, &a.Date, &a.Message); err != nil {
+		if err := rows.Scan(&a.UserID, &a.Date, &a.Message, &a.EnteredBy); err != nil {
 			return nil, fmt.Errorf("ListUserAbsences scan: %w", err)
 		}
 		out = append(out, a)
@@ -337,7 +384,7 @@ func (s *Postgres) DeleteExcludedDay(ctx context.Context, date time.Time) error
 func (s *Postgres) ListTraces(ctx context.Context, limit int) ([]Trace, error) {
 	const q = `
 		SELECT id, created_at, user_name, message, message_type, path,
-		       classification, action, has_error
+		       classification, action, has_error, parent_id
 		FROM bot_trace
 		WHERE created_at > now() - interval '21 days'
 		ORDER BY created_at DESC
@@ -352,7 +399,7 @@ func (s *Postgres) ListTraces(ctx context.Context, limit int) ([]Trace, error) {
 	for rows.Next() {
 		var t Trace
 		if err := rows.Scan(&t.ID, &t.CreatedAt, &t.UserName, &t.Message,
-			&t.MessageType, &t.Path, &t.Classification, &t.Action, &t.HasError); err != nil {
+			&t.MessageType, &t.Path, &t.Classification, &t.Action, &t.HasError, &t.ParentID); err != nil {
 			return nil, fmt.Errorf("ListTraces scan: %w", err)
 		}
 		out = append(out, t)
@@ -364,14 +411,15 @@ func (s *Postgres) GetTrace(ctx context.Context, id int64) (*Trace, error) {
 	const q = `
 		SELECT id, created_at, remote_jid, user_id, user_name, message, message_type,
 		       path, classification, action, has_error,
-		       COALESCE(raw_payload::text, ''), trace::text
+		       COALESCE(raw_payload::text, ''), trace::text, parent_id,
+		       ARRAY(SELECT c.id FROM bot_trace c WHERE c.parent_id = bot_trace.id ORDER BY c.id)
 		FROM bot_trace WHERE id = $1`
 	var t Trace
 	var rawPayload, stepsJSON string
 	err := s.db.QueryRowContext(ctx, q, id).Scan(
 		&t.ID, &t.CreatedAt, &t.RemoteJid, &t.UserID, &t.UserName, &t.Message,
 		&t.MessageType, &t.Path, &t.Classification, &t.Action, &t.HasError,
-		&rawPayload, &stepsJSON)
+		&rawPayload, &stepsJSON, &t.ParentID, pq.Array(&t.FollowUps))
 	if err != nil {
 		return nil, fmt.Errorf("GetTrace: %w", err)
 	}
@@ -389,13 +437,97 @@ func (s *Postgres) GetTrace(ctx context.Context, id int64) (*Trace, error) {
 	return &t, nil
 }
 
+// --- Bot-Outbox (bot_outbox, vom whatsapp-bot angelegt) ---
+
+func (s *Postgres) ListOutbox(ctx context.Context, limit int) ([]OutboxMessage, error) {
+	const q = `
+		SELECT id, created_at, source, recipient, text, caption, image IS NOT NULL,
+		       status, attempts, next_attempt_at, last_error, sent_at
+		FROM bot_outbox
+		ORDER BY id DESC
+		LIMIT $1`
+	rows, err := s.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListOutbox: %w", err)
+	}
+	defer rows.Close()
+
+	var out []OutboxMessage
+	for rows.Next() {
+		var m OutboxMessage
+		if err := rows.Scan(&m.ID, &m.CreatedAt, &m.Source, &m.Recipient, &m.Text, &m.Caption, &m.HasImage,
+			&m.Status, &m.Attempts, &m.NextAttemptAt, &m.LastError, &m.SentAt); err != nil {
+			return nil, fmt.Errorf("ListOutbox scan: %w", err)
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// --- Bot-Scheduler (bot_job/bot_job_run, vom whatsapp-bot angelegt) ---
+
+func (s *Postgres) ListJobs(ctx context.Context, runsPerJob int) ([]Job, error) {
+	const q = `
+		SELECT name, cron, payload::text, enabled, next_run_at, retry_at, attempts, locked_by, locked_until
+		FROM bot_job
+		ORDER BY name`
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("ListJobs: %w", err)
+	}
+	var out []Job
+	byName := map[string]int{}
+	for rows.Next() {
+		var j Job
+		if err := rows.Scan(&j.Name, &j.Cron, &j.Payload, &j.Enabled, &j.NextRunAt, &j.RetryAt,
+			&j.Attempts, &j.LockedBy, &j.LockedUntil); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("ListJobs scan: %w", err)
+		}
+		byName[j.Name] = len(out)
+		out = append(out, j)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListJobs: %w", err)
+	}
+
+	const runsQ = `
+		SELECT job, id, scheduled_for, started_at, finished_at, status, attempt, runner, detail, error
+		FROM (
+		  SELECT r.*, ROW_NUMBER() OVER (PARTITION BY job ORDER BY id DESC) AS n
+		  FROM bot_job_run r
+		) r
+		WHERE n <= $1
+		ORDER BY job, id DESC`
+	rows, err = s.db.QueryContext(ctx, runsQ, runsPerJob)
+	if err != nil {
+		return nil, fmt.Errorf("ListJobs runs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			job string
+			r   JobRun
+		)
+		if err := rows.Scan(&job, &r.ID, &r.ScheduledFor, &r.StartedAt, &r.FinishedAt, &r.Status,
+			&r.Attempt, &r.Runner, &r.Detail, &r.Error); err != nil {
+			return nil, fmt.Errorf("ListJobs runs scan: %w", err)
+		}
+		if i, ok := byName[job]; ok {
+			out[i].Runs = append(out[i].Runs, r)
+		}
+	}
+	return out, rows.Err()
+}
+
 // --- ML-Shadow-Modus (ml_messages) ---
 
 func (s *Postgres) ListMLMessages(ctx context.Context, onlyDisagree bool, limit int) ([]MLMessage, error) {
 	q := `
 		SELECT id, created_at, COALESCE(user_id,''), COALESCE(user_name,''), message,
 		       COALESCE(gemini_label,''), model_label, model_confidence, agree,
-		       verified, corrected_label
+		       verified, corrected_label, COALESCE(route,'shadow')
 		FROM ml_messages`
 	if onlyDisagree {
 		// Disagreement = Modell widerspricht ODER Modell hat nicht geantwortet.
@@ -413,7 +545,7 @@ func (s *Postgres) ListMLMessages(ctx context.Context, onlyDisagree bool, limit
 		var m MLMessage
 		if err := rows.Scan(&m.ID, &m.CreatedAt, &m.UserID, &m.UserName, &m.Message,
 			&m.GeminiLabel, &m.ModelLabel, &m.ModelConfidence, &m.Agree,
-			&m.Verified, &m.CorrectedLabel); err != nil {
+			&m.Verified, &m.CorrectedLabel, &m.Route); err != nil {
 			return nil, fmt.Errorf("ListMLMessages scan: %w", err)
 		}
 		out = append(out, m)
@@ -432,8 +564,9 @@ func (s *Postgres) MLShadowStats(ctx context.Context) (MLShadowStats, error) {
 		return st, fmt.Errorf("MLShadowStats: %w", err)
 	}
 	const perLabel = `
-		SELECT COALESCE(gemini_label,''), count(*), count(*) FILTER (WHERE agree)
+		SELECT gemini_label, count(*), count(*) FILTER (WHERE agree)
 		FROM ml_messages
+		WHERE gemini_label IS NOT NULL
 		GROUP BY 1 ORDER BY 1`
 	rows, err := s.db.QueryContext(ctx, perLabel)
 	if err != nil {
@@ -447,7 +580,74 @@ func (s *Postgres) MLShadowStats(ctx context.Context) (MLShadowStats, error) {
 		}
 		st.PerLabel = append(st.PerLabel, ls)
 	}
-	return st, rows.Err()
+	if err := rows.Err(); err != nil {
+		return st, fmt.Errorf("MLShadowStats per label: %w", err)
+	}
+
+	// Je Route: korrigiert = geprüft und das gehandelte Label (Modell auf
+	// canary/fallback, sonst Gemini) weicht vom richtigen ab.
+	const perRoute = `
+		SELECT COALESCE(route,'shadow'), count(*),
+		       count(*) FILTER (WHERE agree IS NOT NULL),
+		       count(*) FILTER (WHERE agree),
+		       count(*) FILTER (WHERE verified),
+		       count(*) FILTER (WHERE verified AND COALESCE(corrected_label, gemini_label) IS DISTINCT FROM
+		         CASE WHEN route IN ('canary','fallback') THEN model_label ELSE gemini_label END)
+		FROM ml_messages
+		GROUP BY 1 ORDER BY 1`
+	rrows, err := s.db.QueryContext(ctx, perRoute)
+	if err != nil {
+		return st, fmt.Errorf("MLShadowStats per route: %w", err)
+	}
+	defer rrows.Close()
+	for rrows.Next() {
+		var rs MLRouteStat
+		if err := rrows.Scan(&rs.Route, &rs.Total, &rs.Compared, &rs.Agree, &rs.Verified, &rs.Corrected); err != nil {
+			return st, fmt.Errorf("MLShadowStats route scan: %w", err)
+		}
+		st.PerRoute = append(st.PerRoute, rs)
+	}
+	return st, rrows.Err()
+}
+
+// MLRoutePolicy liest ml_route_policy (legt der Bot an). Fehlt die Tabelle
+// oder die Zeile, läuft kein Bot mit classifier-service: nil, kein Fehler.
+func (s *Postgres) MLRoutePolicy(ctx context.Context) (*MLRoutePolicy, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `SELECT to_regclass('ml_route_policy') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("MLRoutePolicy: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	var p MLRoutePolicy
+	err := s.db.QueryRowContext(ctx, `
+		SELECT share, min_confidence, killed, killed_at, updated_at
+		FROM ml_route_policy WHERE id = 1`).Scan(&p.Share, &p.MinConfidence, &p.Killed, &p.KilledAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("MLRoutePolicy: %w", err)
+	}
+	return &p, nil
+}
+
+func (s *Postgres) SetMLRouteKilled(ctx context.Context, killed bool) (*MLRoutePolicy, error) {
+	var p MLRoutePolicy
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE ml_route_policy
+		SET killed = $1, killed_at = CASE WHEN $1 THEN now() END
+		WHERE id = 1
+		RETURNING share, min_confidence, killed, killed_at, updated_at`, killed).
+		Scan(&p.Share, &p.MinConfidence, &p.Killed, &p.KilledAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("SetMLRouteKilled: keine Canary-Policy (Bot ohne classifier-service?)")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("SetMLRouteKilled: %w", err)
+	}
+	return &p, nil
 }
 
 func (s *Postgres) VerifyMLMessage(ctx context.Context, id int64, correctedLabel *string) (*MLMessage, error) {
@@ -456,12 +656,12 @@ func (s *Postgres) VerifyMLMessage(ctx context.Context, id int64, correctedLabel
 		WHERE id = $1
 		RETURNING id, created_at, COALESCE(user_id,''), COALESCE(user_name,''), message,
 		          COALESCE(gemini_label,''), model_label, model_confidence, agree,
-		          verified, corrected_label`
+		          verified, corrected_label, COALESCE(route,'shadow')`
 	var m MLMessage
 	err := s.db.QueryRowContext(ctx, q, id, correctedLabel).Scan(
 		&m.ID, &m.CreatedAt, &m.UserID, &m.UserName, &m.Message,
 		&m.GeminiLabel, &m.ModelLabel, &m.ModelConfidence, &m.Agree,
-		&m.Verified, &m.CorrectedLabel)
+		&m.Verified, &m.CorrectedLabel, &m.Route)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("VerifyMLMessage: Eintrag %d nicht gefunden", id)
 	}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+
+	"github.com/michael/zumba-shared/rules"
+	sharedstore "github.com/michael/zumba-shared/store"
+)
+
+// EnsureRuleSchema legt classifier_rule idempotent an (geteilte DDL im
+// shared-Modul; der whatsapp-bot ruft dieselbe Funktion).
+func (s *Postgres) EnsureRuleSchema(ctx context.Context) error {
+	return sharedstore.EnsureRuleSchema(ctx, s.db)
+}
+
+func (s *Postgres) ListRules(ctx context.Context) ([]rules.Rule, error) {
+	return sharedstore.ListRules(ctx, s.db)
+}
+
+func (s *Postgres) InsertRule(ctx context.Context, r rules.Rule) (int64, error) {
+	return sharedstore.InsertRule(ctx, s.db, r)
+}
+
+func (s *Postgres) SetRuleEnabled(ctx context.Context, id int64, enabled bool) error {
+	return sharedstore.SetRuleEnabled(ctx, s.db, id, enabled)
+}
+
+func (s *Postgres) DeleteRule(ctx context.Context, id int64) error {
+	return sharedstore.DeleteRule(ctx, s.db, id)
+}
+
+func (s *Postgres) VerifiedCorpus(ctx context.Context) ([]rules.Sample, error) {
+	return sharedstore.VerifiedCorpus(ctx, s.db)
+}
@@ -7,7 +7,9 @@ import (
 	"context"
 	"time"
 
+	"github.com/michael/zumba-shared/domain"
 	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-shared/rules"
 	sharedstore "github.com/michael/zumba-shared/store"
 
 	"github.com/michael/zumba-admin-ui/internal/timeutil"
@@ -24,6 +26,9 @@ type Absence struct {
 	UserID  string
 	Date    time.Time
 	Message *string
+	// EnteredBy: wer die Absage für den User eingetragen hat (userId; nil =
+	// selbst bzw. Admin-UI). Nur ListUserAbsences füllt es.
+	EnteredBy *string
 }
 
 // LeaderboardRow ist eine Zeile der Rangliste (geteilter Typ, geteilte Query
@@ -31,7 +36,11 @@ type Absence struct {
 // Anwesenheits-Serie, <0 = aktuelle Abwesenheits-Serie.
 type LeaderboardRow = sharedstore.LeaderboardRow
 
-// StripDay ist eine Kachel des Donnerstags-Strips: Datum, Sperrtag-Flag und
+// RosterEntry ist eine Zeile der Teilnehmerliste eines Treffens (geteilter
+// Typ und Query mit dem Bot-Befehl "wer kommt").
+type RosterEntry = sharedstore.RosterEntry
+
+// StripDay ist eine Kachel des Termin-Strips: Datum, Sperrtag-Flag und
 // Anzahl Abmeldungen – komplett in SQL aggregiert.
 type StripDay struct {
 	Date     time.Time
@@ -39,7 +48,7 @@ type StripDay struct {
 	Away     int
 }
 
-// DayAbsences sind die Abmeldungen eines gültigen Donnerstags (GROUP BY in SQL).
+// DayAbsences sind die Abmeldungen eines gültigen Treffen-Tags (GROUP BY in SQL).
 type DayAbsences struct {
 	Date          time.Time
 	AbsentUserIDs []string
@@ -50,6 +59,8 @@ type Store interface {
 	ListUsers(ctx context.Context) ([]User, error)
 	// GetUser liefert einen einzelnen User (nil, wenn unbekannt).
 	GetUser(ctx context.Context, userID string) (*User, error)
+	// ListThursdays liefert die gültigen Treffen-Tage laut Schedule (historischer
+	// Name aus der Donnerstags-Zeit), ohne Sperrtage, neueste zuerst.
 	ListThursdays(ctx context.Context, p timeutil.Period) ([]time.Time, error)
 	ListExcludedDays(ctx context.Context, p timeutil.Period) ([]time.Time, error)
 	// IsExcludedDay prüft einen einzelnen Tag (EXISTS statt Liste + Scan).
@@ -62,10 +73,13 @@ type Store interface {
 	Leaderboard(ctx context.Context, p timeutil.Period) ([]LeaderboardRow, error)
 	// UserLeaderboardRow liefert die Leaderboard-Zeile eines einzelnen Users.
 	UserLeaderboardRow(ctx context.Context, p timeutil.Period, userID string) (LeaderboardRow, error)
-	// ThursdayStrip liefert die jüngsten Donnerstage (inkl. Sperrtage) bis
+	// ThursdayStrip liefert die jüngsten Treffen-Tage (inkl. Sperrtage) bis
 	// heute mit Abmelde-Zahl, aufsteigend sortiert; limit 0 = alle.
 	ThursdayStrip(ctx context.Context, p timeutil.Period, limit int) ([]StripDay, error)
-	// ListDayAbsences gruppiert Abmeldungen je gültigem Donnerstag (neueste zuerst).
+	// Roster liefert für das Treffen am Tag date, wer abgesagt, wer
+	// ausdrücklich zugesagt und wer sich nicht gemeldet hat.
+	Roster(ctx context.Context, date time.Time) ([]RosterEntry, error)
+	// ListDayAbsences gruppiert Abmeldungen je gültigem Treffen-Tag (neueste zuerst).
 	ListDayAbsences(ctx context.Context, p timeutil.Period) ([]DayAbsences, error)
 
 	InsertAbsence(ctx context.Context, userID string, date time.Time, message *string) error
@@ -76,14 +90,50 @@ type Store interface {
 	InsertExcludedDay(ctx context.Context, date time.Time) error
 	DeleteExcludedDay(ctx context.Context, date time.Time) error
 
+	// Spitznamen (user_alias), unter denen der Bot ein Mitglied in "Tom und
+	// ich kommen nicht" erkennt. AddAlias liefert sharedstore.ErrAliasTaken,
+	// wenn der Name schon vergeben ist.
+	ListAliases(ctx context.Context, userID string) ([]string, error)
+	AddAlias(ctx context.Context, userID, alias string) error
+	DeleteAlias(ctx context.Context, userID, alias string) error
+
+	// Entschuldigte Zeiträume (stammtisch_entschuldigt): Treffen darin sind
+	// für Strafen neutral und fallen – je nach LEADERBOARD_EXCLUDE_EXCUSED –
+	// aus der Rangliste.
+	ListEntschuldigungen(ctx context.Context) (domain.Entschuldigungen, error)
+	InsertEntschuldigung(ctx context.Context, e domain.Entschuldigung) error
+	DeleteEntschuldigung(ctx context.Context, id int64) error
+
+	// Vorklassifikation (classifier_rule): Regeln, die der Bot vor dem
+	// Classifier prüft. Neue Regeln sind ausgeschaltet; VerifiedCorpus sind
+	// die handgeprüften ml_messages, gegen die sie getestet werden.
+	ListRules(ctx context.Context) ([]rules.Rule, error)
+	InsertRule(ctx context.Context, r rules.Rule) (int64, error)
+	SetRuleEnabled(ctx context.Context, id int64, enabled bool) error
+	DeleteRule(ctx context.Context, id int64) error
+	VerifiedCorpus(ctx context.Context) ([]rules.Sample, error)
+
 	// Bot-Trace (Verlauf-Ansicht): ListTraces liefert Zusammenfassungen,
 	// GetTrace die volle Aufzeichnung inkl. Schritte + Roh-Payload.
 	ListTraces(ctx context.Context, limit int) ([]Trace, error)
 	GetTrace(ctx context.Context, id int64) (*Trace, error)
 
+	// Bot-Outbox (Ausgang-Ansicht): ausgehende Gruppen-Nachrichten mit
+	// Zustellstatus, neueste zuerst.
+	ListOutbox(ctx context.Context, limit int) ([]OutboxMessage, error)
+
+	// Bot-Scheduler (Zeitplan-Ansicht): Jobs aus bot_job mit ihren letzten
+	// runsPerJob Läufen (neueste zuerst).
+	ListJobs(ctx context.Context, runsPerJob int) ([]Job, error)
+
 	// ML-Shadow-Modus (ml_messages): Gemini- vs. eigenes Modell-Label.
 	ListMLMessages(ctx context.Context, onlyDisagree bool, limit int) ([]MLMessage, error)
 	MLShadowStats(ctx context.Context) (MLShadowStats, error)
+	// MLRoutePolicy liefert die Canary-Policy des Bots (nil = noch keine,
+	// Bot ohne classifier-service). SetMLRouteKilled zieht bzw. löst den
+	// Kill-Switch und liefert die aktualisierte Policy.
+	MLRoutePolicy(ctx context.Context) (*MLRoutePolicy, error)
+	SetMLRouteKilled(ctx context.Context, killed bool) (*MLRoutePolicy, error)
 	// VerifyMLMessage markiert einen Eintrag als handgeprüft; correctedLabel
 	// ist das korrekte Label (nil = Gemini-Label war korrekt). Liefert die
 	// aktualisierte Zeile (UPDATE ... RETURNING).
@@ -111,6 +161,29 @@ type Store interface {
 	// LoescheStrafe ist ein Soft-Delete (status=geloescht): die Zeile bleibt
 	// als Reset-Marker erhalten, taucht aber nirgends mehr auf.
 	LoescheStrafe(ctx context.Context, id int64) error
+	// EntscheideEinspruch entscheidet über den offenen Einspruch gegen die
+	// Strafe id (eingelegt per Bot-Befehl): bestätigt lässt sie offen,
+	// aufgehoben löscht sie (soft) im selben Schritt.
+	EntscheideEinspruch(ctx context.Context, id int64, status penalty.EinspruchStatus, notiz string) error
+	// Strafen-Regelwerke (strafen_regelwerk), ältestes zuerst. SaveRuleSet
+	// legt an (ID == 0) bzw. ändert; das letzte Regelwerk lässt sich nicht
+	// löschen. Regelwerke, die schon gelten, sind unveränderlich: SaveRuleSet
+	// und DeleteRuleSet liefern dann penalty.ErrRuleSetInKraft.
+	ListRuleSets(ctx context.Context) (penalty.RuleSets, error)
+	SaveRuleSet(ctx context.Context, r penalty.RuleSet) error
+	DeleteRuleSet(ctx context.Context, id int64) error
+	// Kassenbuch (strafen_kasse), neueste Buchung zuerst. InsertBuchung mit
+	// begleicht=true setzt die Strafe b.StrafeID im selben Schritt auf
+	// beglichen (beglichen_am = b.Datum); DeleteBuchung storniert eine
+	// Fehlbuchung und setzt eine damit nicht mehr gedeckte Strafe wieder auf
+	// offen (betrag = bewerteter Betrag ihrer Strafe, für Fehltage).
+	ListBuchungen(ctx context.Context) ([]penalty.Buchung, error)
+	InsertBuchung(ctx context.Context, b penalty.Buchung, begleicht bool) error
+	DeleteBuchung(ctx context.Context, id int64, betrag int) error
+	// Mahnwesen: vom Bot verschickte Mahnungen (strafen_mahnung, älteste
+	// zuerst) und wer dabei nicht in der Gruppe erwähnt werden will.
+	ListMahnungen(ctx context.Context) ([]penalty.Mahnung, error)
+	ListMahnOptOuts(ctx context.Context) (map[string]bool, error)
 }
 
 // MLTestMessage ist ein manuell eingegebener Testfall aus dem Admin-UI.
@@ -136,6 +209,47 @@ type MLMessage struct {
 	Agree           *bool
 	Verified        bool
 	CorrectedLabel  *string
+	Route           string // Route der Entscheidung (RouteShadow …)
+}
+
+// Routen einer Entscheidung (ml_messages.route, Vertrag mit dem whatsapp-bot).
+const (
+	RouteShadow   = "shadow"        // Gemini entschied, Modell lief mit
+	RouteCanary   = "canary"        // Canary: das Modell entschied
+	RouteGated    = "canary-gemini" // Canary, Modell unsicher → Gemini entschied
+	RouteFallback = "fallback"      // Gemini ausgefallen, das Modell entschied
+)
+
+// ModelDecided sagt, ob auf dieser Route das Modell entschieden hat.
+func ModelDecided(route string) bool { return route == RouteCanary || route == RouteFallback }
+
+// DecidedLabel ist das Label, nach dem der Bot gehandelt hat.
+func (m MLMessage) DecidedLabel() string {
+	if ModelDecided(m.Route) && m.ModelLabel != nil {
+		return *m.ModelLabel
+	}
+	return m.GeminiLabel
+}
+
+// MLRoutePolicy ist die Canary-Policy aus ml_route_policy: den Anteil und die
+// Schwelle schreibt der Bot beim Start, den Kill-Switch das Admin-UI.
+type MLRoutePolicy struct {
+	Share         int // Prozent der Nachrichten im Canary (0 = aus)
+	MinConfidence float64
+	Killed        bool
+	KilledAt      *time.Time
+	UpdatedAt     time.Time
+}
+
+// MLRouteStat vergleicht eine Route: Übereinstimmung (wo beide Labels da
+// sind) und Korrekturen (geprüft, und das gehandelte Label war falsch).
+type MLRouteStat struct {
+	Route     string
+	Total     int
+	Compared  int // beide Labels vorhanden
+	Agree     int
+	Verified  int
+	Corrected int
 }
 
 // MLLabelStat aggregiert Übereinstimmung je Gemini-Label.
@@ -151,18 +265,25 @@ type MLShadowStats struct {
 	WithModel int // davon mit Modell-Antwort
 	Agree     int // davon Übereinstimmung Gemini == Modell
 	PerLabel  []MLLabelStat
+	PerRoute  []MLRouteStat
 }
 
 // Knoten-IDs des festen Bot-Flow-Graphen (Vertrag mit dem whatsapp-bot).
 const (
 	NodeReceived       = "received"
-	NodeCheckStatistik = "check_statistik"
-	NodeBuildStats     = "build_stats"
-	NodeSendStats      = "send_stats"
+	NodeUndo           = "undo_original"
+	NodeCheckStatistik = "check_statistik" // "Befehl?"
+	NodeCommand        = "command"         // Befehl ausführen
+	NodeBuildStats     = "build_stats"     // nur noch in alten Traces (heute NodeCommand)
+	NodeSendStats      = "send_stats"      // Antwort senden
 	NodeGuardType      = "guard_type"
 	NodeGuardGroup     = "guard_group"
-	NodeGuardThursday  = "guard_thursday"
+	NodeGuardThursday  = "guard_thursday" // nur noch in alten Traces (Tages-Guard entfallen)
+	NodeAnswer         = "clarify_answer" // Antwort auf eine Rückfrage des Bots
+	NodeRules          = "rules"          // Vorklassifikation (Regeltabelle)
 	NodeClassify       = "classify"
+	NodeContext        = "context" // Gesprächskontext (zweite Klassifikation)
+	NodeResolveDates   = "resolve_dates"
 	NodeMarkAbsent     = "mark_absent"
 	NodeMarkPresent    = "mark_present"
 	NodeNoAction       = "no_action"
@@ -186,10 +307,65 @@ type Trace struct {
 	UserName       string
 	Message        string
 	MessageType    string
-	Path           string // statistik | classify | ignored
+	Path           string // statistik | command | classify | revoke | ignored
 	Classification string // true | false | invalid | ""
 	Action         string
 	HasError       bool
 	RawPayload     string // hübsch eingerücktes JSON (nur in GetTrace befüllt)
 	Steps          []TraceStep
+
+	// ParentID zeigt bei Bearbeitung/Löschung auf den Trace der
+	// Original-Nachricht; FollowUps sind umgekehrt dessen Folge-Traces (nur
+	// in GetTrace befüllt).
+	ParentID  *int64
+	FollowUps []int64
+}
+
+// Job ist ein zeitgesteuerter Bot-Job aus bot_job (z. B. der Wochenreport).
+type Job struct {
+	Name        string
+	Cron        string
+	Payload     string // JSON
+	Enabled     bool
+	NextRunAt   time.Time
+	RetryAt     *time.Time // gesetzt = letzter Versuch gescheitert, Wiederholung geplant
+	Attempts    int
+	LockedBy    *string // Replica, die den Job gerade ausführt
+	LockedUntil *time.Time
+	Runs        []JobRun
+}
+
+// Running meldet, ob der Job gerade läuft (Lease noch gültig).
+func (j Job) Running(now time.Time) bool {
+	return j.LockedUntil != nil && j.LockedUntil.After(now)
+}
+
+// JobRun ist ein Lauf aus bot_job_run.
+type JobRun struct {
+	ID           int64
+	ScheduledFor time.Time
+	StartedAt    time.Time
+	FinishedAt   *time.Time
+	Status       string // running | ok | failed | skipped
+	Attempt      int
+	Runner       string
+	Detail       string
+	Error        *string
+}
+
+// OutboxMessage ist eine ausgehende Bot-Nachricht aus bot_outbox (Statistik,
+// Wochenreport) mit Zustellstatus.
+type OutboxMessage struct {
+	ID            int64
+	CreatedAt     time.Time
+	Source        string // statistik | wochenreport
+	Recipient     string
+	Text          string
+	Caption       string
+	HasImage      bool
+	Status        string // pending | sent | failed
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     *string
+	SentAt        *time.Time
 }
@@ -6,9 +6,11 @@ import (
 
 	"github.com/michael/zumba-shared/penalty"
 	sharedstore "github.com/michael/zumba-shared/store"
+
+	"github.com/michael/zumba-admin-ui/internal/timeutil"
 )
 
-// EnsureStrafenSchema legt die Strafen-Tabelle idempotent an (geteilte DDL im
+// EnsureStrafenSchema legt die Strafen-Tabellen idempotent an (geteilte DDL im
 // shared-Modul; der whatsapp-bot ruft dieselbe Funktion, Deploy-Reihenfolge
 // offen).
 func (s *Postgres) EnsureStrafenSchema(ctx context.Context) error {
@@ -34,3 +36,39 @@ func (s *Postgres) BegleicheStrafe(ctx context.Context, id int64) error {
 func (s *Postgres) LoescheStrafe(ctx context.Context, id int64) error {
 	return sharedstore.LoescheStrafe(ctx, s.db, id)
 }
+
+func (s *Postgres) EntscheideEinspruch(ctx context.Context, id int64, status penalty.EinspruchStatus, notiz string) error {
+	return sharedstore.EntscheideEinspruch(ctx, s.db, id, status, notiz)
+}
+
+func (s *Postgres) ListRuleSets(ctx context.Context) (penalty.RuleSets, error) {
+	return sharedstore.ListRuleSets(ctx, s.db)
+}
+
+func (s *Postgres) SaveRuleSet(ctx context.Context, r penalty.RuleSet) error {
+	return sharedstore.SaveRuleSet(ctx, s.db, r, timeutil.StartOfDay(time.Now()))
+}
+
+func (s *Postgres) DeleteRuleSet(ctx context.Context, id int64) error {
+	return sharedstore.DeleteRuleSet(ctx, s.db, id, timeutil.StartOfDay(time.Now()))
+}
+
+func (s *Postgres) ListBuchungen(ctx context.Context) ([]penalty.Buchung, error) {
+	return sharedstore.ListBuchungen(ctx, s.db)
+}
+
+func (s *Postgres) InsertBuchung(ctx context.Context, b penalty.Buchung, begleicht bool) error {
+	return sharedstore.InsertBuchung(ctx, s.db, b, begleicht)
+}
+
+func (s *Postgres) DeleteBuchung(ctx context.Context, id int64, betrag int) error {
+	return sharedstore.DeleteBuchung(ctx, s.db, id, betrag)
+}
+
+func (s *Postgres) ListMahnungen(ctx context.Context) ([]penalty.Mahnung, error) {
+	return sharedstore.ListMahnungen(ctx, s.db)
+}
+
+func (s *Postgres) ListMahnOptOuts(ctx context.Context) (map[string]bool, error) {
+	return sharedstore.MahnOptOuts(ctx, s.db)
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/michael/zumba-shared/domain"
+	"github.com/michael/zumba-admin-ui/internal/timeutil"
+	"github.com/michael/zumba-admin-ui/web/templates/members"
+)
+
+// Entschuldigte Zeiträume (Mitglieder-Seite): lange Krankheit, Elternzeit
+// o. ä. Treffen darin zählen für Strafen nicht als Fehltage.
+
+func (s *Server) handleAddEntschuldigung(w http.ResponseWriter, r *http.Request) {
+	invalid := func(msg string) {
+		s.triggerToast(w, "error", msg)
+		http.Error(w, msg, http.StatusUnprocessableEntity)
+	}
+	userID := r.PathValue("userId")
+	von, err := timeutil.ParseISO(r.FormValue("von"))
+	if err != nil {
+		invalid("Ungültiges Von-Datum.")
+		return
+	}
+	bis, err := timeutil.ParseISO(r.FormValue("bis"))
+	if err != nil {
+		invalid("Ungültiges Bis-Datum.")
+		return
+	}
+	e := domain.Entschuldigung{UserID: userID, Von: von, Bis: bis, Grund: strings.TrimSpace(r.FormValue("grund"))}
+	if err := domain.ValidateEntschuldigung(e); err != nil {
+		invalid("Zeitraum ungültig: " + err.Error())
+		return
+	}
+	if err := s.store.InsertEntschuldigung(r.Context(), e); err != nil {
+		s.fail(w, "insert entschuldigung", err)
+		return
+	}
+	s.triggerToast(w, "success", "Zeitraum entschuldigt.")
+	s.renderEntschuldigt(w, r, userID)
+}
+
+func (s *Server) handleDeleteEntschuldigung(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "ungültige ID", http.StatusUnprocessableEntity)
+		return
+	}
+	if err := s.store.DeleteEntschuldigung(r.Context(), id); err != nil {
+		s.fail(w, "delete entschuldigung", err)
+		return
+	}
+	s.triggerToast(w, "success", "Zeitraum entfernt.")
+	s.renderEntschuldigt(w, r, r.PathValue("userId"))
+}
+
+// renderEntschuldigt rendert nur die Zeitraum-Region (HTMX swap target).
+func (s *Server) renderEntschuldigt(w http.ResponseWriter, r *http.Request, userID string) {
+	all, err := s.store.ListEntschuldigungen(r.Context())
+	if err != nil {
+		s.fail(w, "entschuldigungen", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := members.EntschuldigtRegion(userID, all.Of(userID)).Render(r.Context(), w); err != nil {
+		log.Printf("render entschuldigt region: %v", err)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/michael/zumba-shared/domain"
+
 	"github.com/michael/zumba-admin-ui/internal/config"
 )
 
@@ -42,6 +44,26 @@ func TestPostExcludedRejectsNonThursday(t *testing.T) {
 	}
 }
 
+func TestPostExcludedFolgtSchedule(t *testing.T) {
+	spy := newSpyStore()
+	cfg := testCfg()
+	cfg.Schedule, _ = domain.ParseSchedule("di")
+	srv := New(spy, cfg, false)
+	for date, want := range map[string]int{"2026-01-06": http.StatusOK, "2026-01-01": http.StatusUnprocessableEntity} {
+		form := url.Values{"date": {date}}
+		req := httptest.NewRequest("POST", "/excluded", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		srv.Routes().ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s: code = %d, want %d", date, rec.Code, want)
+		}
+	}
+	if spy.insertedExcluded != "2026-01-06" {
+		t.Errorf("InsertExcludedDay nur für den Dienstag erwartet, got %q", spy.insertedExcluded)
+	}
+}
+
 func TestDeleteExcluded(t *testing.T) {
 	spy := newSpyStore()
 	srv := New(spy, testCfg(), false)
@@ -0,0 +1,170 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-admin-ui/internal/timeutil"
+	"github.com/michael/zumba-admin-ui/web/templates/kasse"
+)
+
+// Kassenbuch (/kasse): Kassenstand, Konten der Mitglieder und alle
+// Buchungen. Zahlungen auf Strafen entstehen auf der Strafen-Seite; hier
+// kommen sonstige Einnahmen und Ausgaben dazu.
+
+func (s *Server) kasseVM(ctx context.Context) (kasse.PageVM, error) {
+	l, err := s.strafenLage(ctx, true)
+	if err != nil {
+		return kasse.PageVM{}, err
+	}
+	vm := kasse.PageVM{
+		Kasse:  penalty.Kassenstand(l.in.Buchungen),
+		Konten: penalty.Konten(l.in, l.entries),
+		Users:  l.users,
+		Heute:  l.stichtag,
+	}
+	for _, k := range vm.Konten {
+		vm.Offen += k.Offen
+	}
+	// Mahnstufe je Mitglied: was der Bot für die aktuell älteste offene
+	// Strafe schon verschickt hat (Stufen-Tage braucht es dafür nicht).
+	mahnungen, err := s.store.ListMahnungen(ctx)
+	if err != nil {
+		return kasse.PageVM{}, err
+	}
+	if vm.MahnOptOut, err = s.store.ListMahnOptOuts(ctx); err != nil {
+		return kasse.PageVM{}, err
+	}
+	vm.Mahnstufe = make(map[string]int)
+	for _, m := range (penalty.Mahnwesen{}).Lage(l.entries, mahnungen, l.stichtag) {
+		if m.Gemahnt > 0 {
+			vm.Mahnstufe[m.UserID] = m.Gemahnt
+		}
+	}
+	names := make(map[string]string, len(l.users))
+	for _, u := range l.users {
+		names[u.ID] = u.Name
+	}
+	strafen := make(map[int64]penalty.Entry, len(l.entries))
+	for _, e := range l.entries {
+		strafen[e.ID] = e
+	}
+	for _, b := range l.in.Buchungen {
+		row := kasse.Row{Buchung: b, UserName: names[b.UserID]}
+		if e, ok := strafen[b.StrafeID]; ok {
+			row.Strafe = strafeText(e)
+		}
+		vm.Rows = append(vm.Rows, row)
+	}
+	return vm, nil
+}
+
+// strafeText beschreibt die Strafe einer Zahlung in der Buchungsliste.
+func strafeText(e penalty.Entry) string {
+	switch e.Art {
+	case penalty.ArtNoShow:
+		return "No-Show " + timeutil.FormatDEShort(e.Datum)
+	case penalty.ArtMahngebuehr:
+		return "Mahngebühr " + timeutil.FormatDEShort(e.Datum)
+	}
+	return fmt.Sprintf("%d Fehltage ab %s", e.Tage, timeutil.FormatDEShort(e.Datum))
+}
+
+func (s *Server) handleKasse(w http.ResponseWriter, r *http.Request) {
+	vm, err := s.kasseVM(r.Context())
+	if err != nil {
+		s.fail(w, "kasse", err)
+		return
+	}
+	s.render(w, r, s.meta("Kassenbuch", "kasse"), kasse.Page(vm))
+}
+
+// handleAddBuchung bucht eine Einnahme ohne Strafe (z. B. Übertrag aus der
+// Bargeld-Kasse) oder eine Ausgabe.
+func (s *Server) handleAddBuchung(w http.ResponseWriter, r *http.Request) {
+	invalid := func(msg string) {
+		s.triggerToast(w, "error", msg)
+		http.Error(w, msg, http.StatusUnprocessableEntity)
+	}
+	datum, err := timeutil.ParseISO(r.FormValue("datum"))
+	if err != nil {
+		invalid("Ungültiges Datum.")
+		return
+	}
+	betrag, err := strconv.Atoi(strings.TrimSpace(r.FormValue("betrag")))
+	if err != nil {
+		invalid("Ungültiger Betrag.")
+		return
+	}
+	b := penalty.Buchung{
+		Art:    penalty.BuchungArt(r.FormValue("art")),
+		UserID: r.FormValue("userId"),
+		Betrag: betrag,
+		Datum:  datum,
+		Notiz:  strings.TrimSpace(r.FormValue("notiz")),
+	}
+	if b.Art == penalty.BuchungAusgabe {
+		b.UserID = ""
+	}
+	if err := penalty.ValidateBuchung(b); err != nil {
+		invalid("Buchung ungültig: " + err.Error())
+		return
+	}
+	if err := s.store.InsertBuchung(r.Context(), b, false); err != nil {
+		s.fail(w, "insert buchung", err)
+		return
+	}
+	s.triggerToast(w, "success", "Gebucht.")
+	s.renderKassePage(w, r)
+}
+
+func (s *Server) handleDeleteBuchung(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "ungültige ID", http.StatusUnprocessableEntity)
+		return
+	}
+	// Bewerteter Betrag der Strafe der Buchung: deckt der Rest ihn nicht
+	// mehr, ist die Strafe wieder offen (Fehltage-Beträge stehen nicht in
+	// der Zeile).
+	l, err := s.strafenLage(r.Context(), false)
+	if err != nil {
+		s.fail(w, "strafen", err)
+		return
+	}
+	var betrag int
+	for _, b := range l.in.Buchungen {
+		if b.ID != id || b.StrafeID == 0 {
+			continue
+		}
+		for _, e := range l.entries {
+			if e.ID == b.StrafeID {
+				betrag = e.Betrag
+			}
+		}
+	}
+	if err := s.store.DeleteBuchung(r.Context(), id, betrag); err != nil {
+		s.fail(w, "delete buchung", err)
+		return
+	}
+	s.triggerToast(w, "success", "Buchung storniert.")
+	s.renderKassePage(w, r)
+}
+
+// renderKassePage rendert die Seite ohne Layout (HTMX-Swap-Ziel).
+func (s *Server) renderKassePage(w http.ResponseWriter, r *http.Request) {
+	vm, err := s.kasseVM(r.Context())
+	if err != nil {
+		s.fail(w, "kasse", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := kasse.Page(vm).Render(r.Context(), w); err != nil {
+		log.Printf("render kasse: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/michael/zumba-shared/penalty"
+)
+
+func TestKassePageRendert(t *testing.T) {
+	spy := newSpyStore()
+	_ = spy.InsertNoShowStrafe(context.TODO(), "u01", mustDate("2026-01-01"), 50)
+	spy.buchungen = []penalty.Buchung{
+		{ID: 1, Art: penalty.BuchungZahlung, UserID: "u01", StrafeID: 1, Betrag: 20, Datum: mustDate("2026-01-08")},
+		{ID: 2, Art: penalty.BuchungAusgabe, Betrag: 15, Datum: mustDate("2026-01-09"), Notiz: "Runde"},
+	}
+	srv := New(spy, testCfg(), false).Routes()
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/kasse", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"Kassenbuch", "5€", "30€ offen · 20€ eingezahlt", "No-Show", "Runde"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Seite ohne %q", want)
+		}
+	}
+}
+
+func TestKasseZeigtMahnstufe(t *testing.T) {
+	spy := newSpyStore()
+	_ = spy.InsertNoShowStrafe(context.TODO(), "u01", mustDate("2026-01-01"), 50)
+	spy.mahnungen = []penalty.Mahnung{
+		{UserID: "u01", Stufe: 1, StrafeID: 1},
+		{UserID: "u01", Stufe: 2, StrafeID: 1},
+		{UserID: "u01", Stufe: 3, StrafeID: 99}, // beglichener Anker von früher
+	}
+	spy.mahnOptOut = map[string]bool{"u01": true}
+	srv := New(spy, testCfg(), false).Routes()
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/kasse", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "📨 2. Mahnung") || !strings.Contains(body, "🔕") {
+		t.Errorf("Mahnstufe fehlt:\n%s", body)
+	}
+}
+
+func TestAddBuchung(t *testing.T) {
+	spy := newSpyStore()
+	srv := New(spy, testCfg(), false).Routes()
+	rec := postForm(t, srv, "/kasse", url.Values{
+		"art": {"ausgabe"}, "userId": {"u01"}, "betrag": {"80"}, "datum": {"2026-12-17"}, "notiz": {"Weihnachtsfeier"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code = %d (%s)", rec.Code, rec.Body.String())
+	}
+	if len(spy.buchungen) != 1 || spy.buchungen[0].Art != penalty.BuchungAusgabe || spy.buchungen[0].UserID != "" {
+		t.Errorf("Ausgabe nicht (ohne Mitglied) gebucht: %+v", spy.buchungen)
+	}
+
+	rec = postForm(t, srv, "/kasse", url.Values{"art": {"ausgabe"}, "betrag": {"10"}, "datum": {"2026-12-17"}})
+	if rec.Code != http.StatusUnprocessableEntity || len(spy.buchungen) != 1 {
+		t.Errorf("Ausgabe ohne Notiz: code=%d, buchungen=%d", rec.Code, len(spy.buchungen))
+	}
+}
+
+func TestDeleteBuchung(t *testing.T) {
+	spy := newSpyStore()
+	srv := New(spy, testCfg(), false).Routes()
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("DELETE", "/kasse/7", nil))
+	if rec.Code != http.StatusOK || spy.deletedBuchung != 7 {
+		t.Errorf("stornieren: code=%d, id=%d", rec.Code, spy.deletedBuchung)
+	}
+}
+
+// Storniert man die Zahlung, mit der eine Fehltage-Strafe beglichen wurde,
+// ist sie wieder offen – der Betrag (25€) kommt aus der Bewertung.
+func TestDeleteBuchungOeffnetStrafe(t *testing.T) {
+	spy := newSpyStore()
+	for _, d := range []string{"2026-01-01", "2026-01-08", "2026-01-15", "2026-01-22", "2026-01-29"} {
+		_ = spy.InsertAbsence(context.TODO(), "u01", mustDate(d), nil)
+	}
+	_ = spy.InsertAutoStrafe(context.TODO(), "u01", mustDate("2026-01-01"))
+	b := penalty.Buchung{Art: penalty.BuchungZahlung, UserID: "u01", StrafeID: 1, Betrag: 25, Datum: mustDate("2026-01-29")}
+	_ = spy.InsertBuchung(context.TODO(), b, true)
+	srv := New(spy, testCfg(), false).Routes()
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("DELETE", "/kasse/1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code = %d (%s)", rec.Code, rec.Body.String())
+	}
+	if s := spy.strafen[0]; s.Status != penalty.StatusOffen || s.BeglichenAm != nil {
+		t.Errorf("Strafe nach Storno: %+v", s)
+	}
+}
@@ -0,0 +1,84 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func postAlias(srv *Server, userID, alias string) *httptest.ResponseRecorder {
+	form := url.Values{"alias": {alias}}
+	req := httptest.NewRequest("POST", "/members/"+userID+"/aliases", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAliasAnlegenUndEntfernen(t *testing.T) {
+	spy := newSpyStore()
+	srv := New(spy, testCfg(), false)
+
+	rec := postAlias(srv, "u01", "  Maxl ")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "maxl") {
+		t.Fatalf("code = %d, body:\n%s", rec.Code, rec.Body.String())
+	}
+	if len(spy.aliases) != 1 || spy.aliases[0] != "u01|maxl" {
+		t.Errorf("aliases = %v", spy.aliases)
+	}
+
+	// Schon vergeben (auch für ein anderes Mitglied) → 409, nichts angelegt.
+	if rec := postAlias(srv, "u02", "MAXL"); rec.Code != http.StatusConflict {
+		t.Errorf("doppelt: code = %d, want 409", rec.Code)
+	}
+
+	req := httptest.NewRequest("DELETE", "/members/u01/aliases/maxl", nil)
+	rec = httptest.NewRecorder()
+	srv.Routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || len(spy.aliases) != 0 {
+		t.Errorf("löschen: code = %d, aliases = %v", rec.Code, spy.aliases)
+	}
+}
+
+func TestAliasLeerAbgelehnt(t *testing.T) {
+	spy := newSpyStore()
+	srv := New(spy, testCfg(), false)
+	if rec := postAlias(srv, "u01", "   "); rec.Code != http.StatusUnprocessableEntity || len(spy.aliases) != 0 {
+		t.Errorf("code = %d, aliases = %v", rec.Code, spy.aliases)
+	}
+}
+
+func TestEntschuldigungAnlegenUndEntfernen(t *testing.T) {
+	spy := newSpyStore()
+	srv := New(spy, testCfg(), false).Routes()
+
+	rec := postForm(t, srv, "/members/u01/entschuldigt", url.Values{
+		"von": {"2026-03-01"}, "bis": {"2026-04-15"}, "grund": {" Reha "},
+	})
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Reha") {
+		t.Fatalf("code = %d, body:\n%s", rec.Code, rec.Body.String())
+	}
+	if len(spy.entschuldigt) != 1 || spy.entschuldigt[0].UserID != "u01" || spy.entschuldigt[0].Grund != "Reha" {
+		t.Errorf("entschuldigt = %+v", spy.entschuldigt)
+	}
+
+	req := httptest.NewRequest("DELETE", "/members/u01/entschuldigt/1", nil)
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || spy.deletedEntschuldigt != 1 {
+		t.Errorf("löschen: code = %d, id = %d", rec.Code, spy.deletedEntschuldigt)
+	}
+}
+
+func TestEntschuldigungUngueltig(t *testing.T) {
+	spy := newSpyStore()
+	srv := New(spy, testCfg(), false).Routes()
+	rec := postForm(t, srv, "/members/u01/entschuldigt", url.Values{
+		"von": {"2026-04-15"}, "bis": {"2026-03-01"}, "grund": {"Reha"},
+	})
+	if rec.Code != http.StatusUnprocessableEntity || len(spy.entschuldigt) != 0 {
+		t.Errorf("code = %d, entschuldigt = %+v", rec.Code, spy.entschuldigt)
+	}
+}
@@ -26,7 +26,12 @@ func (s *Server) handleMLShadow(w http.ResponseWriter, r *http.Request) {
 		s.fail(w, "ml messages", err)
 		return
 	}
-	vm := mlshadow.VM{Stats: stats, Messages: msgs, OnlyDisagree: onlyDisagree}
+	policy, err := s.store.MLRoutePolicy(ctx)
+	if err != nil {
+		s.fail(w, "ml route policy", err)
+		return
+	}
+	vm := mlshadow.VM{Stats: stats, Messages: msgs, OnlyDisagree: onlyDisagree, Policy: policy}
 	s.render(w, r, s.meta("ML-Shadow", "mlshadow"), mlshadow.Page(vm))
 }
 
@@ -60,6 +65,30 @@ func (s *Server) handleMLVerify(w http.ResponseWriter, r *http.Request) {
 	_ = mlshadow.Row(*m).Render(ctx, w)
 }
 
+// handleMLCanary zieht bzw. löst den Kill-Switch des Canary-Routings. Der
+// Bot liest ihn bei jeder Nachricht im Canary – ohne Neustart.
+func (s *Server) handleMLCanary(w http.ResponseWriter, r *http.Request) {
+	killed, err := strconv.ParseBool(r.URL.Query().Get("killed"))
+	if err != nil {
+		s.triggerToast(w, "error", "Ungültiger Wert.")
+		http.Error(w, "bad killed", http.StatusBadRequest)
+		return
+	}
+	p, err := s.store.SetMLRouteKilled(r.Context(), killed)
+	if err != nil {
+		s.triggerToast(w, "error", "Speichern fehlgeschlagen.")
+		s.fail(w, "ml canary", err)
+		return
+	}
+	if killed {
+		s.triggerToast(w, "success", "Kill-Switch gezogen – Gemini entscheidet alles.")
+	} else {
+		s.triggerToast(w, "success", "Canary wieder freigegeben.")
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = mlshadow.Canary(*p).Render(r.Context(), w)
+}
+
 func (s *Server) handleMLDocs(w http.ResponseWriter, r *http.Request) {
 	s.render(w, r, s.meta("ML-Doku", "mldocs"), mldocs.Page())
 }
@@ -0,0 +1,51 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/michael/zumba-admin-ui/internal/store"
+)
+
+func TestCanaryKillSwitch(t *testing.T) {
+	spy := newSpyStore()
+	srv := New(spy, testCfg(), false)
+
+	rec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(rec, httptest.NewRequest("POST", "/ml-shadow/canary?killed=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code = %d, want 200", rec.Code)
+	}
+	if len(spy.routeKilled) != 1 || !spy.routeKilled[0] {
+		t.Errorf("SetMLRouteKilled: %v, want [true]", spy.routeKilled)
+	}
+	if !strings.Contains(rec.Body.String(), "wieder freigeben") {
+		t.Errorf("Partial ohne Freigabe-Button: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	srv.Routes().ServeHTTP(rec, httptest.NewRequest("POST", "/ml-shadow/canary?killed=vielleicht", nil))
+	if rec.Code != http.StatusBadRequest || len(spy.routeKilled) != 1 {
+		t.Errorf("ungültiger Wert: code %d, Aufrufe %v", rec.Code, spy.routeKilled)
+	}
+}
+
+func TestMLShadowZeigtCanary(t *testing.T) {
+	spy := newSpyStore()
+	srv := New(spy, testCfg(), false)
+
+	rec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(rec, httptest.NewRequest("GET", "/ml-shadow", nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "Kill-Switch") {
+		t.Errorf("ohne Policy: code %d, Kill-Switch sichtbar", rec.Code)
+	}
+
+	spy.routePolicy = &store.MLRoutePolicy{Share: 20, MinConfidence: 0.9}
+	rec = httptest.NewRecorder()
+	srv.Routes().ServeHTTP(rec, httptest.NewRequest("GET", "/ml-shadow", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "20 % der Nachrichten") || !strings.Contains(body, "Kill-Switch") {
+		t.Errorf("mit Policy: Canary-Panel fehlt")
+	}
+}
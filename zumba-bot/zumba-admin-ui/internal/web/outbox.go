@@ -0,0 +1,29 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/michael/zumba-admin-ui/web/templates/jobs"
+	"github.com/michael/zumba-admin-ui/web/templates/outbox"
+)
+
+// handleOutbox zeigt die ausgehenden Bot-Nachrichten mit Zustellstatus.
+func (s *Server) handleOutbox(w http.ResponseWriter, r *http.Request) {
+	msgs, err := s.store.ListOutbox(r.Context(), 100)
+	if err != nil {
+		s.fail(w, "ListOutbox", err)
+		return
+	}
+	s.render(w, r, s.meta("Ausgang", "outbox"), outbox.List(msgs))
+}
+
+// handleJobs zeigt die zeitgesteuerten Bot-Jobs mit ihren letzten Läufen.
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	list, err := s.store.ListJobs(r.Context(), 10)
+	if err != nil {
+		s.fail(w, "ListJobs", err)
+		return
+	}
+	s.render(w, r, s.meta("Zeitplan", "jobs"), jobs.List(list, time.Now()))
+}
@@ -0,0 +1,156 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	sharedrules "github.com/michael/zumba-shared/rules"
+
+	"github.com/michael/zumba-admin-ui/web/templates/rules"
+)
+
+// Vorklassifikation (/rules): Regeln pflegen, gegen den geprüften Korpus
+// testen und erst dann einschalten.
+
+func (s *Server) handleRules(w http.ResponseWriter, r *http.Request) {
+	vm, err := s.rulesVM(r.Context())
+	if err != nil {
+		s.fail(w, "rules", err)
+		return
+	}
+	s.render(w, r, s.meta("Regeln", "rules"), rules.Page(vm))
+}
+
+// handleRuleTest prüft die Regel aus dem Formular gegen den Korpus, ohne sie
+// zu speichern.
+func (s *Server) handleRuleTest(w http.ResponseWriter, r *http.Request) {
+	rule, ok := s.ruleForm(w, r)
+	if !ok {
+		return
+	}
+	corpus, err := s.store.VerifiedCorpus(r.Context())
+	if err != nil {
+		s.fail(w, "corpus", err)
+		return
+	}
+	rep, err := sharedrules.Evaluate(rule, corpus)
+	if err != nil {
+		s.triggerToast(w, "error", "Regel ungültig: "+err.Error())
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = rules.Report(rule, rep).Render(r.Context(), w)
+}
+
+// handleAddRule legt die Regel ausgeschaltet an.
+func (s *Server) handleAddRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := s.ruleForm(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.store.InsertRule(r.Context(), rule); err != nil {
+		s.triggerToast(w, "error", "Speichern fehlgeschlagen.")
+		s.fail(w, "insert rule", err)
+		return
+	}
+	s.triggerToast(w, "success", "Regel angelegt (ausgeschaltet).")
+	s.renderRuleList(w, r)
+}
+
+func (s *Server) handleRuleEnabled(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "bad id", http.StatusBadRequest)
+		return
+	}
+	enabled := r.FormValue("enabled") == "true"
+	if err := s.store.SetRuleEnabled(r.Context(), id, enabled); err != nil {
+		s.fail(w, "rule enabled", err)
+		return
+	}
+	if enabled {
+		s.triggerToast(w, "success", "Regel eingeschaltet – der Bot übernimmt sie binnen einer Minute.")
+	} else {
+		s.triggerToast(w, "success", "Regel ausgeschaltet.")
+	}
+	s.renderRuleList(w, r)
+}
+
+func (s *Server) handleDeleteRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "bad id", http.StatusBadRequest)
+		return
+	}
+	if err := s.store.DeleteRule(r.Context(), id); err != nil {
+		s.fail(w, "delete rule", err)
+		return
+	}
+	s.triggerToast(w, "success", "Regel gelöscht.")
+	s.renderRuleList(w, r)
+}
+
+// ruleForm liest und validiert die Regel aus dem Formular; bei Fehlern ist
+// die Antwort schon geschrieben.
+func (s *Server) ruleForm(w http.ResponseWriter, r *http.Request) (sharedrules.Rule, bool) {
+	rule := sharedrules.Rule{
+		Kind:     r.FormValue("kind"),
+		Pattern:  strings.TrimSpace(r.FormValue("pattern")),
+		Label:    r.FormValue("label"),
+		Priority: 100,
+		Note:     strings.TrimSpace(r.FormValue("note")),
+	}
+	if p := strings.TrimSpace(r.FormValue("priority")); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			s.triggerToast(w, "error", "Priorität muss eine Zahl sein.")
+			http.Error(w, "bad priority", http.StatusUnprocessableEntity)
+			return rule, false
+		}
+		rule.Priority = n
+	}
+	if err := sharedrules.Validate(rule); err != nil {
+		s.triggerToast(w, "error", "Regel ungültig: "+err.Error())
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return rule, false
+	}
+	return rule, true
+}
+
+// renderRuleList rendert nur die Regelliste (HTMX swap target).
+func (s *Server) renderRuleList(w http.ResponseWriter, r *http.Request) {
+	vm, err := s.rulesVM(r.Context())
+	if err != nil {
+		s.fail(w, "rules", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = rules.List(vm).Render(r.Context(), w)
+}
+
+// rulesVM lädt die Regeln und wertet jede gegen den Korpus aus – so sieht
+// man vor dem Einschalten, was sie träfe.
+func (s *Server) rulesVM(ctx context.Context) (rules.PageVM, error) {
+	all, err := s.store.ListRules(ctx)
+	if err != nil {
+		return rules.PageVM{}, err
+	}
+	corpus, err := s.store.VerifiedCorpus(ctx)
+	if err != nil {
+		return rules.PageVM{}, err
+	}
+	vm := rules.PageVM{CorpusSize: len(corpus)}
+	for _, rule := range all {
+		row := rules.Row{Rule: rule}
+		if rep, err := sharedrules.Evaluate(rule, corpus); err != nil {
+			row.Err = err.Error()
+		} else {
+			row.Report = rep
+		}
+		vm.Rows = append(vm.Rows, row)
+	}
+	return vm, nil
+}
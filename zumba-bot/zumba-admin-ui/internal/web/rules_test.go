@@ -0,0 +1,74 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/michael/zumba-shared/rules"
+)
+
+func postRuleForm(srv *Server, path string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRegelTestenGegenKorpus(t *testing.T) {
+	spy := newSpyStore()
+	spy.corpus = []rules.Sample{
+		{Message: "👎", Label: "false"},
+		{Message: "👎", Label: "invalid"},
+		{Message: "bin dabei", Label: "true"},
+	}
+	srv := New(spy, testCfg(), false)
+
+	rec := postRuleForm(srv, "/rules/test", url.Values{"kind": {"emoji"}, "pattern": {"👎"}, "label": {"false"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code = %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "2/3 erfasst, 1 falsch") {
+		t.Errorf("Report:\n%s", body)
+	}
+	if len(spy.rules) != 0 {
+		t.Error("Testen darf nichts speichern")
+	}
+}
+
+func TestRegelAnlegenAusgeschaltet(t *testing.T) {
+	spy := newSpyStore()
+	srv := New(spy, testCfg(), false)
+
+	rec := postRuleForm(srv, "/rules", url.Values{"kind": {"exact"}, "pattern": {"bin raus"}, "label": {"false"}, "priority": {"20"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code = %d", rec.Code)
+	}
+	if len(spy.rules) != 1 || spy.rules[0].Enabled || spy.rules[0].Priority != 20 {
+		t.Fatalf("rules = %+v", spy.rules)
+	}
+
+	if rec := postRuleForm(srv, "/rules/1/enabled", url.Values{"enabled": {"true"}}); rec.Code != http.StatusOK || !spy.ruleEnabled[1] {
+		t.Errorf("einschalten: code = %d, enabled = %v", rec.Code, spy.ruleEnabled)
+	}
+}
+
+func TestUngueltigeRegelAbgelehnt(t *testing.T) {
+	spy := newSpyStore()
+	srv := New(spy, testCfg(), false)
+	for _, form := range []url.Values{
+		{"kind": {"regex"}, "pattern": {"("}, "label": {"false"}},
+		{"kind": {"exact"}, "pattern": {"bin raus"}, "label": {"vielleicht"}},
+		{"kind": {"emoji"}, "pattern": {"👎"}, "label": {"false"}, "priority": {"hoch"}},
+	} {
+		if rec := postRuleForm(srv, "/rules", form); rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%v: code = %d, want 422", form, rec.Code)
+		}
+	}
+	if len(spy.rules) != 0 {
+		t.Errorf("rules = %+v", spy.rules)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/a-h/templ"
+	sharedstore "github.com/michael/zumba-shared/store"
 
 	"github.com/michael/zumba-admin-ui/assets"
 	"github.com/michael/zumba-admin-ui/internal/config"
@@ -56,6 +58,10 @@ func (s *Server) Routes() http.Handler {
 	mux.HandleFunc("GET /dashboard", s.handleDashboard)
 	mux.HandleFunc("GET /members", s.handleMembers)
 	mux.HandleFunc("GET /members/{userId}", s.handleMemberDetail)
+	mux.HandleFunc("POST /members/{userId}/aliases", s.handleAddAlias)
+	mux.HandleFunc("DELETE /members/{userId}/aliases/{alias}", s.handleDeleteAlias)
+	mux.HandleFunc("POST /members/{userId}/entschuldigt", s.handleAddEntschuldigung)
+	mux.HandleFunc("DELETE /members/{userId}/entschuldigt/{id}", s.handleDeleteEntschuldigung)
 	mux.HandleFunc("GET /days", s.handleDays)
 	mux.HandleFunc("GET /days/{date}", s.handleDayDetail)
 	mux.HandleFunc("GET /excluded", s.handleExcluded)
@@ -65,14 +71,30 @@ func (s *Server) Routes() http.Handler {
 	mux.HandleFunc("GET /strafen", s.handleStrafen)
 	mux.HandleFunc("POST /strafen", s.handleAddStrafe)
 	mux.HandleFunc("POST /strafen/{id}/begleichen", s.handleBegleicheStrafe)
+	mux.HandleFunc("POST /strafen/{id}/zahlung", s.handleZahlungStrafe)
+	mux.HandleFunc("POST /strafen/{id}/einspruch", s.handleEinspruch)
 	mux.HandleFunc("DELETE /strafen/{id}", s.handleDeleteStrafe)
+	mux.HandleFunc("POST /regelwerk", s.handleSaveRuleSet)
+	mux.HandleFunc("POST /regelwerk/{id}", s.handleSaveRuleSet)
+	mux.HandleFunc("DELETE /regelwerk/{id}", s.handleDeleteRuleSet)
+	mux.HandleFunc("GET /kasse", s.handleKasse)
+	mux.HandleFunc("POST /kasse", s.handleAddBuchung)
+	mux.HandleFunc("DELETE /kasse/{id}", s.handleDeleteBuchung)
 	mux.HandleFunc("GET /bot-test", s.handleBotTest)
 	mux.HandleFunc("GET /bot-test/example/{kind}", s.handleBotTestExample)
 	mux.HandleFunc("POST /bot-test/run", s.handleBotTestRun)
 	mux.HandleFunc("GET /trace", s.handleTraceList)
 	mux.HandleFunc("GET /trace/{id}", s.handleTraceDetail)
+	mux.HandleFunc("GET /outbox", s.handleOutbox)
+	mux.HandleFunc("GET /jobs", s.handleJobs)
 	mux.HandleFunc("GET /ml-shadow", s.handleMLShadow)
 	mux.HandleFunc("POST /ml-shadow/verify/{id}", s.handleMLVerify)
+	mux.HandleFunc("POST /ml-shadow/canary", s.handleMLCanary)
+	mux.HandleFunc("GET /rules", s.handleRules)
+	mux.HandleFunc("POST /rules", s.handleAddRule)
+	mux.HandleFunc("POST /rules/test", s.handleRuleTest)
+	mux.HandleFunc("POST /rules/{id}/enabled", s.handleRuleEnabled)
+	mux.HandleFunc("DELETE /rules/{id}", s.handleDeleteRule)
 	mux.HandleFunc("GET /ml-test", s.handleMLTest)
 	mux.HandleFunc("POST /ml-test/run", s.handleMLTestRun)
 	mux.HandleFunc("POST /ml-test/judge/{id}", s.handleMLTestJudge)
@@ -111,12 +133,23 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	strip, err := s.buildStrip(ctx, period, 0, len(board)) // alle Donnerstage – auf dem Dashboard auswählbar
+	strip, err := s.buildStrip(ctx, period, 0, len(board)) // alle Termine – auf dem Dashboard auswählbar
 	if err != nil {
 		s.fail(w, "strip", err)
 		return
 	}
 
+	rosterDate, err := s.nextMeeting(ctx)
+	if err != nil {
+		s.fail(w, "next meeting", err)
+		return
+	}
+	roster, err := s.store.Roster(ctx, rosterDate)
+	if err != nil {
+		s.fail(w, "roster", err)
+		return
+	}
+
 	totalThursdays := 0
 	totalAtt := 0
 	totalAbs := 0
@@ -144,11 +177,28 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		AverageRate:      avgRate,
 		StripItems:       strip,
 		Leaderboard:      board,
+		RosterDate:       rosterDate,
+		Roster:           dashboard.SortRoster(roster),
 	}
 
 	s.render(w, r, s.meta("Dashboard", "dashboard"), dashboard.Page(vm))
 }
 
+// nextMeeting liefert das nächste Treffen ab heute (heute eingeschlossen),
+// Sperrtage übersprungen – wie der Bot-Befehl "wer kommt" ohne Datum.
+func (s *Server) nextMeeting(ctx context.Context) (time.Time, error) {
+	sched := s.cfg.Schedule.OrDefault()
+	d := sched.OnOrAfter(time.Now())
+	for range 53 {
+		excluded, err := s.store.IsExcludedDay(ctx, d)
+		if err != nil || !excluded {
+			return d, err
+		}
+		d = sched.Next(d)
+	}
+	return d, nil
+}
+
 // Mitglieder sind jetzt direkt im Dashboard integriert; alte /members-Links umleiten.
 func (s *Server) handleMembers(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/dashboard", http.StatusMovedPermanently)
@@ -185,20 +235,95 @@ func (s *Server) handleMemberDetail(w http.ResponseWriter, r *http.Request) {
 		s.fail(w, "absences", err)
 		return
 	}
-	absenceMap := make(map[string]*string, len(absences))
+	absenceMap := make(map[string]store.Absence, len(absences))
 	for _, a := range absences {
-		absenceMap[timeutil.FormatISO(a.Date)] = a.Message
+		absenceMap[timeutil.FormatISO(a.Date)] = a
+	}
+	aliases, err := s.store.ListAliases(ctx, userId)
+	if err != nil {
+		s.fail(w, "aliases", err)
+		return
+	}
+	users, err := s.store.ListUsers(ctx)
+	if err != nil {
+		s.fail(w, "users", err)
+		return
+	}
+	names := make(map[string]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+	excused, err := s.store.ListEntschuldigungen(ctx)
+	if err != nil {
+		s.fail(w, "entschuldigungen", err)
+		return
 	}
 
 	entries := make([]members.DetailEntry, 0, len(thursdays))
 	for _, t := range thursdays {
 		key := timeutil.FormatISO(t)
-		msg, absent := absenceMap[key]
-		entries = append(entries, members.DetailEntry{Date: t, Absent: absent, Message: msg})
+		a, absent := absenceMap[key]
+		e := members.DetailEntry{Date: t, Absent: absent, Message: a.Message}
+		if x, ok := excused.Covering(userId, t); ok {
+			e.Excused = x.Grund
+		}
+		if a.EnteredBy != nil {
+			e.EnteredBy = names[*a.EnteredBy]
+			if e.EnteredBy == "" {
+				e.EnteredBy = *a.EnteredBy
+			}
+		}
+		entries = append(entries, e)
 	}
 
 	s.render(w, r, s.meta(user.Name, "dashboard"),
-		members.Detail(members.DetailVM{User: *user, Stats: stats, Entries: entries}))
+		members.Detail(members.DetailVM{User: *user, Stats: stats, Entries: entries, Aliases: aliases,
+			Entschuldigt: excused.Of(userId)}))
+}
+
+func (s *Server) handleAddAlias(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("userId")
+	alias := sharedstore.NormalizeAlias(r.FormValue("alias"))
+	if alias == "" {
+		s.triggerToast(w, "error", "Bitte einen Spitznamen eingeben.")
+		http.Error(w, "leerer Spitzname", http.StatusUnprocessableEntity)
+		return
+	}
+	err := s.store.AddAlias(r.Context(), userID, alias)
+	if errors.Is(err, sharedstore.ErrAliasTaken) {
+		s.triggerToast(w, "error", "„"+alias+"“ ist schon vergeben.")
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		s.fail(w, "add alias", err)
+		return
+	}
+	s.triggerToast(w, "success", "Spitzname angelegt.")
+	s.renderAliases(w, r, userID)
+}
+
+func (s *Server) handleDeleteAlias(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("userId")
+	if err := s.store.DeleteAlias(r.Context(), userID, r.PathValue("alias")); err != nil {
+		s.fail(w, "delete alias", err)
+		return
+	}
+	s.triggerToast(w, "success", "Spitzname entfernt.")
+	s.renderAliases(w, r, userID)
+}
+
+// renderAliases rendert nur die Spitznamen-Region (HTMX swap target).
+func (s *Server) renderAliases(w http.ResponseWriter, r *http.Request, userID string) {
+	aliases, err := s.store.ListAliases(r.Context(), userID)
+	if err != nil {
+		s.fail(w, "aliases", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := members.AliasRegion(userID, aliases).Render(r.Context(), w); err != nil {
+		log.Printf("render alias region: %v", err)
+	}
 }
 
 func (s *Server) handleDays(w http.ResponseWriter, r *http.Request) {
@@ -231,7 +356,7 @@ func (s *Server) handleDays(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	s.render(w, r, s.meta("Donnerstage", "days"),
+	s.render(w, r, s.meta("Termine", "days"),
 		days.List(days.ListVM{StripItems: strip, Days: cards, TotalUsers: len(users)}))
 }
 
@@ -262,6 +387,11 @@ func (s *Server) handleDayDetail(w http.ResponseWriter, r *http.Request) {
 			s.fail(w, "absences", err)
 			return
 		}
+		excused, err := s.store.ListEntschuldigungen(ctx)
+		if err != nil {
+			s.fail(w, "entschuldigungen", err)
+			return
+		}
 		absMap := make(map[string]*string, len(dayAbsences))
 		for _, a := range dayAbsences {
 			absMap[a.UserID] = a.Message
@@ -269,12 +399,16 @@ func (s *Server) handleDayDetail(w http.ResponseWriter, r *http.Request) {
 		cells = make([]days.Cell, 0, len(users))
 		for _, u := range users {
 			msg, absent := absMap[u.ID]
-			cells = append(cells, days.Cell{
+			c := days.Cell{
 				UserID:  u.ID,
 				Name:    u.Name,
 				Absent:  absent,
 				Message: msg,
-			})
+			}
+			if x, ok := excused.Covering(u.ID, date); ok {
+				c.Excused = x.Grund
+			}
+			cells = append(cells, c)
 		}
 		sort.SliceStable(cells, func(i, j int) bool {
 			if cells[i].Absent != cells[j].Absent {
@@ -307,9 +441,9 @@ func (s *Server) handleAddExcluded(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "ungültiges Datum", http.StatusUnprocessableEntity)
 		return
 	}
-	if !timeutil.IsThursday(date) {
-		s.triggerToast(w, "error", "Nur Donnerstage können gesperrt werden.")
-		http.Error(w, "kein Donnerstag", http.StatusUnprocessableEntity)
+	if !s.cfg.Schedule.IsMeeting(date) {
+		s.triggerToast(w, "error", "Nur Stammtisch-Tage ("+s.cfg.Schedule.Describe()+") können gesperrt werden.")
+		http.Error(w, "kein Stammtisch-Tag", http.StatusUnprocessableEntity)
 		return
 	}
 	if err := s.store.InsertExcludedDay(r.Context(), date); err != nil {
@@ -347,7 +481,7 @@ func (s *Server) renderExcludedList(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// buildStrip baut die Donnerstags-Kacheln aus einer einzigen SQL-Abfrage
+// buildStrip baut die Termin-Kacheln aus einer einzigen SQL-Abfrage
 // (Union, Abmelde-Zahl, Limit und Sortierung passieren in der DB).
 // limit == 0 => alle (Dashboard); limit > 0 => nur die jüngsten N.
 func (s *Server) buildStrip(ctx context.Context, period timeutil.Period, limit, totalUsers int) ([]partials.ThursdayStripItem, error) {
@@ -399,7 +533,7 @@ func (s *Server) handleToggleAbsence(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-var botExampleKinds = map[string]bool{"statistik": true, "absage": true, "zusage": true}
+var botExampleKinds = map[string]bool{"statistik": true, "absage": true, "zusage": true, "bearbeitet": true, "geloescht": true}
 
 func (s *Server) loadExample(kind string) (string, bool) {
 	if !botExampleKinds[kind] {
@@ -434,17 +568,19 @@ func (s *Server) handleBotTestExample(w http.ResponseWriter, r *http.Request) {
 
 // botOutcome mirrors the whatsapp-bot Outcome JSON.
 type botOutcome struct {
-	Path           string `json:"path"`
-	Classification string `json:"classification"`
-	Action         string `json:"action"`
-	Message        string `json:"message"`
-	Recipient      string `json:"recipient"`
-	Date           string `json:"date"`
-	UserID         string `json:"userId"`
-	Reason         string `json:"reason"`
-	DryRun         bool   `json:"dryRun"`
-	PreviewTo      string `json:"previewTo"`
-	ImageBase64    string `json:"imageBase64"`
+	Path           string   `json:"path"`
+	Command        string   `json:"command"`
+	Classification string   `json:"classification"`
+	Action         string   `json:"action"`
+	Message        string   `json:"message"`
+	Recipient      string   `json:"recipient"`
+	Date           string   `json:"date"`
+	Dates          []string `json:"dates"`
+	UserID         string   `json:"userId"`
+	Reason         string   `json:"reason"`
+	DryRun         bool     `json:"dryRun"`
+	PreviewTo      string   `json:"previewTo"`
+	ImageBase64    string   `json:"imageBase64"`
 }
 
 // modeQuery übersetzt den Modus der Bot-Test-Seite in den Query-Parameter des Bots.
@@ -518,9 +654,14 @@ func (s *Server) proxyBot(w http.ResponseWriter, r *http.Request, url string, bo
 		_ = bottest.ErrorPanel("Antwort nicht lesbar: "+err.Error()).Render(r.Context(), w)
 		return
 	}
+	// Voraus-Absagen können mehrere Ziel-Termine haben.
+	date := out.Date
+	if len(out.Dates) > 1 {
+		date = strings.Join(out.Dates, ", ")
+	}
 	_ = bottest.Response(bottest.ResponseVM{
-		Path: out.Path, Classification: out.Classification, Action: out.Action,
-		Message: out.Message, Recipient: out.Recipient, Date: out.Date, UserID: out.UserID,
+		Path: out.Path, Command: out.Command, Classification: out.Classification, Action: out.Action,
+		Message: out.Message, Recipient: out.Recipient, Date: date, UserID: out.UserID,
 		DryRun: out.DryRun, PreviewTo: out.PreviewTo, ImageBase64: out.ImageBase64,
 	}).Render(r.Context(), w)
 }
@@ -2,9 +2,13 @@ package web
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	"github.com/michael/zumba-shared/domain"
 	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-shared/rules"
+	sharedstore "github.com/michael/zumba-shared/store"
 	"github.com/michael/zumba-admin-ui/internal/store"
 	"github.com/michael/zumba-admin-ui/internal/timeutil"
 )
@@ -22,6 +26,24 @@ type spyStore struct {
 	nextStrafeID     int64
 	beglichenStrafe  int64
 	geloeschteStrafe int64
+	ruleSets         penalty.RuleSets
+	deletedRuleSet   int64
+	buchungen        []penalty.Buchung
+	deletedBuchung   int64
+	mahnungen        []penalty.Mahnung
+	mahnOptOut       map[string]bool
+
+	aliases []string // "userId|alias" der aktuellen Spitznamen
+
+	entschuldigt        domain.Entschuldigungen
+	deletedEntschuldigt int64
+
+	rules       []rules.Rule
+	ruleEnabled map[int64]bool // SetRuleEnabled-Aufrufe
+	corpus      []rules.Sample
+
+	routePolicy *store.MLRoutePolicy
+	routeKilled []bool // SetMLRouteKilled-Aufrufe
 }
 
 func newSpyStore() *spyStore {
@@ -53,6 +75,9 @@ func (s *spyStore) Leaderboard(_ context.Context, _ timeutil.Period) ([]store.Le
 func (s *spyStore) UserLeaderboardRow(_ context.Context, _ timeutil.Period, _ string) (store.LeaderboardRow, error) {
 	return store.LeaderboardRow{}, nil
 }
+func (s *spyStore) Roster(_ context.Context, _ time.Time) ([]store.RosterEntry, error) {
+	return nil, nil
+}
 func (s *spyStore) ListUserAbsences(_ context.Context, _ timeutil.Period, userID string) ([]store.Absence, error) {
 	var out []store.Absence
 	for _, a := range s.absences {
@@ -95,6 +120,73 @@ func (s *spyStore) DeleteAbsence(_ context.Context, userID string, date time.Tim
 	s.deletedAbsence = userID + "@" + timeutil.FormatISO(date)
 	return nil
 }
+func (s *spyStore) ListEntschuldigungen(context.Context) (domain.Entschuldigungen, error) {
+	return s.entschuldigt, nil
+}
+func (s *spyStore) InsertEntschuldigung(_ context.Context, e domain.Entschuldigung) error {
+	e.ID = int64(len(s.entschuldigt) + 1)
+	s.entschuldigt = append(s.entschuldigt, e)
+	return nil
+}
+func (s *spyStore) DeleteEntschuldigung(_ context.Context, id int64) error {
+	s.deletedEntschuldigt = id
+	return nil
+}
+func (s *spyStore) ListAliases(_ context.Context, userID string) ([]string, error) {
+	var out []string
+	for _, a := range s.aliases {
+		if u, alias, _ := strings.Cut(a, "|"); u == userID {
+			out = append(out, alias)
+		}
+	}
+	return out, nil
+}
+func (s *spyStore) AddAlias(_ context.Context, userID, alias string) error {
+	for _, a := range s.aliases {
+		if _, taken, _ := strings.Cut(a, "|"); taken == alias {
+			return sharedstore.ErrAliasTaken
+		}
+	}
+	s.aliases = append(s.aliases, userID+"|"+alias)
+	return nil
+}
+func (s *spyStore) DeleteAlias(_ context.Context, userID, alias string) error {
+	out := s.aliases[:0]
+	for _, a := range s.aliases {
+		if a != userID+"|"+alias {
+			out = append(out, a)
+		}
+	}
+	s.aliases = out
+	return nil
+}
+func (s *spyStore) ListRules(context.Context) ([]rules.Rule, error) { return s.rules, nil }
+func (s *spyStore) InsertRule(_ context.Context, r rules.Rule) (int64, error) {
+	if err := rules.Validate(r); err != nil {
+		return 0, err
+	}
+	r.ID = int64(len(s.rules) + 1)
+	s.rules = append(s.rules, r)
+	return r.ID, nil
+}
+func (s *spyStore) SetRuleEnabled(_ context.Context, id int64, enabled bool) error {
+	if s.ruleEnabled == nil {
+		s.ruleEnabled = map[int64]bool{}
+	}
+	s.ruleEnabled[id] = enabled
+	return nil
+}
+func (s *spyStore) DeleteRule(_ context.Context, id int64) error {
+	out := s.rules[:0]
+	for _, r := range s.rules {
+		if r.ID != id {
+			out = append(out, r)
+		}
+	}
+	s.rules = out
+	return nil
+}
+func (s *spyStore) VerifiedCorpus(context.Context) ([]rules.Sample, error) { return s.corpus, nil }
 func (s *spyStore) InsertExcludedDay(_ context.Context, date time.Time) error {
 	s.insertedExcluded = timeutil.FormatISO(date)
 	return nil
@@ -106,6 +198,12 @@ func (s *spyStore) DeleteExcludedDay(_ context.Context, date time.Time) error {
 
 func (s *spyStore) ListTraces(_ context.Context, _ int) ([]store.Trace, error) { return nil, nil }
 func (s *spyStore) GetTrace(_ context.Context, _ int64) (*store.Trace, error)  { return nil, nil }
+func (s *spyStore) ListOutbox(_ context.Context, _ int) ([]store.OutboxMessage, error) {
+	return nil, nil
+}
+func (s *spyStore) ListJobs(context.Context, int) ([]store.Job, error) {
+	return nil, nil
+}
 
 func mustDate(s string) time.Time {
 	d, err := timeutil.ParseISO(s)
@@ -121,6 +219,13 @@ func (s *spyStore) ListMLMessages(_ context.Context, _ bool, _ int) ([]store.MLM
 func (s *spyStore) MLShadowStats(_ context.Context) (store.MLShadowStats, error) {
 	return store.MLShadowStats{}, nil
 }
+func (s *spyStore) MLRoutePolicy(_ context.Context) (*store.MLRoutePolicy, error) {
+	return s.routePolicy, nil
+}
+func (s *spyStore) SetMLRouteKilled(_ context.Context, killed bool) (*store.MLRoutePolicy, error) {
+	s.routeKilled = append(s.routeKilled, killed)
+	return &store.MLRoutePolicy{Share: 20, MinConfidence: 0.9, Killed: killed}, nil
+}
 func (s *spyStore) VerifyMLMessage(_ context.Context, id int64, correctedLabel *string) (*store.MLMessage, error) {
 	return &store.MLMessage{ID: id, Verified: true, CorrectedLabel: correctedLabel}, nil
 }
@@ -181,3 +286,106 @@ func (s *spyStore) LoescheStrafe(_ context.Context, id int64) error {
 	}
 	return nil
 }
+
+func (s *spyStore) EntscheideEinspruch(_ context.Context, id int64, status penalty.EinspruchStatus, notiz string) error {
+	for i := range s.strafen {
+		if s.strafen[i].ID == id {
+			s.strafen[i].Einspruch.Status, s.strafen[i].Einspruch.Notiz = status, notiz
+			if status == penalty.EinspruchAufgehoben {
+				s.strafen[i].Status = penalty.StatusGeloescht
+			}
+		}
+	}
+	if status != penalty.EinspruchAufgehoben {
+		return nil
+	}
+	for _, m := range s.mahnungen {
+		for i := range s.strafen {
+			if m.StrafeID == id && m.GebuehrID != 0 && s.strafen[i].ID == m.GebuehrID && s.strafen[i].Status == penalty.StatusOffen {
+				s.strafen[i].Status = penalty.StatusGeloescht
+			}
+		}
+	}
+	return nil
+}
+
+func (s *spyStore) ListRuleSets(context.Context) (penalty.RuleSets, error) {
+	return s.ruleSets.Sorted(), nil
+}
+func (s *spyStore) SaveRuleSet(_ context.Context, r penalty.RuleSet) error {
+	if err := penalty.ValidateRuleSetSave(s.ruleSets, r, s.strafen, timeutil.StartOfDay(time.Now())); err != nil {
+		return err
+	}
+	if r.ID == 0 {
+		r.ID = int64(len(s.ruleSets) + 100)
+		s.ruleSets = append(s.ruleSets, r)
+		return nil
+	}
+	for i := range s.ruleSets {
+		if s.ruleSets[i].ID == r.ID {
+			s.ruleSets[i] = r
+		}
+	}
+	return nil
+}
+func (s *spyStore) DeleteRuleSet(_ context.Context, id int64) error {
+	if err := penalty.ValidateRuleSetChange(s.ruleSets, id, s.strafen, timeutil.StartOfDay(time.Now())); err != nil {
+		return err
+	}
+	s.deletedRuleSet = id
+	return nil
+}
+
+func (s *spyStore) ListBuchungen(context.Context) ([]penalty.Buchung, error) { return s.buchungen, nil }
+func (s *spyStore) ListMahnungen(context.Context) ([]penalty.Mahnung, error) { return s.mahnungen, nil }
+func (s *spyStore) ListMahnOptOuts(context.Context) (map[string]bool, error) {
+	return s.mahnOptOut, nil
+}
+func (s *spyStore) InsertBuchung(ctx context.Context, b penalty.Buchung, begleicht bool) error {
+	b.ID = int64(len(s.buchungen) + 1)
+	s.buchungen = append(s.buchungen, b)
+	if !begleicht {
+		return nil
+	}
+	if err := s.BegleicheStrafe(ctx, b.StrafeID); err != nil {
+		return err
+	}
+	for i := range s.strafen {
+		if s.strafen[i].ID == b.StrafeID {
+			datum := b.Datum
+			s.strafen[i].BeglichenAm = &datum
+		}
+	}
+	return nil
+}
+func (s *spyStore) DeleteBuchung(_ context.Context, id int64, betrag int) error {
+	s.deletedBuchung = id
+	var strafeID int64
+	for i, b := range s.buchungen {
+		if b.ID == id {
+			strafeID = b.StrafeID
+			s.buchungen = append(s.buchungen[:i], s.buchungen[i+1:]...)
+			break
+		}
+	}
+	// Wie Postgres: nicht mehr gedeckte beglichene Strafe wieder offen.
+	var bezahlt int
+	for _, b := range s.buchungen {
+		if b.StrafeID == strafeID && b.Art == penalty.BuchungZahlung {
+			bezahlt += b.Betrag
+		}
+	}
+	for i := range s.strafen {
+		r := &s.strafen[i]
+		if strafeID == 0 || r.ID != strafeID || r.Status != penalty.StatusBeglichen {
+			continue
+		}
+		if r.Betrag != 0 {
+			betrag = r.Betrag
+		}
+		if bezahlt < betrag {
+			r.Status, r.BeglichenAm = penalty.StatusOffen, nil
+		}
+	}
+	return nil
+}
@@ -2,43 +2,77 @@ package web
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-admin-ui/internal/store"
 	"github.com/michael/zumba-admin-ui/internal/timeutil"
 	"github.com/michael/zumba-admin-ui/web/templates/strafen"
 )
 
-// strafenVM bewertet alle Strafen zum heutigen Tag (Stichtag-Simulation gibt
-// es nur auf der Bot-Test-Seite über den Wochenreport-Endpoint). Neu erkannte
-// Fehltage-Strafen werden idempotent persistiert (Marker), damit sie sofort
-// begleich-/löschbar sind – dieselbe Erkennung läuft auch im Bot beim Report.
-func (s *Server) strafenVM(ctx context.Context) (strafen.PageVM, error) {
+// strafenLage ist die bewertete Strafenlage zum heutigen Tag – Grundlage der
+// Strafen- und der Kassenbuch-Seite.
+type strafenLage struct {
+	stichtag  time.Time
+	users     []store.User
+	ruleSets  penalty.RuleSets
+	thursdays []time.Time // gültige Stammtisch-Tage, neueste zuerst
+	in        penalty.Input
+	entries   []penalty.Entry
+}
+
+// strafenLage bewertet alle Strafen zum heutigen Tag (Stichtag-Simulation
+// gibt es nur auf der Bot-Test-Seite über den Wochenreport-Endpoint). Mit
+// persist werden neu erkannte Fehltage-Strafen idempotent persistiert
+// (Marker), damit sie sofort begleich-/löschbar sind – dieselbe Erkennung
+// läuft auch im Bot beim Report. Aktionen auf eine Strafe lesen nur
+// (persist=false): Die Strafe hat ihre ID schon von der Seite.
+func (s *Server) strafenLage(ctx context.Context, persist bool) (strafenLage, error) {
 	stichtag := timeutil.StartOfDay(time.Now())
 	users, err := s.store.ListUsers(ctx)
 	if err != nil {
-		return strafen.PageVM{}, err
+		return strafenLage{}, err
+	}
+	ruleSets, err := s.store.ListRuleSets(ctx)
+	if err != nil {
+		return strafenLage{}, err
+	}
+	buchungen, err := s.store.ListBuchungen(ctx)
+	if err != nil {
+		return strafenLage{}, err
 	}
+	excused, err := s.store.ListEntschuldigungen(ctx)
+	if err != nil {
+		return strafenLage{}, err
+	}
+	// Beginnt das erste Regelwerk vor dem Auswertungszeitraum, zählen auch
+	// die Abwesenheiten davor.
 	period := timeutil.Period{Start: s.cfg.EvalPeriodStart, End: stichtag}
+	if start := ruleSets.Start(); start.Before(period.Start) {
+		period.Start = start
+	}
 	absences, err := s.store.ListAbsences(ctx, period)
 	if err != nil {
-		return strafen.PageVM{}, err
+		return strafenLage{}, err
 	}
 	excluded, err := s.store.ListExcludedDays(ctx, period)
 	if err != nil {
-		return strafen.PageVM{}, err
+		return strafenLage{}, err
 	}
 	rows, err := s.store.ListStrafen(ctx)
 	if err != nil {
-		return strafen.PageVM{}, err
+		return strafenLage{}, err
 	}
-	// Für das No-Show-Formular: nur echte Stammtisch-Donnerstage anbieten.
+	// Für das No-Show-Formular: nur echte Stammtisch-Tage anbieten.
 	thursdays, err := s.store.ListThursdays(ctx, period)
 	if err != nil {
-		return strafen.PageVM{}, err
+		return strafenLage{}, err
 	}
 
 	input := func(rows []penalty.Row) penalty.Input {
@@ -46,12 +80,13 @@ func (s *Server) strafenVM(ctx context.Context) (strafen.PageVM, error) {
 		for _, a := range absences {
 			byUser[a.UserID] = append(byUser[a.UserID], a.Date)
 		}
-		in := penalty.Input{Excluded: excluded, Rows: rows}
+		in := penalty.Input{Excluded: excluded, Rows: rows, Schedule: s.cfg.Schedule, Rules: ruleSets, Buchungen: buchungen}
 		for _, u := range users {
 			in.Users = append(in.Users, penalty.UserData{
 				UserID: u.ID, Name: u.Name,
-				EffectiveStart: penalty.ClampStart(u.StartDate),
+				EffectiveStart: ruleSets.ClampStart(u.StartDate),
 				Absences:       byUser[u.ID],
+				Entschuldigt:   excused.Of(u.ID),
 			})
 		}
 		return in
@@ -63,7 +98,7 @@ func (s *Server) strafenVM(ctx context.Context) (strafen.PageVM, error) {
 	// Aktions-Buttons echte IDs haben.
 	persisted := false
 	for _, e := range entries {
-		if e.ID != 0 {
+		if !persist || e.ID != 0 {
 			continue
 		}
 		if err := s.store.InsertAutoStrafe(ctx, e.UserID, e.Datum); err != nil {
@@ -74,29 +109,42 @@ func (s *Server) strafenVM(ctx context.Context) (strafen.PageVM, error) {
 	}
 	if persisted {
 		if rows, err = s.store.ListStrafen(ctx); err != nil {
-			return strafen.PageVM{}, err
+			return strafenLage{}, err
 		}
 		entries = penalty.Assess(input(rows), stichtag)
 	}
+	return strafenLage{stichtag: stichtag, users: users, ruleSets: ruleSets, thursdays: thursdays,
+		in: input(rows), entries: entries}, nil
+}
 
+func (s *Server) strafenVM(ctx context.Context) (strafen.PageVM, error) {
+	l, err := s.strafenLage(ctx, true)
+	if err != nil {
+		return strafen.PageVM{}, err
+	}
 	vm := strafen.PageVM{
-		Users:         users,
-		Thursdays:     thursdays,
-		NoShowDefault: penalty.NoShowDefault,
+		Users:     l.users,
+		Thursdays: l.thursdays,
+		Current:   l.ruleSets.At(l.stichtag),
+		RuleSets:  l.ruleSets,
+		InKraft:   make(map[int64]bool, len(l.ruleSets)),
 	}
-	for _, e := range entries {
+	for _, r := range l.ruleSets {
+		vm.InKraft[r.ID] = penalty.ValidateRuleSetChange(l.ruleSets, r.ID, l.in.Rows, l.stichtag) != nil
+	}
+	for _, e := range l.entries {
 		if e.Status == penalty.StatusGeloescht {
 			continue
 		}
 		row := strafen.Row{
-			ID: e.ID, UserName: e.Name, Art: e.Art, Datum: e.Datum,
-			Tage: e.Tage, Betrag: e.Betrag, Status: e.Status,
+			ID: e.ID, UserID: e.UserID, UserName: e.Name, Art: e.Art, Datum: e.Datum,
+			Tage: e.Tage, Betrag: e.Betrag, Bezahlt: e.Bezahlt, Status: e.Status,
 			BeglichenAm: e.BeglichenAm,
-			Sichtbar:    penalty.VisibleAt(e, stichtag),
+			Sichtbar:    penalty.VisibleAt(e, l.stichtag),
+			Einspruch:   e.Einspruch,
 		}
-		if e.Status == penalty.StatusBeglichen && e.BeglichenAm != nil {
-			bis := penalty.NextThursday(*e.BeglichenAm)
-			row.SichtbarBis = &bis
+		if e.Status == penalty.StatusBeglichen {
+			row.SichtbarBis = e.SichtbarBis
 		}
 		vm.Rows = append(vm.Rows, row)
 	}
@@ -125,12 +173,17 @@ func (s *Server) handleAddStrafe(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "ungültiges Datum", http.StatusUnprocessableEntity)
 		return
 	}
-	if !timeutil.IsThursday(datum) {
-		s.triggerToast(w, "error", "No-Shows gibt es nur an Donnerstagen.")
-		http.Error(w, "kein Donnerstag", http.StatusUnprocessableEntity)
+	if !s.cfg.Schedule.IsMeeting(datum) {
+		s.triggerToast(w, "error", "No-Shows gibt es nur an Stammtisch-Tagen.")
+		http.Error(w, "kein Stammtisch-Tag", http.StatusUnprocessableEntity)
 		return
 	}
-	betrag := penalty.NoShowDefault
+	rules, err := s.store.ListRuleSets(r.Context())
+	if err != nil {
+		s.fail(w, "regelwerke", err)
+		return
+	}
+	betrag := rules.At(datum).NoShowDefault
 	if b := r.FormValue("betrag"); b != "" {
 		v, err := strconv.Atoi(b)
 		if err != nil || v <= 0 {
@@ -148,13 +201,24 @@ func (s *Server) handleAddStrafe(w http.ResponseWriter, r *http.Request) {
 	s.renderStrafenRegion(w, r)
 }
 
+// handleBegleicheStrafe bucht den offenen Rest als Zahlung ins Kassenbuch
+// und setzt die Strafe auf beglichen.
 func (s *Server) handleBegleicheStrafe(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
-	if err != nil {
-		http.Error(w, "ungültige ID", http.StatusUnprocessableEntity)
+	e, ok := s.offeneStrafe(w, r)
+	if !ok {
 		return
 	}
-	if err := s.store.BegleicheStrafe(r.Context(), id); err != nil {
+	var err error
+	if rest := e.Rest(); rest > 0 {
+		b := zahlung(e, rest)
+		if !s.begleichungGueltig(w, e, b) {
+			return
+		}
+		err = s.store.InsertBuchung(r.Context(), b, true)
+	} else {
+		err = s.store.BegleicheStrafe(r.Context(), e.ID)
+	}
+	if err != nil {
 		s.fail(w, "begleiche strafe", err)
 		return
 	}
@@ -162,6 +226,87 @@ func (s *Server) handleBegleicheStrafe(w http.ResponseWriter, r *http.Request) {
 	s.renderStrafenRegion(w, r)
 }
 
+// handleZahlungStrafe bucht eine (Teil-)Zahlung auf eine offene Strafe. Deckt
+// sie den Rest, ist die Strafe damit beglichen; mehr als den Rest nimmt die
+// Strafe nicht an (sonst stimmen Konto und Kassenstand nicht mehr).
+func (s *Server) handleZahlungStrafe(w http.ResponseWriter, r *http.Request) {
+	betrag, err := strconv.Atoi(strings.TrimSpace(r.FormValue("betrag")))
+	if err != nil || betrag <= 0 {
+		s.triggerToast(w, "error", "Ungültiger Betrag.")
+		http.Error(w, "ungültiger Betrag", http.StatusUnprocessableEntity)
+		return
+	}
+	e, ok := s.offeneStrafe(w, r)
+	if !ok {
+		return
+	}
+	if betrag > e.Rest() {
+		msg := fmt.Sprintf("Zahlung von %d€ übersteigt den offenen Rest von %d€.", betrag, e.Rest())
+		s.triggerToast(w, "error", msg)
+		http.Error(w, msg, http.StatusUnprocessableEntity)
+		return
+	}
+	begleicht := betrag == e.Rest()
+	b := zahlung(e, betrag)
+	if begleicht && !s.begleichungGueltig(w, e, b) {
+		return
+	}
+	if err := s.store.InsertBuchung(r.Context(), b, begleicht); err != nil {
+		s.fail(w, "zahlung strafe", err)
+		return
+	}
+	if begleicht {
+		s.triggerToast(w, "success", fmt.Sprintf("%d€ gebucht – Strafe beglichen.", betrag))
+	} else {
+		s.triggerToast(w, "success", fmt.Sprintf("%d€ gebucht – noch %d€ offen.", betrag, e.Rest()-betrag))
+	}
+	s.renderStrafenRegion(w, r)
+}
+
+// offeneStrafe bewertet die Strafe {id} aus dem Pfad (Rest nach
+// Teilzahlungen), ohne etwas zu schreiben; bei Fehlern ist die Antwort schon
+// geschrieben.
+func (s *Server) offeneStrafe(w http.ResponseWriter, r *http.Request) (penalty.Entry, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "ungültige ID", http.StatusUnprocessableEntity)
+		return penalty.Entry{}, false
+	}
+	l, err := s.strafenLage(r.Context(), false)
+	if err != nil {
+		s.fail(w, "strafen", err)
+		return penalty.Entry{}, false
+	}
+	for _, e := range l.entries {
+		if e.ID == id && e.Status == penalty.StatusOffen {
+			return e, true
+		}
+	}
+	s.triggerToast(w, "error", "Strafe nicht (mehr) offen.")
+	http.Error(w, "keine offene Strafe", http.StatusNotFound)
+	return penalty.Entry{}, false
+}
+
+// begleichungGueltig prüft, ob die Zahlung b die Strafe e begleichen darf
+// (penalty.ValidateBegleichung); sonst ist die 422-Antwort schon geschrieben.
+func (s *Server) begleichungGueltig(w http.ResponseWriter, e penalty.Entry, b penalty.Buchung) bool {
+	if err := penalty.ValidateBegleichung(e, b); err != nil {
+		msg := "Begleichen nicht möglich: " + err.Error()
+		s.triggerToast(w, "error", msg)
+		http.Error(w, msg, http.StatusUnprocessableEntity)
+		return false
+	}
+	return true
+}
+
+// zahlung ist die Kassenbuch-Buchung einer Zahlung auf die Strafe e.
+func zahlung(e penalty.Entry, betrag int) penalty.Buchung {
+	return penalty.Buchung{
+		Art: penalty.BuchungZahlung, UserID: e.UserID, StrafeID: e.ID,
+		Betrag: betrag, Datum: timeutil.StartOfDay(time.Now()),
+	}
+}
+
 func (s *Server) handleDeleteStrafe(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
@@ -176,6 +321,34 @@ func (s *Server) handleDeleteStrafe(w http.ResponseWriter, r *http.Request) {
 	s.renderStrafenRegion(w, r)
 }
 
+// handleEinspruch entscheidet über einen offenen Einspruch: "bestaetigt"
+// lässt die Strafe offen, "aufgehoben" löscht sie. Eine Begründung ist
+// Pflicht – das Mitglied soll sie nachlesen können.
+func (s *Server) handleEinspruch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "ungültige ID", http.StatusUnprocessableEntity)
+		return
+	}
+	status := penalty.EinspruchStatus(r.FormValue("entscheidung"))
+	notiz := strings.TrimSpace(r.FormValue("notiz"))
+	if err := penalty.ValidateEntscheidung(status, notiz); err != nil {
+		s.triggerToast(w, "error", "Entscheidung ungültig: "+err.Error())
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err := s.store.EntscheideEinspruch(r.Context(), id, status, notiz); err != nil {
+		s.fail(w, "einspruch", err)
+		return
+	}
+	if status == penalty.EinspruchAufgehoben {
+		s.triggerToast(w, "success", "Einspruch stattgegeben – Strafe aufgehoben.")
+	} else {
+		s.triggerToast(w, "success", "Einspruch abgelehnt – Strafe bleibt offen.")
+	}
+	s.renderStrafenRegion(w, r)
+}
+
 // renderStrafenRegion rendert nur die Liste (HTMX-Swap-Ziel).
 func (s *Server) renderStrafenRegion(w http.ResponseWriter, r *http.Request) {
 	vm, err := s.strafenVM(r.Context())
@@ -188,3 +361,102 @@ func (s *Server) renderStrafenRegion(w http.ResponseWriter, r *http.Request) {
 		log.Printf("render strafen region: %v", err)
 	}
 }
+
+// handleSaveRuleSet legt ein Regelwerk an bzw. ändert es ({id} im Pfad).
+// Die ganze Seite wird neu gerendert – Kopfzeile und Beträge hängen daran.
+func (s *Server) handleSaveRuleSet(w http.ResponseWriter, r *http.Request) {
+	rs, ok := s.ruleSetForm(w, r)
+	if !ok {
+		return
+	}
+	if id := r.PathValue("id"); id != "" {
+		var err error
+		if rs.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+			http.Error(w, "ungültige ID", http.StatusUnprocessableEntity)
+			return
+		}
+	}
+	if err := s.store.SaveRuleSet(r.Context(), rs); errors.Is(err, penalty.ErrRuleSetInKraft) {
+		s.ruleSetInKraft(w, err)
+		return
+	} else if err != nil {
+		s.triggerToast(w, "error", "Speichern fehlgeschlagen – gilt ab dem Tag schon ein Regelwerk?")
+		s.fail(w, "save regelwerk", err)
+		return
+	}
+	s.triggerToast(w, "success", "Regelwerk gespeichert – Serien ab "+timeutil.FormatDEShort(rs.GiltAb)+" gelten danach.")
+	s.renderStrafenPage(w, r)
+}
+
+func (s *Server) handleDeleteRuleSet(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "ungültige ID", http.StatusUnprocessableEntity)
+		return
+	}
+	if err := s.store.DeleteRuleSet(r.Context(), id); errors.Is(err, penalty.ErrRuleSetInKraft) {
+		s.ruleSetInKraft(w, err)
+		return
+	} else if err != nil {
+		s.triggerToast(w, "error", "Das letzte Regelwerk lässt sich nicht löschen.")
+		s.fail(w, "delete regelwerk", err)
+		return
+	}
+	s.triggerToast(w, "success", "Regelwerk gelöscht.")
+	s.renderStrafenPage(w, r)
+}
+
+// ruleSetInKraft lehnt die Änderung eines geltenden Regelwerks ab (422):
+// sie würde alte Strafen stillschweigend umrechnen. Neue Regeln kommen als
+// neues Regelwerk ab heute oder später dazu.
+func (s *Server) ruleSetInKraft(w http.ResponseWriter, err error) {
+	msg := "Regelwerk gilt schon und bleibt unverändert – neue Regeln bitte als neues Regelwerk ab heute anlegen."
+	s.triggerToast(w, "error", msg)
+	http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+}
+
+// ruleSetForm liest und validiert ein Regelwerk aus dem Formular; bei
+// Fehlern ist die Antwort schon geschrieben.
+func (s *Server) ruleSetForm(w http.ResponseWriter, r *http.Request) (penalty.RuleSet, bool) {
+	invalid := func(msg string) (penalty.RuleSet, bool) {
+		s.triggerToast(w, "error", msg)
+		http.Error(w, msg, http.StatusUnprocessableEntity)
+		return penalty.RuleSet{}, false
+	}
+	giltAb, err := timeutil.ParseISO(r.FormValue("giltAb"))
+	if err != nil {
+		return invalid("Ungültiges Datum.")
+	}
+	rs := penalty.RuleSet{GiltAb: giltAb, Notiz: strings.TrimSpace(r.FormValue("notiz"))}
+	for _, f := range []struct {
+		name string
+		dst  *int
+	}{
+		{"minFehltage", &rs.MinFehltage}, {"basisBetrag", &rs.BasisBetrag},
+		{"proTagBetrag", &rs.ProTagBetrag}, {"noShowDefault", &rs.NoShowDefault},
+	} {
+		v, err := strconv.Atoi(strings.TrimSpace(r.FormValue(f.name)))
+		if err != nil {
+			return invalid("Alle Werte müssen ganze Zahlen sein.")
+		}
+		*f.dst = v
+	}
+	if err := penalty.ValidateRuleSet(rs); err != nil {
+		return invalid("Regelwerk ungültig: " + err.Error())
+	}
+	return rs, true
+}
+
+// renderStrafenPage rendert die ganze Seite ohne Layout (HTMX-Swap-Ziel nach
+// Änderungen am Regelwerk).
+func (s *Server) renderStrafenPage(w http.ResponseWriter, r *http.Request) {
+	vm, err := s.strafenVM(r.Context())
+	if err != nil {
+		s.fail(w, "strafen", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := strafen.Page(vm).Render(r.Context(), w); err != nil {
+		log.Printf("render strafen: %v", err)
+	}
+}
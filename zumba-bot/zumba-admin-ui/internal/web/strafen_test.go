@@ -7,8 +7,12 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/michael/zumba-shared/domain"
 	"github.com/michael/zumba-shared/penalty"
+	"github.com/michael/zumba-admin-ui/internal/store"
+	"github.com/michael/zumba-admin-ui/internal/timeutil"
 )
 
 func postForm(t *testing.T, srv http.Handler, path string, form url.Values) *httptest.ResponseRecorder {
@@ -71,6 +75,9 @@ func TestBegleicheUndLoescheStrafe(t *testing.T) {
 	if rec.Code != http.StatusOK || spy.beglichenStrafe != 1 {
 		t.Fatalf("begleichen: code=%d, id=%d", rec.Code, spy.beglichenStrafe)
 	}
+	if len(spy.buchungen) != 1 || spy.buchungen[0].Betrag != 50 || spy.buchungen[0].StrafeID != 1 {
+		t.Errorf("Rest nicht als Zahlung gebucht: %+v", spy.buchungen)
+	}
 
 	req := httptest.NewRequest("DELETE", "/strafen/1", nil)
 	del := httptest.NewRecorder()
@@ -101,3 +108,219 @@ func TestStrafenSeitePersistiertAutoStrafe(t *testing.T) {
 		t.Errorf("25€ nicht in der Seite")
 	}
 }
+
+// Liegen zwei der fünf Fehltage in einem entschuldigten Zeitraum, ist die
+// Serie nur 3 lang – keine Strafe.
+func TestStrafenSeiteEntschuldigt(t *testing.T) {
+	spy := newSpyStore()
+	for _, d := range []string{"2026-01-01", "2026-01-08", "2026-01-15", "2026-01-22", "2026-01-29"} {
+		_ = spy.InsertAbsence(context.TODO(), "u01", mustDate(d), nil)
+	}
+	spy.entschuldigt = domain.Entschuldigungen{{ID: 1, UserID: "u01", Von: mustDate("2026-01-14"), Bis: mustDate("2026-01-23"), Grund: "Grippe"}}
+	srv := New(spy, testCfg(), false).Routes()
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/strafen", nil))
+	if rec.Code != http.StatusOK || len(spy.strafen) != 0 {
+		t.Errorf("code = %d, strafen %+v", rec.Code, spy.strafen)
+	}
+}
+
+func TestRegelwerkAnlegenUndAendern(t *testing.T) {
+	spy := newSpyStore()
+	srv := New(spy, testCfg(), false).Routes()
+	form := url.Values{
+		"giltAb": {timeutil.FormatISO(time.Now().AddDate(0, 1, 0))}, "minFehltage": {"4"}, "basisBetrag": {"30"},
+		"proTagBetrag": {"5"}, "noShowDefault": {"60"}, "notiz": {" Abstimmung März "},
+	}
+	rec := postForm(t, srv, "/regelwerk", form)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code = %d (%s)", rec.Code, rec.Body.String())
+	}
+	if len(spy.ruleSets) != 1 || spy.ruleSets[0].MinFehltage != 4 || spy.ruleSets[0].Notiz != "Abstimmung März" {
+		t.Fatalf("Regelwerk nicht angelegt: %+v", spy.ruleSets)
+	}
+	// Die ganze Seite kommt zurück, mit den neuen Regeln im Kopf.
+	if !strings.Contains(rec.Body.String(), `id="strafen-page"`) || !strings.Contains(rec.Body.String(), "ab 4 Fehltagen in Folge 30€") {
+		t.Errorf("Antwort ohne aktualisierte Seite:\n%s", rec.Body.String())
+	}
+
+	form.Set("basisBetrag", "35")
+	if rec := postForm(t, srv, "/regelwerk/100", form); rec.Code != http.StatusOK || spy.ruleSets[0].BasisBetrag != 35 {
+		t.Errorf("ändern: code=%d, %+v", rec.Code, spy.ruleSets)
+	}
+}
+
+func TestRegelwerkUngueltig(t *testing.T) {
+	spy := newSpyStore()
+	srv := New(spy, testCfg(), false).Routes()
+	rec := postForm(t, srv, "/regelwerk", url.Values{
+		"giltAb": {"2026-03-05"}, "minFehltage": {"0"}, "basisBetrag": {"30"},
+		"proTagBetrag": {"5"}, "noShowDefault": {"60"},
+	})
+	if rec.Code != http.StatusUnprocessableEntity || len(spy.ruleSets) != 0 {
+		t.Errorf("code = %d, ruleSets %+v", rec.Code, spy.ruleSets)
+	}
+}
+
+func TestNoShowBetragAusRegelwerk(t *testing.T) {
+	spy := newSpyStore()
+	spy.ruleSets = penalty.RuleSets{penalty.DefaultRuleSet,
+		{ID: 2, GiltAb: mustDate("2026-01-01"), MinFehltage: 5, BasisBetrag: 25, ProTagBetrag: 5, NoShowDefault: 70}}
+	srv := New(spy, testCfg(), false).Routes()
+	rec := postForm(t, srv, "/strafen", url.Values{"userId": {"u01"}, "datum": {"2026-01-01"}})
+	if rec.Code != http.StatusOK || len(spy.strafen) != 1 || spy.strafen[0].Betrag != 70 {
+		t.Errorf("code = %d, strafen %+v", rec.Code, spy.strafen)
+	}
+
+	// Das Regelwerk gilt und deckt die Strafe ab – löschen würde sie umrechnen.
+	req := httptest.NewRequest("DELETE", "/regelwerk/2", nil)
+	del := httptest.NewRecorder()
+	srv.ServeHTTP(del, req)
+	if del.Code != http.StatusUnprocessableEntity || spy.deletedRuleSet != 0 {
+		t.Errorf("löschen: code=%d, id=%d", del.Code, spy.deletedRuleSet)
+	}
+}
+
+// Regelwerke sind append-only, sobald sie gelten: kein gilt_ab in der
+// Vergangenheit, keine Änderung und kein Löschen geltender Regelwerke.
+// Künftige ohne Strafen im Zeitraum bleiben änderbar.
+func TestRegelwerkInKraftUnveraenderlich(t *testing.T) {
+	spy := newSpyStore()
+	future := timeutil.StartOfDay(time.Now()).AddDate(0, 2, 0)
+	spy.ruleSets = penalty.RuleSets{
+		{ID: 1, GiltAb: mustDate("2025-12-01"), MinFehltage: 5, BasisBetrag: 25, ProTagBetrag: 5, NoShowDefault: 50},
+		{ID: 2, GiltAb: future, MinFehltage: 4, BasisBetrag: 30, ProTagBetrag: 5, NoShowDefault: 50},
+	}
+	_ = spy.InsertNoShowStrafe(context.TODO(), "u01", mustDate("2026-01-01"), 50)
+	srv := New(spy, testCfg(), false).Routes()
+	form := func(giltAb time.Time) url.Values {
+		return url.Values{"giltAb": {timeutil.FormatISO(giltAb)}, "minFehltage": {"3"}, "basisBetrag": {"20"},
+			"proTagBetrag": {"5"}, "noShowDefault": {"40"}}
+	}
+	rejected := func(name string, rec *httptest.ResponseRecorder) {
+		t.Helper()
+		if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Header().Get("HX-Trigger"), "error") {
+			t.Errorf("%s: code=%d, HX-Trigger=%q", name, rec.Code, rec.Header().Get("HX-Trigger"))
+		}
+	}
+
+	rejected("anlegen in der Vergangenheit", postForm(t, srv, "/regelwerk", form(mustDate("2026-02-05"))))
+	rejected("geltendes ändern", postForm(t, srv, "/regelwerk/1", form(mustDate("2025-12-01"))))
+	del := httptest.NewRecorder()
+	srv.ServeHTTP(del, httptest.NewRequest("DELETE", "/regelwerk/1", nil))
+	rejected("geltendes löschen", del)
+	if len(spy.ruleSets) != 2 || spy.ruleSets[0].MinFehltage != 5 || spy.deletedRuleSet != 0 {
+		t.Fatalf("Regelwerke verändert: %+v, gelöscht %d", spy.ruleSets, spy.deletedRuleSet)
+	}
+
+	if rec := postForm(t, srv, "/regelwerk/2", form(future)); rec.Code != http.StatusOK || spy.ruleSets[1].MinFehltage != 3 {
+		t.Errorf("künftiges ändern: code=%d, %+v", rec.Code, spy.ruleSets)
+	}
+	del = httptest.NewRecorder()
+	srv.ServeHTTP(del, httptest.NewRequest("DELETE", "/regelwerk/2", nil))
+	if del.Code != http.StatusOK || spy.deletedRuleSet != 2 {
+		t.Errorf("künftiges löschen: code=%d, id=%d", del.Code, spy.deletedRuleSet)
+	}
+}
+
+// Teilzahlung: 30 von 50€ lassen die Strafe offen, die restlichen 20€
+// begleichen sie.
+func TestTeilzahlungStrafe(t *testing.T) {
+	spy := newSpyStore()
+	_ = spy.InsertNoShowStrafe(context.TODO(), "u01", mustDate("2026-01-01"), 50)
+	srv := New(spy, testCfg(), false).Routes()
+
+	rec := postForm(t, srv, "/strafen/1/zahlung", url.Values{"betrag": {"30"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("zahlung: code=%d (%s)", rec.Code, rec.Body.String())
+	}
+	if spy.beglichenStrafe != 0 || len(spy.buchungen) != 1 || spy.buchungen[0].UserID != "u01" {
+		t.Fatalf("Teilzahlung: beglichen=%d, buchungen=%+v", spy.beglichenStrafe, spy.buchungen)
+	}
+	if !strings.Contains(rec.Body.String(), "30€ bezahlt, 20€ offen") {
+		t.Errorf("Teilzahlung nicht angezeigt:\n%s", rec.Body.String())
+	}
+
+	if rec := postForm(t, srv, "/strafen/1/zahlung", url.Values{"betrag": {"20"}}); rec.Code != http.StatusOK {
+		t.Fatalf("Restzahlung: code=%d", rec.Code)
+	}
+	if spy.beglichenStrafe != 1 || len(spy.buchungen) != 2 {
+		t.Errorf("Restzahlung begleicht nicht: beglichen=%d, buchungen=%+v", spy.beglichenStrafe, spy.buchungen)
+	}
+	if b := spy.strafen[0].BeglichenAm; b == nil || !b.Equal(spy.buchungen[1].Datum) {
+		t.Errorf("beglichen am %v, want Buchungstag %v", b, spy.buchungen[1].Datum)
+	}
+
+	if rec := postForm(t, srv, "/strafen/1/zahlung", url.Values{"betrag": {"5"}}); rec.Code != http.StatusNotFound {
+		t.Errorf("Zahlung auf beglichene Strafe: code=%d, want 404", rec.Code)
+	}
+}
+
+// Mehr als der offene Rest wird nicht gebucht – weder auf einmal noch nach
+// einer Teilzahlung. Die abgelehnte Aktion schreibt auch keine Marker.
+func TestZahlungUeberRestAbgelehnt(t *testing.T) {
+	spy := newSpyStore()
+	_ = spy.InsertNoShowStrafe(context.TODO(), "u01", mustDate("2026-01-01"), 50)
+	spy.users = append(spy.users, store.User{ID: "u02", Name: "Eva"})
+	for _, d := range []string{"2026-01-01", "2026-01-08", "2026-01-15", "2026-01-22", "2026-01-29"} {
+		_ = spy.InsertAbsence(context.TODO(), "u02", mustDate(d), nil)
+	}
+	srv := New(spy, testCfg(), false).Routes()
+
+	rec := postForm(t, srv, "/strafen/1/zahlung", url.Values{"betrag": {"60"}})
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Header().Get("HX-Trigger"), "übersteigt") {
+		t.Fatalf("Überzahlung: code=%d, trigger=%q", rec.Code, rec.Header().Get("HX-Trigger"))
+	}
+	if len(spy.buchungen) != 0 || spy.beglichenStrafe != 0 {
+		t.Errorf("Überzahlung gebucht: beglichen=%d, buchungen=%+v", spy.beglichenStrafe, spy.buchungen)
+	}
+	if len(spy.strafen) != 1 {
+		t.Errorf("Zahlung darf keine Fehltage-Marker schreiben: %+v", spy.strafen)
+	}
+
+	if rec := postForm(t, srv, "/strafen/1/zahlung", url.Values{"betrag": {"30"}}); rec.Code != http.StatusOK {
+		t.Fatalf("Teilzahlung: code=%d", rec.Code)
+	}
+	if rec := postForm(t, srv, "/strafen/1/zahlung", url.Values{"betrag": {"25"}}); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Überzahlung nach Teilzahlung: code=%d, want 422", rec.Code)
+	}
+	if len(spy.buchungen) != 1 || spy.beglichenStrafe != 0 {
+		t.Errorf("nach Teilzahlung: beglichen=%d, buchungen=%+v", spy.beglichenStrafe, spy.buchungen)
+	}
+}
+
+// Offener Einspruch: steht oben mit Beleg-Links; Aufheben löscht die Strafe,
+// ohne Begründung wird nicht entschieden.
+func TestEinspruchEntscheiden(t *testing.T) {
+	spy := newSpyStore()
+	_ = spy.InsertNoShowStrafe(context.TODO(), "u01", mustDate("2026-01-01"), 50)
+	spy.strafen[0].Einspruch = penalty.Einspruch{Status: penalty.EinspruchOffen, Grund: "hab abgesagt", TraceID: 42}
+	// Für die Strafe ist schon eine Mahngebühr angefallen.
+	spy.strafen = append(spy.strafen, penalty.Row{ID: 2, UserID: "u01", Art: penalty.ArtMahngebuehr,
+		Datum: mustDate("2026-02-12"), Betrag: 5, Status: penalty.StatusOffen})
+	spy.nextStrafeID = 2
+	spy.mahnungen = []penalty.Mahnung{{ID: 1, UserID: "u01", Stufe: 3, StrafeID: 1, Offen: 50, GebuehrID: 2}}
+	srv := New(spy, testCfg(), false).Routes()
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/strafen", nil))
+	for _, want := range []string{"Offene Einsprüche", "„hab abgesagt“", `href="/trace/42"`} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Seite ohne %q", want)
+		}
+	}
+
+	if rec := postForm(t, srv, "/strafen/1/einspruch", url.Values{"entscheidung": {"aufgehoben"}}); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("ohne Begründung: code=%d, want 422", rec.Code)
+	}
+	rec = postForm(t, srv, "/strafen/1/einspruch", url.Values{"entscheidung": {"aufgehoben"}, "notiz": {"Absage im Trace"}})
+	if rec.Code != http.StatusOK || spy.strafen[0].Status != penalty.StatusGeloescht || spy.strafen[0].Einspruch.Notiz != "Absage im Trace" {
+		t.Fatalf("code=%d, strafe %+v", rec.Code, spy.strafen[0])
+	}
+	if strings.Contains(rec.Body.String(), "Offene Einsprüche") {
+		t.Error("entschiedener Einspruch weiter offen gelistet")
+	}
+	if spy.strafen[1].Status != penalty.StatusGeloescht {
+		t.Errorf("Mahngebühr der aufgehobenen Strafe bleibt offen: %+v", spy.strafen[1])
+	}
+}
@@ -25,7 +25,7 @@ func MarkerFor(state string) string {
 
 const (
 	viewW    = 1040.0
-	viewH    = 916.0
+	viewH    = 1046.0
 	nodeW    = 210.0
 	nodeH    = 72.0
 	colLeft  = 175.0 // Karten-Mittelpunkt linke Spalte
@@ -51,17 +51,21 @@ type edgeDef struct {
 // Feste Topologie (top-down; alle Kanten verlaufen abwärts).
 var nodes = []nodeDef{
 	{store.NodeReceived, colMid, 20, "📨", "Webhook empfangen"},
-	{store.NodeCheckStatistik, colMid, 140, "❓", `"statistik"?`},
-	{store.NodeBuildStats, colLeft, 290, "📊", "Statistik berechnen"},
+	{store.NodeCheckStatistik, colMid, 140, "❓", "Befehl?"},
+	{store.NodeCommand, colLeft, 290, "💬", "Befehl ausführen"},
 	{store.NodeGuardType, colMid, 290, "🛡️", "messageType?"},
-	{store.NodeSendStats, colLeft, 430, "📤", "An Gruppe senden"},
+	{store.NodeSendStats, colLeft, 430, "📤", "Antwort senden"},
 	{store.NodeGuardGroup, colMid, 430, "🛡️", "Zumba-Gruppe?"},
 	{store.NodeIgnored, colRight, 430, "🚫", "Ignoriert"},
-	{store.NodeGuardThursday, colMid, 560, "🛡️", "Donnerstag?"},
-	{store.NodeClassify, colMid, 690, "🤖", "Classifier"},
-	{store.NodeMarkAbsent, colLeft, 820, "📝", "Absage: DB-Insert"},
-	{store.NodeMarkPresent, colMid, 820, "✅", "Zusage: DB-Delete"},
-	{store.NodeNoAction, colRight, 820, "➖", "keine Aktion"},
+	{store.NodeRules, colMid, 560, "📏", "Regel?"},
+	{store.NodeUndo, colRight, 560, "↩️", "Original rückgängig"},
+	{store.NodeAnswer, colRight, 690, "🤔", "Antwort auf Rückfrage"},
+	{store.NodeClassify, colLeft, 690, "🤖", "Classifier"},
+	{store.NodeResolveDates, colMid, 820, "📅", "Zieltermine"},
+	{store.NodeContext, colLeft, 820, "💭", "Gesprächskontext"},
+	{store.NodeMarkAbsent, colLeft, 950, "📝", "Absage: DB-Insert"},
+	{store.NodeMarkPresent, colMid, 950, "✅", "Zusage: DB-Delete"},
+	{store.NodeNoAction, colRight, 950, "➖", "keine Aktion"},
 }
 
 func anchor(cx, yTop float64) (botX, botY, topX, topY float64) {
@@ -82,16 +86,24 @@ func mkEdges() []edgeDef {
 	}
 	return []edgeDef{
 		def(store.NodeReceived, store.NodeCheckStatistik, ""),
-		def(store.NodeCheckStatistik, store.NodeBuildStats, "ja"),
+		def(store.NodeCheckStatistik, store.NodeCommand, "ja"),
 		def(store.NodeCheckStatistik, store.NodeGuardType, "nein"),
-		def(store.NodeBuildStats, store.NodeSendStats, ""),
+		def(store.NodeCommand, store.NodeSendStats, ""),
 		def(store.NodeGuardType, store.NodeGuardGroup, "ja"),
 		def(store.NodeGuardType, store.NodeIgnored, "nein"),
-		def(store.NodeGuardGroup, store.NodeGuardThursday, ""),
-		def(store.NodeGuardThursday, store.NodeClassify, ""),
-		def(store.NodeClassify, store.NodeMarkAbsent, "false"),
-		def(store.NodeClassify, store.NodeMarkPresent, "true"),
+		def(store.NodeGuardGroup, store.NodeRules, "ja"),
+		def(store.NodeGuardGroup, store.NodeUndo, "Edit/Löschung"),
+		def(store.NodeGuardGroup, store.NodeAnswer, "Antwort/Reaktion"),
+		def(store.NodeAnswer, store.NodeResolveDates, "👍/👎"),
+		def(store.NodeRules, store.NodeClassify, "keine Regel"),
+		def(store.NodeRules, store.NodeResolveDates, "Regel: true/false"),
+		def(store.NodeRules, store.NodeNoAction, "Regel: invalid"),
+		def(store.NodeClassify, store.NodeResolveDates, "true/false"),
 		def(store.NodeClassify, store.NodeNoAction, "invalid"),
+		def(store.NodeClassify, store.NodeContext, "allein invalid"),
+		def(store.NodeResolveDates, store.NodeMarkAbsent, "false"),
+		def(store.NodeResolveDates, store.NodeMarkPresent, "true"),
+		def(store.NodeResolveDates, store.NodeNoAction, "kein Termin"),
 	}
 }
 
@@ -139,8 +151,27 @@ func nodeState(step store.TraceStep, reached bool) string {
 // BuildGraph mappt einen Trace auf die feste Topologie.
 func BuildGraph(steps []store.TraceStep) GraphVM {
 	byNode := make(map[string]store.TraceStep, len(steps))
-	for _, s := range steps {
+	pos := make(map[string]int, len(steps)) // Index des letzten Schritts je Knoten
+	for i, s := range steps {
+		if s.Node == store.NodeBuildStats {
+			s.Node = store.NodeCommand // alte Traces: "statistik" war der einzige Befehl
+		}
 		byNode[s.Node] = s
+		pos[s.Node] = i
+	}
+	// Erreichen mehrere Vorgänger denselben Knoten (Zieltermine nach
+	// Rückfrage, Regel oder Classifier), gilt die Kante vom zuletzt davor
+	// protokollierten.
+	via := map[string]string{}
+	for _, e := range edges {
+		from, okFrom := pos[e.from]
+		to, okTo := pos[e.to]
+		if !okFrom || !okTo || from > to {
+			continue
+		}
+		if prev, ok := via[e.to]; !ok || pos[prev] < from {
+			via[e.to] = e.from
+		}
 	}
 
 	g := GraphVM{W: viewW, H: viewH}
@@ -163,7 +194,7 @@ func BuildGraph(steps []store.TraceStep) GraphVM {
 	for _, e := range edges {
 		_, reached := byNode[e.to]
 		state := "skipped"
-		if reached {
+		if src, ok := via[e.to]; reached && (!ok || src == e.from) {
 			state = "taken"
 		}
 		g.Edges = append(g.Edges, EdgeVM{
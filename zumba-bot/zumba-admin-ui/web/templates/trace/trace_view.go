@@ -25,8 +25,12 @@ func PathIcon(p string) string {
 	switch p {
 	case "statistik":
 		return "📊"
+	case "command":
+		return "💬"
 	case "classify":
 		return "🤖"
+	case "revoke":
+		return "🗑️"
 	default:
 		return "🚫"
 	}
@@ -36,8 +40,12 @@ func PathLabel(p string) string {
 	switch p {
 	case "statistik":
 		return "Statistik"
+	case "command":
+		return "Befehl"
 	case "classify":
 		return "Klassifizierung"
+	case "revoke":
+		return "Löschung"
 	case "ignored":
 		return "Ignoriert"
 	default:
@@ -70,6 +78,14 @@ func ActionLabel(a string) string {
 		return "würde Absage eintragen"
 	case "would_mark_present":
 		return "würde Absage entfernen"
+	case "reverted":
+		return "Original rückgängig gemacht"
+	case "would_revert":
+		return "würde Original rückgängig machen"
+	case "asked":
+		return "Rückfrage gestellt"
+	case "would_ask":
+		return "würde nachfragen"
 	case "", "none":
 		return "—"
 	default: